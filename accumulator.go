@@ -0,0 +1,112 @@
+package adss
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Progress describes how far along an Accumulator is.
+type Progress struct {
+	Collected int  // number of shares accepted so far
+	Threshold int  // number of shares needed to attempt recovery
+	Recovered bool // whether the secret has been recovered
+}
+
+func (p Progress) String() string {
+	if p.Recovered {
+		return fmt.Sprintf("%d of %d collected, recovered", p.Collected, p.Threshold)
+	}
+	return fmt.Sprintf("%d of %d collected", p.Collected, p.Threshold)
+}
+
+// Accumulator collects shares one at a time, as holders submit them, and
+// recovers the secret as soon as the collected shares allow it. Each share is
+// checked for consistency with the earlier ones when it is added so problems
+// are reported to the holder that caused them. It is safe for concurrent use.
+type Accumulator struct {
+	mu     sync.Mutex
+	opts   []RecoverOption
+	shares []*SecretShare
+
+	recovered bool
+	secret    []byte
+	valid     []*SecretShare
+	lastErr   error
+}
+
+// NewAccumulator returns an empty Accumulator. The options are used for every
+// recovery attempt.
+func NewAccumulator(opts ...RecoverOption) *Accumulator {
+	return &Accumulator{opts: opts}
+}
+
+// Add validates share against the shares collected so far and, if it is
+// consistent with them, adds it. Once at least the threshold of shares has
+// been collected, recovery is attempted after each addition.
+//
+// An error is only returned when the share is rejected. A failed recovery
+// attempt is not an error since more shares may allow it to succeed; use
+// Secret to see why it failed.
+func (a *Accumulator) Add(share *SecretShare) (Progress, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.recovered {
+		return a.progress(), fmt.Errorf("secret already recovered")
+	}
+
+	if share.As.T == 0 || share.ID >= share.As.N {
+		return a.progress(), &ShareError{ID: share.ID, Field: FieldID, Err: fmt.Errorf("%w for %d-of-%d", ErrShareIDOutOfRange, share.As.T, share.As.N)}
+	}
+
+	if len(a.shares) > 0 {
+		if err := checkConsistent(a.shares[0], share); err != nil {
+			return a.progress(), err
+		}
+	}
+
+	for _, existing := range a.shares {
+		if existing.ID == share.ID {
+			return a.progress(), &ShareError{ID: share.ID, Field: FieldID, Err: ErrDuplicateShareID}
+		}
+	}
+
+	a.shares = append(a.shares, share)
+	if len(a.shares) >= int(share.As.T) {
+		a.secret, a.valid, a.lastErr = Recover(a.shares, a.opts...)
+		a.recovered = a.lastErr == nil
+	}
+
+	return a.progress(), nil
+}
+
+// Progress reports how many shares have been collected.
+func (a *Accumulator) Progress() Progress {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.progress()
+}
+
+func (a *Accumulator) progress() Progress {
+	p := Progress{Collected: len(a.shares), Recovered: a.recovered}
+	if len(a.shares) > 0 {
+		p.Threshold = int(a.shares[0].As.T)
+	}
+	return p
+}
+
+// Secret returns the recovered secret and the shares that explain it. If the
+// secret hasn't been recovered it returns the error from the last recovery
+// attempt, or an error saying more shares are needed.
+func (a *Accumulator) Secret() ([]byte, []*SecretShare, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.recovered {
+		return a.secret, a.valid, nil
+	}
+	if a.lastErr != nil {
+		return nil, nil, a.lastErr
+	}
+	return nil, nil, fmt.Errorf("not enough shares: %s", a.progress())
+}
@@ -0,0 +1,67 @@
+package adss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAccumulator(t *testing.T) {
+	msg := []byte("hello world")
+	shares, err := Share(NewAccessStructure(3, 5), msg, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	other, err := Share(NewAccessStructure(3, 5), msg, []byte("other ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	bad := cloneShare(shares[2])
+	bad.Sec[0]++
+
+	acc := NewAccumulator()
+	if _, _, err := acc.Secret(); err == nil || err.Error() != "not enough shares: 0 of 0 collected" {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	var steps = []struct {
+		share    *SecretShare
+		err      string
+		progress string
+	}{
+		// The bad share is consistent with the others so it is accepted, but
+		// recovery fails until enough good shares are collected.
+		{bad, "", "1 of 3 collected"},
+		{bad, "share 2: duplicate share ID", "1 of 3 collected"},
+		{other[1], "share 1: shares have inconsistent tags", "1 of 3 collected"},
+		{shares[0], "", "2 of 3 collected"},
+		{shares[1], "", "3 of 3 collected"},
+		{shares[3], "", "4 of 3 collected, recovered"},
+		{shares[4], "secret already recovered", "4 of 3 collected, recovered"},
+	}
+
+	for i, step := range steps {
+		progress, err := acc.Add(step.share)
+		if step.err == "" && err != nil {
+			t.Errorf("step %d: unexpected error: %s", i, err)
+		}
+		if step.err != "" && (err == nil || err.Error() != step.err) {
+			t.Errorf("step %d: unexpected error, expected: %s, got: %v", i, step.err, err)
+		}
+		if progress.String() != step.progress {
+			t.Errorf("step %d: progress = %s, expected: %s", i, progress, step.progress)
+		}
+	}
+
+	recov, V, err := acc.Secret()
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x != %x", recov, msg)
+	}
+	if sharesDesc(V) != "{ID:0, ID:1, ID:3}" {
+		t.Errorf("unexpected valid shares: %s", sharesDesc(V))
+	}
+}
@@ -2,69 +2,319 @@ package adss
 
 import (
 	"bytes"
-	"crypto/aes"
-	"crypto/cipher"
+	"context"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"io"
 )
 
+// AccessStructure is a T-of-N threshold access structure. It is a value, so
+// each sharing holds its own copy and it is safe to use concurrently.
 type AccessStructure struct {
-	T, N uint8
+	T, N uint16
+
+	// Exclusions are sets of share IDs that may not jointly recover. See
+	// NewAccessStructureWithExclusions.
+	Exclusions []IDSet `json:",omitempty"`
+	// Mandatory are the IDs of shares that must be present to recover. See
+	// WithMandatory.
+	Mandatory IDSet `json:",omitempty"`
+	// Formula, if set, says which sets of shares can recover in place of the
+	// threshold. See NewFormulaAccessStructure.
+	Formula *Formula `json:",omitempty"`
+	// Holders are the names of the holders of the shares by ID. See
+	// WithHolders.
+	Holders []string `json:",omitempty"`
 }
 
-func NewAccessStructure(t, n uint8) AccessStructure {
+// NewAccessStructure returns a t-of-n threshold access structure. It isn't
+// checked until it is used, so call Validate to catch a t of zero or above n
+// up front, such as when it comes from configuration.
+func NewAccessStructure(t, n uint16) AccessStructure {
 	return AccessStructure{T: t, N: n}
 }
 
+// Validate returns an error wrapping ErrInvalidAccessStructure unless there is
+// at least one share, the threshold is between 1 and the number of shares,
+// and any exclusions, mandatory shares, formula and holder names are
+// consistent with them, and the exclusions leave some set of shares that can
+// recover. Share and Recover refuse access structures that
+// aren't valid, and Share accepts every one that is. ShareWithScheme also
+// fails wrapping ErrInvalidAccessStructure for valid structures that the
+// scheme can't share, such as replicated sharings of more than 16 shares.
+func (as AccessStructure) Validate() error {
+	switch {
+	case as.N == 0:
+		return fmt.Errorf("%w: %d-of-%d has no shares", ErrInvalidAccessStructure, as.T, as.N)
+	case as.T == 0 || as.T > as.N:
+		return fmt.Errorf("%w: %d-of-%d", ErrInvalidAccessStructure, as.T, as.N)
+	}
+	for _, validate := range []func() error{as.validateExclusions, as.validateMandatory, as.validateFormula, as.validateHolders, as.validateQualified} {
+		if err := validate(); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidAccessStructure, err)
+		}
+	}
+	return nil
+}
+
+// Bytes returns the threshold and count, followed by the mandatory shares,
+// if any, after mandatoryMarker and their number, the formula, if any, after
+// formulaMarker and its length as two bytes, and then the holder names, if
+// any, after holdersMarker, each prefixed with its length.
+//
+// Access structures of more than 255 shares start with two zero bytes and
+// wideMarker, which no narrower structure can, and then the threshold and
+// count as two bytes each. Share IDs, and the number of mandatory shares,
+// are two bytes too, and the length of the formula four.
 func (as *AccessStructure) Bytes() []byte {
-	bytes := make([]byte, 2)
-	bytes[0] = as.T
-	bytes[1] = as.N
+	bytes := make([]byte, 0, 7+2*len(as.Mandatory))
+	if as.wide() {
+		bytes = append(bytes, 0, 0, wideMarker)
+		bytes = appendIDs(bytes, []uint16{as.T, as.N}, true)
+	} else {
+		bytes = append(bytes, byte(as.T), byte(as.N))
+	}
+	if len(as.Mandatory) > 0 {
+		bytes = append(bytes, mandatoryMarker)
+		bytes = appendIDs(bytes, []uint16{uint16(len(as.Mandatory))}, as.wide())
+		bytes = appendIDs(bytes, as.Mandatory, as.wide())
+	}
+	if as.Formula != nil {
+		formula := as.Formula.appendBytes(nil, as.wide())
+		bytes = append(bytes, formulaMarker)
+		if as.wide() {
+			bytes = appendUint32(bytes, uint32(len(formula)))
+		} else {
+			bytes = append(bytes, byte(len(formula)>>8), byte(len(formula)))
+		}
+		bytes = append(bytes, formula...)
+	}
+	if as.Holders != nil {
+		bytes = append(bytes, holdersMarker)
+		bytes = append(bytes, as.holderBytes()...)
+	}
 	return bytes
 }
 
-func (as *AccessStructure) isSupportedIDSet(IDs []uint8) bool {
-	// TODO: implement
-	return true
+// isSupportedIDSet reports whether the shares with the IDs may recover
+// together, see checkIDSet.
+func (as *AccessStructure) isSupportedIDSet(IDs []uint16) bool {
+	return as.checkIDSet(IDs) == nil
 }
 
+// checkIDSet returns an error unless the IDs are unique, within [0, N), at
+// least T of them, include every mandatory share, don't complete an
+// exclusion and satisfy the formula, if any.
+func (as *AccessStructure) checkIDSet(IDs []uint16) error {
+	var small [256]bool
+	present := small[:]
+	if int(as.N) > len(small) {
+		present = make([]bool, as.N)
+	}
+	for _, id := range IDs {
+		if id >= as.N {
+			return &ShareError{ID: id, Field: FieldID, Err: fmt.Errorf("%w for %d-of-%d", ErrShareIDOutOfRange, as.T, as.N)}
+		}
+		if present[id] {
+			return &ShareError{ID: id, Field: FieldID, Err: ErrDuplicateShareID}
+		}
+		present[id] = true
+	}
+	if len(IDs) < int(as.T) {
+		return fmt.Errorf("%w: have %d, need %d", ErrNotEnoughShares, len(IDs), as.T)
+	}
+	for _, id := range as.Mandatory {
+		if !present[id] {
+			return fmt.Errorf("%w: %d", ErrMissingMandatory, id)
+		}
+	}
+	for _, exclusion := range as.Exclusions {
+		excluded := true
+		for _, id := range exclusion {
+			excluded = excluded && present[id]
+		}
+		if excluded {
+			return fmt.Errorf("%w: %s", ErrExcludedShares, exclusion)
+		}
+	}
+	if as.Formula != nil && !as.Formula.satisfied(present) {
+		return fmt.Errorf("%w: %v", ErrUnsatisfiedFormula, IDs)
+	}
+	return nil
+}
+
+// Versions of the encoding of the hash inputs, recorded in each share.
+const (
+	// VersionUnframed shares were created before shares were versioned. Their
+	// hash inputs are concatenated without framing, so different splits of the
+	// same bytes between the message and associated data hash the same. They
+	// can still be recovered but are no longer created.
+	VersionUnframed uint8 = 0
+	// VersionFramed shares prefix each hash input with its length.
+	VersionFramed uint8 = 1
+	// VersionLabeled shares are also framed, and domain separate the hashes
+	// with labels naming the version and the share's Domain, such as
+	// "adss/v2/K".
+	VersionLabeled uint8 = 2
+
+	// currentVersion is the version of new shares.
+	currentVersion = VersionLabeled
+)
+
+// DefaultDomain is the domain of shares with an empty Domain.
+const DefaultDomain = "adss"
+
+// SecretShare is one share of a sharing. Shares created together reference
+// the same Pub slices rather than each holding a copy, so the public parts
+// must be treated as read-only; copy them before making changes.
+//
+// Shares are never modified by the library once created and don't reference
+// the caller's inputs, so a share can be read from, and passed to Recover by,
+// any number of goroutines at once as long as none of them modifies it.
 type SecretShare struct {
 	As  AccessStructure // S.as
-	ID  uint8           // S.ID
+	ID  uint16          // S.ID
 	Pub struct {        // S.Pub
 		C, D, J []byte
 	}
 	Sec []byte // S.Sec
 	Tag []byte // S.Tag
+
+	// Hardening holds the Argon2id parameters used to strengthen the hash
+	// inputs when the sharing was created with ShareHardened. It is nil for
+	// regular sharings.
+	Hardening *Argon2Params `json:",omitempty"`
+
+	// Version is the encoding of the hash inputs the sharing was created
+	// with. Changing it changes the checksum so it is authenticated along
+	// with the rest of the share.
+	Version uint8 `json:",omitempty"`
+
+	// Domain separates the hashes of the sharing from those of other
+	// applications, see ShareInDomain. It is empty for DefaultDomain.
+	Domain string `json:",omitempty"`
+
+	// Scheme is the base scheme the key is split with, see ShareWithScheme.
+	// It is part of the hash labels so it is authenticated too.
+	Scheme uint8 `json:",omitempty"`
+
+	// Points are the Shamir evaluation points of the shares by ID when the
+	// sharing was created with ShareWithPoints. It is nil for the default of
+	// ID+1.
+	Points []uint8 `json:",omitempty"`
+}
+
+// shareParams are the parameters of a sharing, other than its inputs, that
+// every share records and that are needed to recompute it.
+type shareParams struct {
+	hardening *Argon2Params
+	// hardened caches the Argon2id outputs of a recovery, see
+	// hardeningCache. It isn't recorded in the shares.
+	hardened hardeningCache
+	version  uint8
+	domain   string
+	scheme   uint8
+	points   []uint8
+}
+
+// newShareParams returns the parameters of a new regular sharing.
+func newShareParams() shareParams {
+	return shareParams{version: currentVersion}
 }
 
+// paramsOf returns the parameters of the sharing the share is from.
+func paramsOf(ss *SecretShare) shareParams {
+	return shareParams{hardening: ss.Hardening, version: ss.Version, domain: ss.Domain, scheme: ss.Scheme, points: ss.Points}
+}
+
+// Equal reports whether the two shares hold the same data. It compares field
+// by field so it doesn't allocate, which matters since recovery calls it in
+// nested loops.
 func (ss *SecretShare) Equal(other *SecretShare) bool {
-	return bytes.Equal(ss.Bytes(), other.Bytes())
-}
-
-func (ss *SecretShare) Bytes() []byte {
-	out := make([]byte, 0)
-	// TODO: This is currently an unrecoverable byte encoding since we have
-	// variable length message and associated data. We'll need to update this to
-	// be decodable later for serialization to disk purpoes.
-	out = append(out, ss.As.Bytes()...)
-	out = append(out, ss.ID)
-	out = append(out, ss.Pub.C...)
-	out = append(out, ss.Pub.D...)
-	out = append(out, ss.Pub.J...)
-	out = append(out, ss.Sec...)
-	out = append(out, ss.Tag...)
-	return out
+	return ss.As.Equal(other.As) &&
+		ss.ID == other.ID &&
+		bytes.Equal(ss.Pub.C, other.Pub.C) &&
+		bytes.Equal(ss.Pub.D, other.Pub.D) &&
+		bytes.Equal(ss.Pub.J, other.Pub.J) &&
+		bytes.Equal(ss.Sec, other.Sec) &&
+		bytes.Equal(ss.Tag, other.Tag) &&
+		ss.Hardening.equal(other.Hardening) &&
+		ss.Version == other.Version &&
+		ss.Domain == other.Domain &&
+		ss.Scheme == other.Scheme &&
+		bytes.Equal(ss.Points, other.Points)
+}
+
+// Point returns the x coordinate the share's Shamir polynomials are evaluated
+// at, which is ID+1 unless the sharing was created with ShareWithPoints. It
+// is in GF(2^16) for sharings of more than 255 shares.
+func (ss *SecretShare) Point() uint16 {
+	if int(ss.ID) < len(ss.Points) {
+		return uint16(ss.Points[ss.ID])
+	}
+	return ss.ID + 1
+}
+
+// Fingerprint identifies the sharing the share belongs to. It is derived from
+// the public payload J, which commits to the message, access structure and
+// associated data, so every share from a sharing has the same fingerprint and
+// shares from different sharings have different ones. It reveals nothing
+// about the secret and can be used to group, label and deduplicate shares.
+func (ss *SecretShare) Fingerprint() string {
+	return sharingFingerprint(ss.Pub.J)
+}
+
+func sharingFingerprint(J []byte) string {
+	h := sha256.New()
+	h.Write([]byte("adss sharing fingerprint"))
+	h.Write(J)
+	return hex.EncodeToString(h.Sum(nil)[:16])
 }
 
-func (ss *SecretShare) toS1() *s1SecretShare {
-	return &s1SecretShare{
-		i:      ss.ID,
-		t:      ss.As.T,
-		n:      ss.As.N,
-		secret: ss.Sec,
+// fingerprint returns a cheap, non-cryptographic hash of a canonical encoding
+// of the share for use as a map key. Every variable length field is length
+// prefixed so shares that differ only in where one field ends and the next
+// begins get different fingerprints.
+func (ss *SecretShare) fingerprint() uint64 {
+	h := fnv.New64a()
+	var length [4]byte
+	h.Write(ss.As.Bytes())
+	h.Write([]byte{byte(ss.ID >> 8), byte(ss.ID), ss.Version, ss.Scheme})
+	for _, field := range [][]byte{ss.Pub.C, ss.Pub.D, ss.Pub.J, ss.Sec, ss.Tag, []byte(ss.Domain), ss.As.exclusionBytes(), ss.Points} {
+		binary.BigEndian.PutUint32(length[:], uint32(len(field)))
+		h.Write(length[:])
+		h.Write(field)
+	}
+	if ss.Hardening != nil {
+		h.Write(ss.Hardening.Bytes())
 	}
+	return h.Sum64()
+}
+
+// constantTimeEqual is like Equal but the time taken doesn't depend on where
+// the shares differ. It returns 1 if they are equal and 0 otherwise.
+func (ss *SecretShare) constantTimeEqual(other *SecretShare) int {
+	eq := subtle.ConstantTimeEq(int32(ss.As.T), int32(other.As.T))
+	eq &= subtle.ConstantTimeEq(int32(ss.As.N), int32(other.As.N))
+	eq &= subtle.ConstantTimeEq(int32(ss.ID), int32(other.ID))
+	eq &= subtle.ConstantTimeByteEq(ss.Version, other.Version)
+	eq &= subtle.ConstantTimeByteEq(ss.Scheme, other.Scheme)
+	eq &= subtle.ConstantTimeCompare(ss.Pub.C, other.Pub.C)
+	eq &= subtle.ConstantTimeCompare(ss.Pub.D, other.Pub.D)
+	eq &= subtle.ConstantTimeCompare(ss.Pub.J, other.Pub.J)
+	eq &= subtle.ConstantTimeCompare(ss.Sec, other.Sec)
+	eq &= subtle.ConstantTimeCompare(ss.Tag, other.Tag)
+	if !ss.Hardening.equal(other.Hardening) || ss.Domain != other.Domain || !ss.As.Equal(other.As) || !bytes.Equal(ss.Points, other.Points) {
+		eq = 0
+	}
+	return eq
 }
 
 // Share creates an ADSS Secret sharing of the provIDed message and returns the shares or error.
@@ -73,55 +323,523 @@ func (ss *SecretShare) toS1() *s1SecretShare {
 // M: message
 // R: random coins, might not be uniform
 // T: associated data authenticated during sharing
+//
+// Share is safe for concurrent use, and the shares don't reference M or T so
+// the caller is free to reuse them afterwards.
 func Share(A AccessStructure, M, T []byte) ([]*SecretShare, error) {
 	R := make([]byte, 32)
 	if _, err := rand.Read(R); err != nil {
 		return nil, err
 	}
 
-	return internalShare(A, M, R, T)
+	return internalShare(A, M, R, T, newShareParams())
 }
 
-func internalShare(A AccessStructure, M, R, T []byte) ([]*SecretShare, error) {
-	// TODO: Validate access structure params like t > 1 and t < n
+// ShareContext is like Share but starts spans with the tracer of ctx, as set
+// with WithTracer.
+func ShareContext(ctx context.Context, A AccessStructure, M, T []byte) ([]*SecretShare, error) {
+	ctx, span := startSpan(ctx, SpanShare)
+	defer span.End()
+	span.SetAttribute("adss.threshold", int64(A.T))
+	span.SetAttribute("adss.shares", int64(A.N))
+
+	R := make([]byte, 32)
+	if _, err := rand.Read(R); err != nil {
+		return nil, err
+	}
+
+	return internalShareContext(ctx, A, M, R, T, newShareParams())
+}
+
+// ShareInDomain is like Share but separates the hashes of the sharing from
+// those of other applications with domain, such as "example.com/backups".
+// Sharings of the same inputs in different domains have unrelated
+// commitments and keys, so independent deployments can't accidentally
+// produce compatible shares. The domain is recorded in the shares. It must be
+// 1 to 64 printable ASCII characters.
+func ShareInDomain(domain string, A AccessStructure, M, T []byte) ([]*SecretShare, error) {
+	if err := validateDomain(domain); err != nil {
+		return nil, err
+	}
+
+	R := make([]byte, 32)
+	if _, err := rand.Read(R); err != nil {
+		return nil, err
+	}
+
+	params := newShareParams()
+	params.domain = domain
+	return internalShare(A, M, R, T, params)
+}
+
+// ShareWithScheme is like Share but splits the key with the given base
+// scheme, SchemeShamir, SchemeReplicated, SchemeFormula or SchemeShamir16.
+// SchemeReplicated enforces exclusions, mandatory shares and formulas in the
+// sharing itself, so a set of shares the access structure doesn't support
+// holds too little to recover even with a modified implementation. It
+// supports at most 16 shares, and the shares grow with the number of sets
+// that can't recover.
+// SchemeFormula enforces the formula of an access structure made by
+// NewFormulaAccessStructure with shares the size of Shamir shares.
+// SchemeShamir16 splits the key over GF(2^16) however few shares there are.
+func ShareWithScheme(scheme uint8, A AccessStructure, M, T []byte) ([]*SecretShare, error) {
+	if scheme > SchemeShamir16 {
+		return nil, fmt.Errorf("unknown scheme %d", scheme)
+	}
+	if scheme == SchemeFormula && A.Formula == nil {
+		return nil, fmt.Errorf("%w: the formula scheme needs an access structure with a formula", ErrInvalidAccessStructure)
+	}
+
+	R := make([]byte, 32)
+	if _, err := rand.Read(R); err != nil {
+		return nil, err
+	}
+
+	params := newShareParams()
+	params.scheme = scheme
+	return internalShare(A, M, R, T, params)
+}
+
+// ShareWithPoints is like Share but evaluates the Shamir polynomials of the
+// share with ID i at points[i] rather than i+1, so the shares of the key
+// follow the point convention of another Shamir deployment. There must be one
+// distinct, non-zero point per share, so there can be at most 255 shares. The
+// points are recorded in every share and bound into the checksum.
+func ShareWithPoints(points []uint8, A AccessStructure, M, T []byte) ([]*SecretShare, error) {
+	if err := validatePoints(points, A); err != nil {
+		return nil, err
+	}
+
+	R := make([]byte, 32)
+	if _, err := rand.Read(R); err != nil {
+		return nil, err
+	}
+
+	params := newShareParams()
+	params.points = points
+	return internalShare(A, M, R, T, params)
+}
+
+func validateDomain(domain string) error {
+	if len(domain) == 0 || len(domain) > 64 {
+		return fmt.Errorf("domain must be 1 to 64 characters, got %d", len(domain))
+	}
+	for _, c := range []byte(domain) {
+		if c < 0x20 || c > 0x7e {
+			return fmt.Errorf("domain must be printable ASCII: %q", domain)
+		}
+	}
+	return nil
+}
+
+// ShareHardened is like Share but strengthens the hash inputs with Argon2id
+// before deriving the key and checksum. This should be used when the secret
+// is low-entropy, such as a passphrase, as it slows down offline guessing of
+// the secret by someone holding fewer than the threshold of shares. The
+// parameters are stored on the shares and recovery takes the same amount of
+// work.
+func ShareHardened(A AccessStructure, M, T []byte, params Argon2Params) ([]*SecretShare, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	R := make([]byte, 32)
+	if _, err := rand.Read(R); err != nil {
+		return nil, err
+	}
+
+	sharing := newShareParams()
+	sharing.hardening = &params
+	return internalShare(A, M, R, T, sharing)
+}
+
+// ShareWithEntropy is like Share but mixes caller-provided entropy, such as
+// hardware RNG output or dice rolls, into the random coins alongside
+// crypto/rand. The result is never weaker than using crypto/rand alone, so
+// this is safe to use even when the provided entropy is of poor quality.
+func ShareWithEntropy(A AccessStructure, M, T []byte, entropy ...[]byte) ([]*SecretShare, error) {
+	R, err := mixEntropy(entropy)
+	if err != nil {
+		return nil, err
+	}
+
+	return internalShare(A, M, R, T, newShareParams())
+}
+
+// ShareWithRand is like Share but reads the random coins from rand rather than
+// crypto/rand, such as from an HSM, a DRBG, or a fixed reader in tests. ADSS
+// keeps the message private with imperfect coins as long as the message and
+// coins together are hard to guess, so rand needn't be uniform. A predictable
+// rand gives up what the coins add, though: sharings of the same inputs with
+// the same coins are identical, so anyone can tell they match.
+func ShareWithRand(rand io.Reader, A AccessStructure, M, T []byte) ([]*SecretShare, error) {
+	if rand == nil {
+		return nil, fmt.Errorf("nil random source")
+	}
+
+	R := make([]byte, 32)
+	if _, err := io.ReadFull(rand, R); err != nil {
+		return nil, fmt.Errorf("reading random coins: %w", err)
+	}
+
+	return internalShare(A, M, R, T, newShareParams())
+}
+
+// ShareDeterministic shares M with the 32 bytes of coins R given by the
+// caller, so sharing the same inputs always produces identical shares. This is
+// meant for reproducible backups and for test vectors shared with other
+// implementations.
+//
+// ADSS keeps M private as long as M and R together are hard to guess, but
+// nothing more: R must be secret and unpredictable to anyone who could guess
+// M, anyone can tell that two sharings with the same R are of the same message
+// and associated data since their public parts are equal, and anyone who
+// learns R can check guesses of M against a single share. Prefer Share unless
+// the shares must be reproducible.
+func ShareDeterministic(A AccessStructure, M, R, T []byte) ([]*SecretShare, error) {
+	if len(R) != 32 {
+		return nil, fmt.Errorf("expected 32 bytes of coins, got %d", len(R))
+	}
+
+	return internalShare(A, M, R, T, newShareParams())
+}
+
+// mixEntropy returns 32 bytes of coins derived from crypto/rand and the
+// provided entropy. Each entropy input is length-prefixed so that different
+// splits of the same bytes mix differently.
+func mixEntropy(entropy [][]byte) ([]byte, error) {
+	R := make([]byte, 32)
+	if _, err := rand.Read(R); err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	h.Write(R)
+	for _, e := range entropy {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(e)))
+		h.Write(length[:])
+		h.Write(e)
+	}
+
+	return h.Sum(nil), nil
+}
+
+// ShareWithDealerKey is like Share but derives the coins from a key held by
+// the dealer and the inputs, so sharing the same message with the same access
+// structure and associated data always produces identical shares. This lets a
+// dealer re-issue a holder's lost share without invalidating the shares the
+// others hold.
+//
+// The determinism has a cost: anyone can tell that two sharings made with the
+// same dealer key are of the same message and associated data, since their
+// public parts are equal. The dealer key must be kept secret and be at least
+// 32 random bytes; it isn't needed to recover.
+func ShareWithDealerKey(A AccessStructure, M, T, dealerKey []byte) ([]*SecretShare, error) {
+	if len(dealerKey) < 32 {
+		return nil, fmt.Errorf("dealer key too short: %d bytes, need at least 32", len(dealerKey))
+	}
+
+	return internalShare(A, M, dealerCoins(A, M, T, dealerKey), T, newShareParams())
+}
+
+// dealerCoins derives the coins for ShareWithDealerKey with HMAC-SHA256 keyed
+// by the dealer key. The variable length inputs are length-prefixed.
+func dealerCoins(A AccessStructure, M, T, dealerKey []byte) []byte {
+	mac := hmac.New(sha256.New, dealerKey)
+	mac.Write([]byte("adss dealer coins"))
+	mac.Write(A.Bytes())
+	for _, input := range [][]byte{M, T} {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(input)))
+		mac.Write(length[:])
+		mac.Write(input)
+	}
+	return mac.Sum(nil)
+}
+
+// ShareFunc is like Share but calls fn with each share as it is produced
+// instead of returning them all, so the caller can write each one out and
+// drop it. This keeps memory bounded for sharings with large messages or many
+// shares. The public parts of every share reference the same underlying
+// slices, so fn must not modify them. If fn returns an error, sharing stops
+// and that error is returned.
+func ShareFunc(A AccessStructure, M, T []byte, fn func(*SecretShare) error) error {
+	R := make([]byte, 32)
+	if _, err := rand.Read(R); err != nil {
+		return err
+	}
+
+	return internalShareFunc(context.Background(), A, M, R, T, newShareParams(), fn)
+}
+
+// ShareToWriters is like ShareFunc but writes the JSON encoding of the share
+// with ID i to writers[i]. There must be exactly one writer per share.
+func ShareToWriters(A AccessStructure, M, T []byte, writers []io.Writer) error {
+	if len(writers) != int(A.N) {
+		return fmt.Errorf("expected %d writers, got %d", A.N, len(writers))
+	}
+
+	return ShareFunc(A, M, T, func(share *SecretShare) error {
+		if err := json.NewEncoder(writers[share.ID]).Encode(share); err != nil {
+			return fmt.Errorf("writing share %d: %w", share.ID, err)
+		}
+		return nil
+	})
+}
+
+func internalShare(A AccessStructure, M, R, T []byte, params shareParams) ([]*SecretShare, error) {
+	return internalShareContext(context.Background(), A, M, R, T, params)
+}
+
+func internalShareContext(ctx context.Context, A AccessStructure, M, R, T []byte, params shareParams) ([]*SecretShare, error) {
+	shares := make([]*SecretShare, 0, A.N)
+	err := internalShareFunc(ctx, A, M, R, T, params, func(share *SecretShare) error {
+		shares = append(shares, share)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shares, nil
+}
+
+func internalShareFunc(ctx context.Context, A AccessStructure, M, R, T []byte, params shareParams, fn func(*SecretShare) error) error {
+	if err := A.Validate(); err != nil {
+		return err
+	}
+
+	// The shares keep the access structure, tag and hardening parameters, so
+	// copy them to stop later changes by the caller from reaching the shares.
+	A = A.clone()
+	if T != nil {
+		T = append([]byte{}, T...)
+	}
+	if params.hardening != nil {
+		hardening := *params.hardening
+		params.hardening = &hardening
+	}
+	if params.points != nil {
+		params.points = append([]uint8{}, params.points...)
+	}
 
 	// 1. Hash the inputs to get J K L
-	J, K, L := computeJKL(A, M, R, T)
+	_, span := startSpan(ctx, SpanComputeJKL)
+	J, K, L := computeJKL(A, M, R, T, params)
+	span.End()
 
 	// 2. Encrypt the message and the randomness into C and D
 	C, D, err := xorKeyStreamTwoInputs(K[:], M, R)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// 3. Split the key into Secret shares
-	shares := make([]*SecretShare, A.N)
-	s1Shares, err := s1Share(A, K, L, nil)
+	s1Shares, err := baseShare(A, K, L, params)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if debugAssertions {
+		assertf(len(s1Shares) == int(A.N), "baseShare returned %d shares, want %d", len(s1Shares), A.N)
+		if params.scheme == SchemeShamir {
+			assertS1Lengths(s1Shares)
+		}
+		assertf(len(C) == len(M) && len(D) == len(R), "ciphertext lengths %d and %d, want %d and %d", len(C), len(D), len(M), len(R))
 	}
 
-	// 4. Construct final Secret shares and return them
-	for i := range shares {
-		shares[i] = &SecretShare{
+	// 4. Construct final Secret shares and emit them
+	for i := range s1Shares {
+		share := &SecretShare{
 			As:  A,
 			ID:  s1Shares[i].i,
 			Pub: struct{ C, D, J []byte }{C, D, J},
 			Sec: s1Shares[i].secret,
 			Tag: T,
+
+			Hardening: params.hardening,
+			Version:   params.version,
+			Domain:    params.domain,
+			Scheme:    params.scheme,
+			Points:    params.points,
+		}
+		if err := fn(share); err != nil {
+			return err
 		}
 	}
 
-	return shares, nil
+	return nil
+}
+
+// baseShare splits the key K with the base scheme, using L as the coins.
+func baseShare(A AccessStructure, K, L []byte, params shareParams) ([]*s1SecretShare, error) {
+	switch params.scheme {
+	case SchemeReplicated:
+		return replicatedShare(A, K, L)
+	case SchemeFormula:
+		return formulaShare(A, K, L)
+	}
+	return s1ShareAt(A, K, L, nil, params.points, A.wide() || params.scheme == SchemeShamir16)
+}
+
+// baseRecover recovers the key from the shares with their base scheme.
+func baseRecover(shares []*SecretShare) ([]byte, error) {
+	switch shares[0].Scheme {
+	case SchemeReplicated:
+		return replicatedRecover(shares)
+	case SchemeFormula:
+		return formulaRecover(shares)
+	}
+	s1Shares := getS1Shares(shares)
+	K, err := s1Recover(s1Shares.ptrs)
+	putS1Shares(s1Shares)
+	return K, err
+}
+
+// RecoverOption configures optional behavior of Recover.
+type RecoverOption func(*recoverConfig)
+
+type recoverConfig struct {
+	uniformWork     bool
+	recipient       *[32]byte
+	requireAllValid bool
+	majorityPayload bool
+	policy          Policy
+	revocations     []trustedRevocationList
+	expectedSHA256  *[32]byte
+
+	associatedData    []byte
+	hasAssociatedData bool
+}
+
+// WithUniformWork hardens recovery against timing side channels. Recovery is
+// attempted with every candidate subset of shares, and every step of each
+// attempt is performed even after a check has failed, so the time taken does
+// not depend on which subset was valid. It still depends on the number and
+// sizes of the shares given. This is considerably slower and is intended for
+// callers in adversarial multi-tenant environments.
+func WithUniformWork() RecoverOption {
+	return func(cfg *recoverConfig) {
+		cfg.uniformWork = true
+	}
+}
+
+// WithRequireAllValid makes recovery fail if any of the provided shares is
+// invalid, even when the valid ones are enough to recover the secret. This is
+// for workflows that must stop and investigate a bad share rather than
+// tolerate it. Shares that are valid but weren't needed are still allowed.
+func WithRequireAllValid() RecoverOption {
+	return func(cfg *recoverConfig) {
+		cfg.requireAllValid = true
+	}
+}
+
+// WithMajorityPayload reconciles shares that disagree on the public payload
+// (C, D and J) by replacing it with the payload held by a strict majority of
+// the shares before recovering. Since the secret part of a share doesn't
+// depend on the payload, this lets a share whose copy of the payload was
+// corrupted, such as by bit rot, still contribute to recovery. The repaired
+// shares are returned in V. Recovery fails if no payload has a majority.
+func WithMajorityPayload() RecoverOption {
+	return func(cfg *recoverConfig) {
+		cfg.majorityPayload = true
+	}
 }
 
-func Recover(shares []*SecretShare) ([]byte, []*SecretShare, error) {
-	return exAxRecover(shares)
+// Recover recovers the secret from the shares, returning it along with the
+// shares that were valid. It does not modify the shares, and the returned
+// secret is never shared with another call, so it is safe to call Recover
+// concurrently, including on the same shares.
+func Recover(shares []*SecretShare, opts ...RecoverOption) ([]byte, []*SecretShare, error) {
+	return RecoverContext(context.Background(), shares, opts...)
+}
+
+// RecoverContext is like Recover but stops searching for an explanation of
+// the shares and returns ctx.Err() if ctx is done. Recovery from many shares
+// can try a large number of candidate subsets so this lets callers bound it.
+func RecoverContext(ctx context.Context, shares []*SecretShare, opts ...RecoverOption) ([]byte, []*SecretShare, error) {
+	ctx, span := startSpan(ctx, SpanRecover)
+	defer span.End()
+	span.SetAttribute("adss.shares", int64(len(shares)))
+
+	cfg := &recoverConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.hasAssociatedData {
+		var err error
+		shares, err = attachAssociatedData(shares, cfg.associatedData)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if cfg.majorityPayload {
+		var err error
+		shares, err = reconcilePayloads(shares)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Revoked shares are removed after any repair of the payloads so a revoked
+	// share can't have its fingerprint changed by the repair.
+	if len(cfg.revocations) > 0 {
+		var err error
+		shares, err = removeRevoked(shares, cfg.revocations)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var M []byte
+	var V []*SecretShare
+	var err error
+	if cfg.uniformWork {
+		M, V, err = exAxRecoverUniform(ctx, shares)
+	} else {
+		M, V, err = exAxRecover(ctx, shares)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if cfg.requireAllValid && len(V) != len(shares) {
+		if err := checkAllValid(shares, V); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if cfg.expectedSHA256 != nil {
+		if err := checkDigest(M, cfg.expectedSHA256); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if cfg.policy != nil {
+		if err := authorize(ctx, cfg.policy, V); err != nil {
+			for i := range M {
+				M[i] = 0
+			}
+			return nil, nil, err
+		}
+	}
+
+	if cfg.recipient != nil {
+		M, err = sealEnvelope(M, cfg.recipient)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return M, V, nil
 }
 
 // exAxRecover implements the EX transform (figure 9) on top of the AX transform
-func exAxRecover(shares []*SecretShare) ([]byte, []*SecretShare, error) {
-	allShareSets, err := computeKPlausibleShareSets(shares)
+func exAxRecover(ctx context.Context, shares []*SecretShare) ([]byte, []*SecretShare, error) {
+	ctx = withHardeningCache(ctx)
+	allShareSets, err := plausibleShareSets(ctx, shares)
 	if err != nil {
 		return nil, nil, fmt.Errorf("plausible shares: %w", err)
 	}
@@ -131,16 +849,17 @@ func exAxRecover(shares []*SecretShare) ([]byte, []*SecretShare, error) {
 	var M []byte
 	var V []*SecretShare
 	for i, shares := range allShareSets {
-		M, err = axRecover(shares)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, ctxErr
+		}
 
-		// NOTE: On line 81 in figure 9, we are told to verify that V = S_i, or that
-		// the valID shares from recovery match the input shares. We don't do that
-		// check here because axRecover doesn't have a way to return any valID
-		// shares that are different than what we provIDed.
+		var Vi []*SecretShare
+		M, Vi, err = axRecoverCandidate(ctx, shares, false)
+		err = checkExplains(shares, Vi, err)
 		if err == nil {
 			// Recovery worked so we have found the first valID explanation.
 			firstExplanationIDx = i
-			V = shares
+			V = Vi
 			break
 		}
 	}
@@ -157,8 +876,12 @@ func exAxRecover(shares []*SecretShare) ([]byte, []*SecretShare, error) {
 	// We start at the first explanation+1 since we know the ones before that
 	// failed to recover since the previous logic stops when it finds the first
 	for _, Vprime := range allShareSets[firstExplanationIDx+1:] {
-		_, err := axRecover(Vprime)
-		if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, ctxErr
+		}
+
+		_, verified, err := axRecoverCandidate(ctx, Vprime, false)
+		if err := checkExplains(Vprime, verified, err); err != nil {
 			// If we error out when recovering, this means at least one the shares
 			// provIDed is bad. Since it dIDn't recover, we know this is alreadly
 			// excluded from the V set, so we just skip it.
@@ -167,8 +890,9 @@ func exAxRecover(shares []*SecretShare) ([]byte, []*SecretShare, error) {
 
 		// If it recovers and is not a subset of the first, fail. In this case there
 		// are multiple ways to recover messages so we can't be sure which is
-		// correct so we must fail.
-		if !isSubset(Vprime, V) {
+		// correct so we must fail. Explanations of the same sharing don't
+		// conflict, which happens when exclusions stop them recovering together.
+		if !isSubset(Vprime, V) && !sameSharing(Vprime, V) {
 			return nil, nil, fmt.Errorf("multiple explanations: %s and %s", sharesDesc(Vprime), sharesDesc(V))
 		}
 	}
@@ -176,6 +900,87 @@ func exAxRecover(shares []*SecretShare) ([]byte, []*SecretShare, error) {
 	return M, V, nil
 }
 
+// exAxRecoverUniform produces the same results as exAxRecover but attempts AX
+// recovery on every candidate subset before deciding on the outcome, so the
+// amount of work doesn't depend on which subsets are valid.
+func exAxRecoverUniform(ctx context.Context, shares []*SecretShare) ([]byte, []*SecretShare, error) {
+	allShareSets, err := plausibleShareSets(ctx, shares)
+	if err != nil {
+		return nil, nil, fmt.Errorf("plausible shares: %w", err)
+	}
+
+	msgs := make([][]byte, len(allShareSets))
+	verified := make([][]*SecretShare, len(allShareSets))
+	errs := make([]error, len(allShareSets))
+	for i, shares := range allShareSets {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, ctxErr
+		}
+
+		// Each candidate hardens its inputs afresh so the work doesn't depend
+		// on how many recover the same message.
+		msgs[i], verified[i], errs[i] = axRecoverCandidate(withHardeningCache(ctx), shares, true)
+		errs[i] = checkExplains(shares, verified[i], errs[i])
+	}
+
+	firstExplanationIDx := -1
+	for i := range allShareSets {
+		if errs[i] == nil {
+			firstExplanationIDx = i
+			break
+		}
+	}
+
+	// Match exAxRecover by reporting the error of the last candidate we tried.
+	if firstExplanationIDx == -1 {
+		return nil, nil, fmt.Errorf("recovery: %w", errs[len(errs)-1])
+	}
+
+	// Every candidate is compared with the explanation, in constant time, so
+	// the work doesn't depend on which of them explain the shares either.
+	V := verified[firstExplanationIDx]
+	var conflict []*SecretShare
+	for i, Vprime := range allShareSets {
+		subset := isSubsetUniform(Vprime, V)
+		same := subtle.ConstantTimeCompare(Vprime[0].Pub.J, V[0].Pub.J) == 1
+		if i != firstExplanationIDx && errs[i] == nil && !subset && !same && conflict == nil {
+			conflict = Vprime
+		}
+	}
+	if conflict != nil {
+		return nil, nil, fmt.Errorf("multiple explanations: %s and %s", sharesDesc(conflict), sharesDesc(V))
+	}
+
+	return msgs[firstExplanationIDx], V, nil
+}
+
+// plausibleShareSets is computeKPlausibleShareSets in a span.
+func plausibleShareSets(ctx context.Context, shares []*SecretShare) ([][]*SecretShare, error) {
+	_, span := startSpan(ctx, SpanPlausibleShareSets)
+	defer span.End()
+	sets, err := computeKPlausibleShareSets(shares)
+	span.SetAttribute("adss.candidates", int64(len(sets)))
+	return sets, err
+}
+
+// axRecoverCandidate is axRecover in a span.
+func axRecoverCandidate(ctx context.Context, shares []*SecretShare, uniform bool) ([]byte, []*SecretShare, error) {
+	ctx, span := startSpan(ctx, SpanRecoverCandidate)
+	defer span.End()
+	span.SetAttribute("adss.candidate.shares", int64(len(shares)))
+	return axRecover(ctx, shares, uniform)
+}
+
+// checkExplains returns err, or if AX recovery of S succeeded but verified
+// only the shares V, an error unless V = S as line 81 of figure 9 requires.
+// V is a subsequence of S so it is enough to compare their lengths.
+func checkExplains(S, V []*SecretShare, err error) error {
+	if err == nil && len(V) != len(S) {
+		return fmt.Errorf("not a subset of resharing")
+	}
+	return err
+}
+
 func sharesDesc(shares []*SecretShare) string {
 	out := "{"
 	for i, share := range shares {
@@ -188,11 +993,19 @@ func sharesDesc(shares []*SecretShare) string {
 	return out
 }
 
+// fingerprintIndexThreshold is the number of pairwise comparisons above which
+// isSubset indexes the set by fingerprint rather than comparing every pair.
+const fingerprintIndexThreshold = 64
+
 func isSubset(subset, set []*SecretShare) bool {
 	if len(subset) > len(set) {
 		return false
 	}
 
+	if len(subset)*len(set) > fingerprintIndexThreshold {
+		return isSubsetIndexed(subset, set)
+	}
+
 	for _, subsetItem := range subset {
 		found := false
 		for _, setItem := range set {
@@ -212,6 +1025,39 @@ func isSubset(subset, set []*SecretShare) bool {
 	return true
 }
 
+// isSubsetIndexed is isSubset for large sets. It indexes set by a cheap
+// fingerprint of each share so that membership checks take O(n) rather than
+// O(n²) comparisons. Fingerprints aren't collision resistant, so matches are
+// confirmed with Equal.
+func isSubsetIndexed(subset, set []*SecretShare) bool {
+	index := make(map[uint64][]*SecretShare, len(set))
+	for _, setItem := range set {
+		fp := setItem.fingerprint()
+		index[fp] = append(index[fp], setItem)
+	}
+
+	for _, subsetItem := range subset {
+		found := false
+		for _, candidate := range index[subsetItem.fingerprint()] {
+			if subsetItem.Equal(candidate) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// maxCandidateSubsets is the most candidate subsets recovery enumerates, so
+// recovering from many more shares than the threshold fails rather than
+// exhausting memory.
+const maxCandidateSubsets = 1 << 20
+
 func computeKPlausibleShareSets(shares []*SecretShare) ([][]*SecretShare, error) {
 	if len(shares) == 0 {
 		return nil, fmt.Errorf("no shares provided")
@@ -221,155 +1067,395 @@ func computeKPlausibleShareSets(shares []*SecretShare) ([][]*SecretShare, error)
 	//   they have unique indexes, the same access structure, and Tags
 	//   We don't check that the indexes are valID for the access structure as
 	//   this is done in axRecover already.
-	as, Tag := shares[0].As, shares[0].Tag
-	seenIndexes := map[uint8]bool{shares[0].ID: true}
-	for _, share := range shares[1:] {
-		if share.As != as {
-			return nil, fmt.Errorf("shares have inconsistent access structures")
+	as := shares[0].As
+	if err := as.Validate(); err != nil {
+		return nil, &ShareError{ID: shares[0].ID, Field: FieldAccessStructure, Err: err}
+	}
+	if shares[0].Version > currentVersion {
+		return nil, shareErrorf(shares[0], FieldVersion, "unsupported version %d", shares[0].Version)
+	}
+	if shares[0].Hardening != nil {
+		if err := shares[0].Hardening.validate(); err != nil {
+			return nil, shareErrorf(shares[0], FieldHardening, "%s", err)
 		}
-
-		if !bytes.Equal(share.Tag, Tag) {
-			return nil, fmt.Errorf("shares have inconsistent tags")
+	}
+	// The scheme is only authenticated by the labels of VersionLabeled.
+	if shares[0].Scheme > SchemeShamir16 || (shares[0].Scheme != SchemeShamir && shares[0].Version < VersionLabeled) {
+		return nil, shareErrorf(shares[0], FieldScheme, "unsupported scheme %d", shares[0].Scheme)
+	}
+	if shares[0].Scheme == SchemeFormula && as.Formula == nil {
+		return nil, shareErrorf(shares[0], FieldScheme, "formula scheme without a formula")
+	}
+	if shares[0].Points != nil {
+		if shares[0].Scheme != SchemeShamir {
+			return nil, shareErrorf(shares[0], FieldPoints, "evaluation points are only used by Shamir sharings")
+		}
+		if err := validatePoints(shares[0].Points, as); err != nil {
+			return nil, shareErrorf(shares[0], FieldPoints, "%s", err)
+		}
+	}
+	seenIndexes := map[uint16]bool{shares[0].ID: true}
+	for _, share := range shares[1:] {
+		if err := checkConsistent(shares[0], share); err != nil {
+			return nil, err
 		}
 
 		if seenIndexes[share.ID] {
-			return nil, fmt.Errorf("duplicate share ID found")
+			return nil, &ShareError{ID: share.ID, Field: FieldID, Err: ErrDuplicateShareID}
 		}
 		seenIndexes[share.ID] = true
 	}
 
+	if len(shares) < int(as.T) {
+		return nil, fmt.Errorf("%w: have %d, need %d", ErrNotEnoughShares, len(shares), as.T)
+	}
+
+	// Every subset of at least the threshold is a candidate, so their number
+	// grows exponentially with the shares beyond the threshold.
+	candidates := 0
+	for i := len(shares); i >= int(as.T); i-- {
+		candidates += binomial(len(shares), i, maxCandidateSubsets)
+		if candidates > maxCandidateSubsets {
+			return nil, fmt.Errorf("%d shares of a %d-of-%d sharing give more than %d candidate subsets", len(shares), as.T, as.N, maxCandidateSubsets)
+		}
+	}
+
 	// We compute all subsets of different sizes above the threshold to use for recovery,
 	// ordering it such that the subsets with the most elements are first.
-	out := make([][]*SecretShare, 0)
+	out := make([][]*SecretShare, 0, candidates)
 	for i := len(shares); i >= int(as.T); i-- {
-		out = append(out, kSubsets(i, shares)...)
+		subsets := kSubsets(i, shares)
+		if debugAssertions {
+			assertSubsets(i, shares, subsets)
+		}
+		out = append(out, subsets...)
 	}
 	return out, nil
 }
 
-func kSubsets(k int, shares []*SecretShare) [][]*SecretShare {
-	if k > len(shares) {
-		panic(fmt.Sprintf("not enough shares to create subsets, k: %d, len: %d", k, len(shares)))
+// checkConsistent returns a ShareError if share doesn't have the same access
+// structure, tag and hardening as first, which means they can't be from the
+// same sharing.
+func checkConsistent(first, share *SecretShare) error {
+	if !share.As.Equal(first.As) {
+		return shareErrorf(share, FieldAccessStructure, "shares have inconsistent access structures")
 	}
 
-	// If k is equal to the length, there are no subsets so we just return them.
-	if k == len(shares) {
-		return [][]*SecretShare{shares}
+	if !bytes.Equal(share.Tag, first.Tag) {
+		return shareErrorf(share, FieldTag, "shares have inconsistent tags")
 	}
 
-	out := make([][]*SecretShare, 0)
+	if !share.Hardening.equal(first.Hardening) {
+		return shareErrorf(share, FieldHardening, "shares have inconsistent hardening")
+	}
 
-	// Triple nested for loops with index manipluation are always a bit complex to
-	// understand but I'll try to explain what this is doing here.
-	//
-	// It uses a psuedo-windowing strategy where we start at the first index and
-	// then try to find the next k-1 elements going forward in the list. We use
-	// k-1 because we always include the i-th element in the start of the set.
-	//
-	// By only going forward we are able to prevent creating any subsets which are
-	// permutations of existing ones.
-	for i := 0; i < len(shares); i++ {
-		for j := i + 1; j < len(shares); j++ {
-			// If this value is larger than the number of shares, we won't be able to
-			// find a total k shares for our subset, so we bail out.
-			if j+k-1 > len(shares) {
-				break
-			}
+	if share.Version != first.Version {
+		return shareErrorf(share, FieldVersion, "shares have inconsistent versions")
+	}
 
-			set := []*SecretShare{shares[i]}
-			for l := 0; l < k-1; l++ {
-				set = append(set, shares[j+l])
-			}
+	if share.Domain != first.Domain {
+		return shareErrorf(share, FieldDomain, "shares have inconsistent domains")
+	}
 
-			out = append(out, set)
-		}
+	if share.Scheme != first.Scheme {
+		return shareErrorf(share, FieldScheme, "shares have inconsistent schemes")
 	}
 
-	return out
+	if !bytes.Equal(share.Points, first.Points) {
+		return shareErrorf(share, FieldPoints, "shares have inconsistent evaluation points")
+	}
+
+	return nil
 }
 
-// axRecover implements the AX transform (figure 8) over the the base Secret sharing scheme
-func axRecover(shares []*SecretShare) ([]byte, error) {
-	s1Shares := make([]*s1SecretShare, len(shares))
-	for i, share := range shares {
-		s1Shares[i] = share.toS1()
+// kSubsets returns every subset of k of the shares, in lexicographic order of
+// their positions.
+func kSubsets(k int, shares []*SecretShare) [][]*SecretShare {
+	if k > len(shares) {
+		panic(fmt.Sprintf("not enough shares to create subsets, k: %d, len: %d", k, len(shares)))
 	}
 
-	K, err := s1Recover(s1Shares)
-	if err != nil {
-		return nil, err
+	// positions holds the indexes of the current subset in increasing order.
+	// Each step advances the last position that can still move and resets
+	// the ones after it to follow it.
+	n := len(shares)
+	out := make([][]*SecretShare, 0, binomial(n, k, maxCandidateSubsets))
+	positions := make([]int, k)
+	for i := range positions {
+		positions[i] = i
+	}
+	for {
+		set := make([]*SecretShare, k)
+		for i, pos := range positions {
+			set[i] = shares[pos]
+		}
+		out = append(out, set)
+
+		i := k - 1
+		for i >= 0 && positions[i] == n-k+i {
+			i--
+		}
+		if i < 0 {
+			return out
+		}
+		positions[i]++
+		for j := i + 1; j < k; j++ {
+			positions[j] = positions[j-1] + 1
+		}
+	}
+}
+
+// binomial returns the number of ways to choose k of n, or limit+1 if it is
+// more than limit.
+func binomial(n, k, limit int) int {
+	if k > n-k {
+		k = n - k
+	}
+	c := 1
+	for i := 1; i <= k; i++ {
+		// c*(n-k+i) is divisible by i since c is C(n-k+i-1, i-1).
+		c = c * (n - k + i) / i
+		if c > limit {
+			return limit + 1
+		}
+	}
+	return c
+}
+
+// axRecover implements the AX transform (figure 8) over the the base Secret sharing scheme
+//
+// It returns the message along with the shares that verified, those that are
+// in the sharing regenerated from the message, which may be fewer than were
+// provided. It is up to the EX transform to decide if that is enough.
+//
+// When uniform is set, every step is performed even after a check has failed,
+// including recovering the key, which continues with a zero key, and
+// comparisons are constant time, so the work done doesn't depend on whether
+// the shares are valid. It still depends on the number and sizes of the
+// shares, which the caller supplied.
+func axRecover(ctx context.Context, shares []*SecretShare, uniform bool) ([]byte, []*SecretShare, error) {
+	K, baseErr := baseRecover(shares)
+	if baseErr != nil {
+		if !uniform {
+			return nil, nil, baseErr
+		}
+		K = make([]byte, 32)
 	}
 
 	share0 := shares[0]
 	A, C, D, J, T := share0.As, share0.Pub.C, share0.Pub.D, share0.Pub.J, share0.Tag
+	params := paramsOf(share0)
+	params.hardened = hardeningCacheFrom(ctx)
 
 	M, R, err := xorKeyStreamTwoInputs(K, C, D)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Verify the integrity of the recovered params
-	recovJ, recovK, _ := computeJKL(A, M, R, T)
-	if !bytes.Equal(recovJ, J) || !bytes.Equal(recovK, K) {
-		return nil, fmt.Errorf("checksum failed")
+	_, span := startSpan(ctx, SpanComputeJKL)
+	recovJ, recovK, _ := computeJKL(A, M, R, T, params)
+	span.End()
+	checksumOK := subtle.ConstantTimeCompare(recovJ, J)&subtle.ConstantTimeCompare(recovK, K) == 1
+	if !checksumOK && !uniform {
+		return nil, nil, fmt.Errorf("checksum failed")
 	}
 
 	// Ensure that this combination of share IDs is supported by the access structure
-	shareIDs := make([]uint8, len(shares))
+	shareIDs := make([]uint16, len(shares))
 	for i, share := range shares {
 		shareIDs[i] = share.ID
 	}
-	if !A.isSupportedIDSet(shareIDs) {
-		return nil, fmt.Errorf("unsupported share IDs: %v", shareIDs)
+	unsupported := A.checkIDSet(shareIDs)
+	if unsupported != nil && !uniform {
+		return nil, nil, fmt.Errorf("unsupported share IDs %v: %w", shareIDs, unsupported)
 	}
 
-	// Verify that the shares provided are a subset of all shares. We regenerate
-	// all shares using the recovered data.
-	reshares, err := internalShare(A, M, R, T)
+	// Find which of the shares provided are in the sharing. We regenerate all
+	// shares using the recovered data.
+	// This can only fail for parameters that don't match the sharing, which
+	// uniform recovery reaches after a failed checksum.
+	reshares, err := internalShareContext(ctx, A, M, R, T, params)
 	if err != nil {
-		panic(err)
+		if baseErr != nil {
+			return nil, nil, baseErr
+		}
+		return nil, nil, fmt.Errorf("resharing: %w", err)
 	}
-	if !isSubset(shares, reshares) {
-		return nil, fmt.Errorf("not a subset of resharing")
+	if debugAssertions && params.scheme == SchemeShamir {
+		assertShareLengths(reshares, len(K))
 	}
 
-	return M, nil
+	if uniform {
+		V := verifiedSharesUniform(shares, reshares)
+		switch {
+		case baseErr != nil:
+			return nil, nil, baseErr
+		case !checksumOK:
+			return nil, nil, fmt.Errorf("checksum failed")
+		case unsupported != nil:
+			return nil, nil, fmt.Errorf("unsupported share IDs %v: %w", shareIDs, unsupported)
+		}
+		return M, V, nil
+	}
+
+	return M, verifiedShares(shares, reshares), nil
 }
 
-// xorKeyStreamTwoInputs will derive an AES keystream using the key and then
-// generate a unique keystream for each input using the IV as a domain separator
-// and return the output. This can be used to encrypt and decrypt.
-func xorKeyStreamTwoInputs(k, p1, p2 []byte) ([]byte, []byte, error) {
-	ciph, err := aes.NewCipher(k)
-	if err != nil {
-		return nil, nil, err
+// sameSharing reports whether two sets of shares that each recovered are from
+// the same sharing, so they recover the same message.
+func sameSharing(shares, other []*SecretShare) bool {
+	return bytes.Equal(shares[0].Pub.J, other[0].Pub.J)
+}
+
+// isSubsetUniform is like isSubset but compares every pair of shares in
+// constant time rather than stopping at the first match or mismatch.
+func isSubsetUniform(subset, set []*SecretShare) bool {
+	return len(subset) <= len(set) && len(verifiedSharesUniform(subset, set)) == len(subset)
+}
+
+// verifiedShares returns the shares that are in the resharing, in order. Like
+// isSubset, it indexes the resharing by fingerprint when it is large.
+func verifiedShares(shares, reshares []*SecretShare) []*SecretShare {
+	var index map[uint64][]*SecretShare
+	if len(shares)*len(reshares) > fingerprintIndexThreshold {
+		index = make(map[uint64][]*SecretShare, len(reshares))
+		for _, reshare := range reshares {
+			fp := reshare.fingerprint()
+			index[fp] = append(index[fp], reshare)
+		}
 	}
 
-	stream1 := cipher.NewCTR(ciph, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0})
-	c1 := make([]byte, len(p1))
-	stream1.XORKeyStream(c1, p1)
+	V := make([]*SecretShare, 0, len(shares))
+	for _, share := range shares {
+		candidates := reshares
+		if index != nil {
+			candidates = index[share.fingerprint()]
+		}
+		for _, candidate := range candidates {
+			if share.Equal(candidate) {
+				V = append(V, share)
+				break
+			}
+		}
+	}
+	return V
+}
 
-	stream2 := cipher.NewCTR(ciph, []byte{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1})
-	c2 := make([]byte, len(p2))
-	stream2.XORKeyStream(c2, p2)
+// verifiedSharesUniform is like verifiedShares but compares every pair of
+// shares in constant time before selecting the ones that were found.
+func verifiedSharesUniform(shares, reshares []*SecretShare) []*SecretShare {
+	found := make([]int, len(shares))
+	for i, share := range shares {
+		for _, reshare := range reshares {
+			found[i] |= share.constantTimeEqual(reshare)
+		}
+	}
 
-	return c1, c2, nil
+	V := make([]*SecretShare, 0, len(shares))
+	for i, share := range shares {
+		if found[i] == 1 {
+			V = append(V, share)
+		}
+	}
+	return V
 }
 
-func computeJKL(A AccessStructure, M, R, T []byte) ([]byte, []byte, []byte) {
-	aBytes := A.Bytes()
-	input := make([]byte, len(aBytes)+len(M)+len(R)+len(T))
-	copy(input, aBytes)
-	copy(input[len(aBytes):], M)
-	copy(input[len(aBytes)+len(M):], R)
-	copy(input[len(aBytes)+len(M)+len(R):], T)
+func computeJKL(A AccessStructure, M, R, T []byte, params shareParams) ([]byte, []byte, []byte) {
+	inputs := hashInputs(A, M, R, T, params.version, params.points)
+
+	// When hardening, we replace the input with a slow hash of it so every guess
+	// at the inputs costs an Argon2id evaluation. The parameters are part of the
+	// salt so that changing them changes every output. Otherwise the parts are
+	// hashed in place so we never hold a second copy of the message.
+	if hardening := params.hardening; hardening != nil {
+		length := 0
+		for _, input := range inputs {
+			length += len(input)
+		}
+		inputBuf := getBytes(length)
+		defer putBytes(inputBuf)
+		input := (*inputBuf)[:0]
+		for _, part := range inputs {
+			input = append(input, part...)
+		}
+
+		inputs = [][]byte{params.hardened.harden(input, hardening)}
+	}
+
+	// Each output hashes the same input, so they are domain separated by a
+	// prefix. Before VersionLabeled it was an incrementing integer. Sharings
+	// with another scheme than SchemeShamir label their outputs as such, such
+	// as "adss/v2/replicated/K", so the scheme can't be changed.
+	prefixes := [][]byte{{1}, {2}, {3}, {4}}
+	if params.version >= VersionLabeled {
+		domain := params.domain
+		if domain == "" {
+			domain = DefaultDomain
+		}
+		for i, name := range []string{"J0", "J1", "K", "L"} {
+			switch params.scheme {
+			case SchemeReplicated:
+				name = "replicated/" + name
+			case SchemeFormula:
+				name = "formula/" + name
+			case SchemeShamir16:
+				name = "shamir16/" + name
+			}
+			label := fmt.Sprintf("%s/v%d/%s", domain, params.version, name)
+			prefixes[i] = append([]byte{byte(len(label))}, label...)
+		}
+	}
+
+	h := sha256.New()
+	hashWithPrefix := func(prefix []byte, out []byte) []byte {
+		h.Reset()
+		h.Write(prefix)
+		for _, input := range inputs {
+			h.Write(input)
+		}
+		return h.Sum(out)
+	}
+
+	J := hashWithPrefix(prefixes[1], hashWithPrefix(prefixes[0], make([]byte, 0, 64)))
+	K := hashWithPrefix(prefixes[2], nil)
+	L := hashWithPrefix(prefixes[3], nil)
 
-	// Incrementing integers used for domain separation because we use the same input
-	J1 := sha256.Sum256(append([]byte{1}, input...))
-	J2 := sha256.Sum256(append([]byte{2}, input...))
-	J := append(J1[:], J2[:]...)
-	K := sha256.Sum256(append([]byte{3}, input...))
-	L := sha256.Sum256(append([]byte{4}, input...))
+	return J, K, L
+}
+
+// hashInputs returns the parts of the encoding of the inputs that are hashed
+// to derive J, K and L, in order. From VersionFramed the version is included
+// and each variable length input is prefixed with its length as a 64-bit big
+// endian integer, so every set of inputs has a distinct encoding.
+//
+// Any exclusions of the access structure follow, framed in every version, so
+// they can't be removed without changing the hash and structures without
+// exclusions hash the same as they always have. Any evaluation points follow
+// them likewise, with the exclusions framed even if there are none so the two
+// can't be confused.
+func hashInputs(A AccessStructure, M, R, T []byte, version uint8, points []uint8) [][]byte {
+	var inputs [][]byte
+	if version == VersionUnframed {
+		inputs = [][]byte{A.Bytes(), M, R, T}
+	} else {
+		header := append(A.Bytes(), version)
+		var lengths [3][8]byte
+		binary.BigEndian.PutUint64(lengths[0][:], uint64(len(M)))
+		binary.BigEndian.PutUint64(lengths[1][:], uint64(len(R)))
+		binary.BigEndian.PutUint64(lengths[2][:], uint64(len(T)))
+		inputs = [][]byte{header, lengths[0][:], M, lengths[1][:], R, lengths[2][:], T}
+	}
 
-	return J[:], K[:], L[:]
+	if exclusions := A.exclusionBytes(); exclusions != nil || points != nil {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(exclusions)))
+		inputs = append(inputs, length[:], exclusions)
+	}
+	if points != nil {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(points)))
+		inputs = append(inputs, length[:], points)
+	}
+	return inputs
 }
@@ -2,7 +2,12 @@ package adss
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 	"testing"
 )
 
@@ -59,13 +64,27 @@ func TestSplitAndRecover(t *testing.T) {
 		{
 			"dup-share",
 			func() []*SecretShare { return []*SecretShare{shares[0], shares[0]} },
-			func() error { return fmt.Errorf("plausible shares: duplicate share ID found") },
+			func() error { return fmt.Errorf("plausible shares: share 0: duplicate share ID") },
 		},
 		{
 			"no-shares",
 			func() []*SecretShare { return []*SecretShare{} },
 			func() error { return fmt.Errorf("plausible shares: no shares provided") },
 		},
+		{
+			"too-few-shares",
+			func() []*SecretShare { return []*SecretShare{shares[1]} },
+			func() error { return fmt.Errorf("plausible shares: not enough shares: have 1, need 2") },
+		},
+		{
+			"zero-threshold",
+			func() []*SecretShare {
+				mod := cloneShare(shares[0])
+				mod.As.T = 0
+				return []*SecretShare{mod}
+			},
+			func() error { return fmt.Errorf("plausible shares: share 0: invalid access structure: 0-of-3") },
+		},
 		{
 			"modified-as",
 			func() []*SecretShare {
@@ -74,7 +93,7 @@ func TestSplitAndRecover(t *testing.T) {
 				return []*SecretShare{mod, shares[1]}
 			},
 			func() error {
-				return fmt.Errorf("plausible shares: shares have inconsistent access structures")
+				return fmt.Errorf("plausible shares: share 1: shares have inconsistent access structures")
 			},
 		},
 		{
@@ -149,7 +168,7 @@ func TestSplitAndRecover(t *testing.T) {
 				return []*SecretShare{mod, shares[1]}
 			},
 			func() error {
-				return fmt.Errorf("plausible shares: shares have inconsistent tags")
+				return fmt.Errorf("plausible shares: share 1: shares have inconsistent tags")
 			},
 		},
 		{"multiple-explanations",
@@ -213,6 +232,14 @@ func TestSplitAndRecover(t *testing.T) {
 			},
 			[]int{1, 2},
 		},
+		{"truncated-sec", msg,
+			func() []*SecretShare {
+				mod := cloneShare(shares[1])
+				mod.Sec = mod.Sec[:5]
+				return []*SecretShare{shares[0], mod, shares[2]}
+			},
+			[]int{0, 2},
+		},
 	}
 	for _, tt := range errRecoveryTests {
 		tt := tt
@@ -243,8 +270,372 @@ func TestSplitAndRecover(t *testing.T) {
 	}
 }
 
+func TestShareWithEntropy(t *testing.T) {
+	msg := []byte("hello world")
+	as := NewAccessStructure(2, 3)
+	entropy := []byte("4 6 1 3 3 5 2 6 1")
+
+	shares1, err := ShareWithEntropy(as, msg, nil, entropy)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	recov, _, err := Recover(shares1[1:])
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x != %x", recov, msg)
+	}
+
+	// The entropy is mixed with crypto/rand, so reusing it must not reproduce
+	// the same sharing.
+	shares2, err := ShareWithEntropy(as, msg, nil, entropy)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if shares1[0].Equal(shares2[0]) {
+		t.Errorf("sharings with the same entropy were identical")
+	}
+}
+
+func TestShareWithRand(t *testing.T) {
+	msg := []byte("hello world")
+	as := NewAccessStructure(2, 3)
+	coins := bytes.Repeat([]byte{7}, 32)
+
+	shares1, err := ShareWithRand(bytes.NewReader(coins), as, msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	recov, _, err := Recover(shares1[:2])
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x != %x", recov, msg)
+	}
+
+	// The coins are all the randomness, so the same coins give the same
+	// sharing.
+	shares2, err := ShareWithRand(bytes.NewReader(coins), as, msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	for i := range shares1 {
+		if !shares1[i].Equal(shares2[i]) {
+			t.Errorf("share %d differs between sharings with the same coins", i)
+		}
+	}
+
+	if _, err := ShareWithRand(bytes.NewReader(coins[:31]), as, msg, nil); err == nil {
+		t.Errorf("expected an error for a random source that runs out")
+	}
+	if _, err := ShareWithRand(nil, as, msg, nil); err == nil {
+		t.Errorf("expected an error for a nil random source")
+	}
+}
+
+func TestShareDeterministic(t *testing.T) {
+	msg := []byte("hello world")
+	as := NewAccessStructure(2, 3)
+	R := bytes.Repeat([]byte{9}, 32)
+
+	shares1, err := ShareDeterministic(as, msg, R, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	shares2, err := ShareDeterministic(as, msg, R, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	for i := range shares1 {
+		if !shares1[i].Equal(shares2[i]) {
+			t.Errorf("share %d differs between sharings of the same inputs", i)
+		}
+	}
+	recov, _, err := Recover(shares1[1:])
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x != %x", recov, msg)
+	}
+
+	if _, err := ShareDeterministic(as, msg, R[:16], nil); err == nil {
+		t.Errorf("expected an error for short coins")
+	}
+}
+
+func TestShareWithDealerKey(t *testing.T) {
+	msg := []byte("hello world")
+	as := NewAccessStructure(2, 3)
+	key := bytes.Repeat([]byte{1}, 32)
+
+	shares1, err := ShareWithDealerKey(as, msg, []byte("ad"), key)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	shares2, err := ShareWithDealerKey(as, msg, []byte("ad"), key)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	// Re-running the sharing re-issues identical shares, so a replacement
+	// share works with the originals.
+	for i := range shares1 {
+		if !shares1[i].Equal(shares2[i]) {
+			t.Errorf("share %d differs between runs", i)
+		}
+	}
+	recov, _, err := Recover([]*SecretShare{shares1[0], shares2[2]})
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x != %x", recov, msg)
+	}
+
+	otherKey, err := ShareWithDealerKey(as, msg, []byte("ad"), bytes.Repeat([]byte{2}, 32))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	otherAD, err := ShareWithDealerKey(as, msg, []byte("other"), key)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if shares1[0].Equal(otherKey[0]) || bytes.Equal(shares1[0].Pub.D, otherAD[0].Pub.D) {
+		t.Errorf("different inputs produced the same coins")
+	}
+
+	if _, err := ShareWithDealerKey(as, msg, nil, bytes.Repeat([]byte{1}, 31)); err == nil {
+		t.Errorf("expected error for a short dealer key")
+	}
+}
+
+func TestShareSharesPublicPayload(t *testing.T) {
+	msg := make([]byte, 1024)
+	shares, err := Share(NewAccessStructure(2, 5), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	// Every share should reference the same public payload rather than hold
+	// its own copy, so memory doesn't grow with the number of shares.
+	for _, share := range shares[1:] {
+		if &share.Pub.C[0] != &shares[0].Pub.C[0] || &share.Pub.D[0] != &shares[0].Pub.D[0] {
+			t.Errorf("share %d has its own copy of the public payload", share.ID)
+		}
+	}
+}
+
+func TestShareFunc(t *testing.T) {
+	msg := []byte("hello world")
+	as := NewAccessStructure(2, 3)
+
+	var shares []*SecretShare
+	err := ShareFunc(as, msg, []byte("ad"), func(share *SecretShare) error {
+		shares = append(shares, share)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if len(shares) != 3 {
+		t.Fatalf("len(shares) = %d, expected: %d", len(shares), 3)
+	}
+
+	recov, _, err := Recover(shares[1:])
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x != %x", recov, msg)
+	}
+
+	calls := 0
+	err = ShareFunc(as, msg, nil, func(share *SecretShare) error {
+		calls++
+		return fmt.Errorf("disk full")
+	})
+	if err == nil || err.Error() != "disk full" {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("callback called %d times after failing", calls)
+	}
+}
+
+func TestShareToWriters(t *testing.T) {
+	msg := []byte("hello world")
+	as := NewAccessStructure(2, 3)
+
+	bufs := []*bytes.Buffer{{}, {}, {}}
+	writers := []io.Writer{bufs[0], bufs[1], bufs[2]}
+	if err := ShareToWriters(as, msg, nil, writers); err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	shares := make([]*SecretShare, len(bufs))
+	for i, buf := range bufs {
+		shares[i] = &SecretShare{}
+		if err := json.Unmarshal(buf.Bytes(), shares[i]); err != nil {
+			t.Fatalf("unmarshal share %d: %s", i, err)
+		}
+		if shares[i].ID != uint16(i) {
+			t.Errorf("writer %d got share %d", i, shares[i].ID)
+		}
+	}
+
+	recov, _, err := Recover(shares[:2])
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x != %x", recov, msg)
+	}
+
+	if err := ShareToWriters(as, msg, nil, writers[:2]); err == nil {
+		t.Errorf("expected error with too few writers")
+	}
+}
+
+func TestRecoverWithUniformWork(t *testing.T) {
+	msg := []byte("hello world")
+	as := NewAccessStructure(2, 3)
+	shares, err := Share(as, msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	other, err := Share(NewAccessStructure(2, 5), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	other2, err := Share(NewAccessStructure(2, 5), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	bad := cloneShare(shares[0])
+	bad.Sec[0] = bad.Sec[0] + 1
+	short := cloneShare(shares[0])
+	short.Sec = short.Sec[:len(short.Sec)-1]
+
+	// Uniform work recovery must produce exactly the same results as the
+	// default early-exit recovery.
+	var tests = []struct {
+		name string
+		data []*SecretShare
+	}{
+		{"all shares", shares},
+		{"two shares", []*SecretShare{shares[1], shares[2]}},
+		{"one bad share", []*SecretShare{bad, shares[1], shares[2]}},
+		{"not enough good shares", []*SecretShare{bad, shares[1]}},
+		{"multiple explanations", []*SecretShare{other[0], other[1], other2[2], other2[3]}},
+		{"short share", []*SecretShare{short, shares[1], shares[2]}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			expected, expectedV, expectedErr := Recover(tt.data)
+			recov, V, err := Recover(tt.data, WithUniformWork())
+
+			if fmt.Sprint(err) != fmt.Sprint(expectedErr) {
+				t.Errorf("unexpected error, expected: %v, got: %v", expectedErr, err)
+			}
+			if !bytes.Equal(recov, expected) {
+				t.Errorf("recovered %x != %x", recov, expected)
+			}
+			if sharesDesc(V) != sharesDesc(expectedV) {
+				t.Errorf("valid shares %s != %s", sharesDesc(V), sharesDesc(expectedV))
+			}
+		})
+	}
+
+	// Recovering the key from shares of different lengths fails, and uniform
+	// work carries on with the rest of the attempt before reporting it.
+	_, _, expectedErr := axRecover(context.Background(), []*SecretShare{short, shares[1]}, false)
+	if _, _, err := axRecover(context.Background(), []*SecretShare{short, shares[1]}, true); err == nil || fmt.Sprint(err) != fmt.Sprint(expectedErr) {
+		t.Errorf("unexpected error, expected: %v, got: %v", expectedErr, err)
+	}
+}
+
+func TestShareHardened(t *testing.T) {
+	msg := []byte("hunter2")
+	as := NewAccessStructure(2, 3)
+	shares, err := ShareHardened(as, msg, nil, testArgon2Params)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	recov, _, err := Recover(shares[:2])
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x != %x", recov, msg)
+	}
+
+	// Changing the parameters on all shares changes the derived values so the
+	// checksum no longer verifies.
+	mod1, mod2 := cloneShare(shares[0]), cloneShare(shares[1])
+	params := *shares[0].Hardening
+	params.Time++
+	mod1.Hardening, mod2.Hardening = &params, &params
+	if _, _, err := Recover([]*SecretShare{mod1, mod2}); err == nil || err.Error() != "recovery: checksum failed" {
+		t.Errorf("unexpected error, expected: recovery: checksum failed, got: %v", err)
+	}
+
+	// Removing the hardening from one share is detected as inconsistent.
+	mod1.Hardening = nil
+	if _, _, err := Recover([]*SecretShare{shares[0], mod1}); err == nil || err.Error() != "plausible shares: share 0: shares have inconsistent hardening" {
+		t.Errorf("unexpected error, expected: plausible shares: shares have inconsistent hardening, got: %v", err)
+	}
+
+	// Parameters that Argon2id rejects, or that would take too long or too
+	// much memory, are refused before hashing.
+	for _, bad := range []Argon2Params{
+		{Time: 0, Memory: 64, Threads: 1},
+		{Time: 1, Memory: 0, Threads: 1},
+		{Time: 1, Memory: 64, Threads: 0},
+		{Time: 1, Memory: 0xffffffff, Threads: 1},
+		{Time: 0xffffffff, Memory: 64, Threads: 1},
+		{Time: 1, Memory: 64, Threads: 255},
+	} {
+		bad := bad
+		mod1, mod2 := cloneShare(shares[0]), cloneShare(shares[1])
+		mod1.Hardening, mod2.Hardening = &bad, &bad
+		_, _, err := Recover([]*SecretShare{mod1, mod2}, WithUniformWork())
+		var shareErr *ShareError
+		if !errors.As(err, &shareErr) || shareErr.Field != FieldHardening {
+			t.Errorf("%+v: expected a hardening error, got: %v", bad, err)
+		}
+		if _, err := ShareHardened(as, msg, nil, bad); err == nil {
+			t.Errorf("%+v: expected an error sharing", bad)
+		}
+	}
+}
+
+func TestHardeningCache(t *testing.T) {
+	cache := hardeningCache{}
+	out := cache.harden([]byte("input"), &testArgon2Params)
+	if again := cache.harden([]byte("input"), &testArgon2Params); !bytes.Equal(again, out) || len(cache) != 1 {
+		t.Errorf("hardening the same input again wasn't cached")
+	}
+	if uncached := hardeningCache(nil).harden([]byte("input"), &testArgon2Params); !bytes.Equal(uncached, out) {
+		t.Errorf("cached output %x differs from %x", out, uncached)
+	}
+	other := testArgon2Params
+	other.Time++
+	if cache.harden([]byte("input"), &other); len(cache) != 2 {
+		t.Errorf("different parameters hit the cache")
+	}
+}
+
 func cloneShare(share *SecretShare) *SecretShare {
-	out := &SecretShare{ID: share.ID, As: share.As}
+	out := &SecretShare{ID: share.ID, As: share.As.clone(), Version: share.Version, Domain: share.Domain, Scheme: share.Scheme}
 	out.Pub = struct{ C, D, J []byte }{
 		append([]byte{}, share.Pub.C...),
 		append([]byte{}, share.Pub.D...),
@@ -252,19 +643,70 @@ func cloneShare(share *SecretShare) *SecretShare {
 	}
 	out.Sec = append([]byte{}, share.Sec...)
 	out.Tag = append([]byte{}, share.Tag...)
+	if share.Hardening != nil {
+		params := *share.Hardening
+		out.Hardening = &params
+	}
+	if share.Points != nil {
+		out.Points = append([]uint8{}, share.Points...)
+	}
 	return out
 }
 
+func TestRecoverNonContiguousSubset(t *testing.T) {
+	msg := []byte("hello world")
+	shares, err := Share(NewAccessStructure(3, 5), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	// Only shares 0, 2 and 4 are valid, which aren't next to each other.
+	given := []*SecretShare{shares[0], cloneShare(shares[1]), shares[2], cloneShare(shares[3]), shares[4]}
+	given[1].Sec[0]++
+	given[3].Sec[0]++
+	recov, V, err := Recover(given)
+	if err != nil || !bytes.Equal(recov, msg) {
+		t.Fatalf("recovered %q, %v", recov, err)
+	}
+	if sharesDesc(V) != "{ID:0, ID:2, ID:4}" {
+		t.Errorf("valid shares %s, expected: {ID:0, ID:2, ID:4}", sharesDesc(V))
+	}
+}
+
+func TestRecoverTooManyCandidates(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 40), []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if _, _, err := Recover(shares); err == nil || !strings.Contains(err.Error(), "candidate subsets") {
+		t.Errorf("expected an error for too many candidate subsets, got: %v", err)
+	}
+}
+
+func Test_binomial(t *testing.T) {
+	for _, tt := range []struct{ n, k, limit, expected int }{
+		{5, 0, 100, 1},
+		{5, 3, 100, 10},
+		{40, 20, 100, 101},
+		{65535, 2, 1 << 40, 65535 * 65534 / 2},
+	} {
+		if got := binomial(tt.n, tt.k, tt.limit); got != tt.expected {
+			t.Errorf("binomial(%d, %d, %d) = %d, expected: %d", tt.n, tt.k, tt.limit, got, tt.expected)
+		}
+	}
+}
+
 func Test_kSubsets(t *testing.T) {
 	var tests = []struct {
 		k        int
 		input    []int
 		expected string
 	}{
-		// {1, []int{0, 1, 2}, "{0,},{1,},{2,},"}, (currently broken though not too important to fix since this doesn't come up in practice)
+		{1, []int{0, 1, 2}, "{0,},{1,},{2,},"},
 		{2, []int{0, 1, 2}, "{0,1,},{0,2,},{1,2,},"},
 		{3, []int{0, 1, 2}, "{0,1,2,},"},
-		{3, []int{0, 1, 2, 3}, "{0,1,2,},{0,2,3,},{1,2,3,},"},
+		{3, []int{0, 1, 2, 3}, "{0,1,2,},{0,1,3,},{0,2,3,},{1,2,3,},"},
+		{3, []int{0, 1, 2, 3, 4}, "{0,1,2,},{0,1,3,},{0,1,4,},{0,2,3,},{0,2,4,},{0,3,4,},{1,2,3,},{1,2,4,},{1,3,4,},{2,3,4,},"},
 	}
 
 	for _, tt := range tests {
@@ -272,7 +714,7 @@ func Test_kSubsets(t *testing.T) {
 		t.Run(fmt.Sprintf("%d-subset of len %d", tt.k, len(tt.input)), func(t *testing.T) {
 			shares := make([]*SecretShare, len(tt.input))
 			for i := range shares {
-				shares[i] = &SecretShare{ID: uint8(tt.input[i])}
+				shares[i] = &SecretShare{ID: uint16(tt.input[i])}
 			}
 
 			subsets := kSubsets(tt.k, shares)
@@ -291,3 +733,342 @@ func Test_kSubsets(t *testing.T) {
 		})
 	}
 }
+
+func TestShareVersions(t *testing.T) {
+	A, R := NewAccessStructure(2, 3), bytes.Repeat([]byte{1}, 32)
+
+	// Without framing, moving bytes between adjacent inputs gives the same
+	// checksum and key.
+	M, T := []byte("hello"), []byte("world")
+	shiftedM, shiftedR := append(append([]byte{}, M...), R[0]), R[1:]
+	unframedJ, unframedK, _ := computeJKL(A, M, R, T, shareParams{version: VersionUnframed})
+	shiftedJ, shiftedK, _ := computeJKL(A, shiftedM, shiftedR, T, shareParams{version: VersionUnframed})
+	if !bytes.Equal(unframedJ, shiftedJ) || !bytes.Equal(unframedK, shiftedK) {
+		t.Fatalf("expected unframed inputs to collide")
+	}
+	framedJ, _, _ := computeJKL(A, M, R, T, shareParams{version: VersionFramed})
+	shiftedJ, _, _ = computeJKL(A, shiftedM, shiftedR, T, shareParams{version: VersionFramed})
+	if bytes.Equal(framedJ, shiftedJ) {
+		t.Errorf("framed inputs collide")
+	}
+	if bytes.Equal(framedJ, unframedJ) {
+		t.Errorf("versions have the same checksum")
+	}
+
+	shares, err := Share(A, M, T)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if shares[0].Version != currentVersion {
+		t.Errorf("new share has version %d, expected: %d", shares[0].Version, currentVersion)
+	}
+
+	// Shares from before versioning, which don't record a version, still
+	// recover.
+	legacy, err := internalShare(A, M, R, T, shareParams{version: VersionUnframed})
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	encoded, err := json.Marshal(legacy[0])
+	if err != nil {
+		t.Fatalf("unexpected error marshalling: %s", err)
+	}
+	if bytes.Contains(encoded, []byte("Version")) {
+		t.Errorf("legacy share encoded with a version: %s", encoded)
+	}
+	decoded, err := DecodeShareString(EncodeShareString(legacy[1]))
+	if err != nil {
+		t.Fatalf("unexpected error decoding: %s", err)
+	}
+	if recov, _, err := Recover([]*SecretShare{legacy[0], decoded}); err != nil || string(recov) != "hello" {
+		t.Errorf("recovered %q, %v from legacy shares", recov, err)
+	}
+
+	// Changing the version of every share breaks the checksum.
+	downgraded := make([]*SecretShare, 2)
+	for i := range downgraded {
+		downgraded[i] = cloneShare(shares[i])
+		downgraded[i].Version = VersionUnframed
+	}
+	if recov, _, err := Recover(downgraded); err == nil {
+		t.Errorf("recovered %q from downgraded shares", recov)
+	}
+
+	future := cloneShare(shares[1])
+	future.Version = currentVersion + 1
+	_, _, err = Recover([]*SecretShare{future, shares[0]})
+	if shareErr, ok := errors.Unwrap(err).(*ShareError); !ok || shareErr.Field != FieldVersion {
+		t.Errorf("unexpected error for an unsupported version: %v", err)
+	}
+	_, _, err = Recover([]*SecretShare{shares[0], legacy[1]})
+	if shareErr, ok := errors.Unwrap(err).(*ShareError); !ok || shareErr.Field != FieldVersion {
+		t.Errorf("unexpected error for mixed versions: %v", err)
+	}
+}
+
+func TestShareInDomain(t *testing.T) {
+	A, M, R, T := NewAccessStructure(2, 3), []byte("hello"), bytes.Repeat([]byte{1}, 32), []byte("ad")
+
+	// The same inputs in different domains are unrelated.
+	defaultJ, defaultK, _ := computeJKL(A, M, R, T, newShareParams())
+	namedJ, _, _ := computeJKL(A, M, R, T, shareParams{version: currentVersion, domain: DefaultDomain})
+	if !bytes.Equal(defaultJ, namedJ) {
+		t.Errorf("empty domain differs from the default domain")
+	}
+	otherJ, otherK, _ := computeJKL(A, M, R, T, shareParams{version: currentVersion, domain: "example.com"})
+	if bytes.Equal(defaultJ, otherJ) || bytes.Equal(defaultK, otherK) {
+		t.Errorf("domains have the same checksum or key")
+	}
+
+	shares, err := ShareInDomain("example.com", A, M, T)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if shares[0].Domain != "example.com" {
+		t.Errorf("share has domain %q", shares[0].Domain)
+	}
+	decoded, err := DecodeShareString(EncodeShareString(shares[1]))
+	if err != nil {
+		t.Fatalf("unexpected error decoding: %s", err)
+	}
+	if !decoded.Equal(shares[1]) {
+		t.Errorf("decoded share doesn't match")
+	}
+	if recov, _, err := Recover([]*SecretShare{shares[0], decoded}); err != nil || !bytes.Equal(recov, M) {
+		t.Errorf("recovered %q, %v", recov, err)
+	}
+
+	// Moving the shares to another domain breaks the checksum.
+	moved := make([]*SecretShare, 2)
+	for i := range moved {
+		moved[i] = cloneShare(shares[i])
+		moved[i].Domain = "example.org"
+	}
+	if recov, _, err := Recover(moved); err == nil {
+		t.Errorf("recovered %q from shares moved to another domain", recov)
+	}
+	moved[0].Domain = ""
+	_, _, err = Recover(moved)
+	if shareErr, ok := errors.Unwrap(err).(*ShareError); !ok || shareErr.Field != FieldDomain {
+		t.Errorf("unexpected error for mixed domains: %v", err)
+	}
+
+	for _, domain := range []string{"", strings.Repeat("a", 65), "tab\tseparated", "caf\u00e9"} {
+		if _, err := ShareInDomain(domain, A, M, T); err == nil {
+			t.Errorf("expected an error for domain %q", domain)
+		}
+	}
+}
+
+func TestShareWithPoints(t *testing.T) {
+	A, M, T := NewAccessStructure(2, 3), []byte("hello"), []byte("ad")
+	shares, err := ShareWithPoints([]uint8{5, 9, 200}, A, M, T)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if shares[2].Point() != 200 {
+		t.Errorf("share 2 has point %d, expected: 200", shares[2].Point())
+	}
+	decoded, err := DecodeShareString(EncodeShareString(shares[1]))
+	if err != nil || !decoded.Equal(shares[1]) {
+		t.Fatalf("share string round trip failed: %v", err)
+	}
+	if recov, _, err := Recover([]*SecretShare{shares[2], decoded}); err != nil || !bytes.Equal(recov, M) {
+		t.Errorf("recovered %q, %v", recov, err)
+	}
+
+	// The points are bound into the checksum.
+	moved := make([]*SecretShare, 2)
+	for i := range moved {
+		moved[i] = cloneShare(shares[i])
+		moved[i].Points = nil
+	}
+	if recov, _, err := Recover(moved); err == nil {
+		t.Errorf("recovered %q after removing the points", recov)
+	}
+	moved[0].Points = shares[0].Points
+	_, _, err = Recover(moved)
+	if shareErr, ok := errors.Unwrap(err).(*ShareError); !ok || shareErr.Field != FieldPoints {
+		t.Errorf("unexpected error for mixed points: %v", err)
+	}
+
+	for _, points := range [][]uint8{{1, 2}, {0, 1, 2}, {1, 2, 1}} {
+		if _, err := ShareWithPoints(points, A, M, T); err == nil {
+			t.Errorf("expected an error for points %v", points)
+		}
+	}
+}
+
+func TestSecretShareEqual(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	if !shares[0].Equal(cloneShare(shares[0])) || shares[0].constantTimeEqual(cloneShare(shares[0])) != 1 {
+		t.Errorf("share not equal to its clone")
+	}
+
+	var tests = []struct {
+		name   string
+		modify func(ss *SecretShare)
+	}{
+		{"as", func(ss *SecretShare) { ss.As.N++ }},
+		{"id", func(ss *SecretShare) { ss.ID++ }},
+		{"C", func(ss *SecretShare) { ss.Pub.C[0]++ }},
+		{"D", func(ss *SecretShare) { ss.Pub.D[0]++ }},
+		{"J", func(ss *SecretShare) { ss.Pub.J[0]++ }},
+		{"sec", func(ss *SecretShare) { ss.Sec[0]++ }},
+		{"tag", func(ss *SecretShare) { ss.Tag[0]++ }},
+		{"hardening", func(ss *SecretShare) { ss.Hardening = &testArgon2Params }},
+		{"version", func(ss *SecretShare) { ss.Version = VersionUnframed }},
+		{"domain", func(ss *SecretShare) { ss.Domain = "example.com" }},
+		{"scheme", func(ss *SecretShare) { ss.Scheme = SchemeReplicated }},
+		{"points", func(ss *SecretShare) { ss.Points = []uint8{1, 2, 3} }},
+		// Moving a byte between adjacent fields keeps the concatenation the same
+		// but the shares are still different.
+		{"shifted tag", func(ss *SecretShare) {
+			ss.Sec, ss.Tag = append(ss.Sec, ss.Tag[0]), ss.Tag[1:]
+		}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			mod := cloneShare(shares[0])
+			tt.modify(mod)
+
+			if shares[0].Equal(mod) || mod.Equal(shares[0]) {
+				t.Errorf("modified share is equal")
+			}
+			if shares[0].constantTimeEqual(mod) != 0 {
+				t.Errorf("modified share is constant time equal")
+			}
+		})
+	}
+}
+
+func TestSecretShareFingerprint(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	other, err := Share(NewAccessStructure(2, 3), []byte("hello world"), []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	fp := shares[0].Fingerprint()
+	if len(fp) != 32 {
+		t.Errorf("unexpected fingerprint length: %s", fp)
+	}
+	for _, share := range shares[1:] {
+		if share.Fingerprint() != fp {
+			t.Errorf("share %d fingerprint %s != %s", share.ID, share.Fingerprint(), fp)
+		}
+	}
+	if other[0].Fingerprint() == fp {
+		t.Errorf("different sharings have the same fingerprint")
+	}
+}
+
+func TestIsSubset(t *testing.T) {
+	as := NewAccessStructure(2, 20)
+	shares, err := Share(as, []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	modified := cloneShare(shares[3])
+	modified.Sec[0]++
+
+	clones := make([]*SecretShare, len(shares))
+	for i, share := range shares {
+		clones[i] = cloneShare(share)
+	}
+
+	// The large cases exceed fingerprintIndexThreshold so they exercise the
+	// indexed implementation.
+	var tests = []struct {
+		name     string
+		subset   []*SecretShare
+		set      []*SecretShare
+		expected bool
+	}{
+		{"small subset", shares[:2], shares[:3], true},
+		{"small not subset", []*SecretShare{modified}, shares[:3], false},
+		{"small larger", shares[:3], shares[:2], false},
+		{"large subset", clones[5:], shares, true},
+		{"large equal", clones, shares, true},
+		{"large not subset", append([]*SecretShare{modified}, clones[5:]...), shares, false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := isSubset(tt.subset, tt.set); actual != tt.expected {
+				t.Errorf("isSubset = %t, expected: %t", actual, tt.expected)
+			}
+			if actual := isSubsetUniform(tt.subset, tt.set); actual != tt.expected {
+				t.Errorf("isSubsetUniform = %t, expected: %t", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func Test_verifiedShares(t *testing.T) {
+	as := NewAccessStructure(2, 20)
+	shares, err := Share(as, []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	modified := cloneShare(shares[3])
+	modified.Sec[0]++
+
+	// Only the shares in the resharing are returned, in the order given.
+	for _, input := range [][]*SecretShare{
+		{shares[1], modified, shares[0]},
+		append([]*SecretShare{modified}, shares[5:]...),
+	} {
+		for _, verified := range [][]*SecretShare{
+			verifiedShares(input, shares),
+			verifiedSharesUniform(input, shares),
+		} {
+			if len(verified) != len(input)-1 {
+				t.Fatalf("got %d verified shares, expected: %d", len(verified), len(input)-1)
+			}
+			j := 0
+			for _, share := range input {
+				if share == modified {
+					continue
+				}
+				if verified[j] != share {
+					t.Errorf("verified share %d is ID %d, expected: %d", j, verified[j].ID, share.ID)
+				}
+				j++
+			}
+		}
+	}
+}
+
+func BenchmarkRecoverWithBadShares(b *testing.B) {
+	msg := make([]byte, 64*1024)
+	shares, err := Share(NewAccessStructure(4, 8), msg, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// Corrupt two shares so that many candidate subsets are tried.
+	shares[0] = cloneShare(shares[0])
+	shares[0].Sec[0]++
+	shares[5] = cloneShare(shares[5])
+	shares[5].Sec[0]++
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := Recover(shares); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
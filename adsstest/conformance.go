@@ -0,0 +1,264 @@
+// Package adsstest helps test code that implements or uses adss.
+//
+// RunConformance checks an implementation of the scheme against the
+// behaviour of the reference implementation in package adss, so alternative
+// suites, such as ones with a different hash, cipher or field, and external
+// implementations can be checked programmatically.
+//
+// Corrupt and CheckCorruptions tamper with individual fields of shares so
+// applications can test their handling of corrupted shares.
+package adsstest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/jakecraige/adss"
+)
+
+// Implementation is a sharing scheme under test.
+type Implementation interface {
+	Share(A adss.AccessStructure, M, T []byte) ([]*adss.SecretShare, error)
+	Recover(shares []*adss.SecretShare) ([]byte, []*adss.SecretShare, error)
+}
+
+// CoinsSharer is implemented by implementations of the reference suite that
+// can share with given coins. RunConformance then also checks they reproduce
+// and recover the Vectors exactly. Implementations of other suites, whose
+// shares necessarily differ, shouldn't implement it.
+type CoinsSharer interface {
+	ShareWithCoins(A adss.AccessStructure, M, R, T []byte) ([]*adss.SecretShare, error)
+}
+
+// Reference is the reference implementation in package adss.
+type Reference struct{}
+
+// Share calls adss.Share.
+func (Reference) Share(A adss.AccessStructure, M, T []byte) ([]*adss.SecretShare, error) {
+	return adss.Share(A, M, T)
+}
+
+// Recover calls adss.Recover.
+func (Reference) Recover(shares []*adss.SecretShare) ([]byte, []*adss.SecretShare, error) {
+	return adss.Recover(shares)
+}
+
+// ShareWithCoins calls adss.ShareDeterministic.
+func (Reference) ShareWithCoins(A adss.AccessStructure, M, R, T []byte) ([]*adss.SecretShare, error) {
+	return adss.ShareDeterministic(A, M, R, T)
+}
+
+// RunConformance runs the conformance checks against impl as subtests of t.
+func RunConformance(t *testing.T, impl Implementation) {
+	t.Run("RoundTrip", func(t *testing.T) { testRoundTrip(t, impl) })
+	t.Run("Thresholds", func(t *testing.T) { testThresholds(t, impl) })
+	t.Run("CorruptShare", func(t *testing.T) { testCorruptShare(t, impl) })
+	t.Run("Corruptions", func(t *testing.T) {
+		M := []byte("hello world")
+		CheckCorruptions(t, impl, mustShare(t, impl, adss.NewAccessStructure(2, 4), M, []byte("ad")), M)
+	})
+	t.Run("Rejects", func(t *testing.T) { testRejects(t, impl) })
+
+	if sharer, ok := impl.(CoinsSharer); ok {
+		t.Run("Vectors", func(t *testing.T) { testVectors(t, impl, sharer) })
+	}
+}
+
+func mustShare(t *testing.T, impl Implementation, A adss.AccessStructure, M, T []byte) []*adss.SecretShare {
+	t.Helper()
+	shares, err := impl.Share(A, M, T)
+	if err != nil {
+		t.Fatalf("unexpected error sharing %d-of-%d: %s", A.T, A.N, err)
+	}
+	if len(shares) != int(A.N) {
+		t.Fatalf("got %d shares, expected: %d", len(shares), A.N)
+	}
+	return shares
+}
+
+func checkRecovers(t *testing.T, impl Implementation, shares []*adss.SecretShare, M []byte) {
+	t.Helper()
+	recov, _, err := impl.Recover(shares)
+	if err != nil {
+		t.Errorf("unexpected error recovering from %s: %s", ids(shares), err)
+		return
+	}
+	if !bytes.Equal(recov, M) {
+		t.Errorf("recovered %x from %s, expected: %x", recov, ids(shares), M)
+	}
+}
+
+func ids(shares []*adss.SecretShare) string {
+	out := make([]uint16, len(shares))
+	for i, share := range shares {
+		out[i] = share.ID
+	}
+	return fmt.Sprintf("shares %v", out)
+}
+
+func testRoundTrip(t *testing.T, impl Implementation) {
+	for _, M := range [][]byte{{0}, []byte("hello world"), bytes.Repeat([]byte{0xa5}, 1000)} {
+		for _, T := range [][]byte{nil, []byte("associated data")} {
+			shares := mustShare(t, impl, adss.NewAccessStructure(2, 3), M, T)
+			checkRecovers(t, impl, shares, M)
+		}
+	}
+}
+
+func testThresholds(t *testing.T, impl Implementation) {
+	M := []byte("hello world")
+	for _, A := range []adss.AccessStructure{
+		adss.NewAccessStructure(1, 1),
+		adss.NewAccessStructure(1, 3),
+		adss.NewAccessStructure(3, 3),
+		adss.NewAccessStructure(3, 5),
+	} {
+		shares := mustShare(t, impl, A, M, nil)
+		// Every window of exactly the threshold, and all of the shares.
+		for i := 0; i+int(A.T) <= len(shares); i++ {
+			checkRecovers(t, impl, shares[i:i+int(A.T)], M)
+		}
+		checkRecovers(t, impl, shares, M)
+
+		if A.T > 1 {
+			if _, _, err := impl.Recover(shares[:A.T-1]); err == nil {
+				t.Errorf("%d-of-%d: recovered from %d shares", A.T, A.N, A.T-1)
+			}
+		}
+	}
+}
+
+func testCorruptShare(t *testing.T, impl Implementation) {
+	M := []byte("hello world")
+	shares := mustShare(t, impl, adss.NewAccessStructure(2, 4), M, nil)
+
+	// A corrupted share among more than the threshold is tolerated and
+	// isn't among the valid shares.
+	corrupt := *shares[0]
+	corrupt.Sec = append([]byte{}, corrupt.Sec...)
+	corrupt.Sec[0] ^= 1
+	provided := []*adss.SecretShare{&corrupt, shares[1], shares[2]}
+
+	recov, V, err := impl.Recover(provided)
+	if err != nil {
+		t.Fatalf("unexpected error recovering with a corrupt share: %s", err)
+	}
+	if !bytes.Equal(recov, M) {
+		t.Errorf("recovered %x, expected: %x", recov, M)
+	}
+	for _, share := range V {
+		if share == &corrupt {
+			t.Errorf("corrupt share returned as valid")
+		}
+	}
+
+	// With only the threshold, a corrupted share can't be explained.
+	if recov, _, err := impl.Recover(provided[:2]); err == nil {
+		t.Errorf("recovered %x from a corrupt share and only the threshold", recov)
+	}
+}
+
+func testRejects(t *testing.T, impl Implementation) {
+	M := []byte("hello world")
+	shares := mustShare(t, impl, adss.NewAccessStructure(2, 3), M, []byte("ad"))
+	other := mustShare(t, impl, adss.NewAccessStructure(2, 3), []byte("other secret"), []byte("ad"))
+
+	cases := []struct {
+		name   string
+		shares func() []*adss.SecretShare
+	}{
+		{"no-shares", func() []*adss.SecretShare { return nil }},
+		{"duplicate-id", func() []*adss.SecretShare { return []*adss.SecretShare{shares[0], shares[0]} }},
+		{"mixed-sharings", func() []*adss.SecretShare { return []*adss.SecretShare{shares[0], other[1]} }},
+		{"modified-tag", func() []*adss.SecretShare {
+			mod := *shares[0]
+			mod.Tag = []byte("other ad")
+			return []*adss.SecretShare{&mod, shares[1]}
+		}},
+		{"modified-payload", func() []*adss.SecretShare {
+			mod0, mod1 := *shares[0], *shares[1]
+			mod0.Pub.C = append([]byte{}, mod0.Pub.C...)
+			mod0.Pub.C[0] ^= 1
+			mod1.Pub = mod0.Pub
+			return []*adss.SecretShare{&mod0, &mod1}
+		}},
+	}
+	for _, c := range cases {
+		recov, _, err := impl.Recover(c.shares())
+		if err == nil {
+			t.Errorf("%s: recovered %x, expected an error", c.name, recov)
+		}
+	}
+}
+
+func testVectors(t *testing.T, impl Implementation, sharer CoinsSharer) {
+	for _, v := range Vectors {
+		t.Run(v.Name, func(t *testing.T) {
+			A, M, R, T := v.Inputs()
+			expected := v.SecretShares()
+
+			shares, err := sharer.ShareWithCoins(A, M, R, T)
+			if err != nil {
+				t.Fatalf("unexpected error sharing: %s", err)
+			}
+			if len(shares) != len(expected) {
+				t.Fatalf("got %d shares, expected: %d", len(shares), len(expected))
+			}
+			for i := range shares {
+				if !shares[i].Equal(expected[i]) {
+					t.Errorf("share %d differs from the vector", expected[i].ID)
+				}
+			}
+
+			checkRecovers(t, impl, expected, M)
+			checkRecovers(t, impl, expected[len(expected)-int(A.T):], M)
+		})
+	}
+}
+
+// Vector is a sharing produced by the reference implementation from known
+// inputs. Byte strings are hex encoded.
+type Vector struct {
+	Name           string
+	Threshold      uint16
+	Count          uint16
+	Version        uint8
+	Message        string
+	Coins          string
+	AssociatedData string
+	Shares         []VectorShare
+}
+
+// VectorShare is one share of a Vector.
+type VectorShare struct {
+	ID      uint16
+	C, D, J string
+	Sec     string
+}
+
+// Inputs returns the decoded inputs of the sharing.
+func (v Vector) Inputs() (A adss.AccessStructure, M, R, T []byte) {
+	return adss.NewAccessStructure(v.Threshold, v.Count), mustHex(v.Message), mustHex(v.Coins), mustHex(v.AssociatedData)
+}
+
+// SecretShares returns the decoded shares.
+func (v Vector) SecretShares() []*adss.SecretShare {
+	A, _, _, T := v.Inputs()
+	shares := make([]*adss.SecretShare, len(v.Shares))
+	for i, vs := range v.Shares {
+		share := &adss.SecretShare{As: A, ID: vs.ID, Sec: mustHex(vs.Sec), Tag: T, Version: v.Version}
+		share.Pub.C, share.Pub.D, share.Pub.J = mustHex(vs.C), mustHex(vs.D), mustHex(vs.J)
+		shares[i] = share
+	}
+	return shares
+}
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(fmt.Sprintf("adsstest: invalid vector hex %q: %s", s, err))
+	}
+	return b
+}
@@ -0,0 +1,26 @@
+package adsstest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jakecraige/adss"
+)
+
+func TestRunConformance(t *testing.T) {
+	RunConformance(t, Reference{})
+}
+
+func TestVectorsRecover(t *testing.T) {
+	for _, v := range Vectors {
+		_, M, _, _ := v.Inputs()
+		recov, _, err := adss.Recover(v.SecretShares())
+		if err != nil {
+			t.Errorf("%s: unexpected error recovering: %s", v.Name, err)
+			continue
+		}
+		if !bytes.Equal(recov, M) {
+			t.Errorf("%s: recovered %x, expected: %x", v.Name, recov, M)
+		}
+	}
+}
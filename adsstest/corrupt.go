@@ -0,0 +1,162 @@
+package adsstest
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/jakecraige/adss"
+)
+
+// Field is a field of a share that can be corrupted.
+type Field int
+
+const (
+	FieldC Field = iota
+	FieldD
+	FieldJ
+	FieldSec
+	FieldTag
+	FieldID
+)
+
+// Fields lists every Field.
+var Fields = []Field{FieldC, FieldD, FieldJ, FieldSec, FieldTag, FieldID}
+
+func (f Field) String() string {
+	switch f {
+	case FieldC:
+		return "C"
+	case FieldD:
+		return "D"
+	case FieldJ:
+		return "J"
+	case FieldSec:
+		return "Sec"
+	case FieldTag:
+		return "Tag"
+	case FieldID:
+		return "ID"
+	default:
+		return fmt.Sprintf("Field(%d)", int(f))
+	}
+}
+
+// Tolerable reports whether recovery is expected to succeed when one share
+// has this field corrupted and the remaining shares meet the threshold.
+// Corrupting the tag or ID may instead make the shares inconsistent, which
+// recovery is allowed to reject outright.
+func (f Field) Tolerable() bool {
+	return f != FieldTag && f != FieldID
+}
+
+// Corrupt returns a copy of share with one bit of field flipped. The bit
+// indexes into the field from its first byte and wraps around, so any bit is
+// valid. An empty field is replaced with a single byte with that bit set. The
+// share itself isn't modified.
+func Corrupt(share *adss.SecretShare, field Field, bit int) *adss.SecretShare {
+	out := *share
+	switch field {
+	case FieldC:
+		out.Pub.C = flipBit(share.Pub.C, bit)
+	case FieldD:
+		out.Pub.D = flipBit(share.Pub.D, bit)
+	case FieldJ:
+		out.Pub.J = flipBit(share.Pub.J, bit)
+	case FieldSec:
+		out.Sec = flipBit(share.Sec, bit)
+	case FieldTag:
+		out.Tag = flipBit(share.Tag, bit)
+	case FieldID:
+		out.ID ^= 1 << uint(bit%8)
+	default:
+		panic(fmt.Sprintf("adsstest: unknown field %d", int(field)))
+	}
+	return &out
+}
+
+func flipBit(data []byte, bit int) []byte {
+	if len(data) == 0 {
+		return []byte{1 << uint(bit%8)}
+	}
+	out := append([]byte{}, data...)
+	out[(bit/8)%len(out)] ^= 1 << uint(bit%8)
+	return out
+}
+
+// Corruption is a share with a single bit of one field flipped.
+type Corruption struct {
+	Field Field
+	Bit   int
+	Share *adss.SecretShare
+}
+
+// Corruptions returns corruptions of share flipping the first, a middle and
+// the last bit of each field.
+func Corruptions(share *adss.SecretShare) []Corruption {
+	var out []Corruption
+	for _, field := range Fields {
+		bits := 8
+		switch field {
+		case FieldC:
+			bits = 8 * len(share.Pub.C)
+		case FieldD:
+			bits = 8 * len(share.Pub.D)
+		case FieldJ:
+			bits = 8 * len(share.Pub.J)
+		case FieldSec:
+			bits = 8 * len(share.Sec)
+		case FieldTag:
+			bits = 8 * len(share.Tag)
+		}
+		if bits == 0 {
+			bits = 8
+		}
+
+		for _, bit := range []int{0, bits / 2, bits - 1} {
+			out = append(out, Corruption{Field: field, Bit: bit, Share: Corrupt(share, field, bit)})
+		}
+	}
+	return out
+}
+
+// CheckCorruptions checks how impl recovers from all of shares, a sharing of
+// M, when one of them is corrupted and provided first. For every corruption of each share,
+// recovery must not return a secret other than M or report the corrupted
+// share as valid, and must succeed if the field is Tolerable. There must be
+// more shares than the threshold.
+func CheckCorruptions(t *testing.T, impl Implementation, shares []*adss.SecretShare, M []byte) {
+	t.Helper()
+	if len(shares) <= int(shares[0].As.T) {
+		t.Fatalf("need more than the threshold of %d shares, got %d", shares[0].As.T, len(shares))
+	}
+
+	for i, share := range shares {
+		for _, c := range Corruptions(share) {
+			// The corrupted share goes first. The reference doesn't try every
+			// subset of the shares, only windows of them, so with it in the
+			// middle of several more than the threshold there are two
+			// explanations among the subsets it tries.
+			provided := []*adss.SecretShare{c.Share}
+			provided = append(provided, shares[:i]...)
+			provided = append(provided, shares[i+1:]...)
+
+			recov, V, err := impl.Recover(provided)
+			name := fmt.Sprintf("share %d with bit %d of %s flipped", share.ID, c.Bit, c.Field)
+			if err != nil {
+				if c.Field.Tolerable() {
+					t.Errorf("%s: unexpected error: %s", name, err)
+				}
+				continue
+			}
+			if !bytes.Equal(recov, M) {
+				t.Errorf("%s: recovered %x, expected: %x", name, recov, M)
+			}
+			for _, valid := range V {
+				if valid == c.Share {
+					t.Errorf("%s: corrupted share returned as valid", name)
+				}
+			}
+		}
+	}
+}
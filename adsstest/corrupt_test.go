@@ -0,0 +1,53 @@
+package adsstest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jakecraige/adss"
+)
+
+func TestCorrupt(t *testing.T) {
+	shares, err := adss.Share(adss.NewAccessStructure(2, 3), []byte("hello world"), []byte("ad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	share := shares[1]
+	original := *share
+	original.Sec = append([]byte{}, share.Sec...)
+
+	corrupted := Corrupt(share, FieldSec, 9)
+	if corrupted.Sec[1] != share.Sec[1]^2 {
+		t.Errorf("bit 9 wasn't flipped: %x, original: %x", corrupted.Sec, share.Sec)
+	}
+	if !share.Equal(&original) {
+		t.Errorf("original share was modified")
+	}
+
+	if id := Corrupt(share, FieldID, 7).ID; id != share.ID^0x80 {
+		t.Errorf("ID = %d, expected: %d", id, share.ID^0x80)
+	}
+
+	noTag := *share
+	noTag.Tag = nil
+	if tag := Corrupt(&noTag, FieldTag, 0).Tag; !bytes.Equal(tag, []byte{1}) {
+		t.Errorf("Tag = %x, expected: 01", tag)
+	}
+}
+
+func TestCorruptions(t *testing.T) {
+	shares, err := adss.Share(adss.NewAccessStructure(2, 3), []byte("hello world"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	corruptions := Corruptions(shares[0])
+	if len(corruptions) != 3*len(Fields) {
+		t.Fatalf("got %d corruptions, expected: %d", len(corruptions), 3*len(Fields))
+	}
+	for _, c := range corruptions {
+		if c.Share.Equal(shares[0]) {
+			t.Errorf("bit %d of %s: share wasn't changed", c.Bit, c.Field)
+		}
+	}
+}
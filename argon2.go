@@ -0,0 +1,99 @@
+package adss
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2Params are the Argon2id parameters used to derive a key from a
+// passphrase or to harden the inputs of a sharing.
+type Argon2Params struct {
+	Time    uint32 // number of passes over the memory
+	Memory  uint32 // memory in KiB
+	Threads uint8  // degree of parallelism
+}
+
+// DefaultArgon2Params are the second recommended option from RFC 9106, which
+// is suitable for memory constrained environments.
+var DefaultArgon2Params = Argon2Params{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// Bytes returns a fixed-length encoding of the parameters.
+func (p *Argon2Params) Bytes() []byte {
+	out := make([]byte, 0, 9)
+	out = appendUint32(out, p.Time)
+	out = appendUint32(out, p.Memory)
+	out = append(out, p.Threads)
+	return out
+}
+
+// Limits on the Argon2id parameters, which come from share and locked share
+// files, so a malicious file can't make recovery or unlocking take hours or
+// allocate more memory than the machine has. They are generous multiples of
+// DefaultArgon2Params.
+const (
+	maxArgon2Time    = 32
+	maxArgon2Memory  = 1024 * 1024 // 1 GiB
+	maxArgon2Threads = 64
+)
+
+func (p *Argon2Params) validate() error {
+	if p.Time == 0 || p.Memory == 0 || p.Threads == 0 {
+		return fmt.Errorf("invalid argon2 params: %+v", *p)
+	}
+	if p.Time > maxArgon2Time || p.Memory > maxArgon2Memory || p.Threads > maxArgon2Threads {
+		return fmt.Errorf("argon2 params %+v exceed the limits of time=%d memory=%dKiB threads=%d", *p, maxArgon2Time, maxArgon2Memory, maxArgon2Threads)
+	}
+	return nil
+}
+
+// hardeningCache remembers the Argon2id outputs of a recovery by a hash of
+// their input, so each distinct message and coins are hardened once rather
+// than once per candidate subset and again to reshare.
+type hardeningCache map[[sha256.Size]byte][]byte
+
+type hardeningCacheKey struct{}
+
+// withHardeningCache returns a context whose recovery attempts share a new
+// hardeningCache.
+func withHardeningCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, hardeningCacheKey{}, hardeningCache{})
+}
+
+// hardeningCacheFrom returns the hardeningCache of the context, or nil.
+func hardeningCacheFrom(ctx context.Context) hardeningCache {
+	cache, _ := ctx.Value(hardeningCacheKey{}).(hardeningCache)
+	return cache
+}
+
+// harden returns the Argon2id hash of input under the parameters, from the
+// cache if it has it.
+func (cache hardeningCache) harden(input []byte, params *Argon2Params) []byte {
+	salt := append([]byte("adss hardening"), params.Bytes()...)
+	if cache == nil {
+		return argon2.IDKey(input, salt, params.Time, params.Memory, params.Threads, 64)
+	}
+
+	h := sha256.New()
+	h.Write(salt)
+	h.Write(input)
+	var key [sha256.Size]byte
+	h.Sum(key[:0])
+	out, ok := cache[key]
+	if !ok {
+		out = argon2.IDKey(input, salt, params.Time, params.Memory, params.Threads, 64)
+		cache[key] = out
+	}
+	return out
+}
+
+// equal reports whether p and other are the same parameters, treating two nil
+// parameters as equal.
+func (p *Argon2Params) equal(other *Argon2Params) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+	return *p == *other
+}
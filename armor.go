@@ -0,0 +1,66 @@
+package adss
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strconv"
+)
+
+// pemShareType is the type of PEM blocks holding a share.
+const pemShareType = "ADSS SHARE"
+
+// EncodeSharePEM armors the binary encoding of the share as a PEM block, such
+// as "-----BEGIN ADSS SHARE-----", so it can be pasted into emails, tickets
+// and paper backups. Headers give the share's ID, access structure, sharing
+// fingerprint and the SHA-256 digest of its associated data for readers; they
+// aren't authenticated, the share itself is.
+func EncodeSharePEM(share *SecretShare) []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:    pemShareType,
+		Headers: pemHeaders(share),
+		Bytes:   share.Bytes(),
+	})
+}
+
+// pemHeaders returns the headers describing the share in its PEM block.
+func pemHeaders(share *SecretShare) map[string]string {
+	tagDigest := sha256.Sum256(share.Tag)
+	return map[string]string{
+		"ID":         strconv.Itoa(int(share.ID)),
+		"Threshold":  fmt.Sprintf("%d-of-%d", share.As.T, share.As.N),
+		"Sharing":    share.Fingerprint(),
+		"Tag-SHA256": hex.EncodeToString(tagDigest[:]),
+	}
+}
+
+// DecodeSharePEM decodes a share armored with EncodeSharePEM. Surrounding
+// text, such as the rest of an email, is ignored. The headers are optional,
+// but any that are present must match the share so a header edited to
+// mislabel the share is noticed.
+func DecodeSharePEM(data []byte) (*SecretShare, error) {
+	var block *pem.Block
+	for rest := data; ; {
+		block, rest = pem.Decode(rest)
+		if block == nil || block.Type == pemShareType {
+			break
+		}
+	}
+	if block == nil {
+		return nil, fmt.Errorf("no %s PEM block found", pemShareType)
+	}
+
+	share, err := ParseSecretShare(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := pemHeaders(share)
+	for name, value := range block.Headers {
+		if want, ok := expected[name]; ok && value != want {
+			return nil, fmt.Errorf("PEM header %s is %s but the share has %s", name, value, want)
+		}
+	}
+	return share, nil
+}
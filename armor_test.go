@@ -0,0 +1,46 @@
+package adss
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSharePEM(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	share := shares[1]
+
+	armored := EncodeSharePEM(share)
+	if !bytes.HasPrefix(armored, []byte("-----BEGIN ADSS SHARE-----\n")) {
+		t.Errorf("unexpected armor: %s", armored)
+	}
+	for _, header := range []string{"ID: 1\n", "Threshold: 2-of-3\n", "Sharing: " + share.Fingerprint() + "\n"} {
+		if !bytes.Contains(armored, []byte(header)) {
+			t.Errorf("armor is missing header %q", header)
+		}
+	}
+
+	// The block can be pasted among other text and blocks.
+	pasted := "Hi,\n\nHere is your share:\n\n" + string(EncodeSharePEM(shares[0]))
+	pasted = strings.Replace(pasted, "ADSS SHARE", "OTHER", -1) + "\n" + string(armored) + "\nThanks\n"
+	for _, data := range []string{string(armored), pasted} {
+		decoded, err := DecodeSharePEM([]byte(data))
+		if err != nil {
+			t.Fatalf("unexpected error decoding: %s", err)
+		}
+		if !decoded.Equal(share) {
+			t.Errorf("decoded share doesn't match")
+		}
+	}
+
+	relabeled := bytes.Replace(armored, []byte("ID: 1\n"), []byte("ID: 2\n"), 1)
+	if _, err := DecodeSharePEM(relabeled); err == nil {
+		t.Errorf("expected an error for a mislabeled share")
+	}
+	if _, err := DecodeSharePEM([]byte("no armor here")); err == nil {
+		t.Errorf("expected an error without a block")
+	}
+}
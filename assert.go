@@ -0,0 +1,86 @@
+package adss
+
+import "fmt"
+
+// The functions in this file check internal invariants that should always
+// hold. They're only run when built with -tags adssdebug, such as in staging,
+// since they repeat work the normal code paths already rely on. Callers guard
+// them with debugAssertions so they are compiled out otherwise.
+
+// assertf panics with the formatted message if cond is false.
+func assertf(cond bool, format string, args ...interface{}) {
+	if !cond {
+		panic("adss: debug assertion failed: " + fmt.Sprintf(format, args...))
+	}
+}
+
+// assertShareLengths checks every share of a sharing has the same access
+// structure and payload and that their secrets have the given length.
+func assertShareLengths(shares []*SecretShare, secretLen int) {
+	for _, share := range shares {
+		assertf(share.As.Equal(shares[0].As), "share %d has access structure %v, want %v", share.ID, share.As, shares[0].As)
+		assertf(len(share.Sec) == secretLen, "share %d has secret of %d bytes, want %d", share.ID, len(share.Sec), secretLen)
+		assertf(len(share.Pub.C) == len(shares[0].Pub.C), "share %d has C of %d bytes, want %d", share.ID, len(share.Pub.C), len(shares[0].Pub.C))
+		assertf(len(share.Pub.D) == len(shares[0].Pub.D), "share %d has D of %d bytes, want %d", share.ID, len(share.Pub.D), len(shares[0].Pub.D))
+		assertf(len(share.Pub.J) == len(shares[0].Pub.J), "share %d has J of %d bytes, want %d", share.ID, len(share.Pub.J), len(shares[0].Pub.J))
+	}
+}
+
+// assertS1Lengths checks every share of the underlying Shamir sharing has a
+// secret of the same length.
+func assertS1Lengths(shares []*s1SecretShare) {
+	for _, share := range shares {
+		assertf(len(share.secret) == len(shares[0].secret), "s1 share %d has secret of %d bytes, want %d", share.i, len(share.secret), len(shares[0].secret))
+	}
+}
+
+// assertSubsets checks that kSubsets returned all C(n, k) subsets of k of the
+// n shares, and that each is a set of k distinct shares.
+func assertSubsets(k int, shares []*SecretShare, subsets [][]*SecretShare) {
+	n := len(shares)
+	want := binomial(n, k, maxCandidateSubsets)
+	assertf(len(subsets) == want, "%d subsets of size %d from %d shares, want %d", len(subsets), k, n, want)
+
+	for _, subset := range subsets {
+		assertf(len(subset) == k, "subset has %d shares, want %d", len(subset), k)
+		seen := map[uint16]bool{}
+		for _, share := range subset {
+			assertf(!seen[share.ID], "subset contains share %d twice", share.ID)
+			seen[share.ID] = true
+		}
+	}
+}
+
+// fieldSelfTest checks the GF(2^8) log and exp tables against each other and
+// that multiplication and division behave as field operations.
+func fieldSelfTest() error {
+	for a := 1; a < 256; a++ {
+		if got := expTable[logTable[a]]; got != uint8(a) {
+			return fmt.Errorf("field self-test: exp(log(%d)) = %d", a, got)
+		}
+	}
+
+	for a := 0; a < 256; a++ {
+		if got := mult(uint8(a), 0); got != 0 {
+			return fmt.Errorf("field self-test: %d * 0 = %d", a, got)
+		}
+		if got := mult(uint8(a), 1); got != uint8(a) {
+			return fmt.Errorf("field self-test: %d * 1 = %d", a, got)
+		}
+
+		for b := 1; b < 256; b++ {
+			product := mult(uint8(a), uint8(b))
+			if product != mult(uint8(b), uint8(a)) {
+				return fmt.Errorf("field self-test: %d * %d is not commutative", a, b)
+			}
+			if got := div(product, uint8(b)); got != uint8(a) {
+				return fmt.Errorf("field self-test: (%d * %d) / %d = %d", a, b, b, got)
+			}
+			if got := multLog(uint8(a), logTable[b]); got != product {
+				return fmt.Errorf("field self-test: multLog(%d, log(%d)) = %d, want %d", a, b, got, product)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package adss
+
+import (
+	"testing"
+)
+
+func Test_fieldSelfTest(t *testing.T) {
+	if err := fieldSelfTest(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_assertSubsets(t *testing.T) {
+	for n := 1; n <= 6; n++ {
+		shares := make([]*SecretShare, n)
+		for i := range shares {
+			shares[i] = &SecretShare{ID: uint16(i)}
+		}
+
+		for k := 1; k <= n; k++ {
+			assertSubsets(k, shares, kSubsets(k, shares))
+		}
+	}
+}
+
+func Test_assertShareLengths(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 3), []byte("secret"), []byte("ad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertShareLengths(shares, len(shares[0].Sec))
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a truncated share")
+		}
+	}()
+	shares[1].Pub.C = shares[1].Pub.C[1:]
+	assertShareLengths(shares, len(shares[0].Sec))
+}
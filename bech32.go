@@ -0,0 +1,392 @@
+package adss
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// shareStringPrefix is the human-readable part of share strings.
+const shareStringPrefix = "adss"
+
+// EncodeShareString encodes the share as a compact Bech32m string, such as
+// "adss1...". The string only uses lowercase letters and digits that are hard
+// to confuse, so it can be read out over the phone or typed in by hand, and
+// the checksum catches transcription mistakes. It is intended for small
+// shares; the string grows with the message and associated data.
+func EncodeShareString(share *SecretShare) string {
+	return bech32Encode(shareStringPrefix, share.compactBytes())
+}
+
+// DecodeShareString decodes a share encoded with EncodeShareString. It returns
+// an error if the checksum doesn't match, which means the string was
+// mistyped.
+func DecodeShareString(s string) (*SecretShare, error) {
+	hrp, data, err := bech32Decode(s)
+	if err != nil {
+		return nil, err
+	}
+	if hrp != shareStringPrefix {
+		return nil, fmt.Errorf("not a share string, prefix: %s", hrp)
+	}
+
+	return parseCompactShare(data)
+}
+
+// Flags in the fourth byte of the compact encoding, saying which optional
+// fields follow. compactExtended says a second byte of flags follows it.
+const (
+	compactHardened = 1 << iota
+	compactVersioned
+	compactDomain
+	compactExclusions
+	compactMandatory
+	compactScheme
+	compactPoints
+	compactExtended
+)
+
+// Flags in the second byte of the compact encoding. compactWide says the
+// high bytes of the threshold, count and ID follow it, for sharings of more
+// than 255 shares.
+const (
+	compactFormula = 1 << iota
+	compactHolders
+	compactWide
+)
+
+// compactBytes returns a decodable binary encoding of the share. Each variable
+// length field is prefixed with its length as a uvarint.
+func (ss *SecretShare) compactBytes() []byte {
+	out := []byte{byte(ss.As.T), byte(ss.As.N), byte(ss.ID), 0}
+	var extended byte
+	if ss.As.Formula != nil {
+		extended |= compactFormula
+	}
+	if ss.As.Holders != nil {
+		extended |= compactHolders
+	}
+	if ss.As.wide() {
+		extended |= compactWide
+	}
+	if extended != 0 {
+		out[3] |= compactExtended
+		out = append(out, extended)
+	}
+	if ss.As.wide() {
+		out = append(out, byte(ss.As.T>>8), byte(ss.As.N>>8), byte(ss.ID>>8))
+	}
+	if ss.Hardening != nil {
+		out[3] |= compactHardened
+		out = append(out, ss.Hardening.Bytes()...)
+	}
+	if ss.Version != VersionUnframed {
+		out[3] |= compactVersioned
+		out = append(out, ss.Version)
+	}
+
+	var length [binary.MaxVarintLen64]byte
+	if ss.Domain != "" {
+		out[3] |= compactDomain
+		n := binary.PutUvarint(length[:], uint64(len(ss.Domain)))
+		out = append(out, length[:n]...)
+		out = append(out, ss.Domain...)
+	}
+	if exclusions := ss.As.exclusionBytes(); exclusions != nil {
+		out[3] |= compactExclusions
+		n := binary.PutUvarint(length[:], uint64(len(exclusions)))
+		out = append(out, length[:n]...)
+		out = append(out, exclusions...)
+	}
+	if len(ss.As.Mandatory) > 0 {
+		out[3] |= compactMandatory
+		mandatory := appendIDs(nil, ss.As.Mandatory, ss.As.wide())
+		n := binary.PutUvarint(length[:], uint64(len(mandatory)))
+		out = append(out, length[:n]...)
+		out = append(out, mandatory...)
+	}
+	if ss.Scheme != SchemeShamir {
+		out[3] |= compactScheme
+		out = append(out, ss.Scheme)
+	}
+	if ss.Points != nil {
+		out[3] |= compactPoints
+		n := binary.PutUvarint(length[:], uint64(len(ss.Points)))
+		out = append(out, length[:n]...)
+		out = append(out, ss.Points...)
+	}
+	if ss.As.Formula != nil {
+		formula := ss.As.Formula.appendBytes(nil, ss.As.wide())
+		n := binary.PutUvarint(length[:], uint64(len(formula)))
+		out = append(out, length[:n]...)
+		out = append(out, formula...)
+	}
+	if holders := ss.As.holderBytes(); holders != nil {
+		n := binary.PutUvarint(length[:], uint64(len(holders)))
+		out = append(out, length[:n]...)
+		out = append(out, holders...)
+	}
+	for _, field := range [][]byte{ss.Pub.C, ss.Pub.D, ss.Pub.J, ss.Sec, ss.Tag} {
+		n := binary.PutUvarint(length[:], uint64(len(field)))
+		out = append(out, length[:n]...)
+		out = append(out, field...)
+	}
+	return out
+}
+
+func parseCompactShare(data []byte) (*SecretShare, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("share too short")
+	}
+
+	share := &SecretShare{As: NewAccessStructure(uint16(data[0]), uint16(data[1])), ID: uint16(data[2])}
+	flags := data[3]
+	data = data[4:]
+	var extended byte
+	if flags&compactExtended != 0 {
+		if len(data) < 1 || data[0] == 0 {
+			return nil, fmt.Errorf("share extended flags invalid")
+		}
+		extended = data[0]
+		data = data[1:]
+	}
+	if extended&^(compactFormula|compactHolders|compactWide) != 0 {
+		return nil, fmt.Errorf("unknown share flags: %d %d", flags, extended)
+	}
+	if extended&compactWide != 0 {
+		if len(data) < 3 {
+			return nil, fmt.Errorf("share too short")
+		}
+		share.As.T |= uint16(data[0]) << 8
+		share.As.N |= uint16(data[1]) << 8
+		share.ID |= uint16(data[2]) << 8
+		data = data[3:]
+		// Narrower sharings have a single encoding, without the flag.
+		if !share.As.wide() {
+			return nil, fmt.Errorf("share count %d too small for the wide flag", share.As.N)
+		}
+	}
+	wide := share.As.wide()
+	if flags&compactHardened != 0 {
+		if len(data) < 9 {
+			return nil, fmt.Errorf("share too short")
+		}
+		share.Hardening = &Argon2Params{
+			Time:    binary.BigEndian.Uint32(data[0:4]),
+			Memory:  binary.BigEndian.Uint32(data[4:8]),
+			Threads: data[8],
+		}
+		data = data[9:]
+	}
+	if flags&compactVersioned != 0 {
+		if len(data) < 1 || data[0] == VersionUnframed {
+			return nil, fmt.Errorf("share version invalid")
+		}
+		share.Version = data[0]
+		data = data[1:]
+	}
+	if flags&compactDomain != 0 {
+		length, n := binary.Uvarint(data)
+		if n <= 0 || length == 0 || length > uint64(len(data)-n) {
+			return nil, fmt.Errorf("share domain invalid")
+		}
+		share.Domain = string(data[n : n+int(length)])
+		data = data[n+int(length):]
+	}
+	if flags&compactExclusions != 0 {
+		length, n := binary.Uvarint(data)
+		if n <= 0 || length == 0 || length > uint64(len(data)-n) {
+			return nil, fmt.Errorf("share exclusions invalid")
+		}
+		exclusions, err := parseExclusions(data[n:n+int(length)], wide)
+		if err != nil {
+			return nil, err
+		}
+		share.As.Exclusions = exclusions
+		data = data[n+int(length):]
+	}
+	if flags&compactMandatory != 0 {
+		length, n := binary.Uvarint(data)
+		if n <= 0 || length == 0 || length > uint64(len(data)-n) {
+			return nil, fmt.Errorf("share mandatory IDs invalid")
+		}
+		mandatory, err := parseIDs(data[n:n+int(length)], wide)
+		if err != nil {
+			return nil, err
+		}
+		share.As.Mandatory = mandatory
+		data = data[n+int(length):]
+	}
+	if flags&compactScheme != 0 {
+		if len(data) < 1 || data[0] == SchemeShamir {
+			return nil, fmt.Errorf("share scheme invalid")
+		}
+		share.Scheme = data[0]
+		data = data[1:]
+	}
+	if flags&compactPoints != 0 {
+		length, n := binary.Uvarint(data)
+		if n <= 0 || length == 0 || length > uint64(len(data)-n) {
+			return nil, fmt.Errorf("share evaluation points invalid")
+		}
+		share.Points = append([]uint8{}, data[n:n+int(length)]...)
+		data = data[n+int(length):]
+	}
+	if extended&compactFormula != 0 {
+		length, n := binary.Uvarint(data)
+		if n <= 0 || length == 0 || length > uint64(len(data)-n) {
+			return nil, fmt.Errorf("share formula invalid")
+		}
+		formula, err := parseFormulaBytes(data[n:n+int(length)], wide)
+		if err != nil {
+			return nil, err
+		}
+		share.As.Formula = formula
+		data = data[n+int(length):]
+	}
+	if extended&compactHolders != 0 {
+		length, n := binary.Uvarint(data)
+		if n <= 0 || length == 0 || length > uint64(len(data)-n) {
+			return nil, fmt.Errorf("share holder names invalid")
+		}
+		holders, err := parseHolders(data[n : n+int(length)])
+		if err != nil {
+			return nil, err
+		}
+		share.As.Holders = holders
+		data = data[n+int(length):]
+	}
+
+	for _, field := range []*[]byte{&share.Pub.C, &share.Pub.D, &share.Pub.J, &share.Sec, &share.Tag} {
+		length, n := binary.Uvarint(data)
+		if n <= 0 || length > uint64(len(data)-n) {
+			return nil, fmt.Errorf("share field length invalid")
+		}
+		*field = append([]byte{}, data[n:n+int(length)]...)
+		data = data[n+int(length):]
+	}
+
+	if len(data) != 0 {
+		return nil, fmt.Errorf("share has %d trailing bytes", len(data))
+	}
+
+	return share, nil
+}
+
+// Bech32m as specified in BIP 350. We don't enforce the 90 character limit
+// from BIP 173 since shares are longer than that. The checksum still detects
+// any error affecting up to 4 characters in strings that short and longer
+// strings are only misread with negligible probability.
+
+const (
+	bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	bech32mConst  = 0x2bc830a3
+)
+
+func bech32Polymod(values []byte) uint32 {
+	gen := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (b>>uint(i))&1 == 1 {
+				chk ^= gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+func bech32HRPExpand(hrp string) []byte {
+	out := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]>>5)
+	}
+	out = append(out, 0)
+	for i := 0; i < len(hrp); i++ {
+		out = append(out, hrp[i]&31)
+	}
+	return out
+}
+
+func bech32Encode(hrp string, data []byte) string {
+	values := convertBits(data, 8, 5, true)
+	polymod := bech32Polymod(append(append(bech32HRPExpand(hrp), values...), 0, 0, 0, 0, 0, 0)) ^ bech32mConst
+
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range values {
+		sb.WriteByte(bech32Charset[v])
+	}
+	for i := 0; i < 6; i++ {
+		sb.WriteByte(bech32Charset[(polymod>>uint(5*(5-i)))&31])
+	}
+	return sb.String()
+}
+
+func bech32Decode(s string) (string, []byte, error) {
+	if strings.ToLower(s) != s && strings.ToUpper(s) != s {
+		return "", nil, fmt.Errorf("bech32: mixed case")
+	}
+	s = strings.ToLower(s)
+
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 || sep+7 > len(s) {
+		return "", nil, fmt.Errorf("bech32: invalid separator position")
+	}
+
+	hrp := s[:sep]
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return "", nil, fmt.Errorf("bech32: invalid character in prefix")
+		}
+	}
+
+	values := make([]byte, 0, len(s)-sep-1)
+	for i := sep + 1; i < len(s); i++ {
+		v := strings.IndexByte(bech32Charset, s[i])
+		if v < 0 {
+			return "", nil, fmt.Errorf("bech32: invalid character %q at position %d", s[i], i)
+		}
+		values = append(values, byte(v))
+	}
+
+	if bech32Polymod(append(bech32HRPExpand(hrp), values...)) != bech32mConst {
+		return "", nil, fmt.Errorf("bech32: invalid checksum")
+	}
+
+	data := convertBits(values[:len(values)-6], 5, 8, false)
+	if data == nil {
+		return "", nil, fmt.Errorf("bech32: invalid padding")
+	}
+	return hrp, data, nil
+}
+
+// convertBits regroups data from fromBits-wide values into toBits-wide values.
+// When pad is false it returns nil if the input has non-zero or excess
+// padding.
+func convertBits(data []byte, fromBits, toBits uint, pad bool) []byte {
+	var acc uint32
+	var bits uint
+	maxv := uint32(1)<<toBits - 1
+	out := make([]byte, 0, len(data)*int(fromBits)/int(toBits)+1)
+	for _, v := range data {
+		acc = acc<<fromBits | uint32(v)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			out = append(out, byte(acc>>bits&maxv))
+		}
+	}
+
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(toBits-bits)&maxv))
+		}
+	} else if bits >= fromBits || acc<<(toBits-bits)&maxv != 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,107 @@
+package adss
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestShareString(t *testing.T) {
+	msg := []byte("hello world")
+	shares, err := Share(NewAccessStructure(2, 3), msg, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	hardened, err := ShareHardened(NewAccessStructure(2, 3), msg, nil, testArgon2Params)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	for _, share := range []*SecretShare{shares[0], shares[2], hardened[1]} {
+		s := EncodeShareString(share)
+		if !strings.HasPrefix(s, "adss1") {
+			t.Errorf("unexpected prefix: %s", s)
+		}
+
+		decoded, err := DecodeShareString(s)
+		if err != nil {
+			t.Fatalf("unexpected error decoding: %s", err)
+		}
+		if !decoded.Equal(share) {
+			t.Errorf("decoded share doesn't match")
+		}
+
+		// Uppercase is accepted since it is easier to read out loud.
+		if _, err := DecodeShareString(strings.ToUpper(s)); err != nil {
+			t.Errorf("unexpected error decoding uppercase: %s", err)
+		}
+	}
+
+	s := EncodeShareString(shares[1])
+	decoded, err := DecodeShareString(s)
+	if err != nil {
+		t.Fatalf("unexpected error decoding: %s", err)
+	}
+	recov, _, err := Recover([]*SecretShare{shares[0], decoded})
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x != %x", recov, msg)
+	}
+
+	// Every single character substitution must be detected.
+	for i := len("adss1"); i < len(s); i++ {
+		for _, c := range []byte(bech32Charset) {
+			if c == s[i] {
+				continue
+			}
+			typo := s[:i] + string(c) + s[i+1:]
+			if _, err := DecodeShareString(typo); err == nil {
+				t.Fatalf("typo at position %d not detected", i)
+			}
+		}
+	}
+}
+
+func Test_bech32m(t *testing.T) {
+	// Test vectors from BIP 350.
+	valid := []string{
+		"A1LQFN3A",
+		"a1lqfn3a",
+		"an83characterlonghumanreadablepartthatcontainsthetheexcludedcharactersbioandnumber11sg7hg6",
+		"abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx",
+		"split1checkupstagehandshakeupstreamerranterredcaperredlc445v",
+		"?1v759aa",
+	}
+	for _, s := range valid {
+		if _, _, err := bech32Decode(s); err != nil {
+			t.Errorf("%s: unexpected error: %s", s, err)
+		}
+	}
+
+	// This vector carries a whole number of bytes so it round trips.
+	s := "abcdef1l7aum6echk45nj3s0wdvt2fg8x9yrzpqzd3ryx"
+	hrp, data, _ := bech32Decode(s)
+	if encoded := bech32Encode(hrp, data); encoded != s {
+		t.Errorf("round trip produced %s, expected: %s", encoded, s)
+	}
+
+	invalid := []string{
+		"A1G7SGD8",                     // bech32 checksum, not bech32m
+		"qyrz8wqd2c9m",                 // no separator
+		"1qyrz8wqd2c9m",                // empty prefix
+		"M1VUXWEZ",                     // checksum calculated with uppercase prefix
+		"in1muywd",                     // too short checksum
+		"mm1crxm3i",                    // invalid character in checksum
+		"au1s5cgom",                    // invalid character in checksum
+		"abc1rzg",                      // too short
+		"a1lqfn3A",                     // mixed case
+		"adss1qqqqqqqqqqqqqqqqqqqqqqq", // bad checksum
+	}
+	for _, s := range invalid {
+		if _, _, err := bech32Decode(s); err == nil {
+			t.Errorf("%s: expected error", s)
+		}
+	}
+}
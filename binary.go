@@ -0,0 +1,69 @@
+package adss
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// binaryMagic starts the binary encoding of a share. Like PNG's signature,
+// the high first byte stops the encoding being mistaken for text and is lost
+// by transfers that strip the eighth bit, so they are detected.
+var binaryMagic = []byte{0x89, 'A', 'D', 'S', 'S'}
+
+// binaryFormatVersion is the version of the binary encoding, written after
+// binaryMagic. It changes whenever the encoding does, so shares written by a
+// newer release are refused with an UnsupportedFormatError rather than
+// misread.
+const binaryFormatVersion = 1
+
+// UnsupportedFormatError is returned when decoding a binary share whose format
+// version this release doesn't know, such as one written by a newer release.
+type UnsupportedFormatError struct {
+	Version uint8
+}
+
+func (e *UnsupportedFormatError) Error() string {
+	return fmt.Sprintf("unsupported share format version %d, expected %d", e.Version, binaryFormatVersion)
+}
+
+// Bytes returns the binary encoding of the share, which ParseSecretShare
+// decodes. It starts with a magic prefix and the format version, followed by
+// the fields with each variable length field prefixed by its length, so
+// shares can be stored without relying on JSON.
+func (ss *SecretShare) Bytes() []byte {
+	return append(append(append([]byte{}, binaryMagic...), binaryFormatVersion), ss.compactBytes()...)
+}
+
+// ParseSecretShare decodes a share encoded with Bytes. It returns an
+// *UnsupportedFormatError if the share was encoded with an unknown format
+// version.
+func ParseSecretShare(data []byte) (*SecretShare, error) {
+	if !bytes.HasPrefix(data, binaryMagic) {
+		return nil, fmt.Errorf("not a binary share, missing magic prefix")
+	}
+	data = data[len(binaryMagic):]
+	if len(data) < 1 {
+		return nil, fmt.Errorf("share too short")
+	}
+	if data[0] != binaryFormatVersion {
+		return nil, &UnsupportedFormatError{Version: data[0]}
+	}
+	return parseCompactShare(data[1:])
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler with the encoding of
+// Bytes, so shares can be stored with gob and other Go serialization.
+func (ss *SecretShare) MarshalBinary() ([]byte, error) {
+	return ss.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, decoding a share
+// encoded with MarshalBinary into ss.
+func (ss *SecretShare) UnmarshalBinary(data []byte) error {
+	share, err := ParseSecretShare(data)
+	if err != nil {
+		return err
+	}
+	*ss = *share
+	return nil
+}
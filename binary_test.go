@@ -0,0 +1,105 @@
+package adss
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"errors"
+	"testing"
+)
+
+func TestParseSecretShare(t *testing.T) {
+	msg := []byte("hello world")
+	as, err := NewAccessStructureWithExclusions(2, 4, IDSet{0, 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if as, err = as.WithMandatory(3); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	shares, err := ShareInDomain("example.com", as, msg, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	hardened, err := ShareHardened(NewAccessStructure(2, 3), msg, nil, testArgon2Params)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	pointed, err := ShareWithPoints([]uint8{2, 4, 6}, NewAccessStructure(2, 3), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	replicated, err := ShareWithScheme(SchemeReplicated, NewAccessStructure(2, 3), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	for _, share := range []*SecretShare{shares[0], hardened[1], pointed[2], replicated[0]} {
+		parsed, err := ParseSecretShare(share.Bytes())
+		if err != nil {
+			t.Fatalf("unexpected error parsing: %s", err)
+		}
+		if !parsed.Equal(share) {
+			t.Errorf("parsed share doesn't match")
+		}
+	}
+
+	encoded := shares[3].Bytes()
+	if !bytes.HasPrefix(encoded, append(binaryMagic, binaryFormatVersion)) {
+		t.Errorf("encoding doesn't start with the magic and version: %x", encoded)
+	}
+	for _, bad := range [][]byte{nil, encoded[:3], encoded[:len(binaryMagic)], encoded[:len(encoded)-1], append(encoded, 0), shares[3].compactBytes()} {
+		if _, err := ParseSecretShare(bad); err == nil {
+			t.Errorf("expected an error parsing %x", bad)
+		}
+	}
+
+	// Newer format versions are refused with a typed error.
+	newer := append([]byte{}, encoded...)
+	newer[len(binaryMagic)]++
+	_, err = ParseSecretShare(newer)
+	var formatErr *UnsupportedFormatError
+	if !errors.As(err, &formatErr) || formatErr.Version != binaryFormatVersion+1 {
+		t.Errorf("got error %v, expected an UnsupportedFormatError", err)
+	}
+}
+
+func TestSecretShareBinaryMarshaler(t *testing.T) {
+	msg := []byte("hello world")
+	as, err := NewAccessStructureWithExclusions(2, 3, IDSet{0, 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	shares, err := ShareHardened(as, msg, []byte("ad"), testArgon2Params)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	var _ encoding.BinaryMarshaler = shares[0]
+	var _ encoding.BinaryUnmarshaler = shares[0]
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(shares); err != nil {
+		t.Fatalf("unexpected error encoding: %s", err)
+	}
+	var decoded []*SecretShare
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatalf("unexpected error decoding: %s", err)
+	}
+	if len(decoded) != len(shares) {
+		t.Fatalf("decoded %d shares, expected: %d", len(decoded), len(shares))
+	}
+	for i := range shares {
+		if !decoded[i].Equal(shares[i]) {
+			t.Errorf("decoded share %d doesn't match", i)
+		}
+	}
+	if recov, _, err := Recover(decoded); err != nil || !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %q, %v", recov, err)
+	}
+
+	var share SecretShare
+	if err := share.UnmarshalBinary([]byte{1}); err == nil {
+		t.Errorf("expected an error unmarshaling a truncated share")
+	}
+}
@@ -49,6 +49,172 @@ func (p *polynomial) evaluate(x uint8) uint8 {
 	return out
 }
 
+// multipointEvaluator evaluates polynomials at a fixed set of points, which is
+// what share generation does for every message block.
+type multipointEvaluator interface {
+	// evaluateAll sets out[j] to the value of the polynomial with the given
+	// coefficients at the j-th point.
+	evaluateAll(coeffs, out []uint8)
+}
+
+// newMultipointEvaluator picks the cheapest evaluator for polynomials of the
+// given degree at the non-zero points. Direct evaluation costs
+// len(points)*(degree+1) multiplications, while the FFT costs a fixed
+// 255*(3+5+17) regardless of the points and the degree, so it wins for large
+// sharings.
+func newMultipointEvaluator(points []uint8, degree int) multipointEvaluator {
+	if len(points)*(degree+1) > fftCost {
+		return &fftEvaluator{points: points}
+	}
+	return newEvaluatorAt(points, degree)
+}
+
+// defaultPoints returns the points 1..n that share i is evaluated at by
+// default, at x = i+1.
+func defaultPoints(n uint8) []uint8 {
+	points := make([]uint8, n)
+	for i := range points {
+		points[i] = uint8(i + 1) // we never evaluate at 0, as that's the secret
+	}
+	return points
+}
+
+// evaluator evaluates polynomials of a fixed degree directly. It precomputes
+// the logarithms of the powers of each point so that evaluating a polynomial
+// only needs one table lookup per term.
+type evaluator struct {
+	terms int
+	// logPowers[j*terms+k] is log(x_j^k) for the j-th point x_j
+	logPowers []uint8
+}
+
+// newEvaluator returns an evaluator at the points 1..n.
+func newEvaluator(n uint8, degree int) *evaluator {
+	return newEvaluatorAt(defaultPoints(n), degree)
+}
+
+// newEvaluatorAt returns an evaluator at the points, which must be non-zero.
+func newEvaluatorAt(points []uint8, degree int) *evaluator {
+	e := &evaluator{terms: degree + 1, logPowers: make([]uint8, len(points)*(degree+1))}
+	for j, x := range points {
+		for k := 0; k < e.terms; k++ {
+			e.logPowers[j*e.terms+k] = uint8((int(logTable[x]) * k) % 255)
+		}
+	}
+	return e
+}
+
+func (e *evaluator) evaluateAll(coeffs, out []uint8) {
+	for j := range out {
+		out[j] = e.evaluate(j, coeffs)
+	}
+}
+
+// evaluate returns the value at the j-th point of the polynomial with the
+// given coefficients. This is equivalent to polynomial.evaluate.
+func (e *evaluator) evaluate(j int, coeffs []uint8) uint8 {
+	logPowers := e.logPowers[j*e.terms : (j+1)*e.terms]
+	out := coeffs[0]
+	for k := 1; k < len(coeffs); k++ {
+		out = add(out, multLog(coeffs[k], logPowers[k]))
+	}
+	return out
+}
+
+// fftCost is the number of multiplications fftEvaluator performs.
+const fftCost = 255 * (3 + 5 + 17)
+
+// fftEvaluator evaluates polynomials at every non-zero element of GF(2^8) at
+// once using a mixed-radix FFT over the multiplicative group, which has order
+// 255 = 3*5*17. Since the non-zero elements are exactly the points 1..255 used
+// for shares, this computes every possible share in O(n*(3+5+17)) rather than
+// O(n*t) and then picks out the ones we need.
+type fftEvaluator struct {
+	// points are the points to pick out, or nil for 1..len(out).
+	points []uint8
+}
+
+func (e *fftEvaluator) evaluateAll(coeffs, out []uint8) {
+	padded := make([]uint8, 255)
+	copy(padded, coeffs)
+
+	// F[k] is the value of the polynomial at g^k where g is the generator the
+	// tables are built from, so the point x is at index log(x).
+	F := dft(padded, 1)
+	for j := range out {
+		x := uint8(j + 1)
+		if e.points != nil {
+			x = e.points[j]
+		}
+		out[j] = F[int(logTable[x])%255]
+	}
+}
+
+// dft computes F[k] = sum_i x[i]*w^(i*k) where w = g^rootLog has order
+// len(x), which must divide 255.
+func dft(x []uint8, rootLog int) []uint8 {
+	n := len(x)
+	p := smallestFactor(n)
+	out := make([]uint8, n)
+
+	// Naive DFT for prime lengths.
+	if p == n {
+		for k := range out {
+			var sum uint8
+			for i, xi := range x {
+				sum = add(sum, multLog(xi, uint8((rootLog*i*k)%255)))
+			}
+			out[k] = sum
+		}
+		return out
+	}
+
+	// Split x into p interleaved subsequences of length m, transform each with
+	// w^p which has order m, and then combine them with the twiddle factors.
+	m := n / p
+	subs := make([][]uint8, p)
+	for r := range subs {
+		sub := make([]uint8, m)
+		for i := range sub {
+			sub[i] = x[i*p+r]
+		}
+		subs[r] = dft(sub, (rootLog*p)%255)
+	}
+
+	for k := range out {
+		var sum uint8
+		for r, sub := range subs {
+			sum = add(sum, multLog(sub[k%m], uint8((rootLog*r*k)%255)))
+		}
+		out[k] = sum
+	}
+	return out
+}
+
+func smallestFactor(n int) int {
+	for p := 2; p*p <= n; p++ {
+		if n%p == 0 {
+			return p
+		}
+	}
+	return n
+}
+
+// multLog multiplies a by the non-zero number whose logarithm is logB in
+// GF(2^8).
+func multLog(a, logB uint8) uint8 {
+	var zero uint8
+	sum := (int(logTable[a]) + int(logB)) % 255
+	ret := expTable[sum]
+
+	// Ensure we return zero if a is zero but aren't subject to timing attacks
+	if subtle.ConstantTimeByteEq(a, 0) == 1 {
+		ret = zero
+	}
+
+	return ret
+}
+
 // interpolatePolynomial takes N sample points and returns
 // the value at a given x using a lagrange interpolation.
 func interpolatePolynomial(x_samples, y_samples []uint8, x uint8) uint8 {
@@ -0,0 +1,133 @@
+package adss
+
+import (
+	"bytes"
+	"fmt"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// SealedBundle holds every share of a sharing, each encrypted to its holder's
+// X25519 public key, along with the manifest of the sharing, so the dealer can
+// archive or publish a single artifact instead of distributing one file per
+// holder. Each holder can only open their own share.
+type SealedBundle struct {
+	Fingerprint string        `json:"fingerprint"`
+	Threshold   uint16        `json:"threshold"`
+	Count       uint16        `json:"count"`
+	Shares      []SealedShare `json:"shares"`
+	// Manifest describes the sharing. It is nil in bundles written before
+	// manifests were included.
+	Manifest *Manifest `json:"manifest,omitempty"`
+}
+
+// SealedShare is one share in a SealedBundle.
+type SealedShare struct {
+	ID        uint16 `json:"id"`
+	Holder    string `json:"holder,omitempty"`
+	Recipient []byte `json:"recipient"`
+	Box       []byte `json:"box"`
+}
+
+// SealShares encrypts each share to the recipient key at the same index.
+// holders is either empty or labels the holder of each share in order. Keys
+// can be created with GenerateEnvelopeKey.
+func SealShares(shares []*SecretShare, recipients []*[32]byte, holders []string) (*SealedBundle, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("no shares provided")
+	}
+	if len(recipients) != len(shares) {
+		return nil, fmt.Errorf("expected %d recipients, got %d", len(shares), len(recipients))
+	}
+	if len(holders) > 0 && len(holders) != len(shares) {
+		return nil, fmt.Errorf("expected %d holders, got %d", len(shares), len(holders))
+	}
+
+	m, err := NewManifest(shares, holders)
+	if err != nil {
+		return nil, err
+	}
+
+	share0 := shares[0]
+	b := &SealedBundle{
+		Fingerprint: share0.Fingerprint(),
+		Threshold:   share0.As.T,
+		Count:       share0.As.N,
+		Shares:      make([]SealedShare, len(shares)),
+		Manifest:    m,
+	}
+	for i, share := range shares {
+		if err := checkConsistent(share0, share); err != nil {
+			return nil, err
+		}
+		if share.Fingerprint() != b.Fingerprint {
+			return nil, fmt.Errorf("shares are from different sharings")
+		}
+
+		sealed, err := sealEnvelope(share.compactBytes(), recipients[i])
+		if err != nil {
+			return nil, err
+		}
+		b.Shares[i] = SealedShare{ID: share.ID, Recipient: recipients[i][:], Box: sealed}
+		if len(holders) > 0 {
+			b.Shares[i].Holder = holders[i]
+		}
+	}
+	return b, nil
+}
+
+// Open decrypts the share sealed to the given key pair. If the bundle has a
+// manifest the share must be the one it lists.
+func (b *SealedBundle) Open(publicKey, privateKey *[32]byte) (*SecretShare, error) {
+	for _, sealed := range b.Shares {
+		if !bytes.Equal(sealed.Recipient, publicKey[:]) {
+			continue
+		}
+
+		data, ok := box.OpenAnonymous(nil, sealed.Box, publicKey, privateKey)
+		if !ok {
+			return nil, fmt.Errorf("failed to open share %d", sealed.ID)
+		}
+		share, err := parseCompactShare(data)
+		if err != nil {
+			return nil, err
+		}
+		if share.ID != sealed.ID || share.Fingerprint() != b.Fingerprint {
+			return nil, fmt.Errorf("sealed share %d doesn't match the bundle", sealed.ID)
+		}
+		if b.Manifest != nil {
+			if err := b.Manifest.VerifyShare(share); err != nil {
+				return nil, fmt.Errorf("sealed share %d: %w", sealed.ID, err)
+			}
+		}
+		return share, nil
+	}
+	return nil, fmt.Errorf("no share in the bundle is sealed to this key")
+}
+
+// Bundle holds every share of a sharing in the clear, along with its
+// manifest, for moving a sharing between trusted systems as a single
+// document. Unlike a SealedBundle it must be protected like the secret.
+type Bundle struct {
+	Manifest *Manifest      `json:"manifest"`
+	Shares   []*SecretShare `json:"shares"`
+}
+
+// NewBundle returns the bundle of the shares. holders is either empty or
+// labels the holder of each share in order.
+func NewBundle(shares []*SecretShare, holders []string) (*Bundle, error) {
+	m, err := NewManifest(shares, holders)
+	if err != nil {
+		return nil, err
+	}
+	return &Bundle{Manifest: m, Shares: shares}, nil
+}
+
+// Verify returns an error unless every share in the bundle is one its
+// manifest lists.
+func (b *Bundle) Verify() error {
+	if b.Manifest == nil {
+		return fmt.Errorf("bundle has no manifest")
+	}
+	return b.Manifest.Verify(b.Shares)
+}
@@ -0,0 +1,119 @@
+package adss
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestSealedBundle(t *testing.T) {
+	msg := []byte("hello world")
+	shares, err := Share(NewAccessStructure(2, 3), msg, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	pubs := make([]*[32]byte, len(shares))
+	privs := make([]*[32]byte, len(shares))
+	for i := range shares {
+		pubs[i], privs[i], err = GenerateEnvelopeKey()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	b, err := SealShares(shares, pubs, []string{"alice", "bob", "carol"})
+	if err != nil {
+		t.Fatalf("unexpected error sealing: %s", err)
+	}
+
+	// The bundle is published as JSON.
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b = new(SealedBundle)
+	if err := json.Unmarshal(data, b); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if b.Manifest == nil || b.Manifest.Holder(1) != "bob" {
+		t.Fatalf("bundle doesn't have the manifest")
+	}
+
+	var opened []*SecretShare
+	for i := range shares {
+		share, err := b.Open(pubs[i], privs[i])
+		if err != nil {
+			t.Fatalf("share %d: unexpected error opening: %s", i, err)
+		}
+		opened = append(opened, share)
+	}
+	recov, _, err := Recover(opened)
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x != %x", recov, msg)
+	}
+
+	outsider, outsiderPriv, _ := GenerateEnvelopeKey()
+	if _, err := b.Open(outsider, outsiderPriv); err == nil {
+		t.Errorf("expected error opening with a key the bundle isn't sealed to")
+	}
+	if _, err := b.Open(pubs[0], privs[1]); err == nil {
+		t.Errorf("expected error opening with the wrong private key")
+	}
+
+	// Shares must match the manifest.
+	b.Manifest.Shares[2].SHA256 = b.Manifest.Shares[1].SHA256
+	if _, err := b.Open(pubs[2], privs[2]); err == nil {
+		t.Errorf("expected error opening a share that doesn't match the manifest")
+	}
+
+	if _, err := SealShares(shares, pubs[:2], nil); err == nil {
+		t.Errorf("expected error for missing recipients")
+	}
+}
+
+func TestBundle(t *testing.T) {
+	msg := []byte("hello world")
+	shares, err := Share(NewAccessStructure(2, 3), msg, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	b, err := NewBundle(shares, nil)
+	if err != nil {
+		t.Fatalf("unexpected error bundling: %s", err)
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b = new(Bundle)
+	if err := json.Unmarshal(data, b); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := b.Verify(); err != nil {
+		t.Fatalf("unexpected error verifying: %s", err)
+	}
+	recov, _, err := Recover(b.Shares)
+	if err != nil || !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %q, %v", recov, err)
+	}
+
+	// A share swapped for one of another sharing is caught.
+	other, err := Share(NewAccessStructure(2, 3), msg, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	b.Shares[1] = other[1]
+	if err := b.Verify(); err == nil {
+		t.Errorf("expected error verifying a bundle with a foreign share")
+	}
+	if err := (&Bundle{Shares: shares}).Verify(); err == nil {
+		t.Errorf("expected error verifying a bundle without a manifest")
+	}
+}
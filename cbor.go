@@ -0,0 +1,426 @@
+package adss
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// Shares are encoded in CBOR (RFC 8949) as a map from the field names below to
+// their values, so they can be read by the CBOR libraries of other languages:
+//
+//	threshold, count, id, version, scheme: unsigned integer
+//	exclusions: array of byte strings, each the IDs of an exclusion
+//	mandatory, points, c, d, j, sec, tag: byte string
+//	formula: byte string, the formula encoded as in AccessStructure.Bytes
+//	holders: array of text strings, the holder name of each share
+//	domain: text string
+//	hardening: map of time, memory and threads to unsigned integers
+//
+// Optional fields are left out when they have their zero value. The encoding
+// follows the core deterministic encoding requirements of section 4.2.1, so a
+// share has exactly one encoding, and is prefixed with the self-described CBOR
+// tag so it can be recognized. Decoding is just as strict.
+
+// cborSelfDescribe is tag 55799, which marks data as CBOR without changing
+// its meaning.
+var cborSelfDescribe = []byte{0xd9, 0xd9, 0xf7}
+
+// Major types of CBOR data items.
+const (
+	cborUint  = 0
+	cborBytes = 2
+	cborText  = 3
+	cborArray = 4
+	cborMap   = 5
+)
+
+// cborMaxDepth bounds the nesting of decoded items. Shares nest at most two
+// levels deep.
+const cborMaxDepth = 4
+
+// MarshalCBOR returns the deterministic CBOR encoding of the share.
+func (ss *SecretShare) MarshalCBOR() ([]byte, error) {
+	fields := map[string][]byte{
+		"threshold": cborHead(cborUint, uint64(ss.As.T)),
+		"count":     cborHead(cborUint, uint64(ss.As.N)),
+		"id":        cborHead(cborUint, uint64(ss.ID)),
+		"c":         cborByteString(ss.Pub.C),
+		"d":         cborByteString(ss.Pub.D),
+		"j":         cborByteString(ss.Pub.J),
+		"sec":       cborByteString(ss.Sec),
+	}
+	if len(ss.As.Exclusions) > 0 {
+		exclusions := cborHead(cborArray, uint64(len(ss.As.Exclusions)))
+		for _, exclusion := range ss.As.Exclusions {
+			exclusions = append(exclusions, cborByteString(appendIDs(nil, exclusion, ss.As.wide()))...)
+		}
+		fields["exclusions"] = exclusions
+	}
+	if len(ss.As.Mandatory) > 0 {
+		fields["mandatory"] = cborByteString(appendIDs(nil, ss.As.Mandatory, ss.As.wide()))
+	}
+	if ss.As.Formula != nil {
+		fields["formula"] = cborByteString(ss.As.Formula.appendBytes(nil, ss.As.wide()))
+	}
+	if ss.As.Holders != nil {
+		holders := cborHead(cborArray, uint64(len(ss.As.Holders)))
+		for _, name := range ss.As.Holders {
+			holders = append(append(holders, cborHead(cborText, uint64(len(name)))...), name...)
+		}
+		fields["holders"] = holders
+	}
+	if len(ss.Tag) > 0 {
+		fields["tag"] = cborByteString(ss.Tag)
+	}
+	if ss.Hardening != nil {
+		fields["hardening"] = cborEncodeMap(map[string][]byte{
+			"time":    cborHead(cborUint, uint64(ss.Hardening.Time)),
+			"memory":  cborHead(cborUint, uint64(ss.Hardening.Memory)),
+			"threads": cborHead(cborUint, uint64(ss.Hardening.Threads)),
+		})
+	}
+	if ss.Version != VersionUnframed {
+		fields["version"] = cborHead(cborUint, uint64(ss.Version))
+	}
+	if ss.Domain != "" {
+		fields["domain"] = append(cborHead(cborText, uint64(len(ss.Domain))), ss.Domain...)
+	}
+	if ss.Scheme != SchemeShamir {
+		fields["scheme"] = cborHead(cborUint, uint64(ss.Scheme))
+	}
+	if len(ss.Points) > 0 {
+		fields["points"] = cborByteString(ss.Points)
+	}
+
+	return append(append([]byte{}, cborSelfDescribe...), cborEncodeMap(fields)...), nil
+}
+
+// UnmarshalCBOR decodes a share encoded with MarshalCBOR into ss. The
+// self-described CBOR tag is optional.
+func (ss *SecretShare) UnmarshalCBOR(data []byte) error {
+	data = bytes.TrimPrefix(data, cborSelfDescribe)
+	d := &cborDecoder{data: data}
+	item, err := d.item(0)
+	if err != nil {
+		return err
+	}
+	if len(d.data) != 0 {
+		return fmt.Errorf("cbor: %d trailing bytes", len(d.data))
+	}
+	fields, ok := item.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("cbor: share isn't a map")
+	}
+
+	var share SecretShare
+	r := cborFields{fields: fields}
+	share.As.T = uint16(r.uint("threshold", 0xffff, true))
+	share.As.N = uint16(r.uint("count", 0xffff, true))
+	share.ID = uint16(r.uint("id", 0xffff, true))
+	// Share IDs are encoded in two bytes in sharings of more than 255 shares.
+	wide := share.As.wide()
+	share.Pub.C = r.bytes("c", true)
+	share.Pub.D = r.bytes("d", true)
+	share.Pub.J = r.bytes("j", true)
+	share.Sec = r.bytes("sec", true)
+	share.Tag = r.bytes("tag", false)
+	if mandatory := r.bytes("mandatory", false); mandatory != nil {
+		var err error
+		if share.As.Mandatory, err = parseIDs(mandatory, wide); err != nil {
+			return fmt.Errorf("cbor: %w", err)
+		}
+	}
+	share.Points = r.bytes("points", false)
+	share.Version = uint8(r.uint("version", 0xff, false))
+	share.Scheme = uint8(r.uint("scheme", 0xff, false))
+	if exclusions, ok := r.take("exclusions"); ok {
+		items, ok := exclusions.([]interface{})
+		if !ok || len(items) == 0 {
+			return fmt.Errorf("cbor: exclusions invalid")
+		}
+		for _, item := range items {
+			data, ok := item.([]byte)
+			if !ok {
+				return fmt.Errorf("cbor: exclusions invalid")
+			}
+			exclusion, err := parseIDs(data, wide)
+			if err != nil {
+				return fmt.Errorf("cbor: %w", err)
+			}
+			share.As.Exclusions = append(share.As.Exclusions, exclusion)
+		}
+	}
+	if formula, ok := r.take("formula"); ok {
+		data, ok := formula.([]byte)
+		if !ok {
+			return fmt.Errorf("cbor: formula invalid")
+		}
+		var err error
+		if share.As.Formula, err = parseFormulaBytes(data, wide); err != nil {
+			return fmt.Errorf("cbor: %w", err)
+		}
+	}
+	if holders, ok := r.take("holders"); ok {
+		items, ok := holders.([]interface{})
+		if !ok || len(items) == 0 {
+			return fmt.Errorf("cbor: holders invalid")
+		}
+		for _, item := range items {
+			name, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("cbor: holders invalid")
+			}
+			share.As.Holders = append(share.As.Holders, name)
+		}
+	}
+	if domain, ok := r.take("domain"); ok {
+		if share.Domain, ok = domain.(string); !ok || share.Domain == "" {
+			return fmt.Errorf("cbor: domain invalid")
+		}
+	}
+	if hardening, ok := r.take("hardening"); ok {
+		params, ok := hardening.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("cbor: hardening invalid")
+		}
+		hr := cborFields{fields: params}
+		share.Hardening = &Argon2Params{
+			Time:    uint32(hr.uint("time", 0xffffffff, true)),
+			Memory:  uint32(hr.uint("memory", 0xffffffff, true)),
+			Threads: uint8(hr.uint("threads", 0xff, true)),
+		}
+		if err := hr.done(); err != nil {
+			return fmt.Errorf("hardening: %w", err)
+		}
+	}
+	if err := r.done(); err != nil {
+		return err
+	}
+
+	// Zero values are left out, so encoding them is a second encoding of the
+	// same share.
+	if (share.Tag != nil && len(share.Tag) == 0) || (share.As.Mandatory != nil && len(share.As.Mandatory) == 0) ||
+		(share.Points != nil && len(share.Points) == 0) || (r.has["version"] && share.Version == VersionUnframed) ||
+		(r.has["scheme"] && share.Scheme == SchemeShamir) {
+		return fmt.Errorf("cbor: optional field with its zero value")
+	}
+
+	*ss = share
+	return nil
+}
+
+// cborHead encodes the head of a data item in the fewest bytes.
+func cborHead(major byte, arg uint64) []byte {
+	major <<= 5
+	switch {
+	case arg < 24:
+		return []byte{major | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major | 24, byte(arg)}
+	case arg <= 0xffff:
+		return []byte{major | 25, byte(arg >> 8), byte(arg)}
+	case arg <= 0xffffffff:
+		out := []byte{major | 26, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(out[1:], uint32(arg))
+		return out
+	default:
+		out := []byte{major | 27, 0, 0, 0, 0, 0, 0, 0, 0}
+		binary.BigEndian.PutUint64(out[1:], arg)
+		return out
+	}
+}
+
+func cborByteString(b []byte) []byte {
+	return append(cborHead(cborBytes, uint64(len(b))), b...)
+}
+
+// cborEncodeMap encodes a map of text keys to encoded values, with the keys
+// in the bytewise order of their encodings.
+func cborEncodeMap(fields map[string][]byte) []byte {
+	keys := make([][]byte, 0, len(fields))
+	values := make(map[string][]byte, len(fields))
+	for key, value := range fields {
+		encoded := append(cborHead(cborText, uint64(len(key))), key...)
+		keys = append(keys, encoded)
+		values[string(encoded)] = value
+	}
+	sort.Slice(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 })
+
+	out := cborHead(cborMap, uint64(len(fields)))
+	for _, key := range keys {
+		out = append(out, key...)
+		out = append(out, values[string(key)]...)
+	}
+	return out
+}
+
+// cborDecoder decodes the subset of CBOR that shares use: unsigned integers,
+// byte and text strings, arrays, and maps with text keys, all in the
+// deterministic encoding.
+type cborDecoder struct {
+	data []byte
+}
+
+// head decodes the head of the next data item, refusing indefinite lengths
+// and arguments that aren't in the fewest bytes.
+func (d *cborDecoder) head() (byte, uint64, error) {
+	if len(d.data) < 1 {
+		return 0, 0, fmt.Errorf("cbor: unexpected end of data")
+	}
+	major, info := d.data[0]>>5, d.data[0]&0x1f
+	d.data = d.data[1:]
+	if info < 24 {
+		return major, uint64(info), nil
+	}
+	if info > 27 {
+		return 0, 0, fmt.Errorf("cbor: unsupported additional information %d", info)
+	}
+
+	size := 1 << (info - 24)
+	if len(d.data) < size {
+		return 0, 0, fmt.Errorf("cbor: unexpected end of data")
+	}
+	var arg uint64
+	for _, b := range d.data[:size] {
+		arg = arg<<8 | uint64(b)
+	}
+	d.data = d.data[size:]
+	if len(cborHead(major, arg)) != 1+size {
+		return 0, 0, fmt.Errorf("cbor: argument not in its shortest form")
+	}
+	return major, arg, nil
+}
+
+// item decodes the next data item as a uint64, []byte, string,
+// []interface{} or map[string]interface{}.
+func (d *cborDecoder) item(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, fmt.Errorf("cbor: nested too deeply")
+	}
+	major, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUint:
+		return arg, nil
+
+	case cborBytes, cborText:
+		if arg > uint64(len(d.data)) {
+			return nil, fmt.Errorf("cbor: unexpected end of data")
+		}
+		value := append([]byte{}, d.data[:arg]...)
+		d.data = d.data[arg:]
+		if major == cborText {
+			return string(value), nil
+		}
+		return value, nil
+
+	case cborArray:
+		// Every item takes at least a byte, which bounds the allocation.
+		if arg > uint64(len(d.data)) {
+			return nil, fmt.Errorf("cbor: unexpected end of data")
+		}
+		items := make([]interface{}, arg)
+		for i := range items {
+			if items[i], err = d.item(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+
+	case cborMap:
+		if arg > uint64(len(d.data)) {
+			return nil, fmt.Errorf("cbor: unexpected end of data")
+		}
+		fields := make(map[string]interface{}, arg)
+		var lastKey []byte
+		for i := uint64(0); i < arg; i++ {
+			start := d.data
+			keyItem, err := d.item(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			key, ok := keyItem.(string)
+			if !ok {
+				return nil, fmt.Errorf("cbor: map key isn't text")
+			}
+			encodedKey := start[:len(start)-len(d.data)]
+			if lastKey != nil && bytes.Compare(lastKey, encodedKey) >= 0 {
+				return nil, fmt.Errorf("cbor: map keys not in order or repeated")
+			}
+			lastKey = encodedKey
+			if fields[key], err = d.item(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		return fields, nil
+
+	default:
+		return nil, fmt.Errorf("cbor: unsupported major type %d", major)
+	}
+}
+
+// cborFields takes the fields of a decoded map, recording the first error
+// and which fields were present.
+type cborFields struct {
+	fields map[string]interface{}
+	has    map[string]bool
+	err    error
+}
+
+func (r *cborFields) take(key string) (interface{}, bool) {
+	value, ok := r.fields[key]
+	if ok {
+		delete(r.fields, key)
+		if r.has == nil {
+			r.has = make(map[string]bool)
+		}
+		r.has[key] = true
+	}
+	return value, ok
+}
+
+func (r *cborFields) uint(key string, max uint64, required bool) uint64 {
+	value, ok := r.take(key)
+	if !ok {
+		if required && r.err == nil {
+			r.err = fmt.Errorf("cbor: missing %s", key)
+		}
+		return 0
+	}
+	n, ok := value.(uint64)
+	if (!ok || n > max) && r.err == nil {
+		r.err = fmt.Errorf("cbor: %s invalid", key)
+	}
+	return n
+}
+
+func (r *cborFields) bytes(key string, required bool) []byte {
+	value, ok := r.take(key)
+	if !ok {
+		if required && r.err == nil {
+			r.err = fmt.Errorf("cbor: missing %s", key)
+		}
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok && r.err == nil {
+		r.err = fmt.Errorf("cbor: %s invalid", key)
+	}
+	return b
+}
+
+// done returns the first error, or an error naming a field that wasn't taken.
+func (r *cborFields) done() error {
+	if r.err != nil {
+		return r.err
+	}
+	for key := range r.fields {
+		return fmt.Errorf("cbor: unknown field %s", key)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package adss
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestSecretShareCBOR(t *testing.T) {
+	msg := []byte("hello world")
+	as, err := NewAccessStructureWithExclusions(2, 4, IDSet{0, 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if as, err = as.WithMandatory(3); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	shares, err := ShareInDomain("example.com", as, msg, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	hardened, err := ShareHardened(NewAccessStructure(2, 3), msg, nil, testArgon2Params)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	pointed, err := ShareWithPoints([]uint8{2, 4, 6}, NewAccessStructure(2, 3), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	replicated, err := ShareWithScheme(SchemeReplicated, NewAccessStructure(2, 3), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	for _, share := range []*SecretShare{shares[0], hardened[1], pointed[2], replicated[0]} {
+		encoded, err := share.MarshalCBOR()
+		if err != nil {
+			t.Fatalf("unexpected error encoding: %s", err)
+		}
+		var decoded SecretShare
+		if err := decoded.UnmarshalCBOR(encoded); err != nil {
+			t.Fatalf("unexpected error decoding: %s", err)
+		}
+		if !decoded.Equal(share) {
+			t.Errorf("decoded share doesn't match")
+		}
+
+		// The encoding is deterministic.
+		again, _ := decoded.MarshalCBOR()
+		if !bytes.Equal(again, encoded) {
+			t.Errorf("encodings differ: %x and %x", encoded, again)
+		}
+	}
+}
+
+func TestSecretShareCBORVector(t *testing.T) {
+	share := &SecretShare{
+		As:      NewAccessStructure(2, 3),
+		ID:      1,
+		Pub:     struct{ C, D, J []byte }{[]byte{0xc}, []byte{0xd}, []byte{0xa}},
+		Sec:     []byte{0x5e},
+		Version: VersionLabeled,
+	}
+	// {"c": h'0c', "d": h'0d', "j": h'0a', "id": 1, "sec": h'5e', "count": 3,
+	// "version": 2, "threshold": 2} with the self-described CBOR tag.
+	expected := "d9d9f7" + "a8" + "6163410c" + "6164410d" + "616a410a" + "62696401" +
+		"63736563415e" + "65636f756e7403" + "6776657273696f6e02" + "697468726573686f6c6402"
+	encoded, err := share.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("unexpected error encoding: %s", err)
+	}
+	if hex.EncodeToString(encoded) != expected {
+		t.Errorf("got %x, expected: %s", encoded, expected)
+	}
+
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"not a map", "01"},
+		{"non-shortest argument", "d9d9f7" + "b808" + expected[8:]},
+		{"keys out of order", "d9d9f7a2" + "616401" + "616301"},
+		{"repeated key", "d9d9f7a2" + "6163410c" + "6163410c"},
+		{"unknown field", "d9d9f7a1" + "617801"},
+		{"missing fields", "d9d9f7a1" + "6163410c"},
+		{"indefinite length", "d9d9f7bf"},
+		{"zero version", strings.Replace(expected, "6776657273696f6e02", "6776657273696f6e00", 1)},
+		{"wrong type", strings.Replace(expected, "62696401", "6269644101", 1)},
+		{"trailing bytes", expected + "00"},
+		{"truncated", expected[:len(expected)-2]},
+	}
+	for _, tt := range tests {
+		data, err := hex.DecodeString(tt.data)
+		if err != nil {
+			t.Fatalf("%s: bad test data: %s", tt.name, err)
+		}
+		var decoded SecretShare
+		if err := decoded.UnmarshalCBOR(data); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
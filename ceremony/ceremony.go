@@ -0,0 +1,313 @@
+// Package ceremony models a multi-party recovery session as a state machine:
+// holders are invited, submit their shares, approvers sign off, and the secret
+// is recovered once there are enough valid shares and approvals.
+//
+// A Session is plain data that can be marshalled, stored and loaded again
+// between steps, so applications can drive it from a web UI, a chat bot or a
+// command line without reinventing the workflow. Until the secret is
+// recovered the stored session contains the submitted shares, so it must be
+// stored as carefully as the shares themselves. The secret itself is never
+// stored and the shares are dropped once it has been recovered.
+package ceremony
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jakecraige/adss"
+)
+
+// State is the stage a Session is at.
+type State string
+
+const (
+	// StateCollecting means the session is waiting for shares or approvals.
+	StateCollecting State = "collecting"
+	// StateReady means there are enough shares and approvals to recover.
+	StateReady State = "ready"
+	// StateRecovered means the secret was recovered. It is final.
+	StateRecovered State = "recovered"
+	// StateCancelled means the session was cancelled. It is final.
+	StateCancelled State = "cancelled"
+)
+
+// ShareStatus is the status of a holder's share within a Session.
+type ShareStatus string
+
+const (
+	StatusPending   ShareStatus = "pending"   // not submitted yet
+	StatusSubmitted ShareStatus = "submitted" // submitted, not yet used for recovery
+	StatusValid     ShareStatus = "valid"     // explained the recovered secret
+	StatusUnused    ShareStatus = "unused"    // valid but not needed
+	StatusInvalid   ShareStatus = "invalid"   // not a share of the recovered sharing
+)
+
+// Holder is a shareholder invited to the session.
+type Holder struct {
+	Name        string
+	Status      ShareStatus
+	Reason      string            `json:",omitempty"` // why the share is invalid
+	SubmittedAt time.Time         `json:",omitempty"`
+	Share       *adss.SecretShare `json:",omitempty"`
+}
+
+// Session is a recovery ceremony.
+type Session struct {
+	ID        string
+	State     State
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	// Manifest, if set, is used to reject shares that aren't part of the
+	// sharing being recovered as soon as they are submitted.
+	Manifest *adss.Manifest `json:",omitempty"`
+
+	Holders []*Holder
+
+	// Approvers may approve the recovery and RequiredApprovals of them must
+	// do so before it can happen.
+	Approvers         []string
+	RequiredApprovals int
+	Approvals         map[string]time.Time
+
+	// LastError is why the last recovery attempt failed, if it did.
+	LastError string `json:",omitempty"`
+	// Fingerprint identifies the sharing once the secret is recovered.
+	Fingerprint string `json:",omitempty"`
+}
+
+// New starts a session that invites the named holders to submit shares and
+// requires approval from requiredApprovals of the approvers.
+func New(id string, holders, approvers []string, requiredApprovals int, manifest *adss.Manifest) (*Session, error) {
+	if len(holders) == 0 {
+		return nil, fmt.Errorf("no holders invited")
+	}
+	if requiredApprovals > len(approvers) {
+		return nil, fmt.Errorf("%d approvals required but only %d approvers", requiredApprovals, len(approvers))
+	}
+
+	now := time.Now().UTC()
+	s := &Session{
+		ID:                id,
+		State:             StateCollecting,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		Manifest:          manifest,
+		Approvers:         approvers,
+		RequiredApprovals: requiredApprovals,
+		Approvals:         make(map[string]time.Time),
+	}
+
+	seen := make(map[string]bool, len(holders))
+	for _, name := range holders {
+		if seen[name] {
+			return nil, fmt.Errorf("holder %s invited twice", name)
+		}
+		seen[name] = true
+		s.Holders = append(s.Holders, &Holder{Name: name, Status: StatusPending})
+	}
+
+	return s, nil
+}
+
+// Unmarshal loads a session stored with Marshal.
+func Unmarshal(data []byte) (*Session, error) {
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if s.Approvals == nil {
+		s.Approvals = make(map[string]time.Time)
+	}
+	return &s, nil
+}
+
+// Marshal encodes the session for storage.
+func (s *Session) Marshal() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+func (s *Session) holder(name string) *Holder {
+	for _, h := range s.Holders {
+		if h.Name == name {
+			return h
+		}
+	}
+	return nil
+}
+
+func (s *Session) checkOpen() error {
+	if s.State == StateRecovered || s.State == StateCancelled {
+		return fmt.Errorf("session is %s", s.State)
+	}
+	return nil
+}
+
+// submitted returns the shares submitted so far.
+func (s *Session) submitted() []*adss.SecretShare {
+	var shares []*adss.SecretShare
+	for _, h := range s.Holders {
+		if h.Share != nil {
+			shares = append(shares, h.Share)
+		}
+	}
+	return shares
+}
+
+// Submit records the holder's share. The share is checked against the
+// manifest, if there is one, and against the shares already submitted, and is
+// rejected if it can't be part of the same sharing. A holder may replace
+// their share until the secret is recovered.
+func (s *Session) Submit(name string, share *adss.SecretShare) error {
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+	h := s.holder(name)
+	if h == nil {
+		return fmt.Errorf("%s is not an invited holder", name)
+	}
+
+	if s.Manifest != nil {
+		if err := s.Manifest.VerifyShare(share); err != nil {
+			return err
+		}
+	}
+	for _, other := range s.Holders {
+		if other == h || other.Share == nil {
+			continue
+		}
+		if other.Share.ID == share.ID {
+			return fmt.Errorf("share %d was already submitted by %s", share.ID, other.Name)
+		}
+		if !other.Share.As.Equal(share.As) {
+			return fmt.Errorf("share has a different access structure than the one submitted by %s", other.Name)
+		}
+		if other.Share.Fingerprint() != share.Fingerprint() {
+			return fmt.Errorf("share is from a different sharing than the one submitted by %s", other.Name)
+		}
+	}
+
+	h.Share = share
+	h.Status = StatusSubmitted
+	h.Reason = ""
+	h.SubmittedAt = time.Now().UTC()
+	s.update()
+	return nil
+}
+
+// Approve records the approver's sign off on the recovery.
+func (s *Session) Approve(approver string) error {
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+
+	allowed := false
+	for _, a := range s.Approvers {
+		if a == approver {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("%s is not an approver", approver)
+	}
+
+	if _, ok := s.Approvals[approver]; !ok {
+		s.Approvals[approver] = time.Now().UTC()
+	}
+	s.update()
+	return nil
+}
+
+// Cancel ends the session without recovering and drops the submitted shares.
+func (s *Session) Cancel() error {
+	if err := s.checkOpen(); err != nil {
+		return err
+	}
+
+	s.State = StateCancelled
+	s.dropShares()
+	s.UpdatedAt = time.Now().UTC()
+	return nil
+}
+
+// Progress returns the number of shares submitted and the number needed,
+// which is 0 until the first share is submitted unless there is a manifest.
+func (s *Session) Progress() (submitted, threshold int) {
+	shares := s.submitted()
+	switch {
+	case len(shares) > 0:
+		threshold = int(shares[0].As.T)
+	case s.Manifest != nil:
+		threshold = int(s.Manifest.Threshold)
+	}
+	return len(shares), threshold
+}
+
+// update moves between collecting and ready as shares and approvals arrive.
+func (s *Session) update() {
+	submitted, threshold := s.Progress()
+	if threshold > 0 && submitted >= threshold && len(s.Approvals) >= s.RequiredApprovals {
+		s.State = StateReady
+	} else {
+		s.State = StateCollecting
+	}
+	s.UpdatedAt = time.Now().UTC()
+}
+
+// Recover recovers the secret once the session is ready. The status of every
+// submitted share is updated and, on success, the shares are dropped from the
+// session. If recovery fails the session keeps collecting so holders can
+// resubmit or more holders can join.
+func (s *Session) Recover(opts ...adss.RecoverOption) ([]byte, error) {
+	if s.State != StateReady {
+		return nil, fmt.Errorf("session is %s, not ready", s.State)
+	}
+
+	shares := s.submitted()
+	secret, valid, err := adss.Recover(shares, opts...)
+	if err != nil {
+		s.LastError = err.Error()
+		s.State = StateCollecting
+		s.UpdatedAt = time.Now().UTC()
+		return nil, err
+	}
+
+	reports, err := adss.ClassifyShares(shares, valid)
+	if err != nil {
+		return nil, err
+	}
+	byShare := make(map[*adss.SecretShare]adss.ShareReport, len(reports))
+	for _, report := range reports {
+		byShare[report.Share] = report
+	}
+	for _, h := range s.Holders {
+		report, ok := byShare[h.Share]
+		if !ok {
+			continue
+		}
+		switch report.Status {
+		case adss.ShareUsed:
+			h.Status = StatusValid
+		case adss.ShareUnused:
+			h.Status = StatusUnused
+		case adss.ShareInvalid:
+			h.Status = StatusInvalid
+			h.Reason = report.Reason.Error()
+		}
+	}
+
+	s.Fingerprint = shares[0].Fingerprint()
+	s.LastError = ""
+	s.State = StateRecovered
+	s.dropShares()
+	s.UpdatedAt = time.Now().UTC()
+	return secret, nil
+}
+
+func (s *Session) dropShares() {
+	for _, h := range s.Holders {
+		h.Share = nil
+	}
+}
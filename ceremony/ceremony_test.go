@@ -0,0 +1,152 @@
+package ceremony
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jakecraige/adss"
+)
+
+// roundTrip stores and loads the session as an application would between
+// steps.
+func roundTrip(t *testing.T, s *Session) *Session {
+	t.Helper()
+	data, err := s.Marshal()
+	if err != nil {
+		t.Fatalf("unexpected error marshalling: %s", err)
+	}
+	s, err = Unmarshal(data)
+	if err != nil {
+		t.Fatalf("unexpected error unmarshalling: %s", err)
+	}
+	return s
+}
+
+func TestSession(t *testing.T) {
+	msg := []byte("hello world")
+	shares, err := adss.Share(adss.NewAccessStructure(2, 3), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	other, err := adss.Share(adss.NewAccessStructure(2, 3), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	m, err := adss.NewManifest(shares, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	s, err := New("restore-ca", []string{"alice", "bob", "carol"}, []string{"ciso"}, 1, m)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := s.Submit("mallory", shares[0]); err == nil {
+		t.Errorf("expected error for an uninvited holder")
+	}
+	if err := s.Submit("alice", other[0]); err == nil {
+		t.Errorf("expected error for a share not in the manifest")
+	}
+	if err := s.Submit("alice", shares[0]); err != nil {
+		t.Fatalf("unexpected error submitting: %s", err)
+	}
+	if err := s.Submit("bob", shares[0]); err == nil {
+		t.Errorf("expected error for a share submitted twice")
+	}
+	s = roundTrip(t, s)
+
+	if _, err := s.Recover(); err == nil {
+		t.Errorf("expected error recovering before ready")
+	}
+	if err := s.Submit("bob", shares[1]); err != nil {
+		t.Fatalf("unexpected error submitting: %s", err)
+	}
+	if submitted, threshold := s.Progress(); submitted != 2 || threshold != 2 {
+		t.Errorf("progress = %d of %d, expected: 2 of 2", submitted, threshold)
+	}
+
+	// Enough shares but still waiting for approval.
+	if s.State != StateCollecting {
+		t.Errorf("state = %s, expected: %s", s.State, StateCollecting)
+	}
+	if err := s.Approve("bob"); err == nil {
+		t.Errorf("expected error for approval by a non-approver")
+	}
+	if err := s.Approve("ciso"); err != nil {
+		t.Fatalf("unexpected error approving: %s", err)
+	}
+	if s.State != StateReady {
+		t.Errorf("state = %s, expected: %s", s.State, StateReady)
+	}
+	s = roundTrip(t, s)
+
+	recov, err := s.Recover()
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x != %x", recov, msg)
+	}
+	if s.State != StateRecovered || s.Fingerprint != shares[0].Fingerprint() {
+		t.Errorf("unexpected final state: %s, %s", s.State, s.Fingerprint)
+	}
+
+	var expected = []ShareStatus{StatusValid, StatusValid, StatusPending}
+	for i, h := range s.Holders {
+		if h.Status != expected[i] {
+			t.Errorf("%s: status = %s, expected: %s", h.Name, h.Status, expected[i])
+		}
+		if h.Share != nil {
+			t.Errorf("%s: share kept after recovery", h.Name)
+		}
+	}
+
+	if err := s.Submit("carol", shares[2]); err == nil {
+		t.Errorf("expected error submitting after recovery")
+	}
+}
+
+func TestSessionFailedRecovery(t *testing.T) {
+	shares, err := adss.Share(adss.NewAccessStructure(2, 3), []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	bad := *shares[1]
+	bad.Sec = append([]byte{}, bad.Sec...)
+	bad.Sec[0]++
+
+	s, err := New("restore", []string{"alice", "bob", "carol"}, nil, 0, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := s.Submit("alice", shares[0]); err != nil {
+		t.Fatalf("unexpected error submitting: %s", err)
+	}
+	if err := s.Submit("bob", &bad); err != nil {
+		t.Fatalf("unexpected error submitting: %s", err)
+	}
+
+	if _, err := s.Recover(); err == nil {
+		t.Fatalf("expected error recovering with a bad share")
+	}
+	if s.State != StateCollecting || s.LastError == "" {
+		t.Errorf("unexpected state after failure: %s, %q", s.State, s.LastError)
+	}
+
+	// Another holder joining lets recovery succeed and the bad share is
+	// identified.
+	if err := s.Submit("carol", shares[2]); err != nil {
+		t.Fatalf("unexpected error submitting: %s", err)
+	}
+	if _, err := s.Recover(); err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if h := s.Holders[1]; h.Status != StatusInvalid || h.Reason != "share 1: secret share is corrupted" {
+		t.Errorf("unexpected status for bad share: %s, %q", h.Status, h.Reason)
+	}
+
+	if err := s.Cancel(); err == nil {
+		t.Errorf("expected error cancelling a recovered session")
+	}
+}
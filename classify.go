@@ -0,0 +1,157 @@
+package adss
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// ShareStatus is how a share provided to Recover relates to the recovered
+// sharing.
+type ShareStatus int
+
+const (
+	// ShareUsed means the share is valid and explained the secret.
+	ShareUsed ShareStatus = iota + 1
+	// ShareUnused means the share is a valid share of the recovered sharing
+	// but wasn't needed to explain it.
+	ShareUnused
+	// ShareInvalid means the share isn't a share of the recovered sharing.
+	ShareInvalid
+)
+
+func (s ShareStatus) String() string {
+	switch s {
+	case ShareUsed:
+		return "valid"
+	case ShareUnused:
+		return "valid but unused"
+	case ShareInvalid:
+		return "invalid"
+	default:
+		return fmt.Sprintf("ShareStatus(%d)", int(s))
+	}
+}
+
+// ShareReport classifies one of the shares provided to Recover.
+type ShareReport struct {
+	Share  *SecretShare
+	Status ShareStatus
+	Reason error // why the share is invalid as a *ShareError, nil otherwise
+}
+
+// ClassifyShares reports the status of each of the shares given to Recover,
+// in the same order, using the valid shares V that it returned. This lets
+// callers tell a share that is fine but wasn't needed apart from one that is
+// corrupted or belongs to a different sharing.
+func ClassifyShares(shares, V []*SecretShare) ([]ShareReport, error) {
+	if len(V) == 0 {
+		return nil, fmt.Errorf("no valid shares")
+	}
+
+	reshares, err := resharing(V)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]ShareReport, len(shares))
+	for i, share := range shares {
+		reports[i] = ShareReport{Share: share}
+		switch {
+		case isSubset([]*SecretShare{share}, V):
+			reports[i].Status = ShareUsed
+		case int(share.ID) >= len(reshares):
+			reports[i].Status = ShareInvalid
+			reports[i].Reason = shareErrorf(share, FieldID, "out of range for %d shares", len(reshares))
+		default:
+			reports[i].Reason = compareShare(share, reshares[share.ID])
+			reports[i].Status = ShareUnused
+			if reports[i].Reason != nil {
+				reports[i].Status = ShareInvalid
+			}
+		}
+	}
+
+	return reports, nil
+}
+
+// checkAllValid returns an error naming every share that ClassifyShares finds
+// invalid.
+func checkAllValid(shares, V []*SecretShare) error {
+	reports, err := ClassifyShares(shares, V)
+	if err != nil {
+		return err
+	}
+
+	var invalid []string
+	for _, report := range reports {
+		if report.Status == ShareInvalid {
+			invalid = append(invalid, report.Reason.Error())
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid shares: %s", strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// resharing regenerates every share of the sharing that V explains.
+func resharing(V []*SecretShare) ([]*SecretShare, error) {
+	M, R, err := recoverInputs(V)
+	if err != nil {
+		return nil, err
+	}
+
+	share0 := V[0]
+	reshares, err := internalShare(share0.As, M, R, share0.Tag, paramsOf(share0))
+	if err != nil {
+		return nil, err
+	}
+	if !isSubset(V, reshares) {
+		return nil, fmt.Errorf("shares don't explain a sharing")
+	}
+
+	return reshares, nil
+}
+
+// recoverInputs decrypts the message and coins of the sharing that V
+// explains. It doesn't check them, callers must do so.
+func recoverInputs(V []*SecretShare) ([]byte, []byte, error) {
+	K, err := baseRecover(V)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return xorKeyStreamTwoInputs(K, V[0].Pub.C, V[0].Pub.D)
+}
+
+// compareShare returns a ShareError describing the first way that share
+// differs from the expected share, or nil if they are the same.
+func compareShare(share, expected *SecretShare) error {
+	switch {
+	case !share.As.Equal(expected.As):
+		return shareErrorf(share, FieldAccessStructure, "access structure %d-of-%d doesn't match the sharing", share.As.T, share.As.N)
+	case !bytes.Equal(share.Tag, expected.Tag):
+		return shareErrorf(share, FieldTag, "associated data doesn't match the sharing")
+	case !share.Hardening.equal(expected.Hardening):
+		return shareErrorf(share, FieldHardening, "hardening doesn't match the sharing")
+	case share.Version != expected.Version:
+		return shareErrorf(share, FieldVersion, "version %d doesn't match the sharing", share.Version)
+	case share.Domain != expected.Domain:
+		return shareErrorf(share, FieldDomain, "domain %q doesn't match the sharing", share.Domain)
+	case share.Scheme != expected.Scheme:
+		return shareErrorf(share, FieldScheme, "scheme %d doesn't match the sharing", share.Scheme)
+	case !bytes.Equal(share.Points, expected.Points):
+		return shareErrorf(share, FieldPoints, "evaluation points don't match the sharing")
+	case !bytes.Equal(share.Pub.J, expected.Pub.J):
+		return shareErrorf(share, FieldCommitment, "from a different sharing")
+	case !bytes.Equal(share.Pub.C, expected.Pub.C) || !bytes.Equal(share.Pub.D, expected.Pub.D):
+		return shareErrorf(share, FieldPayload, "public payload is corrupted")
+	case len(share.Sec) != len(expected.Sec):
+		return shareErrorf(share, FieldSecret, "secret share is %d bytes, expected: %d", len(share.Sec), len(expected.Sec))
+	case !bytes.Equal(share.Sec, expected.Sec):
+		return shareErrorf(share, FieldSecret, "secret share is corrupted")
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,106 @@
+package adss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestClassifyShares(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 4), []byte("hello world"), []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	corruptSec := cloneShare(shares[2])
+	corruptSec.Sec[0]++
+	corruptC := cloneShare(shares[2])
+	corruptC.Pub.C[0]++
+	outOfRange := cloneShare(shares[0])
+	outOfRange.ID = 9
+
+	V := []*SecretShare{shares[0], shares[1]}
+	input := []*SecretShare{shares[0], shares[1], shares[3], corruptSec, corruptC, outOfRange}
+	reports, err := ClassifyShares(input, V)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var expected = []struct {
+		status ShareStatus
+		reason string
+	}{
+		{ShareUsed, ""},
+		{ShareUsed, ""},
+		{ShareUnused, ""},
+		{ShareInvalid, "share 2: secret share is corrupted"},
+		{ShareInvalid, "share 2: public payload is corrupted"},
+		{ShareInvalid, "share 9: out of range for 4 shares"},
+	}
+	for i, tt := range expected {
+		report := reports[i]
+		if report.Share != input[i] {
+			t.Errorf("%d: report for the wrong share", i)
+		}
+		if report.Status != tt.status {
+			t.Errorf("%d: status = %s, expected: %s", i, report.Status, tt.status)
+		}
+		reason := ""
+		if report.Reason != nil {
+			reason = report.Reason.Error()
+		}
+		if reason != tt.reason {
+			t.Errorf("%d: reason = %q, expected: %q", i, reason, tt.reason)
+		}
+	}
+
+	// The reports for a real recovery agree with the valid shares it returned.
+	_, V, err = Recover([]*SecretShare{shares[0], corruptSec, shares[1]})
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	reports, err = ClassifyShares([]*SecretShare{shares[0], corruptSec, shares[1]}, V)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if reports[0].Status != ShareUsed || reports[1].Status != ShareInvalid || reports[2].Status != ShareUsed {
+		t.Errorf("unexpected statuses: %s, %s, %s", reports[0].Status, reports[1].Status, reports[2].Status)
+	}
+
+	if _, err := ClassifyShares(input, []*SecretShare{corruptSec, shares[0]}); err == nil {
+		t.Errorf("expected error for shares that don't explain a sharing")
+	}
+}
+
+func TestRecoverWithRequireAllValid(t *testing.T) {
+	msg := []byte("hello world")
+	shares, err := Share(NewAccessStructure(2, 4), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	bad := cloneShare(shares[2])
+	bad.Sec[0]++
+
+	recov, _, err := Recover(shares, WithRequireAllValid())
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x != %x", recov, msg)
+	}
+
+	// Without the option the bad share is tolerated.
+	input := []*SecretShare{shares[0], bad, shares[1]}
+	if _, _, err := Recover(input); err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+
+	recov, V, err := Recover(input, WithRequireAllValid())
+	expected := "invalid shares: share 2: secret share is corrupted"
+	if err == nil || err.Error() != expected {
+		t.Errorf("unexpected error, expected: %s, got: %v", expected, err)
+	}
+	if recov != nil || V != nil {
+		t.Errorf("returned results with error")
+	}
+}
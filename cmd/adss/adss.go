@@ -1,29 +1,83 @@
 package main
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
 	"encoding/base64"
-	"encoding/json"
+	"encoding/hex"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jakecraige/adss"
 )
 
+// command is a subcommand of the CLI. setup defines the command's flags on
+// the flag set and returns the function that runs it once they are parsed.
+type command struct {
+	name    string
+	summary string
+	setup   func(*flag.FlagSet) func() error
+}
+
+func allCommands() []command {
+	return []command{
+		{"split", "Split a secret into shares", split},
+		{"split-env", "Split each entry of a .env or properties file into its own sharing", splitEnv},
+		{"recover", "Recover a secret from shares", doRecover},
+		{"inspect", "Print the non-secret details of shares", inspect},
+		{"redact", "Write copies of shares without their secret part", redact},
+		{"manifest-keygen", "Create a key pair for signing manifests", manifestKeygen},
+		{"receipt-keygen", "Create a key pair for a holder to sign receipts with", receiptKeygen},
+		{"receipt", "Sign a receipt acknowledging a share was received", receipt},
+		{"verify-receipts", "Check every share in a manifest was acknowledged by its holder", verifyReceipts},
+		{"revoke", "Sign a list of compromised shares that recovery must refuse", revoke},
+		{"transcript-sign", "Sign the transcript of a split or recovery", transcriptSign},
+		{"transcript-verify", "Check every participant signed a transcript", transcriptVerify},
+		{"prove", "Prove possession of a share without revealing it", prove},
+		{"verify-proofs", "Check proofs of share possession against a manifest", verifyProofs},
+		{"envelope-keygen", "Create a key pair to encrypt recovered secrets to", envelopeKeygen},
+		{"envelope-open", "Decrypt a secret encrypted by recover -recipient", envelopeOpen},
+		{"bundle-open", "Extract a holder's share from a bundle written by split -bundle-path", bundleOpen},
+		{"vault-init", "Initialize HashiCorp Vault and split its unseal key", vaultInit},
+		{"vault-unseal", "Recover the unseal key and unseal HashiCorp Vault", vaultUnseal},
+		{"selftest", "Check this build splits and recovers correctly before trusting it", selftest},
+		{"bench", "Measure how fast this machine splits and recovers secrets", bench},
+		{"completion", "Print a bash, zsh or fish completion script", completion},
+		{"version", "Print the version of adss and of the scheme and share format", printVersion},
+	}
+}
+
 func main() {
-	cmd := os.Args[1]
-	var err error
-	switch cmd {
-	case "split":
-		err = split()
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
 
-	case "recover":
-		err = doRecover()
+	var err error
+	switch cmd := os.Args[1]; cmd {
+	case "help", "-h", "-help", "--help":
+		err = help(os.Args[2:])
 
 	default:
-		err = fmt.Errorf("Unknown command: %s\n", cmd)
+		c, ok := findCommand(cmd)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Unknown command: %s\n\n", cmd)
+			usage(os.Stderr)
+			os.Exit(2)
+		}
+		fs := newFlagSet(c, flag.ExitOnError)
+		run := c.setup(fs)
+		fs.Parse(os.Args[2:])
+		err = run()
 	}
 
 	if err != nil {
@@ -32,112 +86,899 @@ func main() {
 	}
 }
 
-func split() error {
-	splitCmd := flag.NewFlagSet("split", flag.ExitOnError)
+func findCommand(name string) (command, bool) {
+	for _, c := range allCommands() {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// newFlagSet returns the flag set for the command with usage text that
+// describes the command and its flags.
+func newFlagSet(c command, errorHandling flag.ErrorHandling) *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, errorHandling)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: adss %s [flags]\n\n%s.\n", c.name, c.summary)
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintf(fs.Output(), "\nFlags:\n")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// usage lists the commands.
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: adss <command> [flags]\n\nCommands:\n")
+	for _, c := range allCommands() {
+		fmt.Fprintf(w, "  %-16s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(w, "\nRun 'adss help <command>' for the flags of a command.\n")
+}
+
+// help prints the usage of the named command, or lists the commands.
+func help(args []string) error {
+	if len(args) == 0 {
+		usage(os.Stdout)
+		return nil
+	}
+
+	c, ok := findCommand(args[0])
+	if !ok {
+		return fmt.Errorf("unknown command: %s", args[0])
+	}
+	fs := newFlagSet(c, flag.ContinueOnError)
+	fs.SetOutput(os.Stdout)
+	c.setup(fs)
+	fs.Usage()
+	return nil
+}
+
+func split(splitCmd *flag.FlagSet) func() error {
 	secPtr := splitCmd.String("secret", "", "Secret to split into shares")
 	secPathPtr := splitCmd.String("secret-path", "", "File to split into shares")
 	adPtr := splitCmd.String("associated-data", "", "Public data to bind with the shares")
+	detachADPtr := splitCmd.Bool("detach-associated-data", false, "Leave the associated data out of the shares so it must be supplied to recover with -associated-data or -ad-path")
+	adFieldsPtr := splitCmd.String("ad-fields", "", "Comma-separated label=value pairs, such as policy=prod,ticket=OPS-1, to bind with the shares instead of -associated-data")
 	tPtr := splitCmd.Uint("threshold", 0, "Threshold to reconstruct secret")
 	nPtr := splitCmd.Uint("count", 0, "Number of shares to create")
+	excludePtr := splitCmd.String("exclude", "", "Comma-separated sets of share IDs, each joined by +, that may not recover together, such as 0+1,2+3 for shares kept in the same facility")
+	mandatoryPtr := splitCmd.String("mandatory", "", "IDs of shares, joined by +, that must be present to recover in addition to meeting the threshold, such as 0 for the security officer's share")
+	formulaPtr := splitCmd.String("formula", "", "Which sets of shares can recover, instead of -threshold and -count, as a formula over the -holders names or share IDs, such as \"(ceo AND cfo) OR 3-of(eng1, eng2, eng3, eng4, eng5)\", enforced with -scheme formula unless -scheme replicated is given")
 	outDirPtr := splitCmd.String("out-dir", ".", "Directory to write the shares to")
-	splitCmd.Parse(os.Args[2:])
+	padToPtr := splitCmd.Int("pad-to", 0, "Pad the secret to a multiple of this many bytes and make all share files the same size")
+	hardenPtr := splitCmd.Bool("harden", false, "Strengthen the sharing with Argon2id, use when the secret is a low-entropy passphrase")
+	domainPtr := splitCmd.String("domain", "", "Application domain, such as example.com/backups, to separate the sharing from other deployments'")
+	schemePtr := splitCmd.String("scheme", "shamir", "Base scheme to split the key with: shamir, replicated to enforce -exclude, -mandatory and -formula in the shares themselves (at most 16 shares), formula to enforce -formula with shares the size of shamir's, or shamir16 to split over GF(2^16) as sharings of more than 255 shares are")
+	pointsPtr := splitCmd.String("points", "", "Comma-separated Shamir evaluation points of the shares in ID order, such as 2,4,6, to match another deployment's convention instead of ID+1")
+	entropyPathPtr := splitCmd.String("entropy-path", "", "File with additional entropy (e.g. dice rolls) to mix into the random coins")
+	dealerKeyPathPtr := splitCmd.String("dealer-key-path", "", "File with a secret dealer key to derive the coins from, so the same inputs always produce the same shares")
+	reissueIDsPtr := splitCmd.String("reissue-ids", "", "Comma-separated IDs of the shares to write, for re-issuing lost shares with -dealer-key-path")
+	nameTemplatePtr := splitCmd.String("name-template", defaultNameTemplate, "File name of each share, from {id}, {fingerprint}, {holder}, {group} (the place of the share in a nested -formula, such as 1.3), {threshold}, {count} and {ext}, such as {fingerprint}-{holder}-{id}.{ext}, share-{holder}.{ext} by default with -holders")
+	formatPtr := splitCmd.String("format", "json", "Share file format: json, yaml, bech32 (a compact string that can be typed by hand), digits (error-correcting digit groups for dictation), pem (armored for emails and paper), mnemonic (BIP 39 words for writing down), binary or cbor")
+	qrPtr := splitCmd.String("qr", "", "Render each share as a QR code of its share string: png to write the share files as images to print instead of -format, or terminal to also print them for holders to scan from the screen")
+	notePtr := splitCmd.String("note", "", "Note describing the secret to record, authenticated, in the shares")
+	createdAtPtr := splitCmd.Bool("created-at", false, "Record the creation time, authenticated, in the shares")
+	manifestPathPtr := splitCmd.String("manifest-path", "", "Write a manifest listing the sharing and its shares, without secret material, to this file")
+	holdersPtr := splitCmd.String("holders", "", "Comma-separated names of the share holders, in share order, to record in the shares and the manifest and name the share files after, such as share-alice.json")
+	signingKeyPathPtr := splitCmd.String("signing-key-path", "", "Sign the manifest with this key from manifest-keygen, writing the signature to <manifest-path>.sig")
+	bundlePathPtr := splitCmd.String("bundle-path", "", "Instead of one file per share, write a single bundle with each share encrypted to its holder's key from -recipients")
+	plainBundlePathPtr := splitCmd.String("plain-bundle-path", "", "Instead of one file per share, write a single unencrypted document with every share and the manifest, for transport between trusted systems")
+	recipientsPtr := splitCmd.String("recipients", "", "Comma-separated base64 public keys from envelope-keygen, in share order, to encrypt the shares in the bundle to")
+	transcriptPathPtr := splitCmd.String("transcript-path", "", "Write a transcript of the split, without secret material, for the participants to sign")
+	participantsPtr := splitCmd.String("participants", "", "Comma-separated names of the ceremony participants to record in the transcript")
+	verifyPtr := splitCmd.Bool("verify", false, "Test recovery from random threshold-sized subsets of the shares, in memory and as read back from the files written, before reporting success")
 
-	if *tPtr == 0 {
-		return fmt.Errorf("-threshold is required")
-	}
-	if *nPtr == 0 {
-		return fmt.Errorf("-count is required")
-	}
+	return func() error {
+		startedAt := time.Now()
+		var formulaAs *adss.AccessStructure
+		if *formulaPtr != "" {
+			if *tPtr != 0 || *nPtr != 0 || *excludePtr != "" || *mandatoryPtr != "" {
+				return fmt.Errorf("-formula cannot be combined with -threshold, -count, -exclude or -mandatory")
+			}
+			// Only the formula and replicated schemes enforce the formula in
+			// the shares themselves, so split with the formula scheme unless
+			// told otherwise and refuse any scheme that can't.
+			if *dealerKeyPathPtr != "" || *hardenPtr || *domainPtr != "" {
+				return fmt.Errorf("-formula cannot be combined with -dealer-key-path, -harden or -domain, which can't enforce it")
+			}
+			schemeSet := false
+			splitCmd.Visit(func(f *flag.Flag) { schemeSet = schemeSet || f.Name == "scheme" })
+			if !schemeSet {
+				*schemePtr = "formula"
+			}
+			if *schemePtr != "formula" && *schemePtr != "replicated" {
+				return fmt.Errorf("-formula needs -scheme formula or replicated to enforce it, not %s", *schemePtr)
+			}
+			var parties []string
+			if *holdersPtr != "" {
+				parties = strings.Split(*holdersPtr, ",")
+			}
+			f, err := adss.ParseFormula(*formulaPtr, parties)
+			if err != nil {
+				return fmt.Errorf("-formula: %w", err)
+			}
+			as, err := adss.NewFormulaAccessStructure(f)
+			if err != nil {
+				return fmt.Errorf("-formula: %w", err)
+			}
+			formulaAs = &as
+			*tPtr, *nPtr = uint(as.T), uint(as.N)
+		}
+		if *tPtr == 0 {
+			return fmt.Errorf("-threshold is required")
+		}
+		if *nPtr == 0 {
+			return fmt.Errorf("-count is required")
+		}
+		if *tPtr > 0xffff || *nPtr > 0xffff {
+			return fmt.Errorf("-threshold and -count must be at most 65535")
+		}
+		if _, ok := shareFormats[*formatPtr]; !ok {
+			return fmt.Errorf("unknown -format: %s", *formatPtr)
+		}
+		switch *qrPtr {
+		case "", "terminal":
+		case "png":
+			if *formatPtr != "json" {
+				return fmt.Errorf("-qr png cannot be combined with -format %s", *formatPtr)
+			}
+			*formatPtr = "qr"
+		default:
+			return fmt.Errorf("unknown -qr: %s, expected png or terminal", *qrPtr)
+		}
+		// Binary shares can't be padded with whitespace like the text formats.
+		if *padToPtr > 0 && (*formatPtr == "binary" || *formatPtr == "cbor") {
+			return fmt.Errorf("-pad-to cannot be combined with -format %s", *formatPtr)
+		}
+		if *padToPtr > 0 && *formatPtr == "qr" {
+			return fmt.Errorf("-pad-to cannot be combined with -qr png")
+		}
+		var holders []string
+		if *holdersPtr != "" {
+			holders = strings.Split(*holdersPtr, ",")
+			if len(holders) != int(*nPtr) {
+				return fmt.Errorf("-holders must name %d holders, got %d", *nPtr, len(holders))
+			}
+		}
+		if *signingKeyPathPtr != "" && *manifestPathPtr == "" {
+			return fmt.Errorf("-signing-key-path requires -manifest-path")
+		}
+		if *bundlePathPtr != "" && *plainBundlePathPtr != "" {
+			return fmt.Errorf("-bundle-path cannot be combined with -plain-bundle-path")
+		}
+		// Bundles hold the shares in place of share files, so there are no
+		// files to render and printing the shares would bypass their
+		// encryption.
+		if *qrPtr != "" && (*bundlePathPtr != "" || *plainBundlePathPtr != "") {
+			return fmt.Errorf("-qr cannot be combined with -bundle-path or -plain-bundle-path")
+		}
+		var recipients []*[32]byte
+		if *bundlePathPtr != "" {
+			if *recipientsPtr == "" {
+				return fmt.Errorf("-bundle-path requires -recipients")
+			}
+			for _, encoded := range strings.Split(*recipientsPtr, ",") {
+				key, err := decodeKey(encoded)
+				if err != nil {
+					return fmt.Errorf("-recipients: %w", err)
+				}
+				recipients = append(recipients, key)
+			}
+			if len(recipients) != int(*nPtr) {
+				return fmt.Errorf("-recipients must list %d keys, got %d", *nPtr, len(recipients))
+			}
+		}
 
-	secret := []byte(*secPtr)
-	var err error
-	if *secPtr == "" {
-		if *secPathPtr == "" {
-			return fmt.Errorf("-secret or -secret-path must be provided")
+		secret := []byte(*secPtr)
+		var err error
+		if *secPtr == "" {
+			if *secPathPtr == "" {
+				return fmt.Errorf("-secret or -secret-path must be provided")
+			}
+
+			secret, err = ioutil.ReadFile(*secPathPtr)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", *secPathPtr, err)
+			}
+		}
+
+		if *padToPtr > 0 {
+			secret, err = adss.PadSecret(secret, *padToPtr)
+			if err != nil {
+				return err
+			}
 		}
 
-		secret, err = ioutil.ReadFile(*secPathPtr)
+		var entropy []byte
+		if *entropyPathPtr != "" {
+			entropy, err = ioutil.ReadFile(*entropyPathPtr)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", *entropyPathPtr, err)
+			}
+		}
+
+		ad := []byte(*adPtr)
+		if *adFieldsPtr != "" {
+			if *adPtr != "" {
+				return fmt.Errorf("-ad-fields cannot be combined with -associated-data")
+			}
+			fields, err := parseADFields(*adFieldsPtr)
+			if err != nil {
+				return err
+			}
+			ad = adss.EncodeFields(fields)
+		}
+		if *notePtr != "" || *createdAtPtr {
+			md := adss.Metadata{Note: *notePtr}
+			if *createdAtPtr {
+				md.CreatedAt = time.Now()
+			}
+			ad = adss.TagWithMetadata(ad, md)
+		}
+
+		var only map[uint16]bool
+		if *reissueIDsPtr != "" {
+			if *dealerKeyPathPtr == "" {
+				return fmt.Errorf("-reissue-ids requires -dealer-key-path")
+			}
+			if *manifestPathPtr != "" {
+				return fmt.Errorf("-reissue-ids cannot be combined with -manifest-path")
+			}
+			only = make(map[uint16]bool)
+			for _, idStr := range strings.Split(*reissueIDsPtr, ",") {
+				id, err := strconv.ParseUint(idStr, 10, 16)
+				if err != nil || id >= uint64(*nPtr) {
+					return fmt.Errorf("invalid share ID: %s", idStr)
+				}
+				only[uint16(id)] = true
+			}
+		}
+
+		as := adss.NewAccessStructure(uint16(*tPtr), uint16(*nPtr))
+		if formulaAs != nil {
+			as = *formulaAs
+		}
+		if *excludePtr != "" {
+			exclusions, err := parseIDSets(strings.Split(*excludePtr, ","))
+			if err != nil {
+				return err
+			}
+			as, err = adss.NewAccessStructureWithExclusions(uint16(*tPtr), uint16(*nPtr), exclusions...)
+			if err != nil {
+				return err
+			}
+		}
+		if *mandatoryPtr != "" {
+			mandatory, err := adss.ParseIDSet(*mandatoryPtr)
+			if err != nil {
+				return err
+			}
+			if as, err = as.WithMandatory(mandatory...); err != nil {
+				return err
+			}
+		}
+		if holders != nil {
+			if as, err = as.WithHolders(holders...); err != nil {
+				return fmt.Errorf("-holders: %w", err)
+			}
+			if *nameTemplatePtr == defaultNameTemplate {
+				*nameTemplatePtr = holderNameTemplate
+			}
+		}
+		var shares []*adss.SecretShare
+		switch {
+		case *dealerKeyPathPtr != "":
+			if *hardenPtr || entropy != nil || *createdAtPtr || *domainPtr != "" || *schemePtr != "shamir" || *pointsPtr != "" {
+				return fmt.Errorf("-dealer-key-path cannot be combined with -harden, -entropy-path, -created-at, -domain, -scheme or -points")
+			}
+			dealerKey, err := ioutil.ReadFile(*dealerKeyPathPtr)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", *dealerKeyPathPtr, err)
+			}
+			shares, err = adss.ShareWithDealerKey(as, secret, ad, dealerKey)
+			if err != nil {
+				return err
+			}
+
+		case *hardenPtr:
+			if entropy != nil || *domainPtr != "" || *schemePtr != "shamir" || *pointsPtr != "" {
+				return fmt.Errorf("-harden cannot be combined with -entropy-path, -domain, -scheme or -points")
+			}
+			shares, err = adss.ShareHardened(as, secret, ad, adss.DefaultArgon2Params)
+
+		case *domainPtr != "":
+			if entropy != nil || *schemePtr != "shamir" || *pointsPtr != "" {
+				return fmt.Errorf("-domain cannot be combined with -entropy-path, -scheme or -points")
+			}
+			shares, err = adss.ShareInDomain(*domainPtr, as, secret, ad)
+
+		case *schemePtr != "shamir":
+			scheme, ok := schemeNames[*schemePtr]
+			if !ok {
+				return fmt.Errorf("unknown scheme %q, expected one of %s", *schemePtr, strings.Join(schemeNameList(), ", "))
+			}
+			if entropy != nil || *pointsPtr != "" {
+				return fmt.Errorf("-scheme cannot be combined with -entropy-path or -points")
+			}
+			shares, err = adss.ShareWithScheme(scheme, as, secret, ad)
+
+		case *pointsPtr != "":
+			if entropy != nil {
+				return fmt.Errorf("-points cannot be combined with -entropy-path")
+			}
+			var points []uint8
+			for _, pointStr := range strings.Split(*pointsPtr, ",") {
+				point, err := strconv.ParseUint(strings.TrimSpace(pointStr), 10, 8)
+				if err != nil {
+					return fmt.Errorf("invalid evaluation point: %s", pointStr)
+				}
+				points = append(points, uint8(point))
+			}
+			shares, err = adss.ShareWithPoints(points, as, secret, ad)
+
+		default:
+			shares, err = adss.ShareWithEntropy(as, secret, ad, entropy)
+		}
+		if err != nil {
+			return err
+		}
+		if *detachADPtr {
+			if len(ad) == 0 {
+				return fmt.Errorf("-detach-associated-data requires associated data")
+			}
+			shares = adss.DetachAssociatedData(shares)
+		}
+
+		names, err := shareFilenames(shares, *nameTemplatePtr, *formatPtr, holders)
 		if err != nil {
-			return fmt.Errorf("reading %s: %w", *secPathPtr, err)
+			return err
+		}
+		if *bundlePathPtr != "" {
+			if err := writeBundle(*bundlePathPtr, shares, recipients, holders); err != nil {
+				return err
+			}
+		} else if *plainBundlePathPtr != "" {
+			if err := writePlainBundle(*plainBundlePathPtr, shares, holders); err != nil {
+				return err
+			}
+		} else if err := writeShares(shares, names, *outDirPtr, *padToPtr, *formatPtr, only); err != nil {
+			return err
+		}
+
+		if *verifyPtr {
+			// Encrypted bundles can't be read back without the holders' keys,
+			// so only the shares in memory are checked.
+			var written []string
+			if *plainBundlePathPtr != "" {
+				written = []string{*plainBundlePathPtr}
+			} else if *bundlePathPtr == "" {
+				for _, share := range shares {
+					if only == nil || only[share.ID] {
+						written = append(written, fmt.Sprintf("%s/%s", *outDirPtr, names[share.ID]))
+					}
+				}
+			}
+			if err := verifySplitOutputs(shares, written, *plainBundlePathPtr != "", secret, ad, *detachADPtr); err != nil {
+				if *bundlePathPtr != "" {
+					written = []string{*bundlePathPtr}
+				}
+				shredFiles(written)
+				return errShredded(err, written)
+			}
+			if len(written) == 0 {
+				fmt.Println("Verified recovery from the shares in memory.")
+			} else {
+				fmt.Println("Verified recovery from the shares in memory and as written.")
+			}
+		}
+
+		if *qrPtr == "terminal" {
+			if err := printShareQRs(shares, only); err != nil {
+				return err
+			}
+		}
+
+		if *manifestPathPtr != "" {
+			m, err := newManifest(shares, holders, names, time.Now())
+			if err != nil {
+				return err
+			}
+			if err := writeManifest(*manifestPathPtr, m); err != nil {
+				return err
+			}
+			if *signingKeyPathPtr != "" {
+				if err := signManifest(*manifestPathPtr, *signingKeyPathPtr); err != nil {
+					return err
+				}
+			}
 		}
+
+		if *transcriptPathPtr != "" {
+			t, err := adss.NewSplitTranscript(shares, splitParticipants(*participantsPtr), startedAt)
+			if err != nil {
+				return err
+			}
+			if err := writeTranscript(*transcriptPathPtr, t); err != nil {
+				return err
+			}
+			fmt.Printf("Transcript written to: %s\n", *transcriptPathPtr)
+		}
+
+		fmt.Println("Complete.")
+		return nil
 	}
+}
 
-	as := adss.NewAccessStructure(uint8(*tPtr), uint8(*nPtr))
-	shares, err := adss.Share(as, secret, []byte(*adPtr))
-	if err != nil {
-		return err
+// parseADFields parses the label=value pairs given to -ad-fields.
+func parseADFields(s string) (map[string][]byte, error) {
+	fields := make(map[string][]byte)
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid -ad-fields entry, expected label=value: %s", pair)
+		}
+		if _, ok := fields[parts[0]]; ok {
+			return nil, fmt.Errorf("-ad-fields label repeated: %s", parts[0])
+		}
+		fields[parts[0]] = []byte(parts[1])
+	}
+	return fields, nil
+}
+
+// writeShares writes each share to outDir, in the file with its name from
+// shareFilenames, in the given format. When padTo is positive the files are
+// padded to the same size. Shares are encoded one at a
+// time so that only one encoded copy of the public payload is held in memory.
+// If only isn't nil, just the shares with those IDs are written.
+func writeShares(shares []*adss.SecretShare, names map[uint16]string, outDir string, padTo int, format string, only map[uint16]bool) error {
+	// Replicated shares differ in size, so the longest encoding sets the
+	// padded size.
+	size := 0
+	if padTo > 0 {
+		for _, share := range shares {
+			encoded, err := encodeShare(share, format)
+			if err != nil {
+				return err
+			}
+			if len(encoded) > size {
+				size = len(encoded)
+			}
+		}
+		size = padSize(size, padTo)
 	}
 
+	// If writing any share fails we shred the ones already written so that an
+	// aborted ceremony doesn't leave fragments of the sharing on disk.
+	written := make([]string, 0, len(shares))
 	for _, share := range shares {
-		jsonShare, err := json.Marshal(share)
+		if only != nil && !only[share.ID] {
+			continue
+		}
+
+		encoded, err := encodeShare(share, format)
 		if err != nil {
-			panic(err)
+			return err
+		}
+		if padTo > 0 {
+			if encoded, err = padFile(encoded, size); err != nil {
+				return err
+			}
 		}
 
-		filename := fmt.Sprintf("%s/share-%d.json", *outDirPtr, share.ID)
-		if err := ioutil.WriteFile(filename, jsonShare, 0644); err != nil {
-			return fmt.Errorf("writing %s: %w", filename, err)
+		filename := fmt.Sprintf("%s/%s", outDir, names[share.ID])
+		if err := writeFileSecure(filename, encoded); err != nil {
+			shredFiles(written)
+			return errShredded(fmt.Errorf("writing %s: %w", filename, err), written)
 		}
+		written = append(written, filename)
+	}
+
+	for _, filename := range written {
 		fmt.Printf("Share written to: %s\n", filename)
 	}
 
-	fmt.Println("Complete.")
 	return nil
 }
 
-func doRecover() error {
-	recoverCmd := flag.NewFlagSet("split", flag.ExitOnError)
+// padSize returns the smallest multiple of blockSize that fits n bytes.
+func padSize(n, blockSize int) int {
+	return ((n + blockSize - 1) / blockSize) * blockSize
+}
+
+// padFile pads the file with trailing whitespace to size bytes. Padding every
+// share file to the same size keeps the storage layer from learning which
+// sharing a file belongs to or how large the secret is. Both JSON and YAML
+// decoding ignore the trailing whitespace. It fails if the file is already
+// longer than size.
+func padFile(file []byte, size int) ([]byte, error) {
+	if len(file) > size {
+		return nil, fmt.Errorf("share of %d bytes is longer than the padded size of %d", len(file), size)
+	}
+	return append(file, bytes.Repeat([]byte(" "), size-len(file))...), nil
+}
+
+func doRecover(recoverCmd *flag.FlagSet) func() error {
 	sharePathsPtr := recoverCmd.String("share-paths", "", "Comma-separated list of share files")
+	bundlePathPtr := recoverCmd.String("bundle-path", "", "Plain bundle from split -plain-bundle-path to recover from instead of -share-paths")
 	outPathPtr := recoverCmd.String("out-path", "", "file path to create with the secret")
-	recoverCmd.Parse(os.Args[2:])
+	outputEncodingPtr := recoverCmd.String("output-encoding", "base64", "Encoding of the secret printed to stdout: base64, hex, or raw to write the bytes alone for piping into other tools")
+	paddedPtr := recoverCmd.Bool("padded", false, "Remove the padding added by split -pad-to from the secret")
+	recipientPtr := recoverCmd.String("recipient", "", "Base64 X25519 public key to encrypt the secret to, see envelope-keygen")
+	unattendedPtr := recoverCmd.Bool("unattended", false, "Non-interactive mode: read shares from -share-fds/-share-envs, write the raw secret to -out-fd and print nothing else")
+	shareFdsPtr := recoverCmd.String("share-fds", "", "Comma-separated list of file descriptors to read shares from (unattended mode)")
+	shareEnvsPtr := recoverCmd.String("share-envs", "", "Comma-separated list of environment variables holding shares (unattended mode)")
+	outFdPtr := recoverCmd.Int("out-fd", -1, "File descriptor to write the raw secret to (unattended mode)")
+	manifestPathPtr := recoverCmd.String("manifest-path", "", "Signed manifest from split; shares that aren't listed in it are refused")
+	requireAllValidPtr := recoverCmd.Bool("require-all-valid", false, "Fail if any share is invalid, even if the secret can be recovered without it")
+	recoverADPtr := recoverCmd.String("associated-data", "", "Associated data of shares split with -detach-associated-data")
+	recoverADPathPtr := recoverCmd.String("ad-path", "", "File with the associated data of shares split with -detach-associated-data")
+	majorityPayloadPtr := recoverCmd.Bool("majority-payload", false, "Repair shares whose public payload differs from the one held by a majority of the shares")
+	manifestPubPtr := recoverCmd.String("manifest-pub", "", "Base64 Ed25519 public key the manifest must be signed with, see manifest-keygen")
+	transcriptPathPtr := recoverCmd.String("transcript-path", "", "Write a transcript of the recovery, without secret material, for the participants to sign")
+	participantsPtr := recoverCmd.String("participants", "", "Comma-separated names of the ceremony participants to record in the transcript")
+	revocationListPtr := recoverCmd.String("revocation-list", "", "Revocation list from revoke; the shares it revokes are ignored")
+	revocationPubPtr := recoverCmd.String("revocation-pub", "", "Base64 Ed25519 public key the revocation list must be signed with, see manifest-keygen")
+	expectedSHA256Ptr := recoverCmd.String("expected-sha256", "", "Hex SHA-256 digest of the secret, as printed by sha256sum; recovery fails without writing the secret if it differs")
+	timeoutPtr := recoverCmd.Duration("timeout", 0, "Give up if recovery takes longer than this, such as 30s, reporting how far it got; 0 waits indefinitely")
+
+	return func() error {
+		startedAt := time.Now()
+		if *unattendedPtr {
+			if err := recoverUnattended(*shareFdsPtr, *shareEnvsPtr, *outFdPtr, *paddedPtr); err != nil {
+				// Unattended mode must never print anything other than the secret, so
+				// failure is only signalled through the exit status.
+				os.Exit(1)
+			}
+			return nil
+		}
+
+		if _, ok := secretEncodings[*outputEncodingPtr]; !ok {
+			return fmt.Errorf("unknown output encoding %q, expected base64, hex or raw", *outputEncodingPtr)
+		}
+		var expectedDigest *[32]byte
+		if *expectedSHA256Ptr != "" {
+			var err error
+			if expectedDigest, err = parseDigest(*expectedSHA256Ptr); err != nil {
+				return fmt.Errorf("-expected-sha256: %w", err)
+			}
+		}
+
+		var sharePaths []string
+		var shares []*adss.SecretShare
+		var err error
+		if *bundlePathPtr != "" {
+			if *sharePathsPtr != "" {
+				return fmt.Errorf("-bundle-path cannot be combined with -share-paths")
+			}
+			shares, sharePaths, err = readPlainBundle(*bundlePathPtr)
+		} else {
+			sharePaths = strings.Split(*sharePathsPtr, ",")
+			shares, err = readShareFiles(sharePaths)
+		}
+		if err != nil {
+			return err
+		}
+
+		if *manifestPathPtr != "" {
+			if *manifestPubPtr == "" {
+				return fmt.Errorf("-manifest-path requires -manifest-pub")
+			}
+			pub, err := decodeKey(*manifestPubPtr)
+			if err != nil {
+				return fmt.Errorf("-manifest-pub: %w", err)
+			}
+			m, err := readSignedManifest(*manifestPathPtr, pub)
+			if err != nil {
+				return err
+			}
+			holders, err := checkManifest(m, shares, sharePaths)
+			if err != nil {
+				return err
+			}
+			for i, holder := range holders {
+				if holder != "" {
+					fmt.Fprintf(os.Stderr, "Share at %s belongs to %s\n", sharePaths[i], holder)
+				}
+			}
+		}
+
+		var opts []adss.RecoverOption
+		if *requireAllValidPtr {
+			opts = append(opts, adss.WithRequireAllValid())
+		}
+		if *majorityPayloadPtr {
+			opts = append(opts, adss.WithMajorityPayload())
+		}
+		switch {
+		case *recoverADPtr != "" && *recoverADPathPtr != "":
+			return fmt.Errorf("-associated-data cannot be combined with -ad-path")
+		case *recoverADPtr != "":
+			opts = append(opts, adss.WithAssociatedData([]byte(*recoverADPtr)))
+		case *recoverADPathPtr != "":
+			ad, err := ioutil.ReadFile(*recoverADPathPtr)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", *recoverADPathPtr, err)
+			}
+			opts = append(opts, adss.WithAssociatedData(ad))
+		}
+		if *revocationListPtr != "" {
+			if *revocationPubPtr == "" {
+				return fmt.Errorf("-revocation-list requires -revocation-pub")
+			}
+			pub, err := decodeKey(*revocationPubPtr)
+			if err != nil {
+				return fmt.Errorf("-revocation-pub: %w", err)
+			}
+			l, err := readRevocationList(*revocationListPtr)
+			if err != nil {
+				return err
+			}
+			if err := l.Verify(ed25519.PublicKey(pub[:])); err != nil {
+				return fmt.Errorf("%s: %w", *revocationListPtr, err)
+			}
+
+			// Revoked shares are dropped here too so they aren't reported as
+			// unused after recovery.
+			active, activePaths := shares[:0:0], sharePaths[:0:0]
+			for i, share := range shares {
+				if l.IsRevoked(share) {
+					fmt.Fprintf(os.Stderr, "WARN: Revoked share at %s\n", sharePaths[i])
+					continue
+				}
+				active, activePaths = append(active, share), append(activePaths, sharePaths[i])
+			}
+			shares, sharePaths = active, activePaths
+			opts = append(opts, adss.WithRevocationList(l, ed25519.PublicKey(pub[:])))
+		}
+		// The digest is of the secret as it was split, so with padding it is
+		// checked once the padding is removed.
+		if expectedDigest != nil && !*paddedPtr {
+			opts = append(opts, adss.WithExpectedSHA256(*expectedDigest))
+		}
+		if *recipientPtr != "" {
+			if *paddedPtr {
+				return fmt.Errorf("-padded cannot be combined with -recipient")
+			}
+
+			recipient, err := decodeKey(*recipientPtr)
+			if err != nil {
+				return fmt.Errorf("-recipient: %w", err)
+			}
+			opts = append(opts, adss.WithEnvelope(recipient))
+		}
+
+		progress := &progressTracer{}
+		ctx, stop := recoverContext(*timeoutPtr, progress)
+		recoveryStartedAt := time.Now()
+		secret, validShares, err := adss.RecoverContext(ctx, shares, opts...)
+		stop()
+		err = stoppedError(err, progress, len(shares), time.Since(recoveryStartedAt))
+		if *transcriptPathPtr != "" {
+			// Failed recoveries are part of the audit trail too.
+			t, terr := adss.NewRecoverTranscript(shares, validShares, err, splitParticipants(*participantsPtr), startedAt)
+			if terr != nil {
+				return terr
+			}
+			if terr := writeTranscript(*transcriptPathPtr, t); terr != nil {
+				return terr
+			}
+			fmt.Fprintf(os.Stderr, "Transcript written to: %s\n", *transcriptPathPtr)
+		}
+		if err != nil {
+			return nameShareError(err, shares, describeShares(shares, sharePaths))
+		}
+		warnInvalidShares(shares, validShares, describeShares(shares, sharePaths))
+
+		if *paddedPtr {
+			secret, err = adss.UnpadSecret(secret)
+			if err != nil {
+				return err
+			}
+			if expectedDigest != nil && sha256.Sum256(secret) != *expectedDigest {
+				return adss.ErrDigestMismatch
+			}
+		}
+
+		// If a filepath is provided store the secret there, otherwise
+		// we print it to stdout in the output encoding.
+		if *outPathPtr != "" {
+			if err := writeFileSecure(*outPathPtr, secret); err != nil {
+				return fmt.Errorf("writing %s: %w", *outPathPtr, err)
+			}
+			fmt.Printf("Secret written to: %s\n", *outPathPtr)
+		} else if _, err := os.Stdout.Write(secretEncodings[*outputEncodingPtr](secret)); err != nil {
+			return fmt.Errorf("writing the secret: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// secretEncodings encode the recovered secret for stdout. The text encodings
+// end with a newline, while raw is the secret's bytes alone so it can be
+// piped into other tools unchanged.
+var secretEncodings = map[string]func([]byte) []byte{
+	"base64": func(secret []byte) []byte { return []byte(base64.StdEncoding.EncodeToString(secret) + "\n") },
+	"hex":    func(secret []byte) []byte { return []byte(hex.EncodeToString(secret) + "\n") },
+	"raw":    func(secret []byte) []byte { return secret },
+}
 
-	sharePaths := strings.Split(*sharePathsPtr, ",")
+// parseDigest parses a hex SHA-256 digest, ignoring surrounding whitespace so
+// the output of sha256sum can be pasted with its file name removed.
+func parseDigest(encoded string) (*[32]byte, error) {
+	raw, err := hex.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != sha256.Size {
+		return nil, fmt.Errorf("invalid digest length: %d, expected: %d", len(raw), sha256.Size)
+	}
+
+	var digest [32]byte
+	copy(digest[:], raw)
+	return &digest, nil
+}
+
+// schemeNames are the base schemes split accepts for -scheme other than the
+// default, shamir.
+var schemeNames = map[string]uint8{
+	"replicated": adss.SchemeReplicated,
+	"formula":    adss.SchemeFormula,
+	"shamir16":   adss.SchemeShamir16,
+}
+
+// schemeNameList returns shamir and the names of schemeNames, sorted.
+func schemeNameList() []string {
+	names := []string{"shamir"}
+	for name := range schemeNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readShareFiles reads and parses the share at each path.
+func readShareFiles(sharePaths []string) ([]*adss.SecretShare, error) {
 	shares := make([]*adss.SecretShare, len(sharePaths))
 	for i, sharePath := range sharePaths {
 		bytes, err := ioutil.ReadFile(sharePath)
 		if err != nil {
-			return fmt.Errorf("reading %s: %w", sharePath, err)
+			return nil, fmt.Errorf("reading %s: %w", sharePath, err)
 		}
 
-		var share adss.SecretShare
-		err = json.Unmarshal(bytes, &share)
+		share, err := parseShare(bytes)
 		if err != nil {
-			return fmt.Errorf("unmarshal %s: %w", sharePath, err)
+			return nil, fmt.Errorf("unmarshal %s: %w", sharePath, err)
 		}
 
-		shares[i] = &share
+		shares[i] = share
 	}
 
-	secret, validShares, err := adss.Recover(shares)
-	if err != nil {
+	return shares, nil
+}
+
+// describeShares returns where each share came from, followed by the name of
+// its holder if the shares record them.
+func describeShares(shares []*adss.SecretShare, paths []string) []string {
+	out := make([]string, len(shares))
+	for i, share := range shares {
+		out[i] = paths[i]
+		if holder := share.Holder(); holder != "" {
+			out[i] = fmt.Sprintf("%s (%s)", paths[i], holder)
+		}
+	}
+	return out
+}
+
+// nameShareError prefixes err with where the offending share came from and
+// the field that failed if it is a ShareError. names[i] describes where
+// shares[i] came from. When several shares have the ID, such as with a
+// duplicate, the last is the one that was rejected.
+func nameShareError(err error, shares []*adss.SecretShare, names []string) error {
+	var shareErr *adss.ShareError
+	if !errors.As(err, &shareErr) {
 		return err
 	}
+	for i := len(shares) - 1; i >= 0; i-- {
+		if shares[i].ID == shareErr.ID {
+			return fmt.Errorf("%s (%s): %w", names[i], shareErr.Field, err)
+		}
+	}
+	return err
+}
 
-	if len(validShares) < len(shares) {
-		for i, inShare := range shares {
-			found := false
-			for _, validShare := range validShares {
-				if inShare.Equal(validShare) {
-					found = true
-					break
-				}
+// warnInvalidShares prints a warning naming each input share that isn't in
+// validShares, with the reason it wasn't used. names[i] describes where
+// shares[i] came from.
+func warnInvalidShares(shares, validShares []*adss.SecretShare, names []string) {
+	if len(validShares) == len(shares) {
+		return
+	}
+
+	reports, err := adss.ClassifyShares(shares, validShares)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARN: Unable to classify shares: %s\n", err)
+		return
+	}
+
+	for i, report := range reports {
+		switch report.Status {
+		case adss.ShareUnused:
+			fmt.Fprintf(os.Stderr, "NOTE: Share at %s is valid but wasn't needed\n", names[i])
+		case adss.ShareInvalid:
+			fmt.Fprintf(os.Stderr, "WARN: Invalid share at %s: %s\n", names[i], report.Reason)
+		}
+	}
+}
+
+// recoverUnattended reads shares from the provided file descriptors and
+// environment variables and writes the raw secret to outFd. It is designed for
+// automated unseal and boot-time pipelines so it never writes to stdout or
+// stderr.
+func recoverUnattended(shareFds, shareEnvs string, outFd int, padded bool) error {
+	if outFd < 0 {
+		return fmt.Errorf("-out-fd is required")
+	}
+
+	shares := make([]*adss.SecretShare, 0)
+	if shareFds != "" {
+		for _, fdStr := range strings.Split(shareFds, ",") {
+			fd, err := strconv.Atoi(fdStr)
+			if err != nil {
+				return fmt.Errorf("invalid fd %s: %w", fdStr, err)
+			}
+
+			f := os.NewFile(uintptr(fd), fmt.Sprintf("fd%d", fd))
+			bytes, err := ioutil.ReadAll(f)
+			f.Close()
+			if err != nil {
+				return fmt.Errorf("reading fd %d: %w", fd, err)
+			}
+
+			share, err := parseShare(bytes)
+			if err != nil {
+				return fmt.Errorf("unmarshal fd %d: %w", fd, err)
+			}
+			shares = append(shares, share)
+		}
+	}
+
+	if shareEnvs != "" {
+		for _, name := range strings.Split(shareEnvs, ",") {
+			value, ok := os.LookupEnv(name)
+			if !ok {
+				return fmt.Errorf("environment variable %s not set", name)
 			}
 
-			if !found {
-				fmt.Fprintf(os.Stderr, "WARN: Invalid share at %s\n", sharePaths[i])
+			share, err := parseShare([]byte(value))
+			if err != nil {
+				return fmt.Errorf("unmarshal %s: %w", name, err)
 			}
+			shares = append(shares, share)
 		}
 	}
 
-	// If a filepath is provided store the secret there, otherwise
-	// we print it to stdout in base64.
-	if *outPathPtr != "" {
-		if err := ioutil.WriteFile(*outPathPtr, secret, 0644); err != nil {
-			return fmt.Errorf("writing %s: %w", *outPathPtr, err)
+	secret, _, err := adss.Recover(shares)
+	if err != nil {
+		return err
+	}
+
+	if padded {
+		secret, err = adss.UnpadSecret(secret)
+		if err != nil {
+			return err
 		}
-		fmt.Printf("Secret written to: %s\n", *outPathPtr)
-	} else {
-		fmt.Printf("%s\n", base64.StdEncoding.EncodeToString(secret))
+	}
+
+	out := os.NewFile(uintptr(outFd), fmt.Sprintf("fd%d", outFd))
+	defer out.Close()
+	if _, err := out.Write(secret); err != nil {
+		return fmt.Errorf("writing fd %d: %w", outFd, err)
 	}
 
 	return nil
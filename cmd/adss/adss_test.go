@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jakecraige/adss"
+)
+
+// runCommand runs the named command with the arguments as main would.
+func runCommand(t *testing.T, name string, args ...string) error {
+	t.Helper()
+	c, ok := findCommand(name)
+	if !ok {
+		t.Fatalf("unknown command %s", name)
+	}
+	fs := newFlagSet(c, flag.ContinueOnError)
+	run := c.setup(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return run()
+}
+
+func TestSplitReplicatedPadTo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "adss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The exclusions leave shares holding different numbers of pieces, so
+	// the replicated shares differ in size.
+	err = runCommand(t, "split", "-secret", "hello", "-threshold", "2", "-count", "4",
+		"-exclude", "2+3,1+3", "-scheme", "replicated", "-pad-to", "16", "-out-dir", dir)
+	if err != nil {
+		t.Fatalf("unexpected error on split: %s", err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "share-*"))
+	if err != nil || len(paths) != 4 {
+		t.Fatalf("expected 4 share files, got %v, %v", paths, err)
+	}
+	size := -1
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if size >= 0 && info.Size() != int64(size) {
+			t.Errorf("%s is %d bytes, expected %d like the others", path, info.Size(), size)
+		}
+		size = int(info.Size())
+	}
+
+	out := filepath.Join(dir, "secret")
+	err = runCommand(t, "recover", "-share-paths", paths[0]+","+paths[1], "-padded", "-out-path", out)
+	if err != nil {
+		t.Fatalf("unexpected error on recover: %s", err)
+	}
+	if secret, err := ioutil.ReadFile(out); err != nil || string(secret) != "hello" {
+		t.Errorf("recovered %q, %v", secret, err)
+	}
+}
+
+func Test_padFile(t *testing.T) {
+	padded, err := padFile([]byte("{}"), 4)
+	if err != nil || string(padded) != "{}  " {
+		t.Errorf("padded to %q, %v", padded, err)
+	}
+	if _, err := padFile([]byte("{}"), 1); err == nil {
+		t.Error("expected an error padding a file longer than the size")
+	}
+}
+
+func TestSplitFormulaScheme(t *testing.T) {
+	dir, err := ioutil.TempDir("", "adss")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	formula := "(0 AND 1) OR 2-of(2, 3, 4)"
+	if err := runCommand(t, "split", "-secret", "hello", "-formula", formula, "-out-dir", dir); err != nil {
+		t.Fatalf("unexpected error on split: %s", err)
+	}
+	shares, err := readShareFiles([]string{filepath.Join(dir, "share-0.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shares[0].Scheme != adss.SchemeFormula {
+		t.Errorf("-formula split with scheme %d, expected the formula scheme", shares[0].Scheme)
+	}
+
+	for _, args := range [][]string{
+		{"-scheme", "shamir"},
+		{"-scheme", "shamir16"},
+		{"-harden"},
+	} {
+		args = append([]string{"-secret", "hello", "-formula", formula, "-out-dir", dir}, args...)
+		if err := runCommand(t, "split", args...); err == nil || !strings.Contains(err.Error(), "-formula") {
+			t.Errorf("%v: expected an error splitting without enforcing the formula, got %v", args, err)
+		}
+	}
+}
+
+func TestSplitUnknownScheme(t *testing.T) {
+	err := runCommand(t, "split", "-secret", "hello", "-threshold", "2", "-count", "3", "-scheme", "shamir17")
+	if err == nil || !strings.Contains(err.Error(), "formula, replicated, shamir, shamir16") {
+		t.Errorf("expected the error to list every scheme, got %v", err)
+	}
+}
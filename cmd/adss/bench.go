@@ -0,0 +1,127 @@
+package main
+
+import (
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jakecraige/adss"
+)
+
+// bench measures how fast this machine splits and recovers secrets so
+// operators can size ceremonies and compare releases.
+func bench(benchCmd *flag.FlagSet) func() error {
+	sizesPtr := benchCmd.String("sizes", "32,1024,65536", "Comma separated secret sizes in bytes")
+	accessStructuresPtr := benchCmd.String("access-structures", "2-of-3,3-of-5,5-of-9", "Comma separated access structures, such as 2-of-3")
+	durationPtr := benchCmd.Duration("duration", time.Second, "How long to run each measurement")
+
+	return func() error {
+		var sizes []int
+		for _, sizeStr := range strings.Split(*sizesPtr, ",") {
+			size, err := strconv.Atoi(sizeStr)
+			if err != nil || size < 1 {
+				return fmt.Errorf("invalid size: %s", sizeStr)
+			}
+			sizes = append(sizes, size)
+		}
+
+		var accessStructures []adss.AccessStructure
+		for _, asStr := range strings.Split(*accessStructuresPtr, ",") {
+			as, err := parseAccessStructure(asStr)
+			if err != nil {
+				return err
+			}
+			accessStructures = append(accessStructures, as)
+		}
+
+		if *durationPtr <= 0 {
+			return fmt.Errorf("-duration must be positive")
+		}
+
+		fmt.Printf("adss %s, %s/%s, %d CPUs\n", buildVersion(), runtime.GOOS, runtime.GOARCH, runtime.NumCPU())
+		fmt.Println("Recovery uses the threshold number of shares.")
+		fmt.Println()
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "ACCESS\tSIZE\tSPLIT/S\tSPLIT MB/S\tRECOVER/S\tRECOVER MB/S\t")
+		for _, as := range accessStructures {
+			for _, size := range sizes {
+				split, recovery, err := benchCase(as, size, *durationPtr)
+				if err != nil {
+					return fmt.Errorf("%d-of-%d with %d bytes: %w", as.T, as.N, size, err)
+				}
+				fmt.Fprintf(w, "%d-of-%d\t%d\t%.1f\t%.2f\t%.1f\t%.2f\t\n",
+					as.T, as.N, size,
+					split, split*float64(size)/1e6,
+					recovery, recovery*float64(size)/1e6,
+				)
+			}
+		}
+		return w.Flush()
+	}
+}
+
+// parseAccessStructure parses a T-of-N access structure such as 2-of-3.
+func parseAccessStructure(s string) (adss.AccessStructure, error) {
+	parts := strings.Split(s, "-of-")
+	if len(parts) != 2 {
+		return adss.AccessStructure{}, fmt.Errorf("invalid access structure: %s", s)
+	}
+	t, errT := strconv.ParseUint(parts[0], 10, 16)
+	n, errN := strconv.ParseUint(parts[1], 10, 16)
+	if errT != nil || errN != nil || t < 1 || t > n {
+		return adss.AccessStructure{}, fmt.Errorf("invalid access structure: %s", s)
+	}
+	return adss.NewAccessStructure(uint16(t), uint16(n)), nil
+}
+
+// benchCase returns the number of splits and recoveries per second for a
+// random secret of the given size, running each for roughly duration.
+func benchCase(as adss.AccessStructure, size int, duration time.Duration) (float64, float64, error) {
+	M := make([]byte, size)
+	if _, err := rand.Read(M); err != nil {
+		return 0, 0, err
+	}
+
+	var shares []*adss.SecretShare
+	split, err := opsPerSecond(duration, func() error {
+		var err error
+		shares, err = adss.Share(as, M, nil)
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	recovery, err := opsPerSecond(duration, func() error {
+		_, _, err := adss.Recover(shares[:as.T])
+		return err
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return split, recovery, nil
+}
+
+// opsPerSecond runs op repeatedly for at least duration and returns how many
+// times per second it ran.
+func opsPerSecond(duration time.Duration, op func() error) (float64, error) {
+	var ops int
+	start := time.Now()
+	for {
+		if err := op(); err != nil {
+			return 0, err
+		}
+		ops++
+		if elapsed := time.Since(start); elapsed >= duration {
+			return float64(ops) / elapsed.Seconds(), nil
+		}
+	}
+}
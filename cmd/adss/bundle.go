@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/jakecraige/adss"
+)
+
+// writeBundle writes every share, each encrypted to its recipient, to a
+// single file that can be published.
+func writeBundle(path string, shares []*adss.SecretShare, recipients []*[32]byte, holders []string) error {
+	b, err := adss.SealShares(shares, recipients, holders)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	fmt.Printf("Bundle written to: %s\n", path)
+	return nil
+}
+
+// writePlainBundle writes every share, unencrypted, and the manifest to a
+// single file for transport between trusted systems.
+func writePlainBundle(path string, shares []*adss.SecretShare, holders []string) error {
+	b, err := adss.NewBundle(shares, holders)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileSecure(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	fmt.Printf("Bundle written to: %s\n", path)
+	return nil
+}
+
+// readPlainBundle reads the shares of a bundle written by writePlainBundle,
+// checking them against its manifest. Each share is named by its position in
+// the bundle, such as bundle.json[0], for messages about it.
+func readPlainBundle(path string) ([]*adss.SecretShare, []string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var b adss.Bundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal %s: %w", path, err)
+	}
+	if err := b.Verify(); err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	names := make([]string, len(b.Shares))
+	for i := range b.Shares {
+		names[i] = fmt.Sprintf("%s[%d]", path, i)
+	}
+	return b.Shares, names, nil
+}
+
+// bundleOpen is run by a holder to extract their share from a bundle.
+func bundleOpen(openCmd *flag.FlagSet) func() error {
+	keyPathPtr := openCmd.String("key-dir", ".", "Directory containing envelope.pub and envelope.key")
+	bundlePathPtr := openCmd.String("bundle-path", "", "Bundle written by split -bundle-path")
+	outDirPtr := openCmd.String("out-dir", ".", "Directory to write the share to")
+	formatPtr := openCmd.String("format", "json", "Share file format: json, yaml, bech32, digits, pem, mnemonic, binary or cbor")
+
+	return func() error {
+		if *bundlePathPtr == "" {
+			return fmt.Errorf("-bundle-path is required")
+		}
+		if _, ok := shareFormats[*formatPtr]; !ok {
+			return fmt.Errorf("unknown -format: %s", *formatPtr)
+		}
+
+		pub, err := readKeyFile(fmt.Sprintf("%s/envelope.pub", *keyPathPtr))
+		if err != nil {
+			return err
+		}
+		priv, err := readKeyFile(fmt.Sprintf("%s/envelope.key", *keyPathPtr))
+		if err != nil {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(*bundlePathPtr)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", *bundlePathPtr, err)
+		}
+		var b adss.SealedBundle
+		if err := json.Unmarshal(data, &b); err != nil {
+			return fmt.Errorf("unmarshal %s: %w", *bundlePathPtr, err)
+		}
+
+		share, err := b.Open(pub, priv)
+		if err != nil {
+			return err
+		}
+		if b.Manifest != nil {
+			if holder := b.Manifest.Holder(share.ID); holder != "" {
+				fmt.Printf("Share %d belongs to %s\n", share.ID, holder)
+			}
+		}
+		shares := []*adss.SecretShare{share}
+		names, err := shareFilenames(shares, defaultNameTemplate, *formatPtr, nil)
+		if err != nil {
+			return err
+		}
+		return writeShares(shares, names, *outDirPtr, 0, *formatPtr, nil)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// completion prints a completion script for the given shell. The scripts are
+// generated from the commands' flag definitions so they can't go stale.
+func completion(completionCmd *flag.FlagSet) func() error {
+	completionCmd.Usage = func() {
+		fmt.Fprintf(completionCmd.Output(), "Usage: adss completion bash|zsh|fish\n\nPrint a completion script for the shell.\n")
+	}
+
+	return func() error {
+		commands := allCommands()
+		flags := make(map[string][]*flag.Flag, len(commands))
+		for _, c := range commands {
+			fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+			c.setup(fs)
+			fs.VisitAll(func(f *flag.Flag) {
+				flags[c.name] = append(flags[c.name], f)
+			})
+		}
+
+		switch shell := completionCmd.Arg(0); shell {
+		case "bash":
+			fmt.Print(bashCompletion(commands, flags))
+		case "zsh":
+			fmt.Print(zshCompletion(commands, flags))
+		case "fish":
+			fmt.Print(fishCompletion(commands, flags))
+		default:
+			return fmt.Errorf("unsupported shell %q, expected bash, zsh or fish", shell)
+		}
+		return nil
+	}
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// isPathFlag reports whether the flag takes a file or directory so the shell
+// can complete paths for it.
+func isPathFlag(f *flag.Flag) bool {
+	return strings.HasSuffix(f.Name, "-path") || strings.HasSuffix(f.Name, "-paths") || strings.HasSuffix(f.Name, "-dir") || strings.HasSuffix(f.Name, "-cert")
+}
+
+func bashCompletion(commands []command, flags map[string][]*flag.Flag) string {
+	var sb strings.Builder
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.name
+	}
+
+	sb.WriteString("_adss() {\n")
+	sb.WriteString("\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	sb.WriteString("\tif [ \"$COMP_CWORD\" -eq 1 ]; then\n")
+	fmt.Fprintf(&sb, "\t\tCOMPREPLY=($(compgen -W \"help %s\" -- \"$cur\"))\n", strings.Join(names, " "))
+	sb.WriteString("\t\treturn\n")
+	sb.WriteString("\tfi\n")
+	sb.WriteString("\tcase \"$cur\" in\n")
+	sb.WriteString("\t-*) ;;\n")
+	sb.WriteString("\t*) return ;;\n")
+	sb.WriteString("\tesac\n")
+	sb.WriteString("\tcase \"${COMP_WORDS[1]}\" in\n")
+	for _, c := range commands {
+		opts := make([]string, len(flags[c.name]))
+		for i, f := range flags[c.name] {
+			opts[i] = "-" + f.Name
+		}
+		fmt.Fprintf(&sb, "\t%s) COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")) ;;\n", c.name, strings.Join(opts, " "))
+	}
+	sb.WriteString("\tesac\n")
+	sb.WriteString("}\n")
+	sb.WriteString("complete -o default -F _adss adss\n")
+	return sb.String()
+}
+
+func zshCompletion(commands []command, flags map[string][]*flag.Flag) string {
+	escape := strings.NewReplacer("'", "'\\''", "[", "\\[", "]", "\\]", ":", "\\:").Replace
+
+	var sb strings.Builder
+	sb.WriteString("#compdef adss\n\n")
+	sb.WriteString("_adss() {\n")
+	sb.WriteString("\tlocal -a commands\n")
+	sb.WriteString("\tcommands=(\n")
+	for _, c := range commands {
+		fmt.Fprintf(&sb, "\t\t'%s:%s'\n", c.name, escape(c.summary))
+	}
+	sb.WriteString("\t)\n")
+	sb.WriteString("\tif (( CURRENT == 2 )); then\n")
+	sb.WriteString("\t\t_describe 'command' commands\n")
+	sb.WriteString("\t\treturn\n")
+	sb.WriteString("\tfi\n")
+	sb.WriteString("\tcase $words[2] in\n")
+	for _, c := range commands {
+		fmt.Fprintf(&sb, "\t%s)\n\t\t_arguments", c.name)
+		for _, f := range flags[c.name] {
+			spec := fmt.Sprintf("-%s[%s]", f.Name, escape(f.Usage))
+			switch {
+			case isBoolFlag(f):
+			case isPathFlag(f):
+				spec += ":path:_files"
+			default:
+				spec += ":value:"
+			}
+			fmt.Fprintf(&sb, " \\\n\t\t\t'%s'", spec)
+		}
+		sb.WriteString("\n\t\t;;\n")
+	}
+	sb.WriteString("\tesac\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("compdef _adss adss\n")
+	return sb.String()
+}
+
+func fishCompletion(commands []command, flags map[string][]*flag.Flag) string {
+	escape := strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace
+
+	var sb strings.Builder
+	for _, c := range commands {
+		fmt.Fprintf(&sb, "complete -c adss -f -n '__fish_use_subcommand' -a %s -d '%s'\n", c.name, escape(c.summary))
+	}
+	for _, c := range commands {
+		for _, f := range flags[c.name] {
+			line := fmt.Sprintf("complete -c adss -n '__fish_seen_subcommand_from %s' -o %s -d '%s'", c.name, f.Name, escape(f.Usage))
+			switch {
+			case isBoolFlag(f):
+			case isPathFlag(f):
+				line += " -r -F"
+			default:
+				line += " -r -f"
+			}
+			sb.WriteString(line + "\n")
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jakecraige/adss"
+)
+
+// envKeyPattern restricts keys to names that are safe to use as file names.
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// envEntry is one key and value from a .env or properties file.
+type envEntry struct {
+	key   string
+	value []byte
+}
+
+// parseEnvFile parses KEY=VALUE lines from a .env file or key=value and
+// key: value lines from a properties file. Blank lines and lines starting with
+// # or ! are skipped, a leading "export " is ignored and values wrapped in
+// matching quotes are unquoted.
+func parseEnvFile(data []byte) ([]envEntry, error) {
+	var entries []envEntry
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "!") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		sep := strings.IndexAny(line, "=:")
+		if sep < 0 {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNum)
+		}
+		key := strings.TrimSpace(line[:sep])
+		value := strings.TrimSpace(line[sep+1:])
+		if !envKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("line %d: invalid key %q", lineNum, key)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("line %d: duplicate key %s", lineNum, key)
+		}
+		seen[key] = true
+
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		entries = append(entries, envEntry{key: key, value: []byte(value)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no entries found")
+	}
+
+	return entries, nil
+}
+
+// envAssociatedData binds the key name into the sharing of its value so that
+// shares for one key can't be passed off as shares for another.
+func envAssociatedData(ad, key string) []byte {
+	return []byte(ad + "\x00" + key)
+}
+
+// envManifest is the combined manifest for every sharing made by split-env.
+type envManifest struct {
+	CreatedAt time.Time            `json:"created_at"`
+	Entries   map[string]*manifest `json:"entries"`
+}
+
+// splitEnv shares each entry of a .env or properties file separately so that
+// one credential can be recovered without exposing the rest.
+func splitEnv(splitEnvCmd *flag.FlagSet) func() error {
+	envPathPtr := splitEnvCmd.String("env-path", "", "The .env or properties file to split")
+	adPtr := splitEnvCmd.String("associated-data", "", "Public data to bind with the shares, the key name is always bound too")
+	tPtr := splitEnvCmd.Uint("threshold", 0, "Threshold to reconstruct each entry")
+	nPtr := splitEnvCmd.Uint("count", 0, "Number of shares to create for each entry")
+	outDirPtr := splitEnvCmd.String("out-dir", ".", "Directory to write a holder-<id> directory of shares to for each holder")
+	formatPtr := splitEnvCmd.String("format", "json", "Share file format: json, yaml, bech32, digits, pem, mnemonic, binary or cbor")
+	manifestPathPtr := splitEnvCmd.String("manifest-path", "", "Write a combined manifest of every sharing to this file")
+
+	return func() error {
+		if *envPathPtr == "" {
+			return fmt.Errorf("-env-path is required")
+		}
+		if *tPtr == 0 {
+			return fmt.Errorf("-threshold is required")
+		}
+		if *nPtr == 0 {
+			return fmt.Errorf("-count is required")
+		}
+		if _, ok := shareFormats[*formatPtr]; !ok {
+			return fmt.Errorf("unknown -format: %s", *formatPtr)
+		}
+
+		data, err := ioutil.ReadFile(*envPathPtr)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", *envPathPtr, err)
+		}
+		entries, err := parseEnvFile(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *envPathPtr, err)
+		}
+
+		now := time.Now()
+		combined := &envManifest{CreatedAt: now.UTC(), Entries: make(map[string]*manifest, len(entries))}
+		as := adss.NewAccessStructure(uint16(*tPtr), uint16(*nPtr))
+
+		// As with split, any failure shreds everything written so far.
+		var written []string
+		fail := func(err error) error {
+			shredFiles(written)
+			return errShredded(err, written)
+		}
+		for _, entry := range entries {
+			shares, err := adss.Share(as, entry.value, envAssociatedData(*adPtr, entry.key))
+			if err != nil {
+				return fail(fmt.Errorf("%s: %w", entry.key, err))
+			}
+
+			m, err := newManifest(shares, nil, nil, now)
+			if err != nil {
+				return fail(err)
+			}
+			for _, share := range shares {
+				holderDir := fmt.Sprintf("holder-%d", share.ID)
+				if err := os.MkdirAll(filepath.Join(*outDirPtr, holderDir), 0700); err != nil {
+					return fail(err)
+				}
+
+				encoded, err := encodeShare(share, *formatPtr)
+				if err != nil {
+					return fail(err)
+				}
+				file := filepath.Join(holderDir, entry.key+"."+shareFormats[*formatPtr])
+				path := filepath.Join(*outDirPtr, file)
+				if err := writeFileSecure(path, encoded); err != nil {
+					return fail(fmt.Errorf("writing %s: %w", path, err))
+				}
+				written = append(written, path)
+				m.Files[share.ID] = file
+			}
+			combined.Entries[entry.key] = m
+		}
+
+		for i := 0; i < int(as.N); i++ {
+			fmt.Printf("Shares for holder %d written to: %s\n", i, filepath.Join(*outDirPtr, fmt.Sprintf("holder-%d", i)))
+		}
+
+		if *manifestPathPtr != "" {
+			out, err := json.MarshalIndent(combined, "", "  ")
+			if err != nil {
+				return err
+			}
+			if err := writeFileSecure(*manifestPathPtr, append(out, '\n')); err != nil {
+				return fmt.Errorf("writing %s: %w", *manifestPathPtr, err)
+			}
+			fmt.Printf("Manifest written to: %s\n", *manifestPathPtr)
+		}
+
+		fmt.Println("Complete.")
+		return nil
+	}
+}
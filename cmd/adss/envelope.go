@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/jakecraige/adss"
+)
+
+// envelopeKeygen creates an X25519 key pair that recover -recipient can
+// encrypt the secret to.
+func envelopeKeygen(keygenCmd *flag.FlagSet) func() error {
+	outDirPtr := keygenCmd.String("out-dir", ".", "Directory to write envelope.pub and envelope.key to")
+
+	return func() error {
+		pub, priv, err := adss.GenerateEnvelopeKey()
+		if err != nil {
+			return err
+		}
+
+		privPath := fmt.Sprintf("%s/envelope.key", *outDirPtr)
+		if err := writeFileSecure(privPath, []byte(base64.StdEncoding.EncodeToString(priv[:])+"\n")); err != nil {
+			return fmt.Errorf("writing %s: %w", privPath, err)
+		}
+
+		pubPath := fmt.Sprintf("%s/envelope.pub", *outDirPtr)
+		if err := ioutil.WriteFile(pubPath, []byte(base64.StdEncoding.EncodeToString(pub[:])+"\n"), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", pubPath, err)
+		}
+
+		fmt.Printf("Private key written to: %s\n", privPath)
+		fmt.Printf("Public key written to: %s\n", pubPath)
+		return nil
+	}
+}
+
+// envelopeOpen decrypts a secret that recover -recipient encrypted.
+func envelopeOpen(openCmd *flag.FlagSet) func() error {
+	keyPathPtr := openCmd.String("key-dir", ".", "Directory containing envelope.pub and envelope.key")
+	envelopePathPtr := openCmd.String("envelope-path", "", "File with the raw envelope written by recover -out-path")
+	outPathPtr := openCmd.String("out-path", "", "file path to create with the secret")
+
+	return func() error {
+		if *envelopePathPtr == "" {
+			return fmt.Errorf("-envelope-path is required")
+		}
+
+		pub, err := readKeyFile(fmt.Sprintf("%s/envelope.pub", *keyPathPtr))
+		if err != nil {
+			return err
+		}
+		priv, err := readKeyFile(fmt.Sprintf("%s/envelope.key", *keyPathPtr))
+		if err != nil {
+			return err
+		}
+
+		envelope, err := ioutil.ReadFile(*envelopePathPtr)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", *envelopePathPtr, err)
+		}
+
+		secret, err := adss.OpenEnvelope(envelope, pub, priv)
+		if err != nil {
+			return err
+		}
+
+		if *outPathPtr != "" {
+			if err := writeFileSecure(*outPathPtr, secret); err != nil {
+				return fmt.Errorf("writing %s: %w", *outPathPtr, err)
+			}
+			fmt.Printf("Secret written to: %s\n", *outPathPtr)
+		} else {
+			fmt.Printf("%s\n", base64.StdEncoding.EncodeToString(secret))
+		}
+
+		return nil
+	}
+}
+
+func readKeyFile(path string) (*[32]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	key, err := decodeKey(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return key, nil
+}
+
+// decodeKey decodes a base64 encoded 32 byte key, such as an X25519 key or an
+// Ed25519 public key or seed.
+func decodeKey(encoded string) (*[32]byte, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != 32 {
+		return nil, fmt.Errorf("invalid key length: %d, expected: 32", len(raw))
+	}
+
+	var key [32]byte
+	copy(key[:], raw)
+	return &key, nil
+}
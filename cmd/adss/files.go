@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// writeFileSecure writes data to path such that a failure never leaves a
+// partially written file behind. The data is written to a temporary file
+// created with 0600 permissions in the same directory, fsynced, and then
+// renamed into place. If anything fails the temporary file is shredded.
+func writeFileSecure(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := tmp.Name()
+	if err := writeAndSync(tmp, data); err != nil {
+		tmp.Close()
+		shredFile(tmpPath)
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		shredFile(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		shredFile(tmpPath)
+		return err
+	}
+
+	return nil
+}
+
+func writeAndSync(f *os.File, data []byte) error {
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// shredFile overwrites the contents of the file at path with zeros, syncs it
+// to disk and then removes it. It is best effort since it is used on error
+// paths: the file is always removed even if overwriting fails.
+//
+// Note that overwriting in place is not a guarantee that the data is
+// unrecoverable on journaling or copy-on-write filesystems and SSDs.
+func shredFile(path string) {
+	defer os.Remove(path)
+
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return
+	}
+
+	zeros := make([]byte, info.Size())
+	if _, err := f.WriteAt(zeros, 0); err != nil {
+		return
+	}
+	f.Sync()
+}
+
+// shredFiles shreds every file in paths, used to clean up the outputs of an
+// aborted ceremony.
+func shredFiles(paths []string) {
+	for _, path := range paths {
+		shredFile(path)
+	}
+}
+
+// errShredded wraps err to tell the operator that earlier outputs were removed.
+func errShredded(err error, paths []string) error {
+	if len(paths) == 0 {
+		return err
+	}
+	return fmt.Errorf("%w (removed %d previously written files)", err, len(paths))
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jakecraige/adss"
+	"gopkg.in/yaml.v2"
+)
+
+// shareFormats maps each supported -format to the extension of the files it
+// produces. qr is chosen with split -qr png.
+var shareFormats = map[string]string{
+	"json":     "json",
+	"yaml":     "yaml",
+	"bech32":   "txt",
+	"digits":   "txt",
+	"binary":   "bin",
+	"cbor":     "cbor",
+	"pem":      "pem",
+	"mnemonic": "txt",
+	"qr":       "png",
+}
+
+// yamlShare is the YAML representation of a share. Byte fields are base64
+// encoded, the same as in JSON, so the files are readable and easy to review.
+type yamlShare struct {
+	Threshold  uint16             `yaml:"threshold"`
+	Count      uint16             `yaml:"count"`
+	Exclusions []string           `yaml:"exclusions,omitempty"`
+	Mandatory  string             `yaml:"mandatory,omitempty"`
+	Formula    string             `yaml:"formula,omitempty"`
+	Holders    []string           `yaml:"holders,omitempty"`
+	ID         uint16             `yaml:"id"`
+	C          string             `yaml:"c"`
+	D          string             `yaml:"d"`
+	J          string             `yaml:"j"`
+	Sec        string             `yaml:"sec"`
+	Tag        string             `yaml:"tag,omitempty"`
+	Hardening  *adss.Argon2Params `yaml:"hardening,omitempty"`
+	Version    uint8              `yaml:"version,omitempty"`
+	Domain     string             `yaml:"domain,omitempty"`
+	Scheme     uint8              `yaml:"scheme,omitempty"`
+	Points     string             `yaml:"points,omitempty"`
+}
+
+// encodeShare encodes the share in the given format.
+func encodeShare(share *adss.SecretShare, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		return json.Marshal(share)
+
+	case "yaml":
+		enc := base64.StdEncoding.EncodeToString
+		return yaml.Marshal(yamlShare{
+			Threshold:  share.As.T,
+			Count:      share.As.N,
+			Exclusions: formatIDSets(share.As.Exclusions),
+			Mandatory:  share.As.Mandatory.String(),
+			Formula:    share.As.Formula.String(),
+			Holders:    share.As.Holders,
+			ID:         share.ID,
+			C:          enc(share.Pub.C),
+			D:          enc(share.Pub.D),
+			J:          enc(share.Pub.J),
+			Sec:        enc(share.Sec),
+			Tag:        enc(share.Tag),
+			Hardening:  share.Hardening,
+			Version:    share.Version,
+			Domain:     share.Domain,
+			Scheme:     share.Scheme,
+			Points:     enc(share.Points),
+		})
+
+	case "bech32":
+		return []byte(adss.EncodeShareString(share) + "\n"), nil
+
+	case "digits":
+		return []byte(adss.EncodeShareDigits(share) + "\n"), nil
+
+	case "binary":
+		return share.Bytes(), nil
+
+	case "cbor":
+		return share.MarshalCBOR()
+
+	case "pem":
+		return adss.EncodeSharePEM(share), nil
+
+	case "mnemonic":
+		return []byte(wrapWords(adss.EncodeShareMnemonic(share), mnemonicLineWords)), nil
+
+	case "qr":
+		return encodeShareQR(share)
+
+	default:
+		return nil, fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+// parseShare decodes a share in any supported format. Images are read as QR
+// codes of a share, the library recognizes its own encodings, and anything
+// else is treated as YAML.
+func parseShare(data []byte) (*adss.SecretShare, error) {
+	if content, ok, err := decodeShareImage(data); ok {
+		if err != nil {
+			return nil, err
+		}
+		data = content
+	}
+
+	if share, err := adss.DecodeShare(data); !errors.Is(err, adss.ErrUnknownShareFormat) {
+		return share, err
+	}
+
+	var ys yamlShare
+	if err := yaml.UnmarshalStrict(data, &ys); err != nil {
+		return nil, err
+	}
+
+	exclusions, err := parseIDSets(ys.Exclusions)
+	if err != nil {
+		return nil, fmt.Errorf("exclusions: %w", err)
+	}
+
+	var mandatory adss.IDSet
+	if ys.Mandatory != "" {
+		if mandatory, err = adss.ParseIDSet(ys.Mandatory); err != nil {
+			return nil, fmt.Errorf("mandatory: %w", err)
+		}
+	}
+
+	var formula *adss.Formula
+	if ys.Formula != "" {
+		if formula, err = adss.ParseFormula(ys.Formula, nil); err != nil {
+			return nil, fmt.Errorf("formula: %w", err)
+		}
+	}
+
+	share := &adss.SecretShare{
+		As:        adss.AccessStructure{T: ys.Threshold, N: ys.Count, Exclusions: exclusions, Mandatory: mandatory, Formula: formula, Holders: ys.Holders},
+		ID:        ys.ID,
+		Hardening: ys.Hardening,
+		Version:   ys.Version,
+		Domain:    ys.Domain,
+		Scheme:    ys.Scheme,
+	}
+	fields := []struct {
+		name string
+		in   string
+		out  *[]byte
+	}{
+		{"c", ys.C, &share.Pub.C},
+		{"d", ys.D, &share.Pub.D},
+		{"j", ys.J, &share.Pub.J},
+		{"sec", ys.Sec, &share.Sec},
+		{"tag", ys.Tag, &share.Tag},
+		{"points", ys.Points, &share.Points},
+	}
+	for _, field := range fields {
+		if field.in == "" {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(field.in)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", field.name, err)
+		}
+		*field.out = decoded
+	}
+
+	return share, nil
+}
+
+// mnemonicLineWords is how many words of a mnemonic are written per line, so
+// they can be checked off a line at a time.
+const mnemonicLineWords = 12
+
+// wrapWords puts n of the space separated words on each line.
+func wrapWords(s string, n int) string {
+	words := strings.Fields(s)
+	var out strings.Builder
+	for i, word := range words {
+		out.WriteString(word)
+		if i%n == n-1 || i == len(words)-1 {
+			out.WriteString("\n")
+		} else {
+			out.WriteString(" ")
+		}
+	}
+	return out.String()
+}
+
+// formatIDSets returns the sets as strings such as "0+1".
+func formatIDSets(sets []adss.IDSet) []string {
+	var out []string
+	for _, set := range sets {
+		out = append(out, set.String())
+	}
+	return out
+}
+
+// parseIDSets parses sets written by formatIDSets.
+func parseIDSets(in []string) ([]adss.IDSet, error) {
+	var sets []adss.IDSet
+	for _, s := range in {
+		set, err := adss.ParseIDSet(s)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
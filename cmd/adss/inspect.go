@@ -0,0 +1,104 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jakecraige/adss"
+)
+
+// inspect prints the non-secret details of shares, including any metadata
+// recorded when they were created.
+func inspect(inspectCmd *flag.FlagSet) func() error {
+	sharePathsPtr := inspectCmd.String("share-paths", "", "Comma-separated list of share files")
+
+	return func() error {
+		if *sharePathsPtr == "" {
+			return fmt.Errorf("-share-paths is required")
+		}
+
+		sharePaths := strings.Split(*sharePathsPtr, ",")
+		shares, err := readShareFiles(sharePaths)
+		if err != nil {
+			return err
+		}
+
+		for i, share := range shares {
+			if i > 0 {
+				fmt.Println()
+			}
+			if err := printShare(sharePaths[i], share); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func printShare(name string, share *adss.SecretShare) error {
+	ad, md, err := adss.ParseTag(share.Tag)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	fmt.Printf("Share: %s\n", name)
+	fmt.Printf("  Sharing: %s\n", share.Fingerprint())
+	fmt.Printf("  Access structure: %d-of-%d\n", share.As.T, share.As.N)
+	if len(share.As.Exclusions) > 0 {
+		fmt.Printf("  Exclusions: %s\n", strings.Join(formatIDSets(share.As.Exclusions), ", "))
+	}
+	if len(share.As.Mandatory) > 0 {
+		fmt.Printf("  Mandatory: %s\n", share.As.Mandatory)
+	}
+	if share.As.Formula != nil {
+		fmt.Printf("  Formula: %s\n", share.As.Formula)
+		fmt.Printf("  Group path: %s\n", adss.FormatGroupPath(share.GroupPath()))
+	}
+	fmt.Printf("  ID: %d\n", share.ID)
+	if holder := share.Holder(); holder != "" {
+		fmt.Printf("  Holder: %s\n", holder)
+	}
+	fmt.Printf("  Version: %d\n", share.Version)
+	if share.Domain != "" {
+		fmt.Printf("  Domain: %s\n", share.Domain)
+	}
+	switch share.Scheme {
+	case adss.SchemeReplicated:
+		fmt.Printf("  Scheme: replicated\n")
+	case adss.SchemeFormula:
+		fmt.Printf("  Scheme: formula\n")
+	case adss.SchemeShamir16:
+		fmt.Printf("  Scheme: shamir16\n")
+	}
+	if share.Points != nil {
+		fmt.Printf("  Evaluation point: %d\n", share.Point())
+	}
+	fmt.Printf("  Secret size: %d bytes\n", len(share.Pub.C))
+	if share.Hardening != nil {
+		fmt.Printf("  Hardening: Argon2id time=%d memory=%dKiB threads=%d\n", share.Hardening.Time, share.Hardening.Memory, share.Hardening.Threads)
+	}
+	if fields, err := adss.DecodeFields(ad); err == nil {
+		labels := make([]string, 0, len(fields))
+		for label := range fields {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		for _, label := range labels {
+			fmt.Printf("  Associated data %s: %q\n", label, fields[label])
+		}
+	} else if len(ad) > 0 {
+		fmt.Printf("  Associated data: %q\n", ad)
+	}
+	if md != nil {
+		if !md.CreatedAt.IsZero() {
+			fmt.Printf("  Created: %s\n", md.CreatedAt.Format(time.RFC3339))
+		}
+		if md.Note != "" {
+			fmt.Printf("  Note: %s\n", md.Note)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/jakecraige/adss"
+)
+
+// manifest is the file written by split -manifest-path. It is the library's
+// manifest of the sharing along with the name of each share file. It contains
+// no secret material so it can be kept with the ceremony records and shared
+// with auditors.
+type manifest struct {
+	adss.Manifest
+	Files map[uint16]string `json:"files"`
+}
+
+// newManifest builds the manifest for shares, which are written to the files
+// with the names from shareFilenames. holders is either empty or names the
+// holder of each share in order.
+func newManifest(shares []*adss.SecretShare, holders []string, names map[uint16]string, createdAt time.Time) (*manifest, error) {
+	m, err := adss.NewManifest(shares, holders)
+	if err != nil {
+		return nil, err
+	}
+	m.CreatedAt = createdAt.UTC()
+
+	files := make(map[uint16]string, len(shares))
+	for _, share := range shares {
+		files[share.ID] = names[share.ID]
+	}
+	return &manifest{Manifest: *m, Files: files}, nil
+}
+
+func writeManifest(path string, m *manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := writeFileSecure(path, append(data, '\n')); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	fmt.Printf("Manifest written to: %s\n", path)
+	return nil
+}
+
+// manifestSigPrefix is prepended to the manifest before signing so that a
+// manifest signature can't be confused with a signature on anything else.
+const manifestSigPrefix = "adss manifest signature\n"
+
+// manifestKeygen creates an Ed25519 key pair for signing manifests.
+func manifestKeygen(keygenCmd *flag.FlagSet) func() error {
+	outDirPtr := keygenCmd.String("out-dir", ".", "Directory to write manifest.pub and manifest.key to")
+
+	return func() error {
+		return writeSigningKeyPair(*outDirPtr, "manifest")
+	}
+}
+
+// writeSigningKeyPair creates an Ed25519 key pair and writes the seed to
+// outDir/name.key and the public key to outDir/name.pub, both base64 encoded.
+func writeSigningKeyPair(outDir, name string) error {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	privPath := fmt.Sprintf("%s/%s.key", outDir, name)
+	if err := writeFileSecure(privPath, []byte(base64.StdEncoding.EncodeToString(priv.Seed())+"\n")); err != nil {
+		return fmt.Errorf("writing %s: %w", privPath, err)
+	}
+
+	pubPath := fmt.Sprintf("%s/%s.pub", outDir, name)
+	if err := ioutil.WriteFile(pubPath, []byte(base64.StdEncoding.EncodeToString(pub)+"\n"), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", pubPath, err)
+	}
+
+	fmt.Printf("Private key written to: %s\n", privPath)
+	fmt.Printf("Public key written to: %s\n", pubPath)
+	return nil
+}
+
+// signManifest writes a detached signature of the manifest file at path to
+// path.sig.
+func signManifest(path, keyPath string) error {
+	seed, err := readKeyFile(keyPath)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	sig := ed25519.Sign(ed25519.NewKeyFromSeed(seed[:]), append([]byte(manifestSigPrefix), data...))
+	sigPath := path + ".sig"
+	if err := ioutil.WriteFile(sigPath, []byte(base64.StdEncoding.EncodeToString(sig)+"\n"), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", sigPath, err)
+	}
+	fmt.Printf("Manifest signature written to: %s\n", sigPath)
+	return nil
+}
+
+// readSignedManifest reads the manifest at path and checks its detached
+// signature at path.sig was made by the dealer's key.
+func readSignedManifest(path string, pub *[32]byte) (*manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	sigPath := path + ".sig"
+	encodedSig, err := ioutil.ReadFile(sigPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", sigPath, err)
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encodedSig)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", sigPath, err)
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(pub[:]), append([]byte(manifestSigPrefix), data...), sig) {
+		return nil, fmt.Errorf("manifest signature is invalid")
+	}
+
+	return parseManifest(path, data)
+}
+
+// readManifest reads the manifest at path without checking a signature, for
+// the dealer's own use.
+func readManifest(path string) (*manifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return parseManifest(path, data)
+}
+
+func parseManifest(path string, data []byte) (*manifest, error) {
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// checkManifest returns an error naming the first share that isn't part of
+// the sharing described by the manifest. names[i] describes where shares[i]
+// came from. It returns the holder of each share, which is empty if the
+// manifest doesn't name holders.
+func checkManifest(m *manifest, shares []*adss.SecretShare, names []string) ([]string, error) {
+	holders := make([]string, len(shares))
+	for i, share := range shares {
+		if err := m.VerifyShare(share); err != nil {
+			return nil, fmt.Errorf("share at %s: %w", names[i], err)
+		}
+		holders[i] = m.Holder(share.ID)
+	}
+	return holders, nil
+}
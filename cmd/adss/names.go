@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jakecraige/adss"
+)
+
+// defaultNameTemplate names the share files share-0.json and so on.
+const defaultNameTemplate = "share-{id}.{ext}"
+
+// holderNameTemplate replaces defaultNameTemplate when the holders are named,
+// naming the files share-alice.json and so on.
+const holderNameTemplate = "share-{holder}.{ext}"
+
+var namePlaceholder = regexp.MustCompile(`\{[^{}]*\}`)
+
+// shareFilenames expands the -name-template for each share, returning the file
+// names by share ID. The placeholders are {id}, {fingerprint}, {holder},
+// {group}, {threshold}, {count} and {ext}, the extension of the format. holders
+// is either empty or names the holder of each share in order.
+//
+// Two shares can't be given the same name, so a template without {id},
+// {holder} or {group} is refused, and names can't include a path separator so every
+// share is written to the output directory.
+func shareFilenames(shares []*adss.SecretShare, template, format string, holders []string) (map[uint16]string, error) {
+	names := make(map[uint16]string, len(shares))
+	seen := make(map[string]bool, len(shares))
+	for _, share := range shares {
+		var err error
+		name := namePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+			switch placeholder {
+			case "{id}":
+				return strconv.Itoa(int(share.ID))
+			case "{fingerprint}":
+				return share.Fingerprint()
+			case "{holder}":
+				if int(share.ID) >= len(holders) {
+					err = fmt.Errorf("{holder} in -name-template requires -holders")
+					return ""
+				}
+				return holders[share.ID]
+			case "{group}":
+				if share.As.Formula == nil {
+					err = fmt.Errorf("{group} in -name-template requires -formula")
+					return ""
+				}
+				return adss.FormatGroupPath(share.GroupPath())
+			case "{threshold}":
+				return strconv.Itoa(int(share.As.T))
+			case "{count}":
+				return strconv.Itoa(int(share.As.N))
+			case "{ext}":
+				return shareFormats[format]
+			}
+			err = fmt.Errorf("unknown placeholder %s in -name-template", placeholder)
+			return ""
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return nil, fmt.Errorf("-name-template gives share %d the invalid file name %q", share.ID, name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("-name-template gives more than one share the file name %q, include {id}", name)
+		}
+		seen[name] = true
+		names[share.ID] = name
+	}
+	return names, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/jakecraige/adss"
+)
+
+// prove is run by a holder during an audit to show they still hold their
+// share without revealing it.
+func prove(proveCmd *flag.FlagSet) func() error {
+	sharePathPtr := proveCmd.String("share-path", "", "The share to prove possession of")
+	noncePtr := proveCmd.String("nonce", "", "Nonce given by the auditor, binding the proof to this audit")
+	outPathPtr := proveCmd.String("out-path", "", "File to write the proof to, to be returned to the auditor")
+
+	return func() error {
+		if *sharePathPtr == "" || *noncePtr == "" || *outPathPtr == "" {
+			return fmt.Errorf("-share-path, -nonce and -out-path are required")
+		}
+
+		shares, err := readShareFiles([]string{*sharePathPtr})
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(shares[0].ProvePossession([]byte(*noncePtr)), "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(*outPathPtr, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", *outPathPtr, err)
+		}
+
+		fmt.Printf("Proof written to: %s\n", *outPathPtr)
+		return nil
+	}
+}
+
+// verifyProofs is run by an auditor to check holders' proofs of possession
+// against the manifest.
+func verifyProofs(verifyCmd *flag.FlagSet) func() error {
+	manifestPathPtr := verifyCmd.String("manifest-path", "", "Manifest written by split -manifest-path")
+	proofPathsPtr := verifyCmd.String("proof-paths", "", "Comma-separated paths to the proofs returned by the holders")
+	noncePtr := verifyCmd.String("nonce", "", "Nonce given to the holders for this audit")
+
+	return func() error {
+		if *manifestPathPtr == "" || *proofPathsPtr == "" || *noncePtr == "" {
+			return fmt.Errorf("-manifest-path, -proof-paths and -nonce are required")
+		}
+
+		m, err := readManifest(*manifestPathPtr)
+		if err != nil {
+			return err
+		}
+
+		for _, path := range strings.Split(*proofPathsPtr, ",") {
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", path, err)
+			}
+			var proof adss.PossessionProof
+			if err := json.Unmarshal(data, &proof); err != nil {
+				return fmt.Errorf("unmarshal %s: %w", path, err)
+			}
+			if err := m.VerifyPossession(&proof, []byte(*noncePtr)); err != nil {
+				return fmt.Errorf("proof at %s: %w", path, err)
+			}
+
+			if holder := m.Holder(proof.ShareID); holder != "" {
+				fmt.Printf("Share %d is held by %s\n", proof.ShareID, holder)
+			} else {
+				fmt.Printf("Share %d is held\n", proof.ShareID)
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"time"
+
+	"github.com/jakecraige/adss"
+)
+
+// progressTracer counts the candidate subsets recovery enumerates and tries,
+// so a recovery that is stopped can report how far it got.
+type progressTracer struct {
+	candidates int64
+	tried      int64
+}
+
+func (p *progressTracer) Start(ctx context.Context, name string) (context.Context, adss.Span) {
+	switch name {
+	case adss.SpanPlausibleShareSets:
+		return ctx, candidatesSpan{p}
+	case adss.SpanRecoverCandidate:
+		atomic.AddInt64(&p.tried, 1)
+	}
+	return ctx, candidatesSpan{}
+}
+
+// candidatesSpan records the number of candidates into its tracer, if any.
+type candidatesSpan struct{ p *progressTracer }
+
+func (s candidatesSpan) SetAttribute(key string, value int64) {
+	if s.p != nil && key == "adss.candidates" {
+		atomic.StoreInt64(&s.p.candidates, value)
+	}
+}
+
+func (s candidatesSpan) End() {}
+
+// recoverContext returns a context for recovery that is cancelled after
+// timeout, if it isn't 0, or when the operator interrupts, so the CLI can
+// report on the recovery rather than being killed mid-search. The returned
+// function must be called once recovery is done.
+func recoverContext(timeout time.Duration, progress *progressTracer) (context.Context, func()) {
+	ctx := adss.WithTracer(context.Background(), progress)
+	var cancel context.CancelFunc
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	go func() {
+		select {
+		case <-interrupts:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(interrupts)
+		cancel()
+	}
+}
+
+// stoppedError describes a recovery that was stopped by its timeout or an
+// interrupt, with how far the search got, or returns err unchanged.
+func stoppedError(err error, progress *progressTracer, shares int, elapsed time.Duration) error {
+	var reason string
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		reason = "timed out"
+	case errors.Is(err, context.Canceled):
+		reason = "interrupted"
+	default:
+		return err
+	}
+	return fmt.Errorf("recovery %s after %s, having tried %d of %d candidate subsets of the %d shares",
+		reason, elapsed.Round(time.Millisecond), atomic.LoadInt64(&progress.tried), atomic.LoadInt64(&progress.candidates), shares)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"strings"
+
+	// Registered so scans saved as JPEG or GIF can be read.
+	_ "image/gif"
+	_ "image/jpeg"
+
+	"github.com/jakecraige/adss"
+	"github.com/jakecraige/adss/internal/qr"
+)
+
+// qrScale is the size in pixels of each module of the QR codes split writes,
+// large enough to print and still scan.
+const qrScale = 8
+
+// shareQR returns a QR code of the share string. The string is uppercased,
+// which share strings allow, so it fits the code's denser alphanumeric mode.
+func shareQR(share *adss.SecretShare) (*qr.Code, error) {
+	code, err := qr.Encode([]byte(strings.ToUpper(adss.EncodeShareString(share))), qr.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("share %d is too large for a QR code, use another -format", share.ID)
+	}
+	return code, nil
+}
+
+// encodeShareQR returns a PNG image of a QR code of the share.
+func encodeShareQR(share *adss.SecretShare) ([]byte, error) {
+	code, err := shareQR(share)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := png.Encode(&out, code.Image(qrScale)); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// printShareQRs prints a QR code of each share, or just those with IDs in
+// only if it isn't nil, for holders to scan from the screen.
+func printShareQRs(shares []*adss.SecretShare, only map[uint16]bool) error {
+	for _, share := range shares {
+		if only != nil && !only[share.ID] {
+			continue
+		}
+		code, err := shareQR(share)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Share %d:\n%s", share.ID, code.Terminal())
+	}
+	return nil
+}
+
+// decodeShareImage returns the contents of the QR code in data if it is an
+// image, and ok false if it isn't one.
+func decodeShareImage(data []byte) (content []byte, ok bool, err error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err == image.ErrFormat {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	content, err = qr.Decode(img)
+	if err != nil {
+		return nil, true, fmt.Errorf("reading the QR code: %w", err)
+	}
+	return content, true, nil
+}
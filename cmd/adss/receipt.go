@@ -0,0 +1,120 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/jakecraige/adss"
+)
+
+// receiptKeygen creates an Ed25519 key pair a holder signs receipts with.
+func receiptKeygen(keygenCmd *flag.FlagSet) func() error {
+	outDirPtr := keygenCmd.String("out-dir", ".", "Directory to write receipt.pub and receipt.key to")
+
+	return func() error {
+		return writeSigningKeyPair(*outDirPtr, "receipt")
+	}
+}
+
+// receipt is run by a holder to acknowledge they received their share.
+func receipt(receiptCmd *flag.FlagSet) func() error {
+	sharePathPtr := receiptCmd.String("share-path", "", "The share that was received")
+	keyPathPtr := receiptCmd.String("key-path", "receipt.key", "Key from receipt-keygen to sign the receipt with")
+	holderPtr := receiptCmd.String("holder", "", "Name of the holder, as recorded in the manifest")
+	outPathPtr := receiptCmd.String("out-path", "", "File to write the receipt to, to be returned to the dealer")
+
+	return func() error {
+		if *sharePathPtr == "" || *outPathPtr == "" {
+			return fmt.Errorf("-share-path and -out-path are required")
+		}
+
+		shares, err := readShareFiles([]string{*sharePathPtr})
+		if err != nil {
+			return err
+		}
+		seed, err := readKeyFile(*keyPathPtr)
+		if err != nil {
+			return err
+		}
+
+		r, err := adss.NewReceipt(shares[0], *holderPtr, ed25519.NewKeyFromSeed(seed[:]))
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(*outPathPtr, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", *outPathPtr, err)
+		}
+
+		fmt.Printf("Receipt written to: %s\n", *outPathPtr)
+		return nil
+	}
+}
+
+// verifyReceipts is run by the dealer to check every share in the manifest
+// was acknowledged by its holder.
+func verifyReceipts(verifyCmd *flag.FlagSet) func() error {
+	manifestPathPtr := verifyCmd.String("manifest-path", "", "Manifest written by split -manifest-path")
+	receiptPathsPtr := verifyCmd.String("receipt-paths", "", "Comma-separated paths to the receipts returned by the holders")
+	holderKeysPtr := verifyCmd.String("holder-keys", "", "Comma-separated name=key pairs of each holder's base64 receipt.pub; receipts must be signed by them")
+
+	return func() error {
+		if *manifestPathPtr == "" || *receiptPathsPtr == "" {
+			return fmt.Errorf("-manifest-path and -receipt-paths are required")
+		}
+
+		m, err := readManifest(*manifestPathPtr)
+		if err != nil {
+			return err
+		}
+
+		var keys map[string]ed25519.PublicKey
+		if *holderKeysPtr != "" {
+			keys = make(map[string]ed25519.PublicKey)
+			for _, pair := range strings.Split(*holderKeysPtr, ",") {
+				name, encoded := splitPair(pair)
+				key, err := decodeKey(encoded)
+				if err != nil {
+					return fmt.Errorf("-holder-keys %s: %w", name, err)
+				}
+				keys[name] = ed25519.PublicKey(key[:])
+			}
+		}
+
+		var receipts []*adss.Receipt
+		for _, path := range strings.Split(*receiptPathsPtr, ",") {
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("reading %s: %w", path, err)
+			}
+			var r adss.Receipt
+			if err := json.Unmarshal(data, &r); err != nil {
+				return fmt.Errorf("unmarshal %s: %w", path, err)
+			}
+			if err := m.VerifyReceipt(&r, keys); err != nil {
+				return fmt.Errorf("receipt at %s: %w", path, err)
+			}
+			receipts = append(receipts, &r)
+		}
+
+		if err := m.VerifyReceipts(receipts, keys); err != nil {
+			return err
+		}
+		fmt.Printf("All %d shares acknowledged.\n", len(m.Shares))
+		return nil
+	}
+}
+
+func splitPair(pair string) (name, value string) {
+	if i := strings.Index(pair, "="); i >= 0 {
+		return pair[:i], pair[i+1:]
+	}
+	return pair, ""
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// redact writes the public part of each share to its own file. The files
+// contain no secret material so they can be attached to tickets and
+// inventories.
+func redact(redactCmd *flag.FlagSet) func() error {
+	sharePathsPtr := redactCmd.String("share-paths", "", "Comma-separated list of share files")
+	outDirPtr := redactCmd.String("out-dir", "", "Directory to write the public share files to")
+
+	return func() error {
+		if *sharePathsPtr == "" || *outDirPtr == "" {
+			return fmt.Errorf("-share-paths and -out-dir are required")
+		}
+
+		sharePaths := strings.Split(*sharePathsPtr, ",")
+		shares, err := readShareFiles(sharePaths)
+		if err != nil {
+			return err
+		}
+
+		for i, share := range shares {
+			data, err := json.MarshalIndent(share.Public(), "", "  ")
+			if err != nil {
+				return err
+			}
+
+			base := filepath.Base(sharePaths[i])
+			path := filepath.Join(*outDirPtr, strings.TrimSuffix(base, filepath.Ext(base))+".public.json")
+			if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+				return fmt.Errorf("writing %s: %w", path, err)
+			}
+			fmt.Printf("Public share written to: %s\n", path)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/jakecraige/adss"
+)
+
+// revoke is run by the dealer to sign a list of shares of a sharing that
+// recovery must no longer accept.
+func revoke(revokeCmd *flag.FlagSet) func() error {
+	sharePathPtr := revokeCmd.String("share-path", "", "Any share, public or not, of the sharing to revoke shares of")
+	idsPtr := revokeCmd.String("ids", "", "Comma-separated IDs of the shares to revoke")
+	keyPathPtr := revokeCmd.String("key-path", "manifest.key", "Key from manifest-keygen to sign the list with")
+	outPathPtr := revokeCmd.String("out-path", "", "File to write the revocation list to")
+
+	return func() error {
+		if *sharePathPtr == "" || *idsPtr == "" || *outPathPtr == "" {
+			return fmt.Errorf("-share-path, -ids and -out-path are required")
+		}
+
+		shares, err := readShareFiles([]string{*sharePathPtr})
+		if err != nil {
+			return err
+		}
+		var ids []uint16
+		for _, idStr := range strings.Split(*idsPtr, ",") {
+			id, err := strconv.ParseUint(idStr, 10, 16)
+			if err != nil || id >= uint64(shares[0].As.N) {
+				return fmt.Errorf("invalid share ID: %s", idStr)
+			}
+			ids = append(ids, uint16(id))
+		}
+		seed, err := readKeyFile(*keyPathPtr)
+		if err != nil {
+			return err
+		}
+
+		l, err := adss.NewRevocationList(shares[0].Fingerprint(), ids, ed25519.NewKeyFromSeed(seed[:]))
+		if err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(l, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(*outPathPtr, append(data, '\n'), 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", *outPathPtr, err)
+		}
+
+		fmt.Printf("Revocation list written to: %s\n", *outPathPtr)
+		return nil
+	}
+}
+
+// readRevocationList reads a list written by revoke.
+func readRevocationList(path string) (*adss.RevocationList, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var l adss.RevocationList
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", path, err)
+	}
+	return &l, nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+
+	"github.com/jakecraige/adss"
+	"github.com/jakecraige/adss/adsstest"
+)
+
+// selfTestCheck is one check run by selftest. It returns an error describing
+// how the build misbehaved.
+type selfTestCheck struct {
+	name string
+	run  func() error
+}
+
+var selfTestChecks = []selfTestCheck{
+	{"known-answer vectors", checkVectors},
+	{"split and recover round trips", checkRoundTrips},
+	{"threshold enforcement", checkThresholds},
+	{"corrupted share tolerance", checkCorruption},
+	{"ambiguity detection", checkAmbiguity},
+	{"hardened sharing", checkHardened},
+}
+
+// selftest exercises the scheme on the local build so operators can validate
+// a binary before trusting it in a ceremony.
+func selftest(selftestCmd *flag.FlagSet) func() error {
+	return func() error {
+		failed := 0
+		for _, check := range selfTestChecks {
+			if err := check.run(); err != nil {
+				fmt.Printf("FAIL  %s: %s\n", check.name, err)
+				failed++
+				continue
+			}
+			fmt.Printf("PASS  %s\n", check.name)
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("self-test failed: %d of %d checks failed", failed, len(selfTestChecks))
+		}
+		fmt.Println("Self-test passed.")
+		return nil
+	}
+}
+
+// expectRecovers returns an error unless the shares recover M.
+func expectRecovers(shares []*adss.SecretShare, M []byte) ([]*adss.SecretShare, error) {
+	recov, V, err := adss.Recover(shares)
+	if err != nil {
+		return nil, fmt.Errorf("recovering from %d shares: %w", len(shares), err)
+	}
+	if !bytes.Equal(recov, M) {
+		return nil, fmt.Errorf("recovered the wrong secret from %d shares", len(shares))
+	}
+	return V, nil
+}
+
+func checkVectors() error {
+	for _, v := range adsstest.Vectors {
+		_, M, _, _ := v.Inputs()
+		if _, err := expectRecovers(v.SecretShares(), M); err != nil {
+			return fmt.Errorf("vector %s: %w", v.Name, err)
+		}
+	}
+	return nil
+}
+
+func checkRoundTrips() error {
+	for _, as := range []adss.AccessStructure{
+		adss.NewAccessStructure(1, 1),
+		adss.NewAccessStructure(2, 3),
+		adss.NewAccessStructure(3, 5),
+		adss.NewAccessStructure(5, 5),
+	} {
+		for _, M := range [][]byte{{0}, []byte("some secret"), bytes.Repeat([]byte{0xa5}, 4096)} {
+			shares, err := adss.Share(as, M, []byte("associated data"))
+			if err != nil {
+				return fmt.Errorf("%d-of-%d: sharing: %w", as.T, as.N, err)
+			}
+			if _, err := expectRecovers(shares, M); err != nil {
+				return fmt.Errorf("%d-of-%d: %w", as.T, as.N, err)
+			}
+		}
+	}
+	return nil
+}
+
+func checkThresholds() error {
+	M := []byte("some secret")
+	as := adss.NewAccessStructure(3, 5)
+	shares, err := adss.Share(as, M, nil)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i+3 <= len(shares); i++ {
+		if _, err := expectRecovers(shares[i:i+3], M); err != nil {
+			return err
+		}
+	}
+	if _, _, err := adss.Recover(shares[:2]); err == nil {
+		return fmt.Errorf("recovered a 3-of-5 sharing from 2 shares")
+	}
+	return nil
+}
+
+func checkCorruption() error {
+	M := []byte("some secret")
+	shares, err := adss.Share(adss.NewAccessStructure(2, 3), M, []byte("associated data"))
+	if err != nil {
+		return err
+	}
+
+	corruptions := map[string]func(*adss.SecretShare){
+		"secret":     func(s *adss.SecretShare) { s.Sec = flipped(s.Sec) },
+		"ciphertext": func(s *adss.SecretShare) { s.Pub.C = flipped(s.Pub.C) },
+		"commitment": func(s *adss.SecretShare) { s.Pub.J = flipped(s.Pub.J) },
+		"coins":      func(s *adss.SecretShare) { s.Pub.D = flipped(s.Pub.D) },
+	}
+	for field, corrupt := range corruptions {
+		bad := *shares[0]
+		corrupt(&bad)
+
+		V, err := expectRecovers([]*adss.SecretShare{&bad, shares[1], shares[2]}, M)
+		if err != nil {
+			return fmt.Errorf("corrupted %s: %w", field, err)
+		}
+		for _, share := range V {
+			if share == &bad {
+				return fmt.Errorf("corrupted %s: share reported as valid", field)
+			}
+		}
+	}
+	return nil
+}
+
+// flipped returns a copy of data with the low bit of its first byte flipped.
+func flipped(data []byte) []byte {
+	out := append([]byte{}, data...)
+	out[0] ^= 1
+	return out
+}
+
+func checkAmbiguity() error {
+	as := adss.NewAccessStructure(2, 4)
+	first, err := adss.Share(as, []byte("first secret"), nil)
+	if err != nil {
+		return err
+	}
+	second, err := adss.Share(as, []byte("second secret"), nil)
+	if err != nil {
+		return err
+	}
+
+	// Each pair explains a different secret, so recovery must refuse to pick
+	// one.
+	mixed := []*adss.SecretShare{first[0], first[1], second[2], second[3]}
+	if recov, _, err := adss.Recover(mixed); err == nil {
+		return fmt.Errorf("recovered %q from two sharings instead of failing", recov)
+	}
+	return nil
+}
+
+func checkHardened() error {
+	M := []byte("correct horse battery staple")
+	params := adss.Argon2Params{Time: 1, Memory: 8 * 1024, Threads: 1}
+	shares, err := adss.ShareHardened(adss.NewAccessStructure(2, 3), M, nil, params)
+	if err != nil {
+		return err
+	}
+	_, err = expectRecovers(shares[1:], M)
+	return err
+}
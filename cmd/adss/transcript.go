@@ -0,0 +1,108 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/jakecraige/adss"
+)
+
+// splitParticipants parses the -participants flag.
+func splitParticipants(participants string) []string {
+	if participants == "" {
+		return nil
+	}
+	return strings.Split(participants, ",")
+}
+
+func writeTranscript(path string, t *adss.Transcript) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+func readTranscript(path string) (*adss.Transcript, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	var t adss.Transcript
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", path, err)
+	}
+	return &t, nil
+}
+
+// transcriptSign is run by each participant to sign the transcript of a
+// ceremony they took part in.
+func transcriptSign(signCmd *flag.FlagSet) func() error {
+	transcriptPathPtr := signCmd.String("transcript-path", "", "Transcript written by split or recover -transcript-path")
+	keyPathPtr := signCmd.String("key-path", "receipt.key", "Key from receipt-keygen to sign with")
+	signerPtr := signCmd.String("signer", "", "Name of the participant signing, as listed in the transcript")
+
+	return func() error {
+		if *transcriptPathPtr == "" || *signerPtr == "" {
+			return fmt.Errorf("-transcript-path and -signer are required")
+		}
+
+		t, err := readTranscript(*transcriptPathPtr)
+		if err != nil {
+			return err
+		}
+		seed, err := readKeyFile(*keyPathPtr)
+		if err != nil {
+			return err
+		}
+		if err := t.Sign(*signerPtr, ed25519.NewKeyFromSeed(seed[:])); err != nil {
+			return err
+		}
+		if err := writeTranscript(*transcriptPathPtr, t); err != nil {
+			return err
+		}
+
+		fmt.Printf("Transcript signed by %s\n", *signerPtr)
+		return nil
+	}
+}
+
+// transcriptVerify checks every participant signed the transcript.
+func transcriptVerify(verifyCmd *flag.FlagSet) func() error {
+	transcriptPathPtr := verifyCmd.String("transcript-path", "", "Transcript written by split or recover -transcript-path")
+	signerKeysPtr := verifyCmd.String("signer-keys", "", "Comma-separated name=key pairs of each participant's base64 receipt.pub")
+
+	return func() error {
+		if *transcriptPathPtr == "" || *signerKeysPtr == "" {
+			return fmt.Errorf("-transcript-path and -signer-keys are required")
+		}
+
+		t, err := readTranscript(*transcriptPathPtr)
+		if err != nil {
+			return err
+		}
+
+		keys := make(map[string]ed25519.PublicKey)
+		for _, pair := range strings.Split(*signerKeysPtr, ",") {
+			name, encoded := splitPair(pair)
+			key, err := decodeKey(encoded)
+			if err != nil {
+				return fmt.Errorf("-signer-keys %s: %w", name, err)
+			}
+			keys[name] = ed25519.PublicKey(key[:])
+		}
+
+		if err := t.Verify(keys); err != nil {
+			return err
+		}
+		fmt.Printf("Transcript of %s is signed by all %d participants.\n", t.Operation, len(t.Participants))
+		return nil
+	}
+}
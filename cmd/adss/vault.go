@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jakecraige/adss"
+)
+
+// vaultClient is a minimal client for the parts of the HashiCorp Vault HTTP
+// API used by key ceremonies.
+type vaultClient struct {
+	addr   string
+	client *http.Client
+}
+
+func newVaultClient(addr, caCertPath string) (*vaultClient, error) {
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	if addr == "" {
+		return nil, fmt.Errorf("-vault-addr or VAULT_ADDR is required")
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if caCertPath != "" {
+		pem, err := ioutil.ReadFile(caCertPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", caCertPath, err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", caCertPath)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	return &vaultClient{
+		addr:   strings.TrimRight(addr, "/"),
+		client: &http.Client{Transport: transport, Timeout: 30 * time.Second},
+	}, nil
+}
+
+// put sends body as JSON to the Vault API path and decodes the JSON response
+// into out.
+func (vc *vaultClient) put(path string, body, out interface{}) error {
+	reqBody, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, vc.addr+path, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := vc.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(respBody, &vaultErr) == nil && len(vaultErr.Errors) > 0 {
+			return fmt.Errorf("vault %s: %s", path, strings.Join(vaultErr.Errors, "; "))
+		}
+		return fmt.Errorf("vault %s: unexpected status %s", path, resp.Status)
+	}
+
+	return json.Unmarshal(respBody, out)
+}
+
+// vaultInit initializes a Vault server with a single unseal key and splits
+// that key with adss, so the ADSS sharing takes the place of Vault's own
+// Shamir sharing.
+func vaultInit(initCmd *flag.FlagSet) func() error {
+	addrPtr := initCmd.String("vault-addr", "", "Vault address, defaults to $VAULT_ADDR")
+	caCertPtr := initCmd.String("ca-cert", "", "PEM file with the CA certificate to verify Vault with")
+	adPtr := initCmd.String("associated-data", "", "Public data to bind with the shares")
+	tPtr := initCmd.Uint("threshold", 0, "Threshold to reconstruct the unseal key")
+	nPtr := initCmd.Uint("count", 0, "Number of shares to create")
+	outDirPtr := initCmd.String("out-dir", ".", "Directory to write the shares to")
+	recoveryPtr := initCmd.Bool("recovery-keys", false, "Split the recovery key of an auto-unsealed Vault instead of the unseal key")
+	rootTokenPathPtr := initCmd.String("root-token-path", "", "File to write the initial root token to, which is otherwise discarded")
+
+	return func() error {
+		if *tPtr == 0 {
+			return fmt.Errorf("-threshold is required")
+		}
+		if *nPtr == 0 {
+			return fmt.Errorf("-count is required")
+		}
+		if *tPtr > 0xffff || *nPtr > 0xffff {
+			return fmt.Errorf("-threshold and -count must be at most 65535")
+		}
+
+		// Vault can only be initialized once and its key is only returned
+		// then, so check everything that could stop the shares being written
+		// before asking for it.
+		as := adss.NewAccessStructure(uint16(*tPtr), uint16(*nPtr))
+		if err := as.Validate(); err != nil {
+			return err
+		}
+		if info, err := os.Stat(*outDirPtr); err != nil {
+			return err
+		} else if !info.IsDir() {
+			return fmt.Errorf("-out-dir %s is not a directory", *outDirPtr)
+		}
+		if *rootTokenPathPtr != "" {
+			if _, err := os.Stat(*rootTokenPathPtr); err == nil {
+				return fmt.Errorf("%s already exists", *rootTokenPathPtr)
+			}
+		}
+
+		vc, err := newVaultClient(*addrPtr, *caCertPtr)
+		if err != nil {
+			return err
+		}
+
+		body := map[string]int{"secret_shares": 1, "secret_threshold": 1}
+		if *recoveryPtr {
+			body = map[string]int{"recovery_shares": 1, "recovery_threshold": 1}
+		}
+
+		var resp struct {
+			KeysB64         []string `json:"keys_base64"`
+			RecoveryKeysB64 []string `json:"recovery_keys_base64"`
+			RootToken       string   `json:"root_token"`
+		}
+		if err := vc.put("/v1/sys/init", body, &resp); err != nil {
+			return err
+		}
+
+		keys := resp.KeysB64
+		if *recoveryPtr {
+			keys = resp.RecoveryKeysB64
+		}
+		if len(keys) != 1 {
+			return fmt.Errorf("vault returned %d keys, expected 1", len(keys))
+		}
+
+		if err := splitVaultKey(as, keys[0], []byte(*adPtr), *outDirPtr); err != nil {
+			return fmt.Errorf("vault is initialized but its key wasn't saved, so it must be re-initialized from empty storage: %w", err)
+		}
+
+		if *rootTokenPathPtr != "" {
+			if err := writeFileSecure(*rootTokenPathPtr, []byte(resp.RootToken+"\n")); err != nil {
+				return fmt.Errorf("writing root token: %w", err)
+			}
+			fmt.Printf("Root token written to: %s\n", *rootTokenPathPtr)
+		} else {
+			fmt.Println("The initial root token was discarded. Generate one with the unseal key when needed.")
+		}
+		fmt.Println("Complete.")
+		return nil
+	}
+}
+
+// splitVaultKey splits the base64 key Vault returned from initialization and
+// writes the shares to outDir.
+func splitVaultKey(as adss.AccessStructure, keyB64 string, ad []byte, outDir string) error {
+	key, err := base64.StdEncoding.DecodeString(keyB64)
+	if err != nil {
+		return fmt.Errorf("decoding key: %w", err)
+	}
+
+	shares, err := adss.Share(as, key, ad)
+	if err != nil {
+		return err
+	}
+
+	names, err := shareFilenames(shares, defaultNameTemplate, "json", nil)
+	if err != nil {
+		return err
+	}
+	return writeShares(shares, names, outDir, 0, "json", nil)
+}
+
+// vaultUnseal recovers a Vault unseal key from shares and submits it to the
+// Vault unseal API.
+func vaultUnseal(unsealCmd *flag.FlagSet) func() error {
+	addrPtr := unsealCmd.String("vault-addr", "", "Vault address, defaults to $VAULT_ADDR")
+	caCertPtr := unsealCmd.String("ca-cert", "", "PEM file with the CA certificate to verify Vault with")
+	sharePathsPtr := unsealCmd.String("share-paths", "", "Comma-separated list of share files")
+
+	return func() error {
+		vc, err := newVaultClient(*addrPtr, *caCertPtr)
+		if err != nil {
+			return err
+		}
+
+		sharePaths := strings.Split(*sharePathsPtr, ",")
+		shares, err := readShareFiles(sharePaths)
+		if err != nil {
+			return err
+		}
+
+		key, validShares, err := adss.Recover(shares)
+		if err != nil {
+			return err
+		}
+		warnInvalidShares(shares, validShares, sharePaths)
+
+		var resp struct {
+			Sealed   bool `json:"sealed"`
+			T        int  `json:"t"`
+			Progress int  `json:"progress"`
+		}
+		body := map[string]string{"key": base64.StdEncoding.EncodeToString(key)}
+		if err := vc.put("/v1/sys/unseal", body, &resp); err != nil {
+			return err
+		}
+
+		if resp.Sealed {
+			return fmt.Errorf("vault is still sealed, progress %d/%d", resp.Progress, resp.T)
+		}
+
+		fmt.Println("Vault unsealed.")
+		return nil
+	}
+}
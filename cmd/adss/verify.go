@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/jakecraige/adss"
+)
+
+// verifyAttempts is how many random subsets are tried when looking for one
+// that the access structure supports.
+const verifyAttempts = 16
+
+// verifySplit test-recovers secret from random threshold-sized subsets of the
+// shares, one containing each share, so every share is known to take part in
+// a successful recovery. Subsets always include the mandatory shares and never
+// complete an exclusion. ad is supplied for shares whose associated data was
+// detached.
+func verifySplit(shares []*adss.SecretShare, secret, ad []byte, detached bool) error {
+	var opts []adss.RecoverOption
+	if detached {
+		opts = append(opts, adss.WithAssociatedData(ad))
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for _, share := range shares {
+		var subset []*adss.SecretShare
+		for i := 0; i < verifyAttempts && subset == nil; i++ {
+			subset = recoverableSubset(shares, share, rng)
+		}
+		if subset == nil {
+			return fmt.Errorf("no recoverable subset of the shares includes share %d", share.ID)
+		}
+		recov, _, err := adss.Recover(subset, opts...)
+		if err != nil {
+			return fmt.Errorf("recovering from shares %v: %w", shareIDs(subset), err)
+		}
+		if !bytes.Equal(recov, secret) {
+			return fmt.Errorf("recovering from shares %v gave a different secret", shareIDs(subset))
+		}
+	}
+	return nil
+}
+
+// readBackShares returns the shares with those written to paths replaced by
+// the share read back from the file.
+func readBackShares(shares []*adss.SecretShare, paths []string) ([]*adss.SecretShare, error) {
+	written, err := readShareFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint16]*adss.SecretShare, len(written))
+	for _, share := range written {
+		byID[share.ID] = share
+	}
+
+	out := make([]*adss.SecretShare, len(shares))
+	for i, share := range shares {
+		out[i] = share
+		if readBack, ok := byID[share.ID]; ok {
+			out[i] = readBack
+		}
+	}
+	return out, nil
+}
+
+// recoverableSubset returns share with the mandatory shares and randomly
+// chosen others, up to the threshold or until they satisfy the formula, that
+// the access structure supports, or nil if there are none.
+func recoverableSubset(shares []*adss.SecretShare, share *adss.SecretShare, rng *rand.Rand) []*adss.SecretShare {
+	as := share.As
+	byID := make(map[uint16]*adss.SecretShare, len(shares))
+	for _, s := range shares {
+		byID[s.ID] = s
+	}
+
+	present := make(map[uint16]bool, as.T)
+	subset := make([]*adss.SecretShare, 0, as.T)
+	add := func(s *adss.SecretShare) {
+		if !present[s.ID] {
+			present[s.ID] = true
+			subset = append(subset, s)
+		}
+	}
+	add(share)
+	for _, id := range as.Mandatory {
+		s, ok := byID[id]
+		if !ok {
+			return nil
+		}
+		add(s)
+	}
+	if completesExclusion(as, present) {
+		return nil
+	}
+
+	for _, i := range rng.Perm(len(shares)) {
+		if len(subset) >= int(as.T) && satisfiesFormula(as, subset) {
+			break
+		}
+		s := shares[i]
+		if present[s.ID] {
+			continue
+		}
+		present[s.ID] = true
+		if completesExclusion(as, present) {
+			delete(present, s.ID)
+			continue
+		}
+		subset = append(subset, s)
+	}
+	if len(subset) < int(as.T) || !satisfiesFormula(as, subset) {
+		return nil
+	}
+	return subset
+}
+
+// satisfiesFormula reports whether the shares satisfy the formula of the
+// access structure, if it has one.
+func satisfiesFormula(as adss.AccessStructure, shares []*adss.SecretShare) bool {
+	if as.Formula == nil {
+		return true
+	}
+	ids := make([]uint16, len(shares))
+	for i, share := range shares {
+		ids[i] = share.ID
+	}
+	return as.Formula.Satisfied(ids)
+}
+
+// completesExclusion reports whether every ID of any exclusion is present.
+func completesExclusion(as adss.AccessStructure, present map[uint16]bool) bool {
+	for _, exclusion := range as.Exclusions {
+		excluded := true
+		for _, id := range exclusion {
+			excluded = excluded && present[id]
+		}
+		if excluded {
+			return true
+		}
+	}
+	return false
+}
+
+// shareIDs returns the IDs of the shares in order.
+func shareIDs(shares []*adss.SecretShare) []uint16 {
+	ids := make([]uint16, len(shares))
+	for i, share := range shares {
+		ids[i] = share.ID
+	}
+	return ids
+}
+
+// verifySplitOutputs runs verifySplit on the shares in memory and then on the
+// shares read back from written, the share files or, if plainBundle is set,
+// the plain bundle.
+func verifySplitOutputs(shares []*adss.SecretShare, written []string, plainBundle bool, secret, ad []byte, detached bool) error {
+	if err := verifySplit(shares, secret, ad, detached); err != nil {
+		return fmt.Errorf("verifying the shares in memory: %w", err)
+	}
+	if len(written) == 0 {
+		return nil
+	}
+
+	var readBack []*adss.SecretShare
+	var err error
+	if plainBundle {
+		readBack, _, err = readPlainBundle(written[0])
+	} else {
+		readBack, err = readBackShares(shares, written)
+	}
+	if err != nil {
+		return fmt.Errorf("verifying the shares written: %w", err)
+	}
+	if err := verifySplit(readBack, secret, ad, detached); err != nil {
+		return fmt.Errorf("verifying the shares written: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"runtime/debug"
+)
+
+// version is the release of the CLI. Release builds set it with
+// -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
+const (
+	// schemeVersion identifies the secret sharing scheme. Shares can only be
+	// recovered by an implementation of the same scheme.
+	schemeVersion = "ADSS 1 (EX and AX transforms over Shamir in GF(2^8) or replicated sharing, AES-CTR, SHA-256)"
+
+	// formatVersion identifies the encodings written by split. It changes
+	// whenever a previous release wouldn't be able to read new share files.
+	formatVersion = 3
+)
+
+// printVersion prints the CLI version along with the scheme and share format
+// versions, which are what matter when checking if shares from one release
+// can be recovered by another.
+func printVersion(versionCmd *flag.FlagSet) func() error {
+	return func() error {
+		fmt.Printf("adss %s\n", buildVersion())
+		fmt.Printf("scheme: %s\n", schemeVersion)
+		fmt.Printf("share format: %d\n", formatVersion)
+		return nil
+	}
+}
+
+// buildVersion returns the release of the CLI, falling back to the module
+// version for builds made with go install.
+func buildVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && version == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return version
+}
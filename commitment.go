@@ -0,0 +1,62 @@
+package adss
+
+import (
+	"crypto/subtle"
+)
+
+// Commitment recomputes the public commitment J of the sharing of message M
+// with access structure A, coins R and associated data T. J binds all of the
+// inputs, so a dealer who kept the coins can later prove exactly what was
+// shared, and an auditor can check a claimed J, without any of the shares.
+//
+// This is for regular sharings made by Share with the current version; use
+// the Commitment method of a share for sharings made any other way.
+func Commitment(A AccessStructure, M, R, T []byte) []byte {
+	J, _, _ := computeJKL(A, M, R, T, newShareParams())
+	return J
+}
+
+// Commitment recomputes J for a sharing of message M with coins R like the
+// one the share is from. The access structure, associated data, version,
+// domain, scheme, points and any hardening are taken from the share, so it
+// works for shares of any version.
+func (ss *SecretShare) Commitment(M, R []byte) []byte {
+	J, _, _ := computeJKL(ss.As, M, R, ss.Tag, paramsOf(ss))
+	return J
+}
+
+// VerifyCommitment reports whether the share belongs to a sharing of message
+// M with coins R, see the Commitment method.
+func (ss *SecretShare) VerifyCommitment(M, R []byte) bool {
+	return subtle.ConstantTimeCompare(ss.Commitment(M, R), ss.Pub.J) == 1
+}
+
+// RecoverWithCoins is like Recover but also returns the coins R of the
+// sharing, so an auditor can reproduce the sharing from the recovered inputs
+// and check it with Commitment and VerifyCommitment. The coins are as
+// sensitive as the secret: together with the shares' public payload they
+// reveal it. With WithEnvelope they are sealed to the recipient like the
+// secret, and opened with OpenEnvelope.
+func RecoverWithCoins(shares []*SecretShare, opts ...RecoverOption) (M, R []byte, V []*SecretShare, err error) {
+	M, V, err = Recover(shares, opts...)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// V has been verified so the coins are the ones it was shared with.
+	_, R, err = recoverInputs(V)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	cfg := &recoverConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.recipient != nil {
+		if R, err = sealEnvelope(R, cfg.recipient); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	return M, R, V, nil
+}
@@ -0,0 +1,96 @@
+package adss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCommitment(t *testing.T) {
+	A := NewAccessStructure(2, 3)
+	M, R, T := []byte("hello world"), bytes.Repeat([]byte{7}, 32), []byte("ad")
+
+	shares, err := internalShare(A, M, R, T, newShareParams())
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	hardened, err := internalShare(A, M, R, T, shareParams{hardening: &testArgon2Params, version: currentVersion})
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	if J := Commitment(A, M, R, T); !bytes.Equal(J, shares[0].Pub.J) {
+		t.Errorf("commitment %x != %x", J, shares[0].Pub.J)
+	}
+	if J := Commitment(A, M, R, nil); bytes.Equal(J, shares[0].Pub.J) {
+		t.Errorf("commitment doesn't depend on the associated data")
+	}
+
+	unframed, err := internalShare(A, M, R, T, shareParams{version: VersionUnframed})
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if J := unframed[0].Commitment(M, R); !bytes.Equal(J, unframed[0].Pub.J) {
+		t.Errorf("commitment of a share of an older version %x != %x", J, unframed[0].Pub.J)
+	}
+
+	for _, share := range []*SecretShare{shares[1], hardened[2], unframed[0]} {
+		if !share.VerifyCommitment(M, R) {
+			t.Errorf("share %d: commitment not verified", share.ID)
+		}
+		if share.VerifyCommitment([]byte("hello world!"), R) {
+			t.Errorf("share %d: commitment verified for a different message", share.ID)
+		}
+		if share.VerifyCommitment(M, bytes.Repeat([]byte{8}, 32)) {
+			t.Errorf("share %d: commitment verified for different coins", share.ID)
+		}
+	}
+}
+
+func TestRecoverWithCoins(t *testing.T) {
+	A := NewAccessStructure(2, 3)
+	M, T := []byte("hello world"), []byte("ad")
+	shares, err := Share(A, M, T)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	recovM, R, V, err := RecoverWithCoins(shares[1:])
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(recovM, M) || len(V) != 2 {
+		t.Errorf("recovered %q from %d shares", recovM, len(V))
+	}
+
+	// The recovered inputs reproduce every share, including the one that
+	// wasn't used.
+	if J := Commitment(A, M, R, T); !bytes.Equal(J, shares[0].Pub.J) {
+		t.Errorf("commitment %x != %x", J, shares[0].Pub.J)
+	}
+	reshares, err := internalShare(A, M, R, T, newShareParams())
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	for i := range shares {
+		if !reshares[i].Equal(shares[i]) {
+			t.Errorf("share %d not reproduced", i)
+		}
+	}
+
+	if _, _, _, err := RecoverWithCoins(shares[:1]); err == nil {
+		t.Errorf("expected error recovering from too few shares")
+	}
+
+	// The coins reveal the secret, so they are sealed along with it.
+	pub, priv, err := GenerateEnvelopeKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, sealedR, _, err := RecoverWithCoins(shares[1:], WithEnvelope(pub))
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if openedR, err := OpenEnvelope(sealedR, pub, priv); err != nil || !bytes.Equal(openedR, R) {
+		t.Errorf("opened coins %x, %v, expected %x", openedR, err, R)
+	}
+}
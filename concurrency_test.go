@@ -0,0 +1,107 @@
+package adss
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// These tests are most useful under the race detector, such as with
+// make test-race.
+
+func TestShareDoesNotAliasInputs(t *testing.T) {
+	msg := []byte("the secret")
+	ad := []byte("the associated data")
+	shares, err := Share(NewAccessStructure(2, 3), msg, ad)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range ad {
+		ad[i] = 0
+	}
+	for i := range msg {
+		msg[i] = 0
+	}
+
+	recovered, _, err := Recover(shares)
+	if err != nil {
+		t.Fatalf("recover after modifying inputs: %s", err)
+	}
+	if !bytes.Equal(recovered, []byte("the secret")) {
+		t.Errorf("recovered %q, want %q", recovered, "the secret")
+	}
+	if !bytes.Equal(shares[0].Tag, []byte("the associated data")) {
+		t.Errorf("share tag changed with the caller's input: %q", shares[0].Tag)
+	}
+}
+
+func TestConcurrentShareAndRecover(t *testing.T) {
+	msg := []byte("shared by every goroutine")
+	shares, err := Share(NewAccessStructure(3, 5), msg, []byte("ad"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupted := *shares[4]
+	corrupted.Sec = append([]byte{}, shares[4].Sec...)
+	corrupted.Sec[0] ^= 1
+	inputs := append(append([]*SecretShare{}, shares[:4]...), &corrupted)
+
+	var snapshot []SecretShare
+	for _, share := range inputs {
+		snapshot = append(snapshot, *share)
+	}
+
+	policy := PolicyFunc(func(ctx context.Context, req *PolicyRequest) error { return nil })
+	recoverOpts := [][]RecoverOption{
+		nil,
+		{WithUniformWork()},
+		{WithMajorityPayload()},
+		{WithPolicy(policy)},
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			opts := recoverOpts[i%len(recoverOpts)]
+			recovered, valid, err := Recover(inputs, opts...)
+			if err != nil {
+				errs <- fmt.Errorf("recover: %w", err)
+				return
+			}
+			if !bytes.Equal(recovered, msg) || len(valid) != 4 {
+				errs <- fmt.Errorf("recovered %q from %d shares", recovered, len(valid))
+				return
+			}
+			// The secret returned to each caller must be its own.
+			recovered[0] ^= 0xff
+
+			own := []byte(fmt.Sprintf("secret %d", i))
+			ownShares, err := Share(NewAccessStructure(2, 3), own, nil)
+			if err != nil {
+				errs <- fmt.Errorf("share: %w", err)
+				return
+			}
+			if recovered, _, err := Recover(ownShares[1:]); err != nil || !bytes.Equal(recovered, own) {
+				errs <- fmt.Errorf("recovered %q, %v from own shares", recovered, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for i, share := range inputs {
+		if !share.Equal(&snapshot[i]) {
+			t.Errorf("share %d was modified during recovery", share.ID)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package adss_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"testing"
+
+	"github.com/jakecraige/adss"
+	"github.com/jakecraige/adss/adsstest"
+)
+
+var updateVectors = flag.Bool("update-vectors", false, "regenerate adsstest/vectors.go from the reference implementation")
+
+func TestConformance(t *testing.T) {
+	if *updateVectors {
+		writeVectors(t)
+	}
+	adsstest.RunConformance(t, adsstest.Reference{})
+}
+
+// vectorInputs are the inputs of the vectors in adsstest/vectors.go.
+var vectorInputs = []struct {
+	name    string
+	t, n    uint16
+	M, R, T []byte
+}{
+	{"1-of-1", 1, 1, []byte("a"), bytes.Repeat([]byte{0x01}, 32), nil},
+	{"2-of-3", 2, 3, []byte("hello world"), bytes.Repeat([]byte{0x02}, 32), nil},
+	{"2-of-3-associated-data", 2, 3, []byte("hello world"), bytes.Repeat([]byte{0x03}, 32), []byte("associated data")},
+	{"3-of-5-long", 3, 5, bytes.Repeat([]byte("0123456789abcdef"), 20), bytes.Repeat([]byte{0x04}, 32), []byte("ad")},
+	{"5-of-5", 5, 5, []byte{0x00, 0xff}, bytes.Repeat([]byte{0x05}, 32), nil},
+}
+
+func writeVectors(t *testing.T) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by go test -run TestConformance -update-vectors. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package adsstest\n\n")
+	fmt.Fprintf(&buf, "// Vectors are sharings produced by the reference implementation.\n")
+	fmt.Fprintf(&buf, "var Vectors = []Vector{\n")
+	for _, in := range vectorInputs {
+		shares, err := adss.ShareDeterministic(adss.NewAccessStructure(in.t, in.n), in.M, in.R, in.T)
+		if err != nil {
+			t.Fatalf("unexpected error sharing %s: %s", in.name, err)
+		}
+
+		fmt.Fprintf(&buf, "{\nName: %q,\nThreshold: %d,\nCount: %d,\nVersion: %d,\n", in.name, in.t, in.n, shares[0].Version)
+		fmt.Fprintf(&buf, "Message: %q,\nCoins: %q,\nAssociatedData: %q,\n", hex.EncodeToString(in.M), hex.EncodeToString(in.R), hex.EncodeToString(in.T))
+		fmt.Fprintf(&buf, "Shares: []VectorShare{\n")
+		for _, share := range shares {
+			fmt.Fprintf(&buf, "{\nID: %d,\nC: %q,\nD: %q,\nJ: %q,\nSec: %q,\n},\n", share.ID,
+				hex.EncodeToString(share.Pub.C), hex.EncodeToString(share.Pub.D), hex.EncodeToString(share.Pub.J), hex.EncodeToString(share.Sec))
+		}
+		fmt.Fprintf(&buf, "},\n},\n")
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		t.Fatalf("unexpected error formatting vectors: %s", err)
+	}
+	if err := ioutil.WriteFile("adsstest/vectors.go", src, 0644); err != nil {
+		t.Fatalf("unexpected error writing vectors: %s", err)
+	}
+}
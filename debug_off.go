@@ -0,0 +1,8 @@
+//go:build !adssdebug
+// +build !adssdebug
+
+package adss
+
+// debugAssertions is disabled by default so the checks in assert.go are
+// compiled out of production builds. Build with -tags adssdebug to enable it.
+const debugAssertions = false
@@ -0,0 +1,14 @@
+//go:build adssdebug
+// +build adssdebug
+
+package adss
+
+// debugAssertions enables the internal invariant checks in assert.go. It is
+// set by building with -tags adssdebug.
+const debugAssertions = true
+
+func init() {
+	if err := fieldSelfTest(); err != nil {
+		panic("adss: " + err.Error())
+	}
+}
@@ -0,0 +1,77 @@
+package adss
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+)
+
+// ErrUnknownShareFormat is returned by DecodeShare when the input isn't in any
+// of the formats it recognizes.
+var ErrUnknownShareFormat = errors.New("unknown share format")
+
+// DecodeShare decodes a share in any of the library's encodings, working out
+// which from the input: the binary encoding of Bytes, CBOR from MarshalCBOR
+// with its self-described tag, JSON, the share strings of EncodeShareString,
+// the digit groups of EncodeShareDigits, PEM armor from EncodeSharePEM, which
+// may be surrounded by other text such as an email, and the words of
+// EncodeShareMnemonic. Surrounding whitespace is ignored for the text
+// encodings. It returns ErrUnknownShareFormat if the input doesn't look like
+// any of them, so callers can fall back to formats of their own.
+func DecodeShare(data []byte) (*SecretShare, error) {
+	switch {
+	case bytes.HasPrefix(data, binaryMagic):
+		return ParseSecretShare(data)
+
+	case bytes.HasPrefix(data, cborSelfDescribe):
+		var share SecretShare
+		if err := share.UnmarshalCBOR(data); err != nil {
+			return nil, err
+		}
+		return &share, nil
+	}
+
+	// PEM armor is looked for first since the text around it, such as an
+	// email, may start like another encoding.
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.Contains(trimmed, []byte("-----BEGIN "+pemShareType+"-----")):
+		return DecodeSharePEM(trimmed)
+
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var share SecretShare
+		if err := json.Unmarshal(trimmed, &share); err != nil {
+			return nil, err
+		}
+		return &share, nil
+
+	// Share strings are case insensitive, see BIP 350.
+	case bytes.HasPrefix(bytes.ToLower(trimmed), []byte(shareStringPrefix+"1")):
+		return DecodeShareString(string(trimmed))
+
+	case len(trimmed) > 0 && trimmed[0] >= '0' && trimmed[0] <= '9':
+		return DecodeShareDigits(string(trimmed))
+
+	case isMnemonic(trimmed):
+		return DecodeShareMnemonic(string(trimmed))
+	}
+
+	return nil, ErrUnknownShareFormat
+}
+
+// isMnemonic reports whether the first word of data is a whole word of one
+// of the mnemonic word lists.
+func isMnemonic(data []byte) bool {
+	fields := bytes.Fields(bytes.ToLower(data))
+	if len(fields) == 0 {
+		return false
+	}
+	first := string(fields[0])
+	for _, wl := range wordLists {
+		words, indexes := wl.load()
+		if i, ok := indexes[first]; ok && words[i] == first {
+			return true
+		}
+	}
+	return false
+}
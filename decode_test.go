@@ -0,0 +1,61 @@
+package adss
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeShare(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	share := shares[1]
+
+	encoded, err := json.Marshal(share)
+	if err != nil {
+		t.Fatalf("unexpected error encoding: %s", err)
+	}
+	cborEncoded, err := share.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("unexpected error encoding: %s", err)
+	}
+	var tests = []struct {
+		name string
+		data string
+	}{
+		{"json", string(encoded)},
+		{"string", EncodeShareString(share)},
+		{"uppercase string", strings.ToUpper(EncodeShareString(share))},
+		{"digits", EncodeShareDigits(share)},
+		{"binary", string(share.Bytes())},
+		{"cbor", string(cborEncoded)},
+		{"pem", string(EncodeSharePEM(share))},
+		{"mnemonic", EncodeShareMnemonic(share)},
+		{"pem in an email", "Hi,\n\n" + string(EncodeSharePEM(share)) + "\nThanks"},
+		{"pem after a digit", "2 shares follow.\n\n" + string(EncodeSharePEM(share))},
+		{"pem after json", "{\"note\": 1}\n" + string(EncodeSharePEM(share))},
+		{"surrounding whitespace", "\n  " + EncodeShareString(share) + "\n"},
+	}
+	for _, tt := range tests {
+		decoded, err := DecodeShare([]byte(tt.data))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", tt.name, err)
+			continue
+		}
+		if !decoded.Equal(share) {
+			t.Errorf("%s: decoded share doesn't match", tt.name)
+		}
+	}
+
+	for _, data := range []string{"", "threshold: 2", "not a share"} {
+		if _, err := DecodeShare([]byte(data)); !errors.Is(err, ErrUnknownShareFormat) {
+			t.Errorf("%q: got %v, expected: %v", data, err, ErrUnknownShareFormat)
+		}
+	}
+	if _, err := DecodeShare([]byte(EncodeShareString(share) + "x")); err == nil || errors.Is(err, ErrUnknownShareFormat) {
+		t.Errorf("expected a checksum error, got %v", err)
+	}
+}
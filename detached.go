@@ -0,0 +1,50 @@
+package adss
+
+import (
+	"bytes"
+)
+
+// DetachAssociatedData returns copies of the shares without their associated
+// data, which must then be supplied to Recover with WithAssociatedData. The
+// associated data is still bound by the checksum, so recovery fails unless
+// the same associated data is supplied.
+//
+// This makes the associated data a recovery-time binding factor for this
+// library and the CLI, such as a ticket ID that must be quoted to recover. It
+// isn't secret: the shares alone still determine the secret to anyone who
+// bypasses the checksum, so it doesn't add to the confidentiality of the
+// secret.
+func DetachAssociatedData(shares []*SecretShare) []*SecretShare {
+	out := make([]*SecretShare, len(shares))
+	for i, share := range shares {
+		detached := *share
+		detached.Tag = nil
+		out[i] = &detached
+	}
+	return out
+}
+
+// WithAssociatedData makes Recover use T as the associated data of the
+// shares, for shares whose associated data was removed with
+// DetachAssociatedData. Shares that still carry associated data must carry T.
+func WithAssociatedData(T []byte) RecoverOption {
+	return func(cfg *recoverConfig) {
+		cfg.associatedData = T
+		cfg.hasAssociatedData = true
+	}
+}
+
+// attachAssociatedData returns copies of the shares with T as their
+// associated data.
+func attachAssociatedData(shares []*SecretShare, T []byte) ([]*SecretShare, error) {
+	out := make([]*SecretShare, len(shares))
+	for i, share := range shares {
+		if len(share.Tag) > 0 && !bytes.Equal(share.Tag, T) {
+			return nil, shareErrorf(share, FieldTag, "associated data differs from the supplied associated data")
+		}
+		attached := *share
+		attached.Tag = T
+		out[i] = &attached
+	}
+	return out, nil
+}
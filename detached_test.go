@@ -0,0 +1,50 @@
+package adss
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestDetachedAssociatedData(t *testing.T) {
+	msg, ad := []byte("hello world"), []byte("TICKET-1")
+	shares, err := Share(NewAccessStructure(2, 3), msg, ad)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	detached := DetachAssociatedData(shares)
+	for i, share := range detached {
+		if share.Tag != nil {
+			t.Errorf("share %d still has associated data", i)
+		}
+		if !bytes.Equal(shares[i].Tag, ad) {
+			t.Errorf("share %d was modified", i)
+		}
+	}
+
+	if _, _, err := Recover(detached); err == nil {
+		t.Errorf("recovered without the associated data")
+	}
+	if _, _, err := Recover(detached, WithAssociatedData([]byte("TICKET-2"))); err == nil {
+		t.Errorf("recovered with the wrong associated data")
+	}
+	recov, V, err := Recover(detached, WithAssociatedData(ad))
+	if err != nil || !bytes.Equal(recov, msg) {
+		t.Fatalf("recovered %q, %v", recov, err)
+	}
+	if !bytes.Equal(V[0].Tag, ad) || detached[0].Tag != nil {
+		t.Errorf("expected the valid shares to have the associated data without modifying the inputs")
+	}
+
+	// Shares that kept their associated data can be mixed in, but only if it
+	// matches.
+	if _, _, err := Recover([]*SecretShare{detached[0], shares[1]}, WithAssociatedData(ad)); err != nil {
+		t.Errorf("unexpected error on recovery: %s", err)
+	}
+	_, _, err = Recover([]*SecretShare{detached[0], shares[1]}, WithAssociatedData([]byte("TICKET-2")))
+	var shareErr *ShareError
+	if !errors.As(err, &shareErr) || shareErr.ID != 1 || shareErr.Field != FieldTag {
+		t.Errorf("unexpected error for mismatched associated data: %v", err)
+	}
+}
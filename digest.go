@@ -0,0 +1,36 @@
+package adss
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+)
+
+// ErrDigestMismatch is returned by Recover with WithExpectedSHA256 when the
+// recovered secret doesn't have the expected digest.
+var ErrDigestMismatch = errors.New("recovered secret doesn't match the expected SHA-256 digest")
+
+// WithExpectedSHA256 makes Recover check the recovered secret against a known
+// SHA-256 digest before returning it, such as one recorded when the secret was
+// split. It catches recovering from the wrong set of shares during drills,
+// which recovery alone can't tell from the right one. On a mismatch the secret
+// is zeroed and ErrDigestMismatch is returned. The check is made before any
+// envelope is sealed so it is of the plaintext.
+func WithExpectedSHA256(digest [32]byte) RecoverOption {
+	return func(cfg *recoverConfig) {
+		cfg.expectedSHA256 = &digest
+	}
+}
+
+// checkDigest returns ErrDigestMismatch and zeroes secret unless it has the
+// expected digest.
+func checkDigest(secret []byte, expected *[32]byte) error {
+	digest := sha256.Sum256(secret)
+	if subtle.ConstantTimeCompare(digest[:], expected[:]) != 1 {
+		for i := range secret {
+			secret[i] = 0
+		}
+		return ErrDigestMismatch
+	}
+	return nil
+}
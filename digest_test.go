@@ -0,0 +1,53 @@
+package adss
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+)
+
+func TestWithExpectedSHA256(t *testing.T) {
+	msg := []byte("hello world")
+	shares, err := Share(NewAccessStructure(2, 3), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	recov, _, err := Recover(shares, WithExpectedSHA256(sha256.Sum256(msg)))
+	if err != nil || !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %q, %v", recov, err)
+	}
+
+	recov, V, err := Recover(shares, WithExpectedSHA256(sha256.Sum256([]byte("other"))))
+	if !errors.Is(err, ErrDigestMismatch) {
+		t.Errorf("got error %v, expected ErrDigestMismatch", err)
+	}
+	if recov != nil || V != nil {
+		t.Errorf("returned the secret or shares on a mismatch")
+	}
+
+	// The digest is of the plaintext even when the secret is sealed.
+	pub, priv, err := GenerateEnvelopeKey()
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %s", err)
+	}
+	envelope, _, err := Recover(shares, WithExpectedSHA256(sha256.Sum256(msg)), WithEnvelope(pub))
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if opened, err := OpenEnvelope(envelope, pub, priv); err != nil || !bytes.Equal(opened, msg) {
+		t.Errorf("opened %q, %v", opened, err)
+	}
+}
+
+func Test_checkDigest(t *testing.T) {
+	secret := []byte("hello world")
+	digest := sha256.Sum256([]byte("other"))
+	if err := checkDigest(secret, &digest); err != ErrDigestMismatch {
+		t.Errorf("got error %v, expected ErrDigestMismatch", err)
+	}
+	if !bytes.Equal(secret, make([]byte, len(secret))) {
+		t.Errorf("secret wasn't zeroed on a mismatch")
+	}
+}
@@ -0,0 +1,217 @@
+package adss
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Share digits encode a share as groups of six digits for reading aloud over
+// a phone line or radio. Each group holds two bytes of the share as five
+// digits followed by a Damm check digit, which catches any single wrong digit
+// and any swap of adjacent digits. After every digitsBlockSize groups comes a
+// parity group, the digit-wise sum of the block mod 10, so one bad group per
+// block can be corrected without asking for it to be read again.
+
+const (
+	digitsGroupLen  = 6
+	digitsBlockSize = 8
+)
+
+// dammTable is the quasigroup of order 10 used by the Damm algorithm.
+var dammTable = [10][10]byte{
+	{0, 3, 1, 7, 5, 9, 8, 6, 4, 2},
+	{7, 0, 9, 2, 1, 5, 4, 8, 6, 3},
+	{4, 2, 0, 6, 8, 7, 1, 3, 5, 9},
+	{1, 7, 5, 0, 9, 8, 3, 4, 2, 6},
+	{6, 1, 2, 3, 0, 4, 5, 9, 7, 8},
+	{3, 6, 7, 4, 2, 0, 9, 5, 8, 1},
+	{5, 8, 6, 9, 7, 2, 0, 1, 3, 4},
+	{8, 9, 4, 5, 3, 6, 2, 0, 1, 7},
+	{9, 4, 3, 8, 6, 1, 7, 2, 0, 5},
+	{2, 5, 8, 1, 4, 3, 6, 7, 9, 0},
+}
+
+func dammDigit(digits []byte) byte {
+	interim := byte(0)
+	for _, d := range digits {
+		interim = dammTable[interim][d]
+	}
+	return interim
+}
+
+// digitGroup is five data digits followed by a check digit.
+type digitGroup [digitsGroupLen]byte
+
+func newDigitGroup(value uint16) digitGroup {
+	var g digitGroup
+	for i := 4; i >= 0; i-- {
+		g[i] = byte(value % 10)
+		value /= 10
+	}
+	g[5] = dammDigit(g[:5])
+	return g
+}
+
+func (g digitGroup) valid() bool {
+	return dammDigit(g[:]) == 0
+}
+
+func (g digitGroup) value() (uint16, bool) {
+	v := 0
+	for _, d := range g[:5] {
+		v = v*10 + int(d)
+	}
+	return uint16(v), v <= 0xffff
+}
+
+func (g digitGroup) String() string {
+	var sb strings.Builder
+	for _, d := range g {
+		sb.WriteByte('0' + d)
+	}
+	return sb.String()
+}
+
+// parityGroup returns the digit-wise sum mod 10 of the groups' data digits.
+func parityGroup(groups []digitGroup) digitGroup {
+	var p digitGroup
+	for _, g := range groups {
+		for i := range p[:5] {
+			p[i] = (p[i] + g[i]) % 10
+		}
+	}
+	p[5] = dammDigit(p[:5])
+	return p
+}
+
+// EncodeShareDigits encodes the share as groups of digits for dictation. Each
+// line is one block of groups ending in its parity group.
+func EncodeShareDigits(share *SecretShare) string {
+	data := share.compactBytes()
+	groups := []digitGroup{newDigitGroup(uint16(len(data)))}
+	for i := 0; i < len(data); i += 2 {
+		v := uint16(data[i]) << 8
+		if i+1 < len(data) {
+			v |= uint16(data[i+1])
+		}
+		groups = append(groups, newDigitGroup(v))
+	}
+
+	lines := make([]string, 0, len(groups)/digitsBlockSize+1)
+	for start := 0; start < len(groups); start += digitsBlockSize {
+		end := start + digitsBlockSize
+		if end > len(groups) {
+			end = len(groups)
+		}
+		block := append(groups[start:end:end], parityGroup(groups[start:end]))
+
+		words := make([]string, len(block))
+		for i, g := range block {
+			words[i] = g.String()
+		}
+		lines = append(lines, strings.Join(words, " "))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// DecodeShareDigits decodes a share encoded with EncodeShareDigits. Spaces,
+// dashes and line breaks are ignored. A single bad group in a block is
+// corrected; it returns an error if a block has more than one.
+func DecodeShareDigits(s string) (*SecretShare, error) {
+	digits := make([]byte, 0, len(s))
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits = append(digits, byte(c-'0'))
+		case c == ' ' || c == '-' || c == '\n' || c == '\r' || c == '\t':
+		default:
+			return nil, fmt.Errorf("invalid character %q", c)
+		}
+	}
+	if len(digits) == 0 {
+		return nil, fmt.Errorf("no digits")
+	}
+	if len(digits)%digitsGroupLen != 0 {
+		return nil, fmt.Errorf("expected groups of %d digits, got %d digits", digitsGroupLen, len(digits))
+	}
+
+	all := make([]digitGroup, len(digits)/digitsGroupLen)
+	for i := range all {
+		copy(all[i][:], digits[i*digitsGroupLen:])
+	}
+
+	var groups []digitGroup
+	for block := 0; len(all) > 0; block++ {
+		n := digitsBlockSize + 1
+		if n > len(all) {
+			n = len(all)
+		}
+		if n < 2 {
+			return nil, fmt.Errorf("block %d is missing its parity group", block+1)
+		}
+
+		corrected, err := correctBlock(all[:n])
+		if err != nil {
+			return nil, fmt.Errorf("block %d: %w", block+1, err)
+		}
+		groups = append(groups, corrected...)
+		all = all[n:]
+	}
+
+	values := make([]uint16, len(groups))
+	for i, g := range groups {
+		v, ok := g.value()
+		if !ok {
+			return nil, fmt.Errorf("group %s out of range", g)
+		}
+		values[i] = v
+	}
+
+	length := int(values[0])
+	if (length+1)/2 != len(values)-1 {
+		return nil, fmt.Errorf("expected %d bytes, got %d groups", length, len(values)-1)
+	}
+	data := make([]byte, 0, length+1)
+	for _, v := range values[1:] {
+		data = append(data, byte(v>>8), byte(v))
+	}
+
+	return parseCompactShare(data[:length])
+}
+
+// correctBlock checks each group in the block, the last of which is the
+// parity group, and uses the parity to rebuild a single bad group. It returns
+// the data groups.
+func correctBlock(block []digitGroup) ([]digitGroup, error) {
+	bad := -1
+	for i, g := range block {
+		if g.valid() {
+			continue
+		}
+		if bad >= 0 {
+			return nil, fmt.Errorf("groups %d and %d are both invalid, please read them again", bad+1, i+1)
+		}
+		bad = i
+	}
+
+	data := append([]digitGroup{}, block[:len(block)-1]...)
+	parity := block[len(block)-1]
+	if bad >= 0 && bad < len(data) {
+		// Subtracting the other groups from the parity leaves the bad group.
+		others := append(append([]digitGroup{}, data[:bad]...), data[bad+1:]...)
+		sum := parityGroup(others)
+		var g digitGroup
+		for i := range g[:5] {
+			g[i] = (parity[i] + 10 - sum[i]) % 10
+		}
+		g[5] = dammDigit(g[:5])
+		data[bad] = g
+	}
+
+	if bad < 0 && parityGroup(data) != parity {
+		return nil, fmt.Errorf("parity doesn't match, please read the block again")
+	}
+
+	return data, nil
+}
@@ -0,0 +1,74 @@
+package adss
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestShareDigits(t *testing.T) {
+	msg := []byte("hello world")
+	shares, err := Share(NewAccessStructure(2, 3), msg, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	for _, share := range shares {
+		s := EncodeShareDigits(share)
+		decoded, err := DecodeShareDigits(s)
+		if err != nil {
+			t.Fatalf("unexpected error decoding: %s", err)
+		}
+		if !decoded.Equal(share) {
+			t.Errorf("decoded share doesn't match")
+		}
+	}
+
+	s := EncodeShareDigits(shares[1])
+	for _, line := range strings.Split(s, "\n") {
+		for _, group := range strings.Fields(line) {
+			if len(group) != digitsGroupLen {
+				t.Fatalf("unexpected group: %s", group)
+			}
+		}
+	}
+
+	// Any single misheard digit is corrected.
+	for i, c := range s {
+		if c < '0' || c > '9' {
+			continue
+		}
+		typo := s[:i] + string('0'+(c-'0'+1)%10) + s[i+1:]
+		decoded, err := DecodeShareDigits(typo)
+		if err != nil {
+			t.Fatalf("digit %d: unexpected error decoding: %s", i, err)
+		}
+		if !decoded.Equal(shares[1]) {
+			t.Fatalf("digit %d: decoded share doesn't match", i)
+		}
+	}
+
+	// Two bad groups in the same block can't be corrected.
+	typo := []byte(s)
+	typo[0] = '0' + (typo[0]-'0'+1)%10
+	typo[7] = '0' + (typo[7]-'0'+1)%10
+	if _, err := DecodeShareDigits(string(typo)); err == nil || !strings.Contains(err.Error(), "groups 1 and 2 are both invalid") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	decoded, err := DecodeShareDigits(strings.Replace(s, " ", "-", -1))
+	if err != nil {
+		t.Fatalf("unexpected error decoding: %s", err)
+	}
+	recov, _, err := Recover([]*SecretShare{shares[0], decoded})
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x != %x", recov, msg)
+	}
+
+	if _, err := DecodeShareDigits(s[:len(s)-1]); err == nil {
+		t.Errorf("expected error for truncated input")
+	}
+}
@@ -0,0 +1,41 @@
+package adss
+
+import (
+	"crypto/rand"
+	"fmt"
+
+	"golang.org/x/crypto/nacl/box"
+)
+
+// WithEnvelope makes Recover encrypt the recovered secret to the recipient's
+// X25519 public key before returning it, so the plaintext never exists
+// outside of the recipient's control. The returned secret is a NaCl sealed
+// box which the recipient can decrypt with OpenEnvelope.
+func WithEnvelope(recipient *[32]byte) RecoverOption {
+	return func(cfg *recoverConfig) {
+		cfg.recipient = recipient
+	}
+}
+
+// GenerateEnvelopeKey creates a new X25519 key pair for use with WithEnvelope.
+func GenerateEnvelopeKey() (publicKey, privateKey *[32]byte, err error) {
+	return box.GenerateKey(rand.Reader)
+}
+
+// OpenEnvelope decrypts a secret produced by Recover with WithEnvelope.
+func OpenEnvelope(envelope []byte, publicKey, privateKey *[32]byte) ([]byte, error) {
+	secret, ok := box.OpenAnonymous(nil, envelope, publicKey, privateKey)
+	if !ok {
+		return nil, fmt.Errorf("failed to open envelope")
+	}
+	return secret, nil
+}
+
+// sealEnvelope encrypts secret to the recipient and zeroes the plaintext.
+func sealEnvelope(secret []byte, recipient *[32]byte) ([]byte, error) {
+	envelope, err := box.SealAnonymous(nil, secret, recipient, rand.Reader)
+	for i := range secret {
+		secret[i] = 0
+	}
+	return envelope, err
+}
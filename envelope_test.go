@@ -0,0 +1,48 @@
+package adss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRecoverWithEnvelope(t *testing.T) {
+	msg := []byte("hello world")
+	shares, err := Share(NewAccessStructure(2, 3), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	pub, priv, err := GenerateEnvelopeKey()
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %s", err)
+	}
+
+	envelope, V, err := Recover(shares, WithEnvelope(pub))
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+
+	if len(V) != 3 {
+		t.Errorf("len(V) = %d, expected: %d", len(V), 3)
+	}
+
+	if bytes.Contains(envelope, msg) {
+		t.Errorf("envelope contains the plaintext")
+	}
+
+	recov, err := OpenEnvelope(envelope, pub, priv)
+	if err != nil {
+		t.Fatalf("unexpected error opening envelope: %s", err)
+	}
+	if !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x != %x", recov, msg)
+	}
+
+	otherPub, otherPriv, err := GenerateEnvelopeKey()
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %s", err)
+	}
+	if _, err := OpenEnvelope(envelope, otherPub, otherPriv); err == nil {
+		t.Errorf("expected error opening envelope with the wrong key")
+	}
+}
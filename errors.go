@@ -0,0 +1,91 @@
+package adss
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Errors for sets of share IDs that can't recover under their access
+// structure. Those caused by a particular share are wrapped in a ShareError
+// for it, so test for them with errors.Is.
+var (
+	ErrDuplicateShareID   = errors.New("duplicate share ID")
+	ErrShareIDOutOfRange  = errors.New("share ID out of range")
+	ErrNotEnoughShares    = errors.New("not enough shares")
+	ErrMissingMandatory   = errors.New("mandatory share missing")
+	ErrExcludedShares     = errors.New("shares excluded from recovering together")
+	ErrUnsatisfiedFormula = errors.New("shares don't satisfy the access formula")
+)
+
+// ErrInvalidAccessStructure is wrapped by the errors of access structures
+// that can't be shared with or recovered from, such as a threshold above the
+// number of shares. See AccessStructure.Validate.
+var ErrInvalidAccessStructure = errors.New("invalid access structure")
+
+// ShareField identifies the part of a share that a ShareError is about.
+type ShareField int
+
+const (
+	FieldAccessStructure ShareField = iota + 1
+	FieldID
+	FieldTag
+	FieldHardening
+	FieldCommitment // Pub.J
+	FieldPayload    // Pub.C and Pub.D
+	FieldSecret     // Sec
+	FieldVersion
+	FieldDomain
+	FieldScheme
+	FieldPoints
+)
+
+func (f ShareField) String() string {
+	switch f {
+	case FieldAccessStructure:
+		return "access structure"
+	case FieldID:
+		return "ID"
+	case FieldTag:
+		return "tag"
+	case FieldHardening:
+		return "hardening"
+	case FieldCommitment:
+		return "commitment"
+	case FieldPayload:
+		return "payload"
+	case FieldSecret:
+		return "secret"
+	case FieldVersion:
+		return "version"
+	case FieldDomain:
+		return "domain"
+	case FieldScheme:
+		return "scheme"
+	case FieldPoints:
+		return "evaluation points"
+	default:
+		return fmt.Sprintf("ShareField(%d)", int(f))
+	}
+}
+
+// ShareError is returned when a specific share fails a check, such as having a
+// different tag to the other shares. Use errors.As to find the offending share
+// and field in an error returned by Recover.
+type ShareError struct {
+	ID    uint16
+	Field ShareField
+	Err   error
+}
+
+func (e *ShareError) Error() string {
+	return fmt.Sprintf("share %d: %s", e.ID, e.Err)
+}
+
+func (e *ShareError) Unwrap() error {
+	return e.Err
+}
+
+// shareErrorf returns a ShareError for the share with a formatted message.
+func shareErrorf(share *SecretShare, field ShareField, format string, args ...interface{}) *ShareError {
+	return &ShareError{ID: share.ID, Field: field, Err: fmt.Errorf(format, args...)}
+}
@@ -0,0 +1,185 @@
+package adss
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestShareError(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	mod := cloneShare(shares[2])
+	mod.Tag = []byte("other ad")
+	_, _, err = Recover([]*SecretShare{shares[0], shares[1], mod})
+
+	var shareErr *ShareError
+	if !errors.As(err, &shareErr) {
+		t.Fatalf("expected a ShareError, got: %v", err)
+	}
+	if shareErr.ID != 2 || shareErr.Field != FieldTag {
+		t.Errorf("ShareError for share %d %s, expected: share 2 tag", shareErr.ID, shareErr.Field)
+	}
+
+	corrupt := cloneShare(shares[1])
+	corrupt.Pub.J[0]++
+	reports, err := ClassifyShares([]*SecretShare{shares[0], corrupt}, []*SecretShare{shares[0], shares[2]})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !errors.As(reports[1].Reason, &shareErr) || shareErr.ID != 1 || shareErr.Field != FieldCommitment {
+		t.Errorf("unexpected reason: %v", reports[1].Reason)
+	}
+}
+
+func TestAccessStructure_checkIDSet(t *testing.T) {
+	as, err := NewAccessStructureWithExclusions(2, 4, IDSet{1, 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if as, err = as.WithMandatory(0); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var tests = []struct {
+		name string
+		IDs  []uint16
+		err  error
+		ID   int // of the ShareError, or -1 if there is none
+	}{
+		{"supported", []uint16{0, 1}, nil, -1},
+		{"all but the exclusion", []uint16{3, 0, 2}, nil, -1},
+		{"duplicate", []uint16{0, 1, 1}, ErrDuplicateShareID, 1},
+		{"out of range", []uint16{0, 4}, ErrShareIDOutOfRange, 4},
+		{"below threshold", []uint16{0}, ErrNotEnoughShares, -1},
+		{"missing mandatory", []uint16{1, 3}, ErrMissingMandatory, -1},
+		{"excluded", []uint16{0, 1, 2}, ErrExcludedShares, -1},
+	}
+	for _, tt := range tests {
+		err := as.checkIDSet(tt.IDs)
+		if !errors.Is(err, tt.err) || (tt.err == nil) != (err == nil) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.err, err)
+			continue
+		}
+		var shareErr *ShareError
+		if errors.As(err, &shareErr) != (tt.ID >= 0) || (tt.ID >= 0 && int(shareErr.ID) != tt.ID) {
+			t.Errorf("%s: expected a ShareError for share %d, got %v", tt.name, tt.ID, err)
+		}
+		if supported := as.isSupportedIDSet(tt.IDs); supported != (tt.err == nil) {
+			t.Errorf("%s: isSupportedIDSet = %v", tt.name, supported)
+		}
+	}
+}
+
+func TestRecoverIDSetErrors(t *testing.T) {
+	as, err := NewAccessStructure(2, 3).WithMandatory(0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	shares, err := Share(as, []byte("hello world"), []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	var tests = []struct {
+		name   string
+		shares []*SecretShare
+		err    error
+	}{
+		{"duplicate", []*SecretShare{shares[0], shares[1], shares[1]}, ErrDuplicateShareID},
+		{"below threshold", []*SecretShare{shares[0]}, ErrNotEnoughShares},
+		{"missing mandatory", []*SecretShare{shares[1], shares[2]}, ErrMissingMandatory},
+	}
+	for _, tt := range tests {
+		if _, _, err := Recover(tt.shares); !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.err, err)
+		}
+	}
+}
+
+func TestAccessStructure_Validate(t *testing.T) {
+	excluded, err := NewAccessStructureWithExclusions(2, 3, IDSet{0, 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := excluded.Validate(); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	badHolders := NewAccessStructure(2, 3)
+	badHolders.Holders = []string{"alice", "bob"}
+
+	var tests = []struct {
+		name string
+		as   AccessStructure
+	}{
+		{"no shares", NewAccessStructure(0, 0)},
+		{"zero threshold", NewAccessStructure(0, 3)},
+		{"threshold above count", NewAccessStructure(4, 3)},
+		{"exclusion out of range", AccessStructure{T: 2, N: 3, Exclusions: []IDSet{{0, 3}}}},
+		{"mandatory out of range", AccessStructure{T: 2, N: 3, Mandatory: IDSet{3}}},
+		{"formula of another count", AccessStructure{T: 2, N: 3, Formula: And(Party(0), Party(1))}},
+		{"holders of another count", badHolders},
+	}
+	for _, tt := range tests {
+		if err := tt.as.Validate(); !errors.Is(err, ErrInvalidAccessStructure) {
+			t.Errorf("%s: expected ErrInvalidAccessStructure, got %v", tt.name, err)
+		}
+		if _, err := Share(tt.as, []byte("hello world"), nil); !errors.Is(err, ErrInvalidAccessStructure) {
+			t.Errorf("%s: expected Share to fail with ErrInvalidAccessStructure, got %v", tt.name, err)
+		}
+	}
+
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	for i := range shares {
+		tampered := *shares[i]
+		tampered.As.T = 4
+		shares[i] = &tampered
+	}
+	_, _, err = Recover(shares)
+	var shareErr *ShareError
+	if !errors.Is(err, ErrInvalidAccessStructure) || !errors.As(err, &shareErr) || shareErr.Field != FieldAccessStructure {
+		t.Errorf("expected an access structure ShareError wrapping ErrInvalidAccessStructure, got %v", err)
+	}
+}
+
+func TestAccessStructure_ValidateShares(t *testing.T) {
+	// Share must accept every access structure Validate does, including
+	// those at the edges of the field and of the coins one HKDF stream gives.
+	for _, as := range []AccessStructure{
+		NewAccessStructure(1, 1),
+		NewAccessStructure(255, 255),
+		NewAccessStructure(2, 256),
+		NewAccessStructure(257, 257),
+		NewAccessStructure(300, 300),
+	} {
+		if err := as.Validate(); err != nil {
+			t.Fatalf("%d-of-%d: unexpected error: %s", as.T, as.N, err)
+		}
+		if _, err := Share(as, []byte("hello world"), nil); err != nil {
+			t.Errorf("%d-of-%d: Validate accepted it but Share failed: %s", as.T, as.N, err)
+		}
+	}
+
+	var tests = []struct {
+		name   string
+		scheme uint8
+		as     AccessStructure
+	}{
+		{"replicated over 16 shares", SchemeReplicated, NewAccessStructure(2, 17)},
+		{"replicated over 1024 sets", SchemeReplicated, NewAccessStructure(8, 16)},
+		{"formula without one", SchemeFormula, NewAccessStructure(2, 3)},
+	}
+	for _, tt := range tests {
+		if err := tt.as.Validate(); err != nil {
+			t.Fatalf("%s: unexpected error: %s", tt.name, err)
+		}
+		if _, err := ShareWithScheme(tt.scheme, tt.as, []byte("hello world"), nil); !errors.Is(err, ErrInvalidAccessStructure) {
+			t.Errorf("%s: expected ErrInvalidAccessStructure, got %v", tt.name, err)
+		}
+	}
+}
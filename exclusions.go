@@ -0,0 +1,351 @@
+package adss
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IDSet is a set of share IDs, sorted in increasing order. It is encoded in
+// JSON as an array of numbers.
+type IDSet []uint16
+
+// ParseIDSet parses a set written by String, such as "0+2".
+func ParseIDSet(s string) (IDSet, error) {
+	var set IDSet
+	for _, part := range strings.Split(s, "+") {
+		id, err := strconv.ParseUint(strings.TrimSpace(part), 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid share ID set %q: %w", s, err)
+		}
+		set = append(set, uint16(id))
+	}
+	return set, nil
+}
+
+// String returns the IDs joined with "+", such as "0+2".
+func (s IDSet) String() string {
+	parts := make([]string, len(s))
+	for i, id := range s {
+		parts[i] = strconv.Itoa(int(id))
+	}
+	return strings.Join(parts, "+")
+}
+
+func (s IDSet) MarshalJSON() ([]byte, error) {
+	ids := make([]int, len(s))
+	for i, id := range s {
+		ids[i] = int(id)
+	}
+	return json.Marshal(ids)
+}
+
+func (s *IDSet) UnmarshalJSON(data []byte) error {
+	var ids []uint16
+	if err := json.Unmarshal(data, &ids); err != nil {
+		return err
+	}
+	*s = ids
+	return nil
+}
+
+// NewAccessStructureWithExclusions returns a t-of-n access structure in which
+// no set of shares including all of the IDs of one of the exclusions can
+// recover, even if it meets the threshold. For example, excluding {0, 1}
+// stops two shares kept in the same facility from recovering together.
+//
+// Each exclusion must have between 2 and t distinct IDs below n, since a
+// larger set always contains a smaller one that isn't excluded. The
+// exclusions are sorted so equal structures have equal encodings.
+func NewAccessStructureWithExclusions(t, n uint16, exclusions ...IDSet) (AccessStructure, error) {
+	as := AccessStructure{T: t, N: n}
+	if t == 0 || t > n {
+		return as, fmt.Errorf("%w: %d-of-%d", ErrInvalidAccessStructure, t, n)
+	}
+
+	for _, exclusion := range exclusions {
+		set := append(IDSet{}, exclusion...)
+		sort.Slice(set, func(i, j int) bool { return set[i] < set[j] })
+		as.Exclusions = append(as.Exclusions, set)
+	}
+	sort.Slice(as.Exclusions, func(i, j int) bool {
+		return compareIDSets(as.Exclusions[i], as.Exclusions[j]) < 0
+	})
+
+	if err := as.validateExclusions(); err != nil {
+		return as, err
+	}
+	if err := as.validateQualified(); err != nil {
+		return as, fmt.Errorf("%w: %s", ErrInvalidAccessStructure, err)
+	}
+	return as, nil
+}
+
+// validateExclusions returns an error if an exclusion can't be satisfied by
+// the shares of the access structure or is repeated.
+func (as *AccessStructure) validateExclusions() error {
+	for i, exclusion := range as.Exclusions {
+		if len(exclusion) < 2 || len(exclusion) > int(as.T) {
+			return fmt.Errorf("exclusion %s must have between 2 and %d IDs", exclusion, as.T)
+		}
+		for j, id := range exclusion {
+			if id >= as.N {
+				return fmt.Errorf("exclusion %s has ID %d, out of range for %d-of-%d", exclusion, id, as.T, as.N)
+			}
+			if j > 0 && id <= exclusion[j-1] {
+				return fmt.Errorf("exclusion %s is not sorted or repeats an ID", exclusion)
+			}
+		}
+		if i > 0 && compareIDSets(exclusion, as.Exclusions[i-1]) == 0 {
+			return fmt.Errorf("exclusion %s is repeated", exclusion)
+		}
+	}
+	return nil
+}
+
+// maxQualifiedSearch bounds the IDs validateQualified compares against the
+// exclusions, since finding a set of shares that avoids every exclusion is
+// hard in general and recovery validates the access structure of untrusted
+// shares.
+const maxQualifiedSearch = 1 << 20
+
+// validateQualified returns an error unless some set of T shares can recover
+// despite the exclusions, including the mandatory shares and satisfying the
+// formula if there is one. It also fails if the exclusions are too many to
+// find one within maxQualifiedSearch.
+func (as *AccessStructure) validateQualified() error {
+	if len(as.Exclusions) == 0 {
+		return nil
+	}
+
+	// The mandatory shares are in every set that can recover. Shares in no
+	// exclusion can't complete one, so without a formula any of them may
+	// fill the set and only the number left matters; they are tried last.
+	present := make([]bool, as.N)
+	for _, id := range as.Mandatory {
+		present[id] = true
+	}
+	excluded := make([]bool, as.N)
+	for _, exclusion := range as.Exclusions {
+		for _, id := range exclusion {
+			excluded[id] = true
+		}
+	}
+	var candidates []uint16
+	for id := uint16(0); id < as.N; id++ {
+		if !present[id] && excluded[id] {
+			candidates = append(candidates, id)
+		}
+	}
+	constrained := len(candidates)
+	for id := uint16(0); id < as.N; id++ {
+		if !present[id] && !excluded[id] {
+			candidates = append(candidates, id)
+		}
+	}
+
+	cost := 1
+	for _, exclusion := range as.Exclusions {
+		cost += len(exclusion)
+	}
+	steps := 0
+	var search func(next, need int) (bool, error)
+	search = func(next, need int) (bool, error) {
+		if steps += cost; steps > maxQualifiedSearch {
+			return false, fmt.Errorf("exclusions are too many to check that a set of shares can recover")
+		}
+		if need == 0 {
+			return as.Formula == nil || as.Formula.satisfied(present), nil
+		}
+		if len(candidates)-next < need {
+			return false, nil
+		}
+		if next >= constrained && as.Formula == nil {
+			return true, nil
+		}
+		id := candidates[next]
+		present[id] = true
+		if !as.hasExclusion(present) {
+			if found, err := search(next+1, need-1); found || err != nil {
+				return found, err
+			}
+		}
+		present[id] = false
+		return search(next+1, need)
+	}
+	found, err := search(0, int(as.T)-len(as.Mandatory))
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("exclusions leave no set of %d shares that can recover", as.T)
+	}
+	return nil
+}
+
+// hasExclusion reports whether every ID of any exclusion is in the set marked
+// present.
+func (as *AccessStructure) hasExclusion(present []bool) bool {
+	for _, exclusion := range as.Exclusions {
+		excluded := true
+		for _, id := range exclusion {
+			excluded = excluded && present[id]
+		}
+		if excluded {
+			return true
+		}
+	}
+	return false
+}
+
+// Equal reports whether the access structures have the same threshold,
+// count, exclusions, mandatory shares, formula and holder names.
+func (as AccessStructure) Equal(other AccessStructure) bool {
+	if as.T != other.T || as.N != other.N || len(as.Exclusions) != len(other.Exclusions) {
+		return false
+	}
+	if compareIDSets(as.Mandatory, other.Mandatory) != 0 || (as.Formula == nil) != (other.Formula == nil) {
+		return false
+	}
+	if as.Formula != nil && !bytes.Equal(as.Formula.appendBytes(nil, true), other.Formula.appendBytes(nil, true)) {
+		return false
+	}
+	if (as.Holders == nil) != (other.Holders == nil) || !bytes.Equal(as.holderBytes(), other.holderBytes()) {
+		return false
+	}
+	for i := range as.Exclusions {
+		if compareIDSets(as.Exclusions[i], other.Exclusions[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// clone returns a copy of the access structure that doesn't share the
+// exclusions, mandatory shares, formula or holder names.
+func (as AccessStructure) clone() AccessStructure {
+	if as.Mandatory != nil {
+		as.Mandatory = append(IDSet{}, as.Mandatory...)
+	}
+	as.Formula = as.Formula.clone()
+	if as.Holders != nil {
+		as.Holders = append([]string{}, as.Holders...)
+	}
+	if as.Exclusions == nil {
+		return as
+	}
+	exclusions := make([]IDSet, len(as.Exclusions))
+	for i, exclusion := range as.Exclusions {
+		exclusions[i] = append(IDSet{}, exclusion...)
+	}
+	as.Exclusions = exclusions
+	return as
+}
+
+// exclusionBytes returns an encoding of the exclusions with each set prefixed
+// by its length, or nil if there are none. The lengths and IDs of wide access
+// structures take two bytes, see appendIDs.
+func (as *AccessStructure) exclusionBytes() []byte {
+	var out []byte
+	for _, exclusion := range as.Exclusions {
+		out = appendIDs(out, []uint16{uint16(len(exclusion))}, as.wide())
+		out = appendIDs(out, exclusion, as.wide())
+	}
+	return out
+}
+
+// parseExclusions decodes the output of exclusionBytes.
+func parseExclusions(data []byte, wide bool) ([]IDSet, error) {
+	size := 1
+	if wide {
+		size = 2
+	}
+	var exclusions []IDSet
+	for len(data) > 0 {
+		if len(data) < size {
+			return nil, fmt.Errorf("exclusion length invalid")
+		}
+		lengths, _ := parseIDs(data[:size], wide)
+		length := int(lengths[0]) * size
+		if length == 0 || length > len(data)-size {
+			return nil, fmt.Errorf("exclusion length invalid")
+		}
+		exclusion, err := parseIDs(data[size:size+length], wide)
+		if err != nil {
+			return nil, err
+		}
+		exclusions = append(exclusions, exclusion)
+		data = data[size+length:]
+	}
+	return exclusions, nil
+}
+
+// wide reports whether the access structure has more than 255 shares, so
+// share IDs don't fit in a byte and its key is shared over GF(2^16).
+func (as *AccessStructure) wide() bool {
+	return as.N > 255
+}
+
+// appendIDs appends the IDs to out, one byte each, or two bytes each, big
+// endian, if wide. Sharings of at most 255 shares keep the one byte encoding
+// they had before IDs were widened.
+func appendIDs(out []byte, ids []uint16, wide bool) []byte {
+	for _, id := range ids {
+		if wide {
+			out = append(out, byte(id>>8))
+		}
+		out = append(out, byte(id))
+	}
+	return out
+}
+
+// parseIDs decodes the output of appendIDs.
+func parseIDs(data []byte, wide bool) (IDSet, error) {
+	if !wide {
+		ids := make(IDSet, len(data))
+		for i, b := range data {
+			ids[i] = uint16(b)
+		}
+		return ids, nil
+	}
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("share IDs truncated")
+	}
+	ids := make(IDSet, len(data)/2)
+	for i := range ids {
+		ids[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+	}
+	return ids, nil
+}
+
+// appendShareID appends the ID to out as a byte, or as two bytes if it is
+// above 255, for signed encodings in which it is followed by a fixed length
+// field or nothing, so signatures over IDs that fit in a byte are unchanged.
+func appendShareID(out []byte, id uint16) []byte {
+	if id > 0xff {
+		out = append(out, byte(id>>8))
+	}
+	return append(out, byte(id))
+}
+
+// compareIDSets compares the sets element by element like bytes.Compare.
+func compareIDSets(a, b IDSet) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		switch {
+		case a[i] < b[i]:
+			return -1
+		case a[i] > b[i]:
+			return 1
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
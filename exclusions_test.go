@@ -0,0 +1,164 @@
+package adss
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewAccessStructureWithExclusions(t *testing.T) {
+	as, err := NewAccessStructureWithExclusions(3, 5, IDSet{4, 2}, IDSet{1, 0})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := AccessStructure{T: 3, N: 5, Exclusions: []IDSet{{0, 1}, {2, 4}}}
+	if !as.Equal(expected) {
+		t.Errorf("got %v, expected: %v", as, expected)
+	}
+
+	var errTests = []struct {
+		name       string
+		t, n       uint16
+		exclusions []IDSet
+	}{
+		{"invalid threshold", 0, 3, nil},
+		{"single ID", 2, 3, []IDSet{{0}}},
+		{"larger than threshold", 2, 3, []IDSet{{0, 1, 2}}},
+		{"out of range", 2, 3, []IDSet{{0, 3}}},
+		{"repeated ID", 2, 3, []IDSet{{1, 1}}},
+		{"repeated exclusion", 2, 3, []IDSet{{0, 1}, {1, 0}}},
+		{"every set excluded", 2, 2, []IDSet{{0, 1}}},
+		{"every pair excluded", 2, 3, []IDSet{{0, 1}, {0, 2}, {1, 2}}},
+	}
+	for _, tt := range errTests {
+		if _, err := NewAccessStructureWithExclusions(tt.t, tt.n, tt.exclusions...); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestAccessStructure_validateQualified(t *testing.T) {
+	var tests = []struct {
+		name     string
+		as       AccessStructure
+		expected bool
+	}{
+		{"no exclusions", NewAccessStructure(2, 3), true},
+		{"one pair left", AccessStructure{T: 2, N: 3, Exclusions: []IDSet{{0, 1}, {0, 2}}}, true},
+		{"mandatory in every pair left", AccessStructure{T: 2, N: 3, Exclusions: []IDSet{{1, 2}}, Mandatory: IDSet{1}}, true},
+		{"mandatory excluded with the rest", AccessStructure{T: 2, N: 3, Exclusions: []IDSet{{0, 1}, {1, 2}}, Mandatory: IDSet{1}}, false},
+		{"formula left unsatisfied", AccessStructure{T: 2, N: 3, Exclusions: []IDSet{{0, 1}, {0, 2}},
+			Formula: And(Party(0), Or(Party(1), Party(2)))}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.as.validateQualified(); (err == nil) != tt.expected {
+			t.Errorf("%s: got %v, expected valid: %v", tt.name, err, tt.expected)
+		}
+	}
+
+	// 20 disjoint pairs leave sets of at most 20 of the 40 shares, which takes
+	// too long to find out.
+	var pairs []IDSet
+	for i := uint16(0); i < 40; i += 2 {
+		pairs = append(pairs, IDSet{i, i + 1})
+	}
+	as := AccessStructure{T: 21, N: 40, Exclusions: pairs}
+	if err := as.validateQualified(); err == nil || !strings.Contains(err.Error(), "too many") {
+		t.Errorf("expected the search to give up, got %v", err)
+	}
+
+	as = AccessStructure{T: 2, N: 2, Exclusions: []IDSet{{0, 1}}}
+	if err := as.Validate(); !errors.Is(err, ErrInvalidAccessStructure) {
+		t.Errorf("expected ErrInvalidAccessStructure, got %v", err)
+	}
+	if _, err := Share(as, []byte("hello world"), nil); !errors.Is(err, ErrInvalidAccessStructure) {
+		t.Errorf("expected Share to fail with ErrInvalidAccessStructure, got %v", err)
+	}
+}
+
+func TestAccessStructure_isSupportedIDSet(t *testing.T) {
+	as, err := NewAccessStructureWithExclusions(2, 5, IDSet{0, 1}, IDSet{2, 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var tests = []struct {
+		IDs      []uint16
+		expected bool
+	}{
+		{[]uint16{0, 2}, true},
+		{[]uint16{1, 3, 4}, true},
+		{[]uint16{1, 0}, false},
+		{[]uint16{4, 3, 2}, false},
+		{[]uint16{0, 1, 2, 3, 4}, false},
+	}
+	for _, tt := range tests {
+		if actual := as.isSupportedIDSet(tt.IDs); actual != tt.expected {
+			t.Errorf("isSupportedIDSet(%v) = %v, expected: %v", tt.IDs, actual, tt.expected)
+		}
+	}
+}
+
+func TestShareWithExclusions(t *testing.T) {
+	msg := []byte("hello world")
+	as, err := NewAccessStructureWithExclusions(2, 3, IDSet{0, 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	shares, err := Share(as, msg, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	if _, _, err := Recover([]*SecretShare{shares[0], shares[1]}); err == nil {
+		t.Errorf("excluded shares recovered")
+	}
+	if recov, _, err := Recover([]*SecretShare{shares[1], shares[2]}); err != nil || !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %q, %v", recov, err)
+	}
+	recov, valid, err := Recover(shares)
+	if err != nil || !bytes.Equal(recov, msg) {
+		t.Fatalf("recovered %q, %v", recov, err)
+	}
+	if len(valid) != 2 {
+		t.Errorf("expected 2 valid shares, got %d", len(valid))
+	}
+
+	// The exclusions are part of the checksum so they can't be removed.
+	stripped := []*SecretShare{cloneShare(shares[0]), cloneShare(shares[1])}
+	for _, share := range stripped {
+		share.As.Exclusions = nil
+	}
+	if recov, _, err := Recover(stripped); err == nil {
+		t.Errorf("recovered %q after removing the exclusions", recov)
+	}
+
+	// They survive every encoding.
+	encoded, err := json.Marshal(shares[0])
+	if err != nil {
+		t.Fatalf("unexpected error marshalling: %s", err)
+	}
+	if !bytes.Contains(encoded, []byte(`"Exclusions":[[0,1]]`)) {
+		t.Errorf("unexpected encoding: %s", encoded)
+	}
+	var decoded SecretShare
+	if err := json.Unmarshal(encoded, &decoded); err != nil || !decoded.Equal(shares[0]) {
+		t.Errorf("JSON round trip failed: %v", err)
+	}
+	if decoded, err := DecodeShareString(EncodeShareString(shares[0])); err != nil || !decoded.Equal(shares[0]) {
+		t.Errorf("share string round trip failed: %v", err)
+	}
+	locked, err := LockShareWithParams(shares[0], []byte("passphrase"), testArgon2Params)
+	if err != nil {
+		t.Fatalf("unexpected error on locking: %s", err)
+	}
+	if unlocked, err := locked.Unlock([]byte("passphrase")); err != nil || !unlocked.Equal(shares[0]) {
+		t.Errorf("lock round trip failed: %v", err)
+	}
+	locked.As.Exclusions = nil
+	if _, err := locked.Unlock([]byte("passphrase")); err == nil {
+		t.Errorf("unlocked after removing the exclusions")
+	}
+}
@@ -0,0 +1,105 @@
+package adss
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// fieldsMagic starts every associated data created by EncodeFields.
+var fieldsMagic = []byte("adss fields\x00\x01")
+
+// EncodeFields returns associated data carrying the labeled fields, such as
+// a policy, ticket ID and environment. Each label and value is length
+// prefixed and the fields are sorted by label, so every set of fields has
+// exactly one encoding and no two sets share one. Pass the result as the
+// associated data to any of the Share functions, or to TagWithMetadata, and
+// use DecodeFields to get the fields back.
+func EncodeFields(fields map[string][]byte) []byte {
+	labels := make([]string, 0, len(fields))
+	for label := range fields {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	out := append([]byte{}, fieldsMagic...)
+	out = appendUvarint(out, uint64(len(labels)))
+	for _, label := range labels {
+		out = appendUvarint(out, uint64(len(label)))
+		out = append(out, label...)
+		out = appendUvarint(out, uint64(len(fields[label])))
+		out = append(out, fields[label]...)
+	}
+	return out
+}
+
+// DecodeFields returns the fields of associated data created by
+// EncodeFields. It returns an error for any other associated data, including
+// encodings that EncodeFields wouldn't produce.
+func DecodeFields(ad []byte) (map[string][]byte, error) {
+	if !bytes.HasPrefix(ad, fieldsMagic) {
+		return nil, fmt.Errorf("associated data doesn't contain fields")
+	}
+	data := ad[len(fieldsMagic):]
+
+	count, err := readUvarint(&data)
+	if err != nil {
+		return nil, fmt.Errorf("field count: %w", err)
+	}
+
+	fields := make(map[string][]byte)
+	var previous string
+	for i := uint64(0); i < count; i++ {
+		label, err := readLengthPrefixed(&data)
+		if err != nil {
+			return nil, fmt.Errorf("field %d label: %w", i, err)
+		}
+		if i > 0 && string(label) <= previous {
+			return nil, fmt.Errorf("field %q is out of order or repeated", label)
+		}
+		previous = string(label)
+
+		value, err := readLengthPrefixed(&data)
+		if err != nil {
+			return nil, fmt.Errorf("field %q value: %w", label, err)
+		}
+		fields[string(label)] = value
+	}
+
+	if len(data) != 0 {
+		return nil, fmt.Errorf("%d bytes after the fields", len(data))
+	}
+	return fields, nil
+}
+
+func appendUvarint(out []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(out, buf[:n]...)
+}
+
+// readUvarint reads a uvarint from the front of data and advances past it.
+func readUvarint(data *[]byte) (uint64, error) {
+	v, n := binary.Uvarint(*data)
+	if n <= 0 {
+		return 0, fmt.Errorf("invalid length")
+	}
+	*data = (*data)[n:]
+	return v, nil
+}
+
+// readLengthPrefixed reads a uvarint length prefixed byte string from the
+// front of data and advances past it.
+func readLengthPrefixed(data *[]byte) ([]byte, error) {
+	length, err := readUvarint(data)
+	if err != nil {
+		return nil, err
+	}
+	if length > uint64(len(*data)) {
+		return nil, fmt.Errorf("length %d exceeds the remaining %d bytes", length, len(*data))
+	}
+	out := (*data)[:length]
+	*data = (*data)[length:]
+	return out, nil
+}
@@ -0,0 +1,75 @@
+package adss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncodeFields(t *testing.T) {
+	fields := map[string][]byte{
+		"policy":      []byte("two-person"),
+		"ticket":      []byte("OPS-1234"),
+		"environment": []byte("production"),
+		"empty":       nil,
+	}
+
+	ad := EncodeFields(fields)
+	decoded, err := DecodeFields(ad)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(decoded) != len(fields) {
+		t.Fatalf("decoded %d fields, expected: %d", len(decoded), len(fields))
+	}
+	for label, value := range fields {
+		if !bytes.Equal(decoded[label], value) {
+			t.Errorf("%s = %q, expected: %q", label, decoded[label], value)
+		}
+	}
+
+	// Fields are hashed as part of the sharing, so the encoding must not
+	// depend on map iteration order.
+	for i := 0; i < 10; i++ {
+		if !bytes.Equal(EncodeFields(fields), ad) {
+			t.Fatalf("encoding isn't deterministic")
+		}
+	}
+
+	// Moving bytes between a label and value or between fields changes the
+	// encoding, unlike naive concatenation.
+	if bytes.Equal(EncodeFields(map[string][]byte{"ab": []byte("c")}), EncodeFields(map[string][]byte{"a": []byte("bc")})) {
+		t.Errorf("different fields have the same encoding")
+	}
+
+	// The fields can carry metadata too.
+	tagged, _, err := ParseTag(TagWithMetadata(ad, Metadata{Note: "note"}))
+	if err != nil || !bytes.Equal(tagged, ad) {
+		t.Errorf("fields didn't round trip through metadata: %v", err)
+	}
+}
+
+func TestDecodeFieldsRejects(t *testing.T) {
+	valid := EncodeFields(map[string][]byte{"a": []byte("1"), "b": []byte("2")})
+
+	var tests = []struct {
+		name string
+		ad   []byte
+	}{
+		{"plain", []byte("plain ad")},
+		{"empty", nil},
+		{"truncated", valid[:len(valid)-1]},
+		{"trailing", append(append([]byte{}, valid...), 0)},
+		{"unsorted", append(append([]byte{}, fieldsMagic...), 2, 1, 'b', 0, 1, 'a', 0)},
+		{"repeated", append(append([]byte{}, fieldsMagic...), 2, 1, 'a', 0, 1, 'a', 0)},
+		{"long length", append(append([]byte{}, fieldsMagic...), 1, 0xff, 0x01)},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if fields, err := DecodeFields(tt.ad); err == nil {
+				t.Errorf("decoded %q, expected an error", fields)
+			}
+		})
+	}
+}
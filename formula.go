@@ -0,0 +1,618 @@
+package adss
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// formulaMarker follows the threshold, count and any mandatory shares in the
+// bytes of an access structure with a formula, see mandatoryMarker.
+const formulaMarker = 0xfe
+
+// maxFormulaDepth bounds the nesting of formulas so decoding one can't
+// exhaust the stack.
+const maxFormulaDepth = 16
+
+// Formula is a monotone boolean formula over share IDs that says which sets
+// of shares can recover, such as "(0 AND 1) OR 2-of(2, 3, 4)". A leaf names a
+// share by its ID, and a gate is satisfied when at least K of its children
+// are: AND is a gate that needs all of them and OR one that needs any. Each
+// share appears in exactly one leaf.
+type Formula struct {
+	// ID is the share a leaf names.
+	ID uint16
+	// K is how many of a gate's children must be satisfied.
+	K uint16
+	// Children are the inputs of a gate, and nil for a leaf.
+	Children []*Formula
+}
+
+// Party returns a leaf naming the share with the ID.
+func Party(id uint16) *Formula {
+	return &Formula{ID: id}
+}
+
+// And returns a gate satisfied when all of the children are.
+func And(children ...*Formula) *Formula {
+	return &Formula{K: uint16(len(children)), Children: children}
+}
+
+// Or returns a gate satisfied when any of the children is.
+func Or(children ...*Formula) *Formula {
+	return &Formula{K: 1, Children: children}
+}
+
+// AtLeast returns a gate satisfied when at least k of the children are.
+func AtLeast(k uint16, children ...*Formula) *Formula {
+	return &Formula{K: k, Children: children}
+}
+
+// NewFormulaAccessStructure returns an access structure in which a set of
+// shares can recover if it satisfies the formula, such as
+//
+//	adss.Or(adss.And(adss.Party(0), adss.Party(1)), adss.AtLeast(3, adss.Party(2), ...))
+//
+// for the CEO and CFO together or three of five engineers. The formula must
+// name the shares 0 to N-1 once each. The threshold is the fewest shares that
+// satisfy it, so T-of-N checks elsewhere still hold.
+//
+// Shares created with Share are Shamir shares of that threshold, so the
+// formula is only enforced by recovery refusing the sets it rules out. Use
+// ShareWithScheme(SchemeFormula, ...) to enforce it in the sharing itself.
+func NewFormulaAccessStructure(f *Formula) (AccessStructure, error) {
+	leaves := f.leaves()
+	if leaves > maxWideShares {
+		return AccessStructure{}, fmt.Errorf("formula names %d shares, at most %d are supported", leaves, maxWideShares)
+	}
+	as := AccessStructure{T: uint16(f.minShares()), N: uint16(leaves), Formula: f.clone()}
+	if err := as.validateFormula(); err != nil {
+		return as, err
+	}
+	return as, nil
+}
+
+// ParseFormula parses a formula written with AND, OR, k-of(...) and
+// parentheses, such as "(ceo AND cfo) OR 3-of(eng1, eng2, eng3, eng4, eng5)".
+// AND binds more tightly than OR. Shares are named by parties, where
+// parties[i] names the share with ID i, or by their IDs if parties is nil.
+func ParseFormula(s string, parties []string) (*Formula, error) {
+	p := &formulaParser{tokens: tokenizeFormula(s)}
+	if parties != nil {
+		if len(parties) > maxWideShares {
+			return nil, fmt.Errorf("at most %d parties are supported", maxWideShares)
+		}
+		p.parties = make(map[string]uint16, len(parties))
+		for i, name := range parties {
+			if _, ok := p.parties[name]; ok {
+				return nil, fmt.Errorf("party %q is named twice", name)
+			}
+			p.parties[name] = uint16(i)
+		}
+	}
+
+	f, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("formula: unexpected %q", p.tokens[p.pos])
+	}
+	return f, nil
+}
+
+// String returns the formula with shares named by their IDs, such as
+// "(0 AND 1) OR 2-of(2, 3, 4)", which ParseFormula(s, nil) parses.
+func (f *Formula) String() string {
+	return f.Format(nil)
+}
+
+// Format returns the formula with the share with ID i named parties[i], or by
+// its ID if parties is nil. A nil formula is formatted as "".
+func (f *Formula) Format(parties []string) string {
+	if f == nil {
+		return ""
+	}
+	var b strings.Builder
+	f.format(&b, parties, false)
+	return b.String()
+}
+
+func (f *Formula) format(b *strings.Builder, parties []string, nested bool) {
+	switch {
+	case len(f.Children) == 0:
+		if int(f.ID) < len(parties) {
+			b.WriteString(parties[f.ID])
+		} else {
+			b.WriteString(strconv.Itoa(int(f.ID)))
+		}
+
+	case f.K == 1 || int(f.K) == len(f.Children):
+		op := " AND "
+		if f.K == 1 {
+			op = " OR "
+		}
+		if nested {
+			b.WriteString("(")
+		}
+		for i, child := range f.Children {
+			if i > 0 {
+				b.WriteString(op)
+			}
+			child.format(b, parties, true)
+		}
+		if nested {
+			b.WriteString(")")
+		}
+
+	default:
+		fmt.Fprintf(b, "%d-of(", f.K)
+		for i, child := range f.Children {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			child.format(b, parties, false)
+		}
+		b.WriteString(")")
+	}
+}
+
+// Satisfied reports whether the shares with the IDs satisfy the formula.
+func (f *Formula) Satisfied(ids []uint16) bool {
+	var present []bool
+	for _, id := range ids {
+		if int(id) >= len(present) {
+			present = append(present, make([]bool, int(id)+1-len(present))...)
+		}
+		present[id] = true
+	}
+	return f.satisfied(present)
+}
+
+// satisfied is Satisfied for the shares with present[ID] set. IDs beyond the
+// end of present are absent.
+func (f *Formula) satisfied(present []bool) bool {
+	if len(f.Children) == 0 {
+		return int(f.ID) < len(present) && present[f.ID]
+	}
+	n := 0
+	for _, child := range f.Children {
+		if child.satisfied(present) {
+			n++
+		}
+	}
+	return n >= int(f.K)
+}
+
+// leaves returns the number of leaves of the formula.
+func (f *Formula) leaves() int {
+	if len(f.Children) == 0 {
+		return 1
+	}
+	n := 0
+	for _, child := range f.Children {
+		n += child.leaves()
+	}
+	return n
+}
+
+// minShares returns the fewest shares that satisfy the formula. Since each
+// share appears once, that is the sum of the K smallest for the children of
+// each gate.
+func (f *Formula) minShares() int {
+	if len(f.Children) == 0 {
+		return 1
+	}
+	mins := make([]int, len(f.Children))
+	for i, child := range f.Children {
+		mins[i] = child.minShares()
+	}
+	sort.Ints(mins)
+	k := int(f.K)
+	if k > len(mins) {
+		k = len(mins)
+	}
+	n := 0
+	for _, m := range mins[:k] {
+		n += m
+	}
+	return n
+}
+
+func (f *Formula) clone() *Formula {
+	if f == nil {
+		return nil
+	}
+	out := &Formula{ID: f.ID, K: f.K}
+	if f.Children != nil {
+		out.Children = make([]*Formula, len(f.Children))
+		for i, child := range f.Children {
+			out.Children[i] = child.clone()
+		}
+	}
+	return out
+}
+
+// validateFormula returns an error unless the formula of the access
+// structure, if any, is well formed, names each share once and has the
+// threshold as the fewest shares that satisfy it.
+func (as *AccessStructure) validateFormula() error {
+	if as.Formula == nil {
+		return nil
+	}
+
+	seen := make([]bool, as.N)
+	leaves := 0
+	var check func(f *Formula, depth int) error
+	check = func(f *Formula, depth int) error {
+		if depth > maxFormulaDepth {
+			return fmt.Errorf("formula is nested more than %d deep", maxFormulaDepth)
+		}
+		if len(f.Children) == 0 {
+			if f.ID >= as.N {
+				return fmt.Errorf("formula names share %d, out of range for %d shares", f.ID, as.N)
+			}
+			if seen[f.ID] {
+				return fmt.Errorf("formula names share %d more than once", f.ID)
+			}
+			seen[f.ID] = true
+			leaves++
+			return nil
+		}
+		if len(f.Children) < 2 {
+			return fmt.Errorf("formula has a gate with one input")
+		}
+		if f.K == 0 || int(f.K) > len(f.Children) {
+			return fmt.Errorf("formula has a gate needing %d of %d inputs", f.K, len(f.Children))
+		}
+		for _, child := range f.Children {
+			if err := check(child, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := check(as.Formula, 1); err != nil {
+		return err
+	}
+
+	if leaves != int(as.N) {
+		return fmt.Errorf("formula names %d shares, expected %d", leaves, as.N)
+	}
+	if min := as.Formula.minShares(); min != int(as.T) {
+		return fmt.Errorf("threshold %d isn't the %d shares that satisfy the formula", as.T, min)
+	}
+	return nil
+}
+
+// appendBytes appends a canonical encoding of the formula to out: a leaf is 0
+// and its ID, and a gate is its number of children, K and then the children.
+// Each is a byte, or two bytes for the formula of a wide access structure,
+// see appendIDs.
+func (f *Formula) appendBytes(out []byte, wide bool) []byte {
+	if len(f.Children) == 0 {
+		return appendIDs(out, []uint16{0, f.ID}, wide)
+	}
+	out = appendIDs(out, []uint16{uint16(len(f.Children)), f.K}, wide)
+	for _, child := range f.Children {
+		out = child.appendBytes(out, wide)
+	}
+	return out
+}
+
+// parseFormulaBytes decodes the output of appendBytes.
+func parseFormulaBytes(data []byte, wide bool) (*Formula, error) {
+	f, rest, err := parseFormulaNode(data, wide, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("formula has %d trailing bytes", len(rest))
+	}
+	return f, nil
+}
+
+func parseFormulaNode(data []byte, wide bool, depth int) (*Formula, []byte, error) {
+	if depth > maxFormulaDepth {
+		return nil, nil, fmt.Errorf("formula is nested more than %d deep", maxFormulaDepth)
+	}
+	size := 2
+	if wide {
+		size = 4
+	}
+	if len(data) < size {
+		return nil, nil, fmt.Errorf("formula truncated")
+	}
+	fields, _ := parseIDs(data[:size], wide)
+	data = data[size:]
+	if fields[0] == 0 {
+		return Party(fields[1]), data, nil
+	}
+
+	f := &Formula{K: fields[1], Children: make([]*Formula, fields[0])}
+	for i := range f.Children {
+		var err error
+		if f.Children[i], data, err = parseFormulaNode(data, wide, depth+1); err != nil {
+			return nil, nil, err
+		}
+	}
+	return f, data, nil
+}
+
+// MarshalJSON encodes the formula as its String.
+func (f *Formula) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.String())
+}
+
+// UnmarshalJSON decodes a formula encoded by MarshalJSON.
+func (f *Formula) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := ParseFormula(s, nil)
+	if err != nil {
+		return err
+	}
+	*f = *parsed
+	return nil
+}
+
+// tokenizeFormula splits a formula into parentheses, commas and words.
+func tokenizeFormula(s string) []string {
+	var tokens []string
+	word := -1
+	for i, r := range s {
+		special := r == '(' || r == ')' || r == ','
+		space := r == ' ' || r == '\t' || r == '\n' || r == '\r'
+		if (special || space) && word >= 0 {
+			tokens = append(tokens, s[word:i])
+			word = -1
+		}
+		switch {
+		case special:
+			tokens = append(tokens, string(r))
+		case !space && word < 0:
+			word = i
+		}
+	}
+	if word >= 0 {
+		tokens = append(tokens, s[word:])
+	}
+	return tokens
+}
+
+// formulaParser is a recursive descent parser of formulas.
+type formulaParser struct {
+	tokens  []string
+	pos     int
+	depth   int
+	parties map[string]uint16
+}
+
+func (p *formulaParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *formulaParser) next() string {
+	tok := p.peek()
+	if tok != "" {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *formulaParser) expect(tok string) error {
+	if got := p.next(); got != tok {
+		if got == "" {
+			return fmt.Errorf("formula: expected %q at the end", tok)
+		}
+		return fmt.Errorf("formula: expected %q, got %q", tok, got)
+	}
+	return nil
+}
+
+// or parses terms joined by OR.
+func (p *formulaParser) or() (*Formula, error) {
+	return p.joined("OR", p.and, Or)
+}
+
+// and parses factors joined by AND.
+func (p *formulaParser) and() (*Formula, error) {
+	return p.joined("AND", p.factor, And)
+}
+
+func (p *formulaParser) joined(op string, parse func() (*Formula, error), gate func(...*Formula) *Formula) (*Formula, error) {
+	first, err := parse()
+	if err != nil {
+		return nil, err
+	}
+	children := []*Formula{first}
+	for strings.EqualFold(p.peek(), op) {
+		p.next()
+		child, err := parse()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return gate(children...), nil
+}
+
+// factor parses a parenthesized formula, a k-of gate or a party.
+func (p *formulaParser) factor() (*Formula, error) {
+	tok := p.next()
+	lower := strings.ToLower(tok)
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("formula: unexpected end")
+
+	case tok == "(":
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		f, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		p.depth--
+		return f, p.expect(")")
+
+	case strings.HasSuffix(lower, "-of"):
+		k, err := strconv.ParseUint(strings.TrimSuffix(lower, "-of"), 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("formula: invalid threshold %q", tok)
+		}
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		var children []*Formula
+		for {
+			child, err := p.or()
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, child)
+			if p.peek() != "," {
+				break
+			}
+			p.next()
+		}
+		p.depth--
+		return AtLeast(uint16(k), children...), p.expect(")")
+
+	case tok == ")" || tok == "," || lower == "and" || lower == "or":
+		return nil, fmt.Errorf("formula: unexpected %q", tok)
+	}
+
+	if p.parties == nil {
+		id, err := strconv.ParseUint(tok, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("formula: invalid share ID %q", tok)
+		}
+		return Party(uint16(id)), nil
+	}
+	id, ok := p.parties[tok]
+	if !ok {
+		return nil, fmt.Errorf("formula: unknown party %q", tok)
+	}
+	return Party(id), nil
+}
+
+// enter notes the parser is inside another level of parentheses.
+func (p *formulaParser) enter() error {
+	p.depth++
+	if p.depth >= maxFormulaDepth {
+		return fmt.Errorf("formula is nested more than %d deep", maxFormulaDepth)
+	}
+	return nil
+}
+
+// formulaShare splits K along the formula of A, with coins from a PRF keyed
+// with L. Each gate splits its input with Shamir's scheme into one piece per
+// child with a threshold of K, and each share is the piece of its leaf.
+func formulaShare(A AccessStructure, K, L []byte) ([]*s1SecretShare, error) {
+	if A.Formula == nil {
+		return nil, fmt.Errorf("the formula scheme needs an access structure with a formula")
+	}
+	if err := A.validateFormula(); err != nil {
+		return nil, err
+	}
+
+	shares := make([]*s1SecretShare, A.N)
+	var split func(f *Formula, secret []byte, label string) error
+	split = func(f *Formula, secret []byte, label string) error {
+		if len(f.Children) == 0 {
+			shares[f.ID] = &s1SecretShare{i: f.ID, t: A.T, n: A.N, x: f.ID + 1, secret: secret}
+			return nil
+		}
+		gate := AccessStructure{T: f.K, N: uint16(len(f.Children))}
+		pieces, err := s1Share(gate, secret, L, []byte(label))
+		if err != nil {
+			return err
+		}
+		for i, child := range f.Children {
+			if err := split(child, pieces[i].secret, label+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := split(A.Formula, K, "adss formula"); err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// formulaRecover combines the pieces of the shares up the formula. It fails
+// with ErrUnsatisfiedFormula if the shares don't satisfy it.
+func formulaRecover(shares []*SecretShare) ([]byte, error) {
+	if len(shares) < 1 {
+		return nil, fmt.Errorf("missing argument: shares, was nil or 0 length")
+	}
+	formula := shares[0].As.Formula
+	if formula == nil {
+		return nil, fmt.Errorf("the formula scheme needs an access structure with a formula")
+	}
+	// Shares with IDs out of range are left for checkIDSet to reject.
+	pieces := make([][]byte, shares[0].As.N)
+	for _, share := range shares {
+		if int(share.ID) < len(pieces) {
+			pieces[share.ID] = share.Sec
+		}
+	}
+
+	var combine func(f *Formula) ([]byte, error)
+	combine = func(f *Formula) ([]byte, error) {
+		if len(f.Children) == 0 {
+			return pieces[f.ID], nil
+		}
+		var inputs []*s1SecretShare
+		for i, child := range f.Children {
+			if len(inputs) == int(f.K) {
+				break
+			}
+			piece, err := combine(child)
+			if err != nil {
+				return nil, err
+			}
+			if piece != nil {
+				inputs = append(inputs, &s1SecretShare{t: f.K, n: uint16(len(f.Children)), x: uint16(i + 1), wide: len(f.Children) > 255, secret: piece})
+			}
+		}
+		if len(inputs) < int(f.K) {
+			return nil, nil
+		}
+		return s1Recover(inputs)
+	}
+
+	K, err := combine(formula)
+	if err != nil {
+		return nil, err
+	}
+	if K == nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsatisfiedFormula, sortedShareIDs(shares))
+	}
+	return K, nil
+}
+
+// sortedShareIDs returns the IDs of the shares in increasing order.
+func sortedShareIDs(shares []*SecretShare) IDSet {
+	ids := make(IDSet, len(shares))
+	for i, share := range shares {
+		ids[i] = share.ID
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,286 @@
+package adss
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// policyFormula is "(ceo AND cfo) OR 3-of(eng1, ..., eng5)".
+func policyFormula(t *testing.T) AccessStructure {
+	t.Helper()
+	f, err := ParseFormula("(ceo AND cfo) OR 3-of(eng1, eng2, eng3, eng4, eng5)",
+		[]string{"ceo", "cfo", "eng1", "eng2", "eng3", "eng4", "eng5"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	as, err := NewFormulaAccessStructure(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return as
+}
+
+func TestParseFormula(t *testing.T) {
+	var tests = []struct {
+		in       string
+		expected string
+		t, n     uint16
+	}{
+		{"0 and 1", "0 AND 1", 2, 2},
+		{"0 OR 1 AND 2", "0 OR (1 AND 2)", 1, 3},
+		{"(0 OR 1) AND 2", "(0 OR 1) AND 2", 2, 3},
+		{"2-of(0, 1 and 2, 3)", "2-of(0, 1 AND 2, 3)", 2, 4},
+		{"3-OF(0,1,2)", "0 AND 1 AND 2", 3, 3},
+		{"1-of(0, 1)", "0 OR 1", 1, 2},
+	}
+	for _, tt := range tests {
+		f, err := ParseFormula(tt.in, nil)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tt.in, err)
+			continue
+		}
+		if f.String() != tt.expected {
+			t.Errorf("%q: got %q, expected: %q", tt.in, f.String(), tt.expected)
+		}
+		as, err := NewFormulaAccessStructure(f)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", tt.in, err)
+			continue
+		}
+		if as.T != tt.t || as.N != tt.n {
+			t.Errorf("%q: got %d-of-%d, expected: %d-of-%d", tt.in, as.T, as.N, tt.t, tt.n)
+		}
+		again, err := ParseFormula(f.String(), nil)
+		if err != nil || again.String() != f.String() {
+			t.Errorf("%q: formatted formula didn't parse back: %v", tt.in, err)
+		}
+	}
+
+	as := policyFormula(t)
+	if as.T != 2 || as.N != 7 {
+		t.Errorf("got %d-of-%d, expected: 2-of-7", as.T, as.N)
+	}
+	parties := []string{"ceo", "cfo", "eng1", "eng2", "eng3", "eng4", "eng5"}
+	if s := as.Formula.Format(parties); s != "(ceo AND cfo) OR 3-of(eng1, eng2, eng3, eng4, eng5)" {
+		t.Errorf("got %q", s)
+	}
+
+	var errTests = []struct {
+		in      string
+		parties []string
+	}{
+		{"", nil},
+		{"0 AND", nil},
+		{"(0 OR 1", nil},
+		{"0 1", nil},
+		{"0 AND AND 1", nil},
+		{"x-of(0, 1)", nil},
+		{"65536", nil},
+		{"ceo AND bob", []string{"ceo", "cfo"}},
+		{"ceo AND cfo", []string{"ceo", "ceo"}},
+	}
+	for _, tt := range errTests {
+		if _, err := ParseFormula(tt.in, tt.parties); err == nil {
+			t.Errorf("%q: expected an error", tt.in)
+		}
+	}
+}
+
+func TestNewFormulaAccessStructure_Invalid(t *testing.T) {
+	var tests = []struct {
+		name string
+		f    *Formula
+	}{
+		{"repeated share", Or(Party(0), Party(0))},
+		{"gap in IDs", Or(Party(0), Party(2))},
+		{"one input", And(Party(0))},
+		{"k too large", AtLeast(3, Party(0), Party(1))},
+		{"k zero", AtLeast(0, Party(0), Party(1))},
+	}
+	for _, tt := range tests {
+		if _, err := NewFormulaAccessStructure(tt.f); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+
+	deep := Party(0)
+	for i := 1; i <= maxFormulaDepth; i++ {
+		deep = Or(deep, Party(uint16(i)))
+	}
+	if _, err := NewFormulaAccessStructure(deep); err == nil {
+		t.Errorf("expected an error for a formula nested too deep")
+	}
+
+	as := policyFormula(t)
+	as.T = 3
+	if err := as.validateFormula(); err == nil {
+		t.Errorf("expected an error for a threshold that doesn't match the formula")
+	}
+}
+
+func TestFormula_Satisfied(t *testing.T) {
+	as := policyFormula(t)
+	var tests = []struct {
+		ids      []uint16
+		expected bool
+	}{
+		{[]uint16{0, 1}, true},
+		{[]uint16{2, 4, 6}, true},
+		{[]uint16{0, 2, 3}, false},
+		{[]uint16{1, 5, 6}, false},
+		{[]uint16{0, 1, 2, 3, 4, 5, 6}, true},
+	}
+	for _, tt := range tests {
+		if got := as.Formula.Satisfied(tt.ids); got != tt.expected {
+			t.Errorf("%v: got %t, expected: %t", tt.ids, got, tt.expected)
+		}
+	}
+}
+
+func TestFormula_Encodings(t *testing.T) {
+	as := policyFormula(t)
+	bytes1 := as.Bytes()
+	if bytes1[2] != formulaMarker {
+		t.Errorf("expected the formula marker after the threshold and count, got %x", bytes1)
+	}
+	parsed, err := parseFormulaBytes(as.Formula.appendBytes(nil, false), false)
+	if err != nil || parsed.String() != as.Formula.String() {
+		t.Errorf("formula bytes didn't round trip: %v", err)
+	}
+	if _, err := parseFormulaBytes(as.Formula.appendBytes(nil, false)[:5], false); err == nil {
+		t.Errorf("expected an error for truncated formula bytes")
+	}
+
+	data, err := json.Marshal(as)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var decoded AccessStructure
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !decoded.Equal(as) {
+		t.Errorf("got %s after a JSON round trip", data)
+	}
+
+	other, err := NewFormulaAccessStructure(Or(And(Party(0), Party(2)), AtLeast(3, Party(1), Party(3), Party(4), Party(5), Party(6))))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if other.Equal(as) || bytes.Equal(other.Bytes(), as.Bytes()) {
+		t.Errorf("access structures with different formulas compared equal")
+	}
+	if as.Equal(NewAccessStructure(2, 7)) {
+		t.Errorf("access structure with a formula equal to one without")
+	}
+}
+
+func TestShareWithFormula(t *testing.T) {
+	msg := []byte("hello world")
+	as := policyFormula(t)
+	for _, scheme := range []uint8{SchemeShamir, SchemeReplicated, SchemeFormula, SchemeShamir16} {
+		shares, err := ShareWithScheme(scheme, as, msg, nil)
+		if err != nil {
+			t.Fatalf("scheme %d: unexpected error on sharing: %s", scheme, err)
+		}
+
+		for _, ids := range [][]uint16{{0, 1}, {2, 4, 6}, {1, 3, 4, 5}} {
+			var subset []*SecretShare
+			for _, id := range ids {
+				subset = append(subset, shares[id])
+			}
+			if recov, _, err := Recover(subset); err != nil || !bytes.Equal(recov, msg) {
+				t.Errorf("scheme %d, shares %v: recovered %q, %v", scheme, ids, recov, err)
+			}
+		}
+		for _, ids := range [][]uint16{{0, 2}, {0, 2, 3}, {1, 5, 6}} {
+			var subset []*SecretShare
+			for _, id := range ids {
+				subset = append(subset, shares[id])
+			}
+			// Replicated shares of such a set don't hold every piece, so its
+			// recovery fails before the formula is checked.
+			_, _, err := Recover(subset)
+			if scheme == SchemeReplicated && err == nil || scheme != SchemeReplicated && !errors.Is(err, ErrUnsatisfiedFormula) {
+				t.Errorf("scheme %d, shares %v: expected ErrUnsatisfiedFormula, got %v", scheme, ids, err)
+			}
+		}
+	}
+}
+
+func TestFormulaScheme_Unsatisfied(t *testing.T) {
+	msg := []byte("hello world")
+	as := policyFormula(t)
+	shares, err := ShareWithScheme(SchemeFormula, as, msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	shamir, err := Share(as, msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if len(shares[0].Sec) != len(shamir[0].Sec) {
+		t.Errorf("got formula shares of %d bytes, expected: %d like Shamir shares", len(shares[0].Sec), len(shamir[0].Sec))
+	}
+
+	// Sidestepping the access structure check, two engineers hold too little
+	// to recover the key.
+	if _, err := formulaRecover([]*SecretShare{shares[2], shares[3]}); !errors.Is(err, ErrUnsatisfiedFormula) {
+		t.Errorf("expected ErrUnsatisfiedFormula, got %v", err)
+	}
+	if _, err := ShareWithScheme(SchemeFormula, NewAccessStructure(2, 3), msg, nil); err == nil {
+		t.Errorf("expected an error for the formula scheme without a formula")
+	}
+
+	// Changing the formula of a share changes the access structure its
+	// commitment binds.
+	tampered := *shares[2]
+	tampered.As = tampered.As.clone()
+	tampered.As.Formula.Children[0].Children[0].ID, tampered.As.Formula.Children[1].Children[0].ID = 2, 0
+	if _, _, err := Recover([]*SecretShare{&tampered, shares[3], shares[4]}); err == nil {
+		t.Errorf("recovered with a tampered formula")
+	}
+}
+
+func TestFormulaShareEncodings(t *testing.T) {
+	as := policyFormula(t)
+	shares, err := ShareWithScheme(SchemeFormula, as, []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	share := shares[3]
+
+	parsed, err := DecodeShareString(EncodeShareString(share))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !parsed.As.Equal(as) || parsed.Scheme != SchemeFormula {
+		t.Errorf("share string didn't round trip the formula")
+	}
+
+	data, err := share.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var fromCBOR SecretShare
+	if err := fromCBOR.UnmarshalCBOR(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !fromCBOR.As.Equal(as) {
+		t.Errorf("CBOR didn't round trip the formula")
+	}
+
+	data, err = share.MarshalProto()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var fromProto SecretShare
+	if err := fromProto.UnmarshalProto(data); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !fromProto.As.Equal(as) {
+		t.Errorf("proto didn't round trip the formula")
+	}
+}
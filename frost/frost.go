@@ -0,0 +1,362 @@
+// Package frost converts an ADSS sharing of an Ed25519 seed into FROST
+// threshold signing shares and implements the two round FROST signing
+// protocol over them. Aggregated signatures are standard Ed25519 signatures
+// that verify with crypto/ed25519.
+//
+// This bridges cold-storage sharing with adss and day-to-day threshold
+// signing: the seed is recovered once by a dealer, converted into signing
+// shares, and from then on the signing key is never reconstructed.
+package frost
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha512"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"filippo.io/edwards25519"
+
+	"github.com/jakecraige/adss"
+	"github.com/jakecraige/adss/internal/primeshamir"
+)
+
+// order is the prime order l of the Ed25519 base point.
+var order, _ = new(big.Int).SetString("7237005577332262213973186563042994240857116359379907606001950938285454250989", 10)
+
+// GroupKey is the public information about a FROST sharing needed to verify
+// signature shares and aggregate them.
+type GroupKey struct {
+	T, N      uint8
+	PublicKey ed25519.PublicKey
+
+	// VerificationShares holds the public key of each signing share, indexed
+	// by ID.
+	VerificationShares [][]byte
+}
+
+// SigningShare is a holder's share of the Ed25519 signing scalar.
+type SigningShare struct {
+	T, N, ID  uint8
+	PublicKey ed25519.PublicKey
+	s         *edwards25519.Scalar
+}
+
+// Nonce is the secret half of a round one commitment. It must only be used to
+// produce a single signature share.
+type Nonce struct {
+	ID   uint8
+	d, e *edwards25519.Scalar
+	used bool
+}
+
+// Commitment is the public half of a round one commitment which is sent to
+// the other signers.
+type Commitment struct {
+	ID   uint8
+	D, E []byte
+}
+
+// SignatureShare is a signer's round two output.
+type SignatureShare struct {
+	ID uint8
+	Z  []byte
+}
+
+// SplitADSS recovers an Ed25519 seed from an ADSS sharing and converts it into
+// a t-of-n FROST sharing. The seed only exists in memory for the duration of
+// the call.
+func SplitADSS(shares []*adss.SecretShare, t, n uint8, rnd io.Reader) (*GroupKey, []*SigningShare, error) {
+	seed, _, err := adss.Recover(shares)
+	if err != nil {
+		return nil, nil, fmt.Errorf("recovering seed: %w", err)
+	}
+	defer zero(seed)
+
+	return SplitSeed(seed, t, n, rnd)
+}
+
+// SplitSeed converts an Ed25519 seed into a t-of-n FROST sharing. Randomness
+// is read from rnd, or crypto/rand if it is nil.
+func SplitSeed(seed []byte, t, n uint8, rnd io.Reader) (*GroupKey, []*SigningShare, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, nil, fmt.Errorf("invalid seed length: %d, expected: %d", len(seed), ed25519.SeedSize)
+	}
+	if t < 2 || t > n {
+		return nil, nil, fmt.Errorf("invalid threshold %d-of-%d", t, n)
+	}
+
+	// Derive the signing scalar the same way crypto/ed25519 does.
+	h := sha512.Sum512(seed)
+	s, err := edwards25519.NewScalar().SetBytesWithClamping(h[:32])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pub := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+	shares, _, err := primeshamir.Split(scalarToInt(s), t, n, order, rnd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	gk := &GroupKey{T: t, N: n, PublicKey: pub, VerificationShares: make([][]byte, n)}
+	signingShares := make([]*SigningShare, n)
+	for i, share := range shares {
+		si := intToScalar(share.Value)
+		signingShares[i] = &SigningShare{T: t, N: n, ID: share.ID, PublicKey: pub, s: si}
+		gk.VerificationShares[i] = new(edwards25519.Point).ScalarBaseMult(si).Bytes()
+	}
+
+	return gk, signingShares, nil
+}
+
+// Commit performs round one of signing, returning a nonce to keep secret and
+// a commitment to send to the other signers. Randomness is read from rnd, or
+// crypto/rand if it is nil.
+func (ss *SigningShare) Commit(rnd io.Reader) (*Nonce, *Commitment, error) {
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+
+	d, err := randomScalar(rnd)
+	if err != nil {
+		return nil, nil, err
+	}
+	e, err := randomScalar(rnd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce := &Nonce{ID: ss.ID, d: d, e: e}
+	commitment := &Commitment{
+		ID: ss.ID,
+		D:  new(edwards25519.Point).ScalarBaseMult(d).Bytes(),
+		E:  new(edwards25519.Point).ScalarBaseMult(e).Bytes(),
+	}
+	return nonce, commitment, nil
+}
+
+// Sign performs round two of signing, producing this holder's signature share
+// of msg. commitments must contain the round one commitments of every signer,
+// including this one. The nonce is consumed and cannot be used again.
+func (ss *SigningShare) Sign(msg []byte, nonce *Nonce, commitments []*Commitment) (*SignatureShare, error) {
+	if nonce.used {
+		return nil, fmt.Errorf("nonce has already been used")
+	}
+	if nonce.ID != ss.ID {
+		return nil, fmt.Errorf("nonce belongs to share %d, not %d", nonce.ID, ss.ID)
+	}
+
+	sc, err := newSigningContext(ss.PublicKey, ss.T, msg, commitments)
+	if err != nil {
+		return nil, err
+	}
+
+	idx, ok := sc.index[ss.ID]
+	if !ok {
+		return nil, fmt.Errorf("commitments do not include share %d", ss.ID)
+	}
+
+	// Mark the nonce as used before it is, so an error can never lead to reuse.
+	nonce.used = true
+	defer func() { nonce.d, nonce.e = nil, nil }()
+
+	// z = d + e*rho + lambda*s*c
+	z := edwards25519.NewScalar().Multiply(nonce.e, sc.rho[idx])
+	z.Add(z, nonce.d)
+	lc := edwards25519.NewScalar().Multiply(sc.lambda[idx], sc.c)
+	z.MultiplyAdd(lc, ss.s, z)
+
+	return &SignatureShare{ID: ss.ID, Z: z.Bytes()}, nil
+}
+
+// Aggregate verifies each signature share and combines them into an Ed25519
+// signature of msg. commitments must be the same set the signers used.
+func (gk *GroupKey) Aggregate(msg []byte, commitments []*Commitment, sigShares []*SignatureShare) ([]byte, error) {
+	sc, err := newSigningContext(gk.PublicKey, gk.T, msg, commitments)
+	if err != nil {
+		return nil, err
+	}
+	if len(sigShares) != len(commitments) {
+		return nil, fmt.Errorf("got %d signature shares for %d commitments", len(sigShares), len(commitments))
+	}
+
+	z := edwards25519.NewScalar()
+	seen := make(map[uint8]bool)
+	for _, share := range sigShares {
+		idx, ok := sc.index[share.ID]
+		if !ok || seen[share.ID] {
+			return nil, fmt.Errorf("unexpected signature share from %d", share.ID)
+		}
+		seen[share.ID] = true
+
+		zi, err := edwards25519.NewScalar().SetCanonicalBytes(share.Z)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signature share from %d: %w", share.ID, err)
+		}
+
+		if err := gk.verifyShare(sc, idx, zi); err != nil {
+			return nil, err
+		}
+		z.Add(z, zi)
+	}
+
+	return append(sc.R.Bytes(), z.Bytes()...), nil
+}
+
+// verifyShare checks z_i*B == D_i + rho_i*E_i + (c*lambda_i)*Y_i.
+func (gk *GroupKey) verifyShare(sc *signingContext, idx int, zi *edwards25519.Scalar) error {
+	id := sc.commitments[idx].ID
+	if int(id) >= len(gk.VerificationShares) {
+		return fmt.Errorf("signature share ID out of range: %d", id)
+	}
+
+	Y, err := new(edwards25519.Point).SetBytes(gk.VerificationShares[id])
+	if err != nil {
+		return fmt.Errorf("invalid verification share %d: %w", id, err)
+	}
+
+	lhs := new(edwards25519.Point).ScalarBaseMult(zi)
+	rhs := new(edwards25519.Point).ScalarMult(sc.rho[idx], sc.E[idx])
+	rhs.Add(rhs, sc.D[idx])
+	cl := edwards25519.NewScalar().Multiply(sc.c, sc.lambda[idx])
+	rhs.Add(rhs, new(edwards25519.Point).ScalarMult(cl, Y))
+
+	if lhs.Equal(rhs) != 1 {
+		return fmt.Errorf("invalid signature share from %d", id)
+	}
+	return nil
+}
+
+// signingContext holds the values derived from the message and commitments
+// that every signer and the aggregator compute identically.
+type signingContext struct {
+	commitments []*Commitment
+	index       map[uint8]int
+	D, E        []*edwards25519.Point
+	rho         []*edwards25519.Scalar
+	lambda      []*edwards25519.Scalar
+	R           *edwards25519.Point
+	c           *edwards25519.Scalar
+}
+
+func newSigningContext(pub ed25519.PublicKey, t uint8, msg []byte, commitments []*Commitment) (*signingContext, error) {
+	if len(commitments) < int(t) {
+		return nil, fmt.Errorf("not enough commitments provided, got: %d, need: %d", len(commitments), t)
+	}
+
+	sorted := make([]*Commitment, len(commitments))
+	copy(sorted, commitments)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	sc := &signingContext{
+		commitments: sorted,
+		index:       make(map[uint8]int),
+		D:           make([]*edwards25519.Point, len(sorted)),
+		E:           make([]*edwards25519.Point, len(sorted)),
+		rho:         make([]*edwards25519.Scalar, len(sorted)),
+		lambda:      make([]*edwards25519.Scalar, len(sorted)),
+		R:           edwards25519.NewIdentityPoint(),
+	}
+
+	ids := make([]uint8, len(sorted))
+	encoded := make([]byte, 0)
+	for i, c := range sorted {
+		if _, ok := sc.index[c.ID]; ok {
+			return nil, fmt.Errorf("duplicate commitment from %d", c.ID)
+		}
+		sc.index[c.ID] = i
+		ids[i] = c.ID
+
+		var err error
+		if sc.D[i], err = new(edwards25519.Point).SetBytes(c.D); err != nil {
+			return nil, fmt.Errorf("invalid commitment from %d: %w", c.ID, err)
+		}
+		if sc.E[i], err = new(edwards25519.Point).SetBytes(c.E); err != nil {
+			return nil, fmt.Errorf("invalid commitment from %d: %w", c.ID, err)
+		}
+
+		encoded = append(encoded, c.ID)
+		encoded = append(encoded, c.D...)
+		encoded = append(encoded, c.E...)
+	}
+
+	msgHash := sha512.Sum512(msg)
+	commitmentsHash := sha512.Sum512(encoded)
+	for i, c := range sorted {
+		h := sha512.New()
+		h.Write([]byte("adss FROST-ED25519-SHA512 rho"))
+		h.Write(pub)
+		h.Write(msgHash[:])
+		h.Write(commitmentsHash[:])
+		h.Write([]byte{c.ID})
+		rho, err := edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+		if err != nil {
+			return nil, err
+		}
+		sc.rho[i] = rho
+
+		lambda, err := primeshamir.LagrangeCoefficient(c.ID, ids, order)
+		if err != nil {
+			return nil, err
+		}
+		sc.lambda[i] = intToScalar(lambda)
+
+		sc.R.Add(sc.R, sc.D[i])
+		sc.R.Add(sc.R, new(edwards25519.Point).ScalarMult(rho, sc.E[i]))
+	}
+
+	// The challenge is computed exactly as in Ed25519 so the aggregated
+	// signature verifies with a standard verifier.
+	h := sha512.New()
+	h.Write(sc.R.Bytes())
+	h.Write(pub)
+	h.Write(msg)
+	c, err := edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	sc.c = c
+
+	return sc, nil
+}
+
+func randomScalar(rnd io.Reader) (*edwards25519.Scalar, error) {
+	var buf [64]byte
+	if _, err := io.ReadFull(rnd, buf[:]); err != nil {
+		return nil, err
+	}
+	return edwards25519.NewScalar().SetUniformBytes(buf[:])
+}
+
+// scalarToInt converts a little-endian scalar to a big.Int.
+func scalarToInt(s *edwards25519.Scalar) *big.Int {
+	b := s.Bytes()
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+// intToScalar converts a big.Int in [0, l) to a scalar.
+func intToScalar(x *big.Int) *edwards25519.Scalar {
+	b := make([]byte, 32)
+	be := x.Bytes()
+	for i := range be {
+		b[i] = be[len(be)-1-i]
+	}
+
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(b)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
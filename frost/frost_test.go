@@ -0,0 +1,74 @@
+package frost
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+
+	"github.com/jakecraige/adss"
+)
+
+func TestSplitADSSAndSign(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		t.Fatal(err)
+	}
+
+	coldShares, err := adss.Share(adss.NewAccessStructure(2, 3), seed, []byte("cold storage"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	gk, shares, err := SplitADSS(coldShares[:2], 3, 5, nil)
+	if err != nil {
+		t.Fatalf("unexpected error converting: %s", err)
+	}
+
+	expectedPub := ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)
+	if !bytes.Equal(expectedPub, gk.PublicKey) {
+		t.Fatalf("group public key does not match seed")
+	}
+
+	msg := []byte("hello world")
+	signers := []*SigningShare{shares[4], shares[1], shares[2]}
+	nonces := make([]*Nonce, len(signers))
+	commitments := make([]*Commitment, len(signers))
+	for i, signer := range signers {
+		nonces[i], commitments[i], err = signer.Commit(nil)
+		if err != nil {
+			t.Fatalf("unexpected error committing: %s", err)
+		}
+	}
+
+	sigShares := make([]*SignatureShare, len(signers))
+	for i, signer := range signers {
+		sigShares[i], err = signer.Sign(msg, nonces[i], commitments)
+		if err != nil {
+			t.Fatalf("unexpected error signing: %s", err)
+		}
+	}
+
+	sig, err := gk.Aggregate(msg, commitments, sigShares)
+	if err != nil {
+		t.Fatalf("unexpected error aggregating: %s", err)
+	}
+
+	if !ed25519.Verify(gk.PublicKey, msg, sig) {
+		t.Errorf("aggregated signature did not verify")
+	}
+
+	if _, err := signers[0].Sign(msg, nonces[0], commitments); err == nil {
+		t.Errorf("expected error reusing a nonce")
+	}
+
+	// A corrupted signature share is detected during aggregation.
+	bad := &SignatureShare{ID: sigShares[0].ID, Z: sigShares[1].Z}
+	if _, err := gk.Aggregate(msg, commitments, []*SignatureShare{bad, sigShares[1], sigShares[2]}); err == nil {
+		t.Errorf("expected error aggregating a bad signature share")
+	}
+
+	if _, err := gk.Aggregate(msg, commitments[:2], sigShares[:2]); err == nil {
+		t.Errorf("expected error aggregating too few shares")
+	}
+}
@@ -0,0 +1,166 @@
+//go:build go1.18
+// +build go1.18
+
+package adss
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// fuzzShares returns the encodings of a sharing for seeding the corpus.
+func fuzzShares(f *testing.F) []*SecretShare {
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), []byte("ad"))
+	if err != nil {
+		f.Fatalf("unexpected error on sharing: %s", err)
+	}
+	return shares
+}
+
+// FuzzDecodeShare feeds mutated share encodings to every decoder and recovers
+// with what decodes. None of them may panic.
+func FuzzDecodeShare(f *testing.F) {
+	shares := fuzzShares(f)
+	for _, share := range shares {
+		data, err := json.Marshal(share)
+		if err != nil {
+			f.Fatalf("unexpected error: %s", err)
+		}
+		f.Add(data)
+		f.Add([]byte(EncodeShareString(share)))
+		f.Add([]byte(EncodeShareDigits(share)))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var decoded []*SecretShare
+		var share SecretShare
+		if json.Unmarshal(data, &share) == nil {
+			decoded = append(decoded, &share)
+		}
+		if share, err := DecodeShareString(string(data)); err == nil {
+			decoded = append(decoded, share)
+		}
+		if share, err := DecodeShareDigits(string(data)); err == nil {
+			decoded = append(decoded, share)
+		}
+		ParseTag(data)
+
+		for _, share := range decoded {
+			Recover([]*SecretShare{share})
+			Recover([]*SecretShare{share, shares[1], shares[2]})
+			share.Public().Fingerprint()
+			share.ProvePossession(nil)
+		}
+	})
+}
+
+// fuzzMessage is the message of every sharing in the FuzzRecover corpus.
+var fuzzMessage = []byte("hello world")
+
+// fuzzSharings returns sharings of fuzzMessage covering the optional fields
+// of shares: hardening, a domain, the replicated and formula schemes,
+// evaluation points and an access structure of more than 255 shares.
+func fuzzSharings(f *testing.F) [][]*SecretShare {
+	formula, err := ParseFormula("(a AND b) OR 2-of(c, d, e)", []string{"a", "b", "c", "d", "e"})
+	if err != nil {
+		f.Fatalf("unexpected error: %s", err)
+	}
+	formulaAs, err := NewFormulaAccessStructure(formula)
+	if err != nil {
+		f.Fatalf("unexpected error: %s", err)
+	}
+	mandatory, err := NewAccessStructure(2, 4).WithMandatory(0)
+	if err != nil {
+		f.Fatalf("unexpected error: %s", err)
+	}
+
+	var sharings [][]*SecretShare
+	for _, share := range []func() ([]*SecretShare, error){
+		func() ([]*SecretShare, error) { return Share(NewAccessStructure(2, 3), fuzzMessage, []byte("ad")) },
+		func() ([]*SecretShare, error) {
+			return ShareHardened(NewAccessStructure(2, 3), fuzzMessage, nil, testArgon2Params)
+		},
+		func() ([]*SecretShare, error) {
+			return ShareInDomain("example.com", NewAccessStructure(2, 3), fuzzMessage, nil)
+		},
+		func() ([]*SecretShare, error) { return ShareWithScheme(SchemeReplicated, mandatory, fuzzMessage, nil) },
+		func() ([]*SecretShare, error) { return ShareWithScheme(SchemeFormula, formulaAs, fuzzMessage, nil) },
+		func() ([]*SecretShare, error) {
+			return ShareWithScheme(SchemeShamir16, NewAccessStructure(2, 3), fuzzMessage, nil)
+		},
+		func() ([]*SecretShare, error) {
+			return ShareWithPoints([]uint8{7, 3, 250}, NewAccessStructure(2, 3), fuzzMessage, nil)
+		},
+		func() ([]*SecretShare, error) { return Share(NewAccessStructure(2, 300), fuzzMessage, nil) },
+	} {
+		shares, err := share()
+		if err != nil {
+			f.Fatalf("unexpected error on sharing: %s", err)
+		}
+		sharings = append(sharings, shares)
+	}
+	return sharings
+}
+
+// fuzzEncodeShares joins the binary encodings of the shares, each prefixed by
+// its length as two bytes, for fuzzDecodeShares.
+func fuzzEncodeShares(shares ...*SecretShare) []byte {
+	var out []byte
+	for _, share := range shares {
+		data := share.Bytes()
+		out = append(out, byte(len(data)>>8), byte(len(data)))
+		out = append(out, data...)
+	}
+	return out
+}
+
+// fuzzDecodeShares splits data into length-prefixed binary shares and returns
+// the ones that parse.
+func fuzzDecodeShares(data []byte) []*SecretShare {
+	var shares []*SecretShare
+	for len(data) >= 2 {
+		length := int(data[0])<<8 | int(data[1])
+		data = data[2:]
+		if length > len(data) {
+			length = len(data)
+		}
+		if share, err := ParseSecretShare(data[:length]); err == nil {
+			shares = append(shares, share)
+		}
+		data = data[length:]
+	}
+	return shares
+}
+
+// FuzzRecover decodes sets of binary shares from the fuzzer's input and
+// recovers from them, with and without uniform work. It may fail but must not
+// panic, and any secret it returns must be the one that was shared.
+func FuzzRecover(f *testing.F) {
+	for _, shares := range fuzzSharings(f) {
+		f.Add(fuzzEncodeShares(shares[0], shares[1]))
+		f.Add(fuzzEncodeShares(shares[len(shares)-1], shares[0], shares[1]))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		shares := fuzzDecodeShares(data)
+		// Recovery tries every subset of the shares, and the hardening
+		// parameters within the limits can take seconds, which is correct
+		// but too slow to fuzz.
+		if len(shares) == 0 || len(shares) > 8 {
+			return
+		}
+		for _, share := range shares {
+			if h := share.Hardening; h != nil && h.validate() == nil && (h.Time > 2 || h.Memory > 1024) {
+				return
+			}
+		}
+
+		for _, opts := range [][]RecoverOption{nil, {WithUniformWork()}} {
+			M, _, err := Recover(shares, opts...)
+			if err == nil && !bytes.Equal(M, fuzzMessage) {
+				t.Fatalf("recovered %q from corrupted shares", M)
+			}
+		}
+	})
+}
@@ -1,5 +1,9 @@
 module github.com/jakecraige/adss
 
-require golang.org/x/crypto v0.0.0-20200117160349-530e935923ad
+require (
+	filippo.io/edwards25519 v1.0.0
+	golang.org/x/crypto v0.0.0-20200117160349-530e935923ad
+	gopkg.in/yaml.v2 v2.4.0
+)
 
 go 1.14
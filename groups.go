@@ -0,0 +1,59 @@
+package adss
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// ShareGroups shares the message under several access structures at once,
+// such as a 2-of-3 executive group and a 4-of-7 engineering group, returning
+// the shares of each group in the same order. A quorum of any group can
+// recover the message. Every group is created with the same coins, so once
+// one group has been recovered VerifySameSecret proves that a share of
+// another group holds the same secret.
+func ShareGroups(structures []AccessStructure, M, T []byte) ([][]*SecretShare, error) {
+	for i, A := range structures {
+		for _, other := range structures[:i] {
+			if A.Equal(other) {
+				return nil, fmt.Errorf("duplicate access structure %d-of-%d", A.T, A.N)
+			}
+		}
+	}
+
+	R := make([]byte, 32)
+	if _, err := rand.Read(R); err != nil {
+		return nil, err
+	}
+
+	groups := make([][]*SecretShare, len(structures))
+	for i, A := range structures {
+		var err error
+		groups[i], err = internalShare(A, M, R, T, newShareParams())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return groups, nil
+}
+
+// VerifySameSecret returns an error unless share was created by ShareGroups
+// in the same call as the valid shares V returned by Recover, which proves
+// that share's group holds the same secret.
+func VerifySameSecret(V []*SecretShare, share *SecretShare) error {
+	if len(V) == 0 {
+		return fmt.Errorf("no valid shares")
+	}
+
+	M, R, err := recoverInputs(V)
+	if err != nil {
+		return err
+	}
+	if !V[0].VerifyCommitment(M, R) {
+		return fmt.Errorf("shares don't explain a sharing")
+	}
+
+	if !share.VerifyCommitment(M, R) {
+		return fmt.Errorf("share %d doesn't hold the same secret", share.ID)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package adss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestShareGroups(t *testing.T) {
+	msg := []byte("hello world")
+	structures := []AccessStructure{NewAccessStructure(2, 3), NewAccessStructure(4, 7)}
+	groups, err := ShareGroups(structures, msg, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if len(groups) != 2 || len(groups[0]) != 3 || len(groups[1]) != 7 {
+		t.Fatalf("unexpected group sizes")
+	}
+
+	execs, V, err := Recover(groups[0][1:])
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	engineers, _, err := Recover(groups[1][:4])
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(execs, msg) || !bytes.Equal(engineers, msg) {
+		t.Errorf("recovered %x and %x, expected: %x", execs, engineers, msg)
+	}
+
+	// Each group is its own sharing.
+	if groups[0][0].Fingerprint() == groups[1][0].Fingerprint() {
+		t.Errorf("groups have the same fingerprint")
+	}
+
+	for _, share := range groups[1] {
+		if err := VerifySameSecret(V, share); err != nil {
+			t.Errorf("unexpected error verifying: %s", err)
+		}
+	}
+
+	other, err := Share(NewAccessStructure(4, 7), msg, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if err := VerifySameSecret(V, other[0]); err == nil {
+		t.Errorf("expected error for a share of an independent sharing")
+	}
+
+	if _, err := ShareGroups([]AccessStructure{structures[0], structures[0]}, msg, nil); err == nil {
+		t.Errorf("expected error for duplicate access structures")
+	}
+}
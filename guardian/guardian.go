@@ -0,0 +1,221 @@
+// Package guardian implements social recovery: the owner of a secret, such as
+// a wallet seed, splits it between guardians they trust, and if they lose it
+// they ask the guardians for help and recover it from enough of their
+// answers.
+//
+// A Set is the published state of the sharing: who the guardians are and a
+// sealed bundle with each guardian's share encrypted to their key. Adding or
+// removing a guardian reshares the secret so a removed guardian's share is
+// useless with the new ones. Recovery sends a Request to the guardians, each
+// answers with their share encrypted to a key only the Recovery has, and the
+// secret is recovered once enough answers are in.
+package guardian
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/jakecraige/adss"
+)
+
+// Guardian is someone trusted to help recover the secret.
+type Guardian struct {
+	Name string
+	// PublicKey is the guardian's X25519 key, from adss.GenerateEnvelopeKey.
+	PublicKey *[32]byte
+}
+
+// Set is the guardians of a secret and their encrypted shares. It contains no
+// secret material that the guardians can't already access so it can be
+// published or stored by each guardian.
+type Set struct {
+	Threshold uint16
+	Guardians []Guardian
+	Bundle    *adss.SealedBundle
+	// Epoch is incremented each time the guardians change.
+	Epoch int
+}
+
+// NewSet splits the secret between the guardians so that any threshold of
+// them can help recover it.
+func NewSet(secret []byte, threshold uint16, guardians []Guardian) (*Set, error) {
+	s := &Set{Threshold: threshold}
+	if err := s.reshare(secret, guardians); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Set) reshare(secret []byte, guardians []Guardian) error {
+	if len(guardians) > 0xffff {
+		return fmt.Errorf("too many guardians: %d", len(guardians))
+	}
+	if s.Threshold == 0 || int(s.Threshold) > len(guardians) {
+		return fmt.Errorf("threshold %d needs between 1 and %d guardians", s.Threshold, len(guardians))
+	}
+
+	names := make([]string, len(guardians))
+	keys := make([]*[32]byte, len(guardians))
+	seen := make(map[string]bool, len(guardians))
+	for i, g := range guardians {
+		if seen[g.Name] {
+			return fmt.Errorf("duplicate guardian: %s", g.Name)
+		}
+		seen[g.Name] = true
+		names[i], keys[i] = g.Name, g.PublicKey
+	}
+
+	shares, err := adss.Share(adss.NewAccessStructure(s.Threshold, uint16(len(guardians))), secret, nil)
+	if err != nil {
+		return err
+	}
+	bundle, err := adss.SealShares(shares, keys, names)
+	if err != nil {
+		return err
+	}
+
+	s.Guardians = guardians
+	s.Bundle = bundle
+	return nil
+}
+
+// AddGuardian reshares the secret to include the guardian. The secret is
+// needed since the existing shares are replaced.
+func (s *Set) AddGuardian(secret []byte, g Guardian) error {
+	guardians := append(append([]Guardian{}, s.Guardians...), g)
+	if err := s.reshare(secret, guardians); err != nil {
+		return err
+	}
+	s.Epoch++
+	return nil
+}
+
+// RemoveGuardian reshares the secret without the named guardian, whose share
+// then can't be combined with the new ones.
+func (s *Set) RemoveGuardian(secret []byte, name string) error {
+	var guardians []Guardian
+	for _, g := range s.Guardians {
+		if g.Name != name {
+			guardians = append(guardians, g)
+		}
+	}
+	if len(guardians) == len(s.Guardians) {
+		return fmt.Errorf("unknown guardian: %s", name)
+	}
+
+	if err := s.reshare(secret, guardians); err != nil {
+		return err
+	}
+	s.Epoch++
+	return nil
+}
+
+// Request asks the guardians for help recovering. It is sent to them over
+// whatever channel the application uses.
+type Request struct {
+	Fingerprint string
+	// RecipientKey is the X25519 key the guardians encrypt their shares to.
+	RecipientKey *[32]byte
+}
+
+// Response is a guardian's answer to a Request: their share encrypted to the
+// request's RecipientKey.
+type Response struct {
+	Guardian string
+	Bundle   *adss.SealedBundle
+}
+
+// Respond opens the guardian's share with their key pair and encrypts it to
+// the requester. The guardian should confirm the request really comes from
+// the owner, out of band, before responding.
+func (s *Set) Respond(req *Request, publicKey, privateKey *[32]byte) (*Response, error) {
+	if req.Fingerprint != s.Bundle.Fingerprint {
+		return nil, fmt.Errorf("request is for sharing %s, not %s", req.Fingerprint, s.Bundle.Fingerprint)
+	}
+
+	name := ""
+	for _, sealed := range s.Bundle.Shares {
+		if bytes.Equal(sealed.Recipient, publicKey[:]) {
+			name = sealed.Holder
+		}
+	}
+	share, err := s.Bundle.Open(publicKey, privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle, err := adss.SealShares([]*adss.SecretShare{share}, []*[32]byte{req.RecipientKey}, []string{name})
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Guardian: name, Bundle: bundle}, nil
+}
+
+// Recovery collects the guardians' responses.
+type Recovery struct {
+	set         *Set
+	pub, priv   *[32]byte
+	accumulator *adss.Accumulator
+	responded   map[string]bool
+}
+
+// NewRecovery starts recovering the secret protected by the set.
+func NewRecovery(s *Set) (*Recovery, error) {
+	pub, priv, err := adss.GenerateEnvelopeKey()
+	if err != nil {
+		return nil, err
+	}
+	return &Recovery{
+		set:         s,
+		pub:         pub,
+		priv:        priv,
+		accumulator: adss.NewAccumulator(),
+		responded:   make(map[string]bool),
+	}, nil
+}
+
+// Request returns the request to send to the guardians.
+func (r *Recovery) Request() *Request {
+	return &Request{Fingerprint: r.set.Bundle.Fingerprint, RecipientKey: r.pub}
+}
+
+// Add records a guardian's response and returns how close the recovery is.
+func (r *Recovery) Add(resp *Response) (adss.Progress, error) {
+	if r.responded[resp.Guardian] {
+		return r.accumulator.Progress(), fmt.Errorf("%s already responded", resp.Guardian)
+	}
+
+	share, err := resp.Bundle.Open(r.pub, r.priv)
+	if err != nil {
+		return r.accumulator.Progress(), fmt.Errorf("response from %s: %w", resp.Guardian, err)
+	}
+	if share.Fingerprint() != r.set.Bundle.Fingerprint {
+		return r.accumulator.Progress(), fmt.Errorf("response from %s is for a different sharing", resp.Guardian)
+	}
+	if !r.set.holds(resp.Guardian, share.ID) {
+		return r.accumulator.Progress(), fmt.Errorf("response from %s has another guardian's share", resp.Guardian)
+	}
+
+	progress, err := r.accumulator.Add(share)
+	if err != nil {
+		return progress, fmt.Errorf("response from %s: %w", resp.Guardian, err)
+	}
+	r.responded[resp.Guardian] = true
+	return progress, nil
+}
+
+// Secret returns the recovered secret once enough guardians have responded.
+func (r *Recovery) Secret() ([]byte, error) {
+	secret, _, err := r.accumulator.Secret()
+	return secret, err
+}
+
+// holds reports whether the named guardian was given the share with the ID.
+func (s *Set) holds(name string, id uint16) bool {
+	for _, sealed := range s.Bundle.Shares {
+		if sealed.Holder == name {
+			return sealed.ID == id
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package guardian
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/jakecraige/adss"
+)
+
+type keyPair struct {
+	pub, priv *[32]byte
+}
+
+func newGuardians(t *testing.T, names ...string) ([]Guardian, map[string]keyPair) {
+	var guardians []Guardian
+	keys := make(map[string]keyPair)
+	for _, name := range names {
+		pub, priv, err := adss.GenerateEnvelopeKey()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		guardians = append(guardians, Guardian{Name: name, PublicKey: pub})
+		keys[name] = keyPair{pub, priv}
+	}
+	return guardians, keys
+}
+
+// recoverFrom runs a recovery with responses from the named guardians.
+func recoverFrom(t *testing.T, s *Set, keys map[string]keyPair, names ...string) ([]byte, error) {
+	t.Helper()
+	r, err := NewRecovery(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	req := r.Request()
+	for _, name := range names {
+		resp, err := s.Respond(req, keys[name].pub, keys[name].priv)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := r.Add(resp); err != nil {
+			return nil, err
+		}
+	}
+	return r.Secret()
+}
+
+func TestGuardians(t *testing.T) {
+	secret := []byte("wallet seed")
+	guardians, keys := newGuardians(t, "alice", "bob", "carol", "dave")
+
+	s, err := NewSet(secret, 2, guardians[:3])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The set is published as JSON.
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	s = new(Set)
+	if err := json.Unmarshal(data, s); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	recov, err := recoverFrom(t, s, keys, "alice", "carol")
+	if err != nil {
+		t.Fatalf("unexpected error recovering: %s", err)
+	}
+	if !bytes.Equal(recov, secret) {
+		t.Errorf("recovered %x != %x", recov, secret)
+	}
+	if _, err := recoverFrom(t, s, keys, "alice"); err == nil {
+		t.Errorf("expected error recovering from one guardian")
+	}
+
+	old := *s
+	if err := s.AddGuardian(secret, guardians[3]); err != nil {
+		t.Fatalf("unexpected error adding guardian: %s", err)
+	}
+	if err := s.RemoveGuardian(secret, "alice"); err != nil {
+		t.Fatalf("unexpected error removing guardian: %s", err)
+	}
+	if s.Epoch != 2 || len(s.Guardians) != 3 {
+		t.Errorf("unexpected set after changes: epoch %d, %d guardians", s.Epoch, len(s.Guardians))
+	}
+
+	recov, err = recoverFrom(t, s, keys, "bob", "dave")
+	if err != nil {
+		t.Fatalf("unexpected error recovering: %s", err)
+	}
+	if !bytes.Equal(recov, secret) {
+		t.Errorf("recovered %x != %x", recov, secret)
+	}
+	if _, err := s.Respond(&Request{Fingerprint: s.Bundle.Fingerprint, RecipientKey: keys["alice"].pub}, keys["alice"].pub, keys["alice"].priv); err == nil {
+		t.Errorf("expected error responding as a removed guardian")
+	}
+
+	// Alice's share from before she was removed can't help recover.
+	r, err := NewRecovery(s)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	stale, err := old.Respond(&Request{Fingerprint: old.Bundle.Fingerprint, RecipientKey: r.Request().RecipientKey}, keys["alice"].pub, keys["alice"].priv)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := r.Add(stale); err == nil {
+		t.Errorf("expected error for a share from before the reshare")
+	}
+
+	if err := s.RemoveGuardian(secret, "bob"); err != nil {
+		t.Fatalf("unexpected error removing guardian: %s", err)
+	}
+	if err := s.RemoveGuardian(secret, "carol"); err == nil {
+		t.Errorf("expected error removing a guardian below the threshold")
+	}
+}
@@ -0,0 +1,269 @@
+// Package heartbeat monitors that share holders still hold their shares. The
+// coordinator periodically sends each holder a fresh challenge, the holder
+// answers it with SecretShare.ProvePossession, and the coordinator raises
+// an alert when a holder stops answering or answers with a corrupted share.
+// The share is never revealed to the coordinator.
+//
+// The Monitor doesn't depend on how challenges reach holders: Run calls a
+// Pinger the application provides for each challenge.
+package heartbeat
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jakecraige/adss"
+)
+
+// Holder is a monitored share holder. The dealer records the share's
+// PossessionKey when the share is created.
+type Holder struct {
+	Name          string
+	Fingerprint   string
+	ShareID       uint16
+	PossessionKey ed25519.PublicKey
+}
+
+// NewHolders returns the holders to monitor for a sharing. names labels the
+// holder of each share in order.
+func NewHolders(shares []*adss.SecretShare, names []string) ([]Holder, error) {
+	if len(names) != len(shares) {
+		return nil, fmt.Errorf("expected %d holders, got %d", len(shares), len(names))
+	}
+
+	holders := make([]Holder, len(shares))
+	for i, share := range shares {
+		holders[i] = Holder{
+			Name:          names[i],
+			Fingerprint:   share.Fingerprint(),
+			ShareID:       share.ID,
+			PossessionKey: share.PossessionKey(),
+		}
+	}
+	return holders, nil
+}
+
+// Status is the health of a holder.
+type Status string
+
+const (
+	// StatusPending means the holder hasn't answered a challenge yet but
+	// isn't overdue.
+	StatusPending Status = "pending"
+	// StatusHealthy means the holder answered recently with an intact share.
+	StatusHealthy Status = "healthy"
+	// StatusDark means the holder hasn't answered within the allowed
+	// silence.
+	StatusDark Status = "dark"
+	// StatusCorrupt means the holder's last answer didn't verify, so their
+	// share is corrupted or they no longer have it.
+	StatusCorrupt Status = "corrupt"
+)
+
+// Report is the state of one holder.
+type Report struct {
+	Holder   string
+	Status   Status
+	LastSeen time.Time // when the holder last answered correctly
+	Err      error     // why the last answer was rejected, if it was
+}
+
+type holderState struct {
+	Holder
+	challenge []byte
+	lastSeen  time.Time
+	corrupt   error
+	alerted   Status
+}
+
+// Monitor tracks the holders' answers to challenges. It is safe for
+// concurrent use.
+type Monitor struct {
+	maxSilence time.Duration
+	now        func() time.Time
+	started    time.Time
+
+	mu      sync.Mutex
+	holders map[string]*holderState
+	order   []string
+}
+
+// NewMonitor starts monitoring the holders. A holder goes dark when they
+// haven't answered a challenge correctly for maxSilence.
+func NewMonitor(holders []Holder, maxSilence time.Duration) (*Monitor, error) {
+	m := &Monitor{
+		maxSilence: maxSilence,
+		now:        time.Now,
+		holders:    make(map[string]*holderState, len(holders)),
+	}
+	m.started = m.now()
+
+	for _, h := range holders {
+		if _, ok := m.holders[h.Name]; ok {
+			return nil, fmt.Errorf("duplicate holder: %s", h.Name)
+		}
+		if len(h.PossessionKey) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("holder %s: invalid possession key length: %d", h.Name, len(h.PossessionKey))
+		}
+		m.holders[h.Name] = &holderState{Holder: h}
+		m.order = append(m.order, h.Name)
+	}
+	return m, nil
+}
+
+func (m *Monitor) holder(name string) (*holderState, error) {
+	h, ok := m.holders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown holder: %s", name)
+	}
+	return h, nil
+}
+
+// Challenge returns a fresh challenge for the holder. Only the latest
+// challenge issued to a holder is accepted, so old answers can't be replayed.
+func (m *Monitor) Challenge(name string) ([]byte, error) {
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, err := m.holder(name)
+	if err != nil {
+		return nil, err
+	}
+	h.challenge = challenge
+	return challenge, nil
+}
+
+// Respond records the holder's proof of possession in answer to their latest
+// challenge. It returns an error, and marks the holder corrupt, if the proof
+// doesn't verify.
+func (m *Monitor) Respond(name string, proof *adss.PossessionProof) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	h, err := m.holder(name)
+	if err != nil {
+		return err
+	}
+	if h.challenge == nil {
+		return fmt.Errorf("no challenge outstanding for %s", name)
+	}
+
+	challenge := h.challenge
+	h.challenge = nil
+	if err := proof.Verify(h.Fingerprint, challenge, h.PossessionKey); err != nil {
+		h.corrupt = fmt.Errorf("proof from %s: %w", name, err)
+		return h.corrupt
+	}
+	h.corrupt = nil
+	h.lastSeen = m.now()
+	return nil
+}
+
+func (m *Monitor) report(h *holderState) Report {
+	r := Report{Holder: h.Name, LastSeen: h.lastSeen, Err: h.corrupt}
+
+	since := h.lastSeen
+	if since.IsZero() {
+		since = m.started
+	}
+	switch {
+	case h.corrupt != nil:
+		r.Status = StatusCorrupt
+	case m.now().Sub(since) > m.maxSilence:
+		r.Status = StatusDark
+	case h.lastSeen.IsZero():
+		r.Status = StatusPending
+	default:
+		r.Status = StatusHealthy
+	}
+	return r
+}
+
+// Reports returns the state of every holder in the order they were given.
+func (m *Monitor) Reports() []Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reports := make([]Report, len(m.order))
+	for i, name := range m.order {
+		reports[i] = m.report(m.holders[name])
+	}
+	return reports
+}
+
+// alerts returns the reports of holders that have become dark or corrupt
+// since the last call.
+func (m *Monitor) alerts() []Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var alerts []Report
+	for _, name := range m.order {
+		h := m.holders[name]
+		r := m.report(h)
+		if (r.Status == StatusDark || r.Status == StatusCorrupt) && r.Status != h.alerted {
+			alerts = append(alerts, r)
+		}
+		h.alerted = r.Status
+	}
+	return alerts
+}
+
+// Pinger delivers a challenge to a holder and returns their proof of
+// possession bound to it.
+type Pinger func(ctx context.Context, holder string, challenge []byte) (*adss.PossessionProof, error)
+
+// Run challenges every holder each interval until ctx is done. Each ping is
+// given until the next round to answer. alert is called once each time a
+// holder becomes dark or corrupt, and again only if they recover and fail
+// again.
+func (m *Monitor) Run(ctx context.Context, interval time.Duration, ping Pinger, alert func(Report)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		m.round(ctx, interval, ping)
+		for _, r := range m.alerts() {
+			alert(r)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// round challenges every holder concurrently and records their answers.
+func (m *Monitor) round(ctx context.Context, timeout time.Duration, ping Pinger) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, name := range m.order {
+		challenge, err := m.Challenge(name)
+		if err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(name string) {
+			defer wg.Done()
+			proof, err := ping(ctx, name, challenge)
+			if err != nil {
+				// An unreachable holder goes dark once they've been
+				// silent for too long.
+				return
+			}
+			m.Respond(name, proof)
+		}(name)
+	}
+	wg.Wait()
+}
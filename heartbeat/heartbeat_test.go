@@ -0,0 +1,115 @@
+package heartbeat
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jakecraige/adss"
+)
+
+func testSharing(t *testing.T) ([]*adss.SecretShare, []Holder) {
+	shares, err := adss.Share(adss.NewAccessStructure(2, 3), []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	holders, err := NewHolders(shares, []string{"alice", "bob", "carol"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return shares, holders
+}
+
+func TestMonitor(t *testing.T) {
+	shares, holders := testSharing(t)
+	m, err := NewMonitor(holders, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	now := m.started
+	m.now = func() time.Time { return now }
+
+	corrupt := *shares[2]
+	corrupt.Sec = append([]byte{}, corrupt.Sec...)
+	corrupt.Sec[0]++
+
+	for _, answer := range []struct {
+		name  string
+		share *adss.SecretShare
+	}{{"alice", shares[0]}, {"carol", &corrupt}} {
+		challenge, err := m.Challenge(answer.name)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		m.Respond(answer.name, answer.share.ProvePossession(challenge))
+	}
+
+	expected := []Status{StatusHealthy, StatusPending, StatusCorrupt}
+	for i, r := range m.Reports() {
+		if r.Status != expected[i] {
+			t.Errorf("%s: status = %s, expected: %s", r.Holder, r.Status, expected[i])
+		}
+	}
+
+	// Replaying alice's answer to an old challenge is rejected.
+	old, _ := m.Challenge("alice")
+	proof := shares[0].ProvePossession(old)
+	if _, err := m.Challenge("alice"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := m.Respond("alice", proof); err == nil {
+		t.Errorf("expected error for an answer to an old challenge")
+	}
+	challenge, _ := m.Challenge("alice")
+	if err := m.Respond("alice", shares[0].ProvePossession(challenge)); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	now = now.Add(2 * time.Hour)
+	expected = []Status{StatusDark, StatusDark, StatusCorrupt}
+	for i, r := range m.Reports() {
+		if r.Status != expected[i] {
+			t.Errorf("%s: status = %s, expected: %s", r.Holder, r.Status, expected[i])
+		}
+	}
+
+	if _, err := m.Challenge("mallory"); err == nil {
+		t.Errorf("expected error for an unknown holder")
+	}
+}
+
+func TestMonitorRun(t *testing.T) {
+	shares, holders := testSharing(t)
+	m, err := NewMonitor(holders, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// Carol has been silent since long before the monitor started.
+	m.started = m.started.Add(-2 * time.Hour)
+
+	byName := map[string]*adss.SecretShare{"alice": shares[0], "bob": shares[1]}
+	ping := func(ctx context.Context, holder string, challenge []byte) (*adss.PossessionProof, error) {
+		share, ok := byName[holder]
+		if !ok {
+			return nil, errors.New("unreachable")
+		}
+		return share.ProvePossession(challenge), nil
+	}
+
+	var mu sync.Mutex
+	var alerts []Report
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	m.Run(ctx, 5*time.Millisecond, ping, func(r Report) {
+		mu.Lock()
+		alerts = append(alerts, r)
+		mu.Unlock()
+	})
+
+	// Carol is unreachable and is alerted on once however many rounds pass.
+	if len(alerts) != 1 || alerts[0].Holder != "carol" || alerts[0].Status != StatusDark {
+		t.Errorf("unexpected alerts: %+v", alerts)
+	}
+}
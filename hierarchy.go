@@ -0,0 +1,123 @@
+package adss
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NewHierarchicalAccessStructure returns an access structure in which k of
+// the groups must each reach their own quorum, such as 2 of 3 departments
+// that are each 2-of-5:
+//
+//	d := adss.NewAccessStructure(2, 5)
+//	as, err := adss.NewHierarchicalAccessStructure(2, d, d, d)
+//
+// A group may itself be hierarchical, or made by NewFormulaAccessStructure,
+// to nest further. The shares of each group are numbered after those of the
+// groups before it, so above share IDs 0-4 are the first department, 5-9 the
+// second and 10-14 the third, and GroupPath gives the place of a share in the
+// hierarchy. Groups can't have exclusions or mandatory shares.
+//
+// The result is a formula access structure, so recovery refuses shares that
+// don't satisfy the hierarchy before interpolating, and
+// ShareWithScheme(SchemeFormula, ...) enforces it in the sharing itself.
+func NewHierarchicalAccessStructure(k uint16, groups ...AccessStructure) (AccessStructure, error) {
+	if len(groups) < 2 {
+		return AccessStructure{}, fmt.Errorf("a hierarchy needs at least 2 groups, got %d", len(groups))
+	}
+
+	children := make([]*Formula, len(groups))
+	next := 0
+	for i, group := range groups {
+		if len(group.Exclusions) > 0 || len(group.Mandatory) > 0 {
+			return AccessStructure{}, fmt.Errorf("group %d: exclusions and mandatory shares aren't supported in a hierarchy", i)
+		}
+		if next+int(group.N) > maxWideShares {
+			return AccessStructure{}, fmt.Errorf("hierarchy has more than %d shares", maxWideShares)
+		}
+		f, err := group.groupFormula()
+		if err != nil {
+			return AccessStructure{}, fmt.Errorf("group %d: %w", i, err)
+		}
+		children[i] = f.offset(uint16(next))
+		next += int(group.N)
+	}
+	return NewFormulaAccessStructure(AtLeast(k, children...))
+}
+
+// groupFormula returns the formula of the access structure, or a k-of gate
+// over its shares if it is a threshold.
+func (as AccessStructure) groupFormula() (*Formula, error) {
+	if err := as.Validate(); err != nil {
+		return nil, err
+	}
+	if as.Formula != nil {
+		return as.Formula, nil
+	}
+	if as.N == 1 {
+		return Party(0), nil
+	}
+	parties := make([]*Formula, as.N)
+	for i := range parties {
+		parties[i] = Party(uint16(i))
+	}
+	return AtLeast(as.T, parties...), nil
+}
+
+// offset returns a copy of the formula with every share ID increased by n.
+func (f *Formula) offset(n uint16) *Formula {
+	out := f.clone()
+	var shift func(f *Formula)
+	shift = func(f *Formula) {
+		f.ID += n
+		for _, child := range f.Children {
+			shift(child)
+		}
+	}
+	shift(out)
+	return out
+}
+
+// GroupPath returns the place of the share with the ID in the formula of the
+// access structure: its position among the inputs of each gate from the
+// outermost down. In 2 of 3 departments that are each 2-of-5 the fourth share
+// of the second department has the path [1 3]. It returns nil for access
+// structures without a formula.
+func (as AccessStructure) GroupPath(id uint16) []int {
+	if as.Formula == nil {
+		return nil
+	}
+	var find func(f *Formula, path []int) []int
+	find = func(f *Formula, path []int) []int {
+		if len(f.Children) == 0 {
+			if f.ID == id {
+				return path
+			}
+			return nil
+		}
+		for i, child := range f.Children {
+			if found := find(child, append(path, i)); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	return find(as.Formula, []int{})
+}
+
+// GroupPath returns the place of the share in the hierarchy of its access
+// structure, see AccessStructure.GroupPath. It is bound into the checksum
+// with the rest of the access structure.
+func (ss *SecretShare) GroupPath() []int {
+	return ss.As.GroupPath(ss.ID)
+}
+
+// FormatGroupPath returns the path joined by dots, such as "1.3".
+func FormatGroupPath(path []int) string {
+	parts := make([]string, len(path))
+	for i, p := range path {
+		parts[i] = strconv.Itoa(p)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,105 @@
+package adss
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// departments is 2 of 3 departments that are each 2-of-5.
+func departments(t *testing.T) AccessStructure {
+	t.Helper()
+	d := NewAccessStructure(2, 5)
+	as, err := NewHierarchicalAccessStructure(2, d, d, d)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	return as
+}
+
+func TestNewHierarchicalAccessStructure(t *testing.T) {
+	as := departments(t)
+	if as.T != 4 || as.N != 15 {
+		t.Errorf("got %d-of-%d, expected: 4-of-15", as.T, as.N)
+	}
+	expected := "2-of(2-of(0, 1, 2, 3, 4), 2-of(5, 6, 7, 8, 9), 2-of(10, 11, 12, 13, 14))"
+	if as.Formula.String() != expected {
+		t.Errorf("got formula %q, expected: %q", as.Formula, expected)
+	}
+
+	// Groups nest and may be formulas or single shares.
+	pair, err := NewFormulaAccessStructure(Or(Party(0), Party(1)))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	nested, err := NewHierarchicalAccessStructure(2, as, pair, NewAccessStructure(1, 1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if nested.T != 2 || nested.N != 18 {
+		t.Errorf("got %d-of-%d, expected: 2-of-18", nested.T, nested.N)
+	}
+	if path := nested.GroupPath(16); !reflect.DeepEqual(path, []int{1, 1}) {
+		t.Errorf("got path %v for share 16, expected: [1 1]", path)
+	}
+	if path := nested.GroupPath(17); !reflect.DeepEqual(path, []int{2}) {
+		t.Errorf("got path %v for share 17, expected: [2]", path)
+	}
+	if path := nested.GroupPath(8); !reflect.DeepEqual(path, []int{0, 1, 3}) {
+		t.Errorf("got path %v for share 8, expected: [0 1 3]", path)
+	}
+
+	excluded, err := NewAccessStructureWithExclusions(2, 3, IDSet{0, 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var errTests = []struct {
+		name   string
+		k      uint16
+		groups []AccessStructure
+	}{
+		{"one group", 1, []AccessStructure{NewAccessStructure(2, 3)}},
+		{"k too large", 3, []AccessStructure{NewAccessStructure(2, 3), NewAccessStructure(2, 3)}},
+		{"invalid group", 1, []AccessStructure{NewAccessStructure(4, 3), NewAccessStructure(2, 3)}},
+		{"exclusions", 1, []AccessStructure{excluded, NewAccessStructure(2, 3)}},
+		{"too many shares", 1, []AccessStructure{NewAccessStructure(2, 60000), NewAccessStructure(2, 10000)}},
+	}
+	for _, tt := range errTests {
+		if _, err := NewHierarchicalAccessStructure(tt.k, tt.groups...); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestShareHierarchical(t *testing.T) {
+	msg := []byte("hello world")
+	as := departments(t)
+	for _, scheme := range []uint8{SchemeShamir, SchemeFormula} {
+		shares, err := ShareWithScheme(scheme, as, msg, nil)
+		if err != nil {
+			t.Fatalf("scheme %d: unexpected error on sharing: %s", scheme, err)
+		}
+		if path := shares[7].GroupPath(); !reflect.DeepEqual(path, []int{1, 2}) {
+			t.Errorf("scheme %d: got path %v for share 7, expected: [1 2]", scheme, path)
+		}
+		if s := FormatGroupPath(shares[7].GroupPath()); s != "1.2" {
+			t.Errorf("scheme %d: got formatted path %q, expected: 1.2", scheme, s)
+		}
+
+		subset := []*SecretShare{shares[0], shares[3], shares[12], shares[14]}
+		if recov, _, err := Recover(subset); err != nil || !bytes.Equal(recov, msg) {
+			t.Errorf("scheme %d: recovered %q, %v", scheme, recov, err)
+		}
+
+		// Four shares meet the threshold but only one department's quorum.
+		subset = []*SecretShare{shares[0], shares[1], shares[2], shares[5]}
+		if _, _, err := Recover(subset); !errors.Is(err, ErrUnsatisfiedFormula) {
+			t.Errorf("scheme %d: expected ErrUnsatisfiedFormula, got %v", scheme, err)
+		}
+	}
+
+	if path := NewAccessStructure(2, 3).GroupPath(1); path != nil {
+		t.Errorf("got path %v for a threshold access structure, expected none", path)
+	}
+}
@@ -0,0 +1,102 @@
+package adss
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// holdersMarker follows the threshold, count, any mandatory shares and any
+// formula in the bytes of an access structure with named holders, see
+// mandatoryMarker.
+const holdersMarker = 0xfd
+
+// maxHolderName is the longest holder name in bytes, so each name's length
+// fits in a byte.
+const maxHolderName = 255
+
+// WithHolders returns a copy of the access structure recording the name of
+// the holder of each share, in ID order, such as WithHolders("alice", "bob",
+// "carol") for a 2-of-3 sharing. The names are part of the access structure,
+// so they are authenticated with the rest of the sharing and every share
+// carries them. Names must be unique, valid UTF-8 without control characters
+// and at most 255 bytes.
+func (as AccessStructure) WithHolders(names ...string) (AccessStructure, error) {
+	as = as.clone()
+	as.Holders = append([]string{}, names...)
+
+	if err := as.validateHolders(); err != nil {
+		return as, err
+	}
+	return as, nil
+}
+
+// validateHolders returns an error unless the access structure has no
+// holder names, or a valid name for each share.
+func (as *AccessStructure) validateHolders() error {
+	if as.Holders == nil {
+		return nil
+	}
+	if len(as.Holders) != int(as.N) {
+		return fmt.Errorf("%d holder names for %d shares", len(as.Holders), as.N)
+	}
+	seen := make(map[string]bool, len(as.Holders))
+	for i, name := range as.Holders {
+		if name == "" || len(name) > maxHolderName {
+			return fmt.Errorf("holder name of share %d must be 1 to %d bytes", i, maxHolderName)
+		}
+		if !utf8.ValidString(name) {
+			return fmt.Errorf("holder name of share %d isn't valid UTF-8", i)
+		}
+		for _, r := range name {
+			if unicode.IsControl(r) {
+				return fmt.Errorf("holder name of share %d has a control character", i)
+			}
+		}
+		if seen[name] {
+			return fmt.Errorf("holder name %q is given to more than one share", name)
+		}
+		seen[name] = true
+	}
+	return nil
+}
+
+// holderBytes returns the holder names, each prefixed with its length as a
+// byte, or nil if there are none.
+func (as *AccessStructure) holderBytes() []byte {
+	if as.Holders == nil {
+		return nil
+	}
+	var out []byte
+	for _, name := range as.Holders {
+		out = append(out, byte(len(name)))
+		out = append(out, name...)
+	}
+	return out
+}
+
+// parseHolders decodes the output of holderBytes.
+func parseHolders(data []byte) ([]string, error) {
+	var names []string
+	for len(data) > 0 {
+		if int(data[0]) >= len(data) {
+			return nil, fmt.Errorf("holder names truncated")
+		}
+		names = append(names, string(data[1:1+data[0]]))
+		data = data[1+data[0]:]
+	}
+	if names == nil {
+		return nil, fmt.Errorf("holder names empty")
+	}
+	return names, nil
+}
+
+// Holder returns the name of the share's holder, or "" if the access
+// structure doesn't name them. It is only authenticated once the share has
+// been verified, such as by being among the valid shares Recover returns.
+func (ss *SecretShare) Holder() string {
+	if int(ss.ID) >= len(ss.As.Holders) {
+		return ""
+	}
+	return ss.As.Holders[ss.ID]
+}
@@ -0,0 +1,133 @@
+package adss
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestAccessStructure_WithHolders(t *testing.T) {
+	as, err := NewAccessStructure(2, 3).WithHolders("alice", "bob", "carol")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := append([]byte{2, 3, holdersMarker}, "\x05alice\x03bob\x05carol"...)
+	if !bytes.Equal(as.Bytes(), expected) {
+		t.Errorf("got bytes %x, expected: %x", as.Bytes(), expected)
+	}
+	if as.Equal(NewAccessStructure(2, 3)) {
+		t.Errorf("access structure with holders equal to one without")
+	}
+	renamed, err := NewAccessStructure(2, 3).WithHolders("alice", "bob", "dave")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if as.Equal(renamed) {
+		t.Errorf("access structures with different holders compared equal")
+	}
+
+	var errTests = []struct {
+		name  string
+		names []string
+	}{
+		{"too few", []string{"alice", "bob"}},
+		{"too many", []string{"alice", "bob", "carol", "dave"}},
+		{"empty", []string{"alice", "", "carol"}},
+		{"repeated", []string{"alice", "bob", "alice"}},
+		{"too long", []string{"alice", "bob", strings.Repeat("c", maxHolderName+1)}},
+		{"control character", []string{"alice", "bob\n", "carol"}},
+		{"invalid UTF-8", []string{"alice", "bob", "\xff"}},
+	}
+	for _, tt := range errTests {
+		if _, err := NewAccessStructure(2, 3).WithHolders(tt.names...); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestShareWithHolders(t *testing.T) {
+	msg := []byte("hello world")
+	as, err := NewAccessStructure(2, 3).WithHolders("alice", "bob", "carol")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	shares, err := Share(as, msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if holder := shares[1].Holder(); holder != "bob" {
+		t.Errorf("got holder %q, expected: bob", holder)
+	}
+	if recov, valid, err := Recover(shares[1:]); err != nil || !bytes.Equal(recov, msg) || len(valid) != 2 {
+		t.Errorf("recovered %q, %d valid shares, %v", recov, len(valid), err)
+	}
+
+	// The holder names are authenticated, so swapping them invalidates the
+	// share.
+	var swapped []*SecretShare
+	for _, share := range shares[1:] {
+		share = cloneShare(share)
+		share.As.Holders[1], share.As.Holders[2] = "carol", "bob"
+		swapped = append(swapped, share)
+	}
+	if _, _, err := Recover(swapped); err == nil {
+		t.Errorf("recovered after swapping the holder names")
+	}
+
+	unnamed, err := Share(NewAccessStructure(2, 3), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if holder := unnamed[0].Holder(); holder != "" {
+		t.Errorf("got holder %q without holder names", holder)
+	}
+}
+
+func TestHolderEncodings(t *testing.T) {
+	as, err := NewAccessStructure(2, 3).WithHolders("alice", "bob", "Zoë Smith")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	shares, err := Share(as, []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	share := shares[2]
+
+	decoded, err := DecodeShareString(EncodeShareString(share))
+	if err != nil || !decoded.Equal(share) {
+		t.Errorf("share string round trip failed: %v", err)
+	}
+
+	data, err := share.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var fromCBOR SecretShare
+	if err := fromCBOR.UnmarshalCBOR(data); err != nil || !fromCBOR.Equal(share) {
+		t.Errorf("CBOR round trip failed: %v", err)
+	}
+
+	data, err = share.MarshalProto()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var fromProto SecretShare
+	if err := fromProto.UnmarshalProto(data); err != nil || !fromProto.Equal(share) {
+		t.Errorf("proto round trip failed: %v", err)
+	}
+
+	data, err = json.Marshal(share)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var fromJSON SecretShare
+	if err := json.Unmarshal(data, &fromJSON); err != nil || !fromJSON.Equal(share) {
+		t.Errorf("JSON round trip failed: %v", err)
+	}
+
+	if _, err := parseHolders([]byte("\x05ali")); err == nil {
+		t.Errorf("expected an error for truncated holder names")
+	}
+}
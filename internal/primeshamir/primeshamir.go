@@ -0,0 +1,122 @@
+// Package primeshamir implements Shamir secret sharing of scalars over a prime
+// field. Unlike the byte-wise GF(2^8) sharing used by adss, shares of a scalar
+// can be combined "in the exponent", which is what threshold signature schemes
+// need to avoid ever reconstructing the key.
+//
+// Share IDs are zero-based and evaluated at x = ID+1, matching the adss
+// convention.
+package primeshamir
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// Share is a single share of a scalar.
+type Share struct {
+	ID    uint8
+	Value *big.Int
+}
+
+// Split creates n shares of secret modulo the prime q such that any t of them
+// can recover it. Coefficients are read from rnd, or crypto/rand if it is nil.
+func Split(secret *big.Int, t, n uint8, q *big.Int, rnd io.Reader) ([]*Share, []*big.Int, error) {
+	if t == 0 || n == 0 || t > n {
+		return nil, nil, fmt.Errorf("invalid threshold %d-of-%d", t, n)
+	}
+	if rnd == nil {
+		rnd = rand.Reader
+	}
+
+	coeffs := make([]*big.Int, t)
+	coeffs[0] = new(big.Int).Mod(secret, q)
+	for i := 1; i < int(t); i++ {
+		c, err := rand.Int(rnd, q)
+		if err != nil {
+			return nil, nil, err
+		}
+		coeffs[i] = c
+	}
+
+	shares := make([]*Share, n)
+	for i := range shares {
+		shares[i] = &Share{ID: uint8(i), Value: evaluate(coeffs, X(uint8(i)), q)}
+	}
+
+	return shares, coeffs, nil
+}
+
+// X returns the evaluation point of the share with the given ID.
+func X(id uint8) *big.Int {
+	return big.NewInt(int64(id) + 1)
+}
+
+// evaluate computes the polynomial at x using Horner's method.
+func evaluate(coeffs []*big.Int, x, q *big.Int) *big.Int {
+	out := new(big.Int)
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		out.Mul(out, x)
+		out.Add(out, coeffs[i])
+		out.Mod(out, q)
+	}
+	return out
+}
+
+// LagrangeCoefficient returns the coefficient for the share with ID id when
+// interpolating the polynomial at 0 using the shares with IDs ids.
+func LagrangeCoefficient(id uint8, ids []uint8, q *big.Int) (*big.Int, error) {
+	num, denom := big.NewInt(1), big.NewInt(1)
+	xi := X(id)
+	found := false
+	for _, other := range ids {
+		if other == id {
+			if found {
+				return nil, fmt.Errorf("duplicate share ID: %d", id)
+			}
+			found = true
+			continue
+		}
+
+		xj := X(other)
+		num.Mul(num, xj)
+		num.Mod(num, q)
+		denom.Mul(denom, new(big.Int).Sub(xj, xi))
+		denom.Mod(denom, q)
+	}
+	if !found {
+		return nil, fmt.Errorf("share ID %d not in set", id)
+	}
+
+	inv := new(big.Int).ModInverse(denom, q)
+	if inv == nil {
+		return nil, fmt.Errorf("share IDs are not distinct")
+	}
+	return num.Mul(num, inv).Mod(num, q), nil
+}
+
+// Recover interpolates the secret from the shares. It is mostly useful for
+// testing since the point of this package is to avoid doing this.
+func Recover(shares []*Share, q *big.Int) (*big.Int, error) {
+	ids := IDs(shares)
+	out := new(big.Int)
+	for _, share := range shares {
+		lambda, err := LagrangeCoefficient(share.ID, ids, q)
+		if err != nil {
+			return nil, err
+		}
+		out.Add(out, new(big.Int).Mul(lambda, share.Value))
+		out.Mod(out, q)
+	}
+	return out, nil
+}
+
+// IDs returns the IDs of the shares.
+func IDs(shares []*Share) []uint8 {
+	ids := make([]uint8, len(shares))
+	for i, share := range shares {
+		ids[i] = share.ID
+	}
+	return ids
+}
@@ -0,0 +1,44 @@
+package primeshamir
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestSplitAndRecover(t *testing.T) {
+	q := big.NewInt(7919)
+	secret := big.NewInt(1234)
+
+	shares, _, err := Split(secret, 3, 5, q, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	var tests = []struct {
+		name   string
+		shares []*Share
+	}{
+		{"first three", shares[:3]},
+		{"last three", shares[2:]},
+		{"all", shares},
+		{"out of order", []*Share{shares[4], shares[0], shares[2]}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			recov, err := Recover(tt.shares, q)
+			if err != nil {
+				t.Fatalf("unexpected error on recovery: %s", err)
+			}
+
+			if recov.Cmp(secret) != 0 {
+				t.Errorf("recovered %s != %s", recov, secret)
+			}
+		})
+	}
+
+	if _, err := Recover([]*Share{shares[0], shares[0], shares[1]}, q); err == nil {
+		t.Errorf("expected error on duplicate shares")
+	}
+}
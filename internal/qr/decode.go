@@ -0,0 +1,240 @@
+package qr
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math/bits"
+)
+
+// ErrNotFound is returned by Decode when the image doesn't contain a QR code
+// it can locate.
+var ErrNotFound = errors.New("qr: no QR code found")
+
+// Decode reads the QR code in an image. The code must be upright and the
+// only dark shape in the image, as in the images Image renders or flat scans
+// of printouts; it corrects damaged modules up to the code's level.
+func Decode(img image.Image) ([]byte, error) {
+	dark, bounds, err := binarize(img)
+	if err != nil {
+		return nil, err
+	}
+
+	// The top left of the dark area is the corner of a finder pattern, whose
+	// top edge is seven modules wide.
+	left, top := bounds.Min.X, bounds.Min.Y
+	edge := 0
+	for x := left; x < bounds.Max.X && dark(x, top); x++ {
+		edge++
+	}
+	run := 0
+	for x := left; x < bounds.Max.X && dark(x, top+edge/14); x++ {
+		run++
+	}
+	version := (int(float64(bounds.Dx())*7/float64(run)+0.5) - 17 + 2) / 4
+	if version < 1 || version > 40 {
+		return nil, ErrNotFound
+	}
+
+	s := newSymbol(version)
+	moduleW := float64(bounds.Dx()) / float64(s.size)
+	moduleH := float64(bounds.Dy()) / float64(s.size)
+	for y := 0; y < s.size; y++ {
+		for x := 0; x < s.size; x++ {
+			s.modules[y*s.size+x] = dark(left+int((float64(x)+0.5)*moduleW), top+int((float64(y)+0.5)*moduleH))
+		}
+	}
+
+	level, mask, err := s.readFormat()
+	if err != nil {
+		return nil, err
+	}
+	s.applyMask(mask)
+
+	raw := s.readCodewords(numRawDataModules(version) / 8)
+	ecc := eccPerBlock[level][version]
+	lengths := blockLengths(version, level)
+	blocks := make([][]byte, len(lengths))
+	for i, n := range lengths {
+		blocks[i] = make([]byte, n+ecc)
+	}
+	for i, index := range interleaving(lengths, ecc) {
+		blocks[index[0]][index[1]] = raw[i]
+	}
+
+	var data []byte
+	for i, block := range blocks {
+		if err := rsCorrect(block, ecc); err != nil {
+			return nil, err
+		}
+		data = append(data, block[:lengths[i]]...)
+	}
+	return parseSegments(data, version)
+}
+
+// binarize returns whether each pixel of the image is dark, thresholding
+// halfway between its darkest and lightest pixels, and the bounds of the
+// dark pixels.
+func binarize(img image.Image) (func(x, y int) bool, image.Rectangle, error) {
+	b := img.Bounds()
+	lum := make([]uint8, b.Dx()*b.Dy())
+	var lo, hi uint8 = 255, 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			l := color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y
+			lum[(y-b.Min.Y)*b.Dx()+x-b.Min.X] = l
+			if l < lo {
+				lo = l
+			}
+			if l > hi {
+				hi = l
+			}
+		}
+	}
+	if int(hi)-int(lo) < 64 {
+		return nil, image.Rectangle{}, ErrNotFound
+	}
+
+	threshold := uint8((int(lo) + int(hi)) / 2)
+	dark := func(x, y int) bool {
+		if !(image.Point{x, y}).In(b) {
+			return false
+		}
+		return lum[(y-b.Min.Y)*b.Dx()+x-b.Min.X] < threshold
+	}
+
+	found := image.Rectangle{Min: b.Max, Max: b.Min}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if !dark(x, y) {
+				continue
+			}
+			found.Min.X, found.Min.Y = min(found.Min.X, x), min(found.Min.Y, y)
+			found.Max.X, found.Max.Y = max(found.Max.X, x+1), max(found.Max.Y, y+1)
+		}
+	}
+	if found.Empty() {
+		return nil, image.Rectangle{}, ErrNotFound
+	}
+	return dark, found, nil
+}
+
+// readFormat returns the level and mask from whichever copy of the format
+// information is closest to a valid one, allowing up to three wrong bits.
+func (s *symbol) readFormat() (Level, int, error) {
+	var copies [2]int
+	first, second := s.formatPositions()
+	for i := 0; i < 15; i++ {
+		if s.at(first[i][0], first[i][1]) {
+			copies[0] |= 1 << uint(i)
+		}
+		if s.at(second[i][0], second[i][1]) {
+			copies[1] |= 1 << uint(i)
+		}
+	}
+
+	bestLevel, bestMask, bestDistance := Low, 0, 16
+	for level := Low; level <= High; level++ {
+		for mask := 0; mask < 8; mask++ {
+			info := formatInfo(level, mask)
+			for _, c := range copies {
+				if d := bits.OnesCount(uint(c ^ info)); d < bestDistance {
+					bestLevel, bestMask, bestDistance = level, mask, d
+				}
+			}
+		}
+	}
+	if bestDistance > 3 {
+		return 0, 0, fmt.Errorf("qr: unreadable format information")
+	}
+	return bestLevel, bestMask, nil
+}
+
+// parseSegments decodes the segments of the data codewords up to the
+// terminator or the end of the data.
+func parseSegments(data []byte, version int) ([]byte, error) {
+	r := bitReader{data: data}
+	var out []byte
+	for r.remaining() >= 4 {
+		mode := r.read(4)
+		if mode == 0 {
+			break
+		}
+		widths, ok := countBits[mode]
+		if !ok {
+			return nil, fmt.Errorf("qr: unsupported segment mode %d", mode)
+		}
+		count := r.read(widths[countIndex(version)])
+
+		switch mode {
+		case modeNumeric:
+			for ; count >= 3; count -= 3 {
+				out = append(out, fmt.Sprintf("%03d", r.read(10))...)
+			}
+			if count == 2 {
+				out = append(out, fmt.Sprintf("%02d", r.read(7))...)
+			} else if count == 1 {
+				out = append(out, fmt.Sprintf("%d", r.read(4))...)
+			}
+
+		case modeAlphanumeric:
+			for ; count >= 2; count -= 2 {
+				v := r.read(11)
+				if v >= 45*45 {
+					return nil, fmt.Errorf("qr: invalid alphanumeric segment")
+				}
+				out = append(out, alphanumericCharset[v/45], alphanumericCharset[v%45])
+			}
+			if count == 1 {
+				v := r.read(6)
+				if v >= 45 {
+					return nil, fmt.Errorf("qr: invalid alphanumeric segment")
+				}
+				out = append(out, alphanumericCharset[v])
+			}
+
+		case modeByte:
+			for ; count > 0; count-- {
+				out = append(out, byte(r.read(8)))
+			}
+		}
+		if r.overrun {
+			return nil, fmt.Errorf("qr: segment longer than the data")
+		}
+	}
+	return out, nil
+}
+
+// bitReader reads bits from a byte slice, most significant first. Reading
+// past the end returns zeros and sets overrun.
+type bitReader struct {
+	data    []byte
+	n       int
+	overrun bool
+}
+
+func (r *bitReader) remaining() int {
+	return len(r.data)*8 - r.n
+}
+
+func (r *bitReader) read(count int) int {
+	v := 0
+	for i := 0; i < count; i++ {
+		v <<= 1
+		if r.n >= len(r.data)*8 {
+			r.overrun = true
+		} else if r.data[r.n/8]>>uint(7-r.n%8)&1 == 1 {
+			v |= 1
+		}
+		r.n++
+	}
+	return v
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
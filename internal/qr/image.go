@@ -0,0 +1,64 @@
+package qr
+
+import (
+	"image"
+	"image/color"
+	"strings"
+)
+
+// quietZone is the width in modules of the light border scanners need around
+// a code.
+const quietZone = 4
+
+// Image renders the code in black and white with scale pixels per module and
+// a quiet zone around it.
+func (c *Code) Image(scale int) *image.Gray {
+	side := (c.Size + 2*quietZone) * scale
+	img := image.NewGray(image.Rect(0, 0, side, side))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			if !c.Black(x, y) {
+				continue
+			}
+			for dy := 0; dy < scale; dy++ {
+				for dx := 0; dx < scale; dx++ {
+					img.SetGray((quietZone+x)*scale+dx, (quietZone+y)*scale+dy, color.Gray{})
+				}
+			}
+		}
+	}
+	return img
+}
+
+// Terminal renders the code as text, two rows of modules to a line using
+// Unicode half blocks. Light modules are drawn as blocks, so the code reads
+// correctly on a terminal with a dark background.
+func (c *Code) Terminal() string {
+	light := func(x, y int) bool {
+		x, y = x-quietZone, y-quietZone
+		return x < 0 || y < 0 || x >= c.Size || y >= c.Size || !c.Black(x, y)
+	}
+
+	var out strings.Builder
+	side := c.Size + 2*quietZone
+	for y := 0; y < side; y += 2 {
+		for x := 0; x < side; x++ {
+			top, bottom := light(x, y), y+1 < side && light(x, y+1)
+			switch {
+			case top && bottom:
+				out.WriteString("█")
+			case top:
+				out.WriteString("▀")
+			case bottom:
+				out.WriteString("▄")
+			default:
+				out.WriteString(" ")
+			}
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
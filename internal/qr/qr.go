@@ -0,0 +1,262 @@
+// Package qr encodes and decodes QR codes (ISO/IEC 18004) so shares can be
+// printed and scanned. It supports the byte and alphanumeric modes, which is
+// all share strings need, and decodes upright images such as those it
+// renders or flat scans of them, not photographs.
+package qr
+
+import "fmt"
+
+// Level is the error correction level of a code, the fraction of it that can
+// be damaged and still decoded.
+type Level int
+
+const (
+	// Low recovers about 7% of the codewords.
+	Low Level = iota
+	// Medium recovers about 15% of the codewords.
+	Medium
+	// Quartile recovers about 25% of the codewords.
+	Quartile
+	// High recovers about 30% of the codewords.
+	High
+)
+
+// formatBits are the two bits identifying each level in the format
+// information.
+var formatBits = [4]int{Low: 1, Medium: 0, Quartile: 3, High: 2}
+
+// eccPerBlock and numBlocks give the number of error correction codewords in
+// each block and the number of blocks for each level and version.
+var eccPerBlock = [4][41]int{
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+var numBlocks = [4][41]int{
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// Segment modes and the width of their character counts in versions 1-9,
+// 10-26 and 27-40.
+const (
+	modeNumeric      = 0x1
+	modeAlphanumeric = 0x2
+	modeByte         = 0x4
+)
+
+var countBits = map[int][3]int{
+	modeNumeric:      {10, 12, 14},
+	modeAlphanumeric: {9, 11, 13},
+	modeByte:         {8, 16, 16},
+}
+
+const alphanumericCharset = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// Code is a QR code, a square of dark and light modules.
+type Code struct {
+	// Size is the number of modules along each side, not counting the quiet
+	// zone.
+	Size    int
+	modules []bool
+}
+
+// Black reports whether the module in column x and row y is dark.
+func (c *Code) Black(x, y int) bool {
+	return c.modules[y*c.Size+x]
+}
+
+// Encode returns the smallest QR code of data at the level. Data made up of
+// uppercase letters, digits and " $%*+-./:" is encoded in alphanumeric mode,
+// which takes about two thirds of the space, and anything else as bytes.
+func Encode(data []byte, level Level) (*Code, error) {
+	version, codewords, err := dataCodewords(data, level)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newSymbol(version)
+	s.drawCodewords(addECC(codewords, version, level))
+
+	best, bestPenalty := -1, 0
+	for mask := 0; mask < 8; mask++ {
+		s.applyMask(mask)
+		s.drawFormat(level, mask)
+		if penalty := s.penalty(); best < 0 || penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		s.applyMask(mask)
+	}
+	s.applyMask(best)
+	s.drawFormat(level, best)
+
+	return &Code{Size: s.size, modules: s.modules}, nil
+}
+
+// dataCodewords returns the smallest version that fits data at the level and
+// its data codewords, padded to fill it.
+func dataCodewords(data []byte, level Level) (int, []byte, error) {
+	mode := modeAlphanumeric
+	for _, b := range data {
+		if indexByte(alphanumericCharset, b) < 0 {
+			mode = modeByte
+			break
+		}
+	}
+
+	for version := 1; version <= 40; version++ {
+		capacity := numDataCodewords(version, level) * 8
+		var bits bitWriter
+		bits.write(mode, 4)
+		count := countBits[mode][countIndex(version)]
+		if len(data) >= 1<<uint(count) {
+			continue
+		}
+		bits.write(len(data), count)
+		if mode == modeAlphanumeric {
+			for i := 0; i+1 < len(data); i += 2 {
+				bits.write(indexByte(alphanumericCharset, data[i])*45+indexByte(alphanumericCharset, data[i+1]), 11)
+			}
+			if len(data)%2 == 1 {
+				bits.write(indexByte(alphanumericCharset, data[len(data)-1]), 6)
+			}
+		} else {
+			for _, b := range data {
+				bits.write(int(b), 8)
+			}
+		}
+		if bits.n > capacity {
+			continue
+		}
+
+		terminator := capacity - bits.n
+		if terminator > 4 {
+			terminator = 4
+		}
+		bits.write(0, terminator)
+		bits.write(0, (8-bits.n%8)%8)
+		for pad := 0xec; bits.n < capacity; pad ^= 0xec ^ 0x11 {
+			bits.write(pad, 8)
+		}
+		return version, bits.bytes, nil
+	}
+	return 0, nil, fmt.Errorf("qr: %d bytes is too long for a QR code", len(data))
+}
+
+// countIndex returns which of the character count widths the version uses.
+func countIndex(version int) int {
+	switch {
+	case version <= 9:
+		return 0
+	case version <= 26:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func indexByte(s string, b byte) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == b {
+			return i
+		}
+	}
+	return -1
+}
+
+// numRawDataModules returns the number of modules of a version that hold
+// codewords, everything but the function patterns and format information.
+func numRawDataModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+// numDataCodewords returns the number of data codewords of a version and
+// level, the codewords not spent on error correction.
+func numDataCodewords(version int, level Level) int {
+	return numRawDataModules(version)/8 - eccPerBlock[level][version]*numBlocks[level][version]
+}
+
+// blockLengths returns the number of data codewords in each block. The
+// blocks are split as evenly as possible, with the longer ones last.
+func blockLengths(version int, level Level) []int {
+	blocks := numBlocks[level][version]
+	raw := numRawDataModules(version) / 8
+	short := blocks - raw%blocks
+	lengths := make([]int, blocks)
+	for i := range lengths {
+		lengths[i] = raw/blocks - eccPerBlock[level][version]
+		if i >= short {
+			lengths[i]++
+		}
+	}
+	return lengths
+}
+
+// addECC splits the data codewords into blocks, appends the error correction
+// codewords to each and interleaves them.
+func addECC(data []byte, version int, level Level) []byte {
+	ecc := eccPerBlock[level][version]
+	lengths := blockLengths(version, level)
+	blocks := make([][]byte, len(lengths))
+	for i, n := range lengths {
+		blocks[i] = append(append([]byte{}, data[:n]...), rsEncode(data[:n], ecc)...)
+		data = data[n:]
+	}
+
+	var out []byte
+	for _, index := range interleaving(lengths, ecc) {
+		out = append(out, blocks[index[0]][index[1]])
+	}
+	return out
+}
+
+// interleaving returns the block and offset of each codeword in the order
+// they are placed in the symbol: the data codewords of every block in turn
+// and then their error correction codewords.
+func interleaving(lengths []int, ecc int) [][2]int {
+	var order [][2]int
+	longest := lengths[len(lengths)-1]
+	for i := 0; i < longest; i++ {
+		for block, n := range lengths {
+			if i < n {
+				order = append(order, [2]int{block, i})
+			}
+		}
+	}
+	for i := 0; i < ecc; i++ {
+		for block, n := range lengths {
+			order = append(order, [2]int{block, n + i})
+		}
+	}
+	return order
+}
+
+// bitWriter appends bits to a byte slice, most significant first.
+type bitWriter struct {
+	bytes []byte
+	n     int
+}
+
+func (w *bitWriter) write(v, count int) {
+	for i := count - 1; i >= 0; i-- {
+		if w.n%8 == 0 {
+			w.bytes = append(w.bytes, 0)
+		}
+		if v>>uint(i)&1 == 1 {
+			w.bytes[w.n/8] |= 1 << uint(7-w.n%8)
+		}
+		w.n++
+	}
+}
@@ -0,0 +1,195 @@
+package qr
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDataCodewords(t *testing.T) {
+	// The version 1-Q "HELLO WORLD" example from the thonky.com QR tutorial.
+	version, codewords, err := dataCodewords([]byte("HELLO WORLD"), Quartile)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if version != 1 {
+		t.Errorf("expected version 1, got %d", version)
+	}
+	want := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236}
+	if !bytes.Equal(codewords, want) {
+		t.Errorf("expected data codewords %v, got %v", want, codewords)
+	}
+	wantECC := []byte{168, 72, 22, 82, 217, 54, 156, 0, 46, 15, 180, 122, 16}
+	if ecc := rsEncode(codewords, eccPerBlock[Quartile][1]); !bytes.Equal(ecc, wantECC) {
+		t.Errorf("expected error correction codewords %v, got %v", wantECC, ecc)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	// Byte mode capacities of version 40 from ISO/IEC 18004 Table 7.
+	for level, want := range map[Level]int{Low: 2953, Medium: 2331, Quartile: 1663, High: 1273} {
+		if _, _, err := dataCodewords(make([]byte, want), level); err != nil {
+			t.Errorf("level %d: expected %d bytes to fit: %s", level, want, err)
+		}
+		if _, _, err := dataCodewords(make([]byte, want+1), level); err == nil {
+			t.Errorf("level %d: expected %d bytes not to fit", level, want+1)
+		}
+	}
+}
+
+func TestFormatInfo(t *testing.T) {
+	tests := []struct {
+		level Level
+		mask  int
+		want  int
+	}{
+		{Low, 0, 0x77c4},
+		{Medium, 0, 0x5412},
+		{Quartile, 0, 0x355f},
+		{High, 0, 0x1689},
+		{Medium, 5, 0x40ce},
+	}
+	for _, tt := range tests {
+		if got := formatInfo(tt.level, tt.mask); got != tt.want {
+			t.Errorf("level %d mask %d: expected %015b, got %015b", tt.level, tt.mask, tt.want, got)
+		}
+	}
+}
+
+func TestVersionInfo(t *testing.T) {
+	s := newSymbol(7)
+	got := 0
+	for i := 0; i < 18; i++ {
+		if s.at(s.size-11+i%3, i/3) {
+			got |= 1 << uint(i)
+		}
+	}
+	if got != 0x07c94 {
+		t.Errorf("expected version information %018b, got %018b", 0x07c94, got)
+	}
+}
+
+func TestAlignmentPositions(t *testing.T) {
+	tests := map[int][]int{
+		1:  nil,
+		2:  {6, 18},
+		7:  {6, 22, 38},
+		32: {6, 34, 60, 86, 112, 138},
+		40: {6, 30, 58, 86, 114, 142, 170},
+	}
+	for version, want := range tests {
+		if got := alignmentPositions(version); !reflect.DeepEqual(got, want) {
+			t.Errorf("version %d: expected %v, got %v", version, want, got)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, level := range []Level{Low, Medium, Quartile, High} {
+		for _, n := range []int{0, 1, 17, 100, 500, 1200} {
+			data := make([]byte, n)
+			rng.Read(data)
+			t.Run(fmt.Sprintf("level %d %d bytes", level, n), func(t *testing.T) {
+				code, err := Encode(data, level)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				decoded, err := Decode(code.Image(3))
+				if err != nil {
+					t.Fatalf("unexpected error decoding: %s", err)
+				}
+				if !bytes.Equal(decoded, data) {
+					t.Errorf("expected %x, got %x", data, decoded)
+				}
+			})
+		}
+	}
+}
+
+func TestAlphanumeric(t *testing.T) {
+	text := []byte("ADSS1QYPQXPQ9QCRSSZG2PVXQ6RS0ZQG3YYC5Z5TPWXQERGD")
+	code, err := Encode(text, Medium)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	// 48 characters need version 3 in alphanumeric mode but 4 as bytes.
+	if code.Size != 3*4+17 {
+		t.Errorf("expected version 3, got size %d", code.Size)
+	}
+	decoded, err := Decode(code.Image(1))
+	if err != nil {
+		t.Fatalf("unexpected error decoding: %s", err)
+	}
+	if !bytes.Equal(decoded, text) {
+		t.Errorf("expected %s, got %s", text, decoded)
+	}
+}
+
+func TestDecodeDamaged(t *testing.T) {
+	data := []byte("adss1 share that will be scribbled on")
+	code, err := Encode(data, Medium)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Scale and offset the code within a larger gray image and draw over a
+	// few modules away from the finder patterns.
+	img := image.NewGray(image.Rect(-10, -10, 300, 300))
+	for i := range img.Pix {
+		img.Pix[i] = 0xc0
+	}
+	rendered := code.Image(5)
+	b := rendered.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			img.SetGray(x+7, y+3, color.Gray{Y: rendered.GrayAt(x, y).Y/2 + 0x20})
+		}
+	}
+	middle := (quietZone + code.Size/2) * 5
+	for y := middle; y < middle+10; y++ {
+		for x := middle; x < middle+10; x++ {
+			img.SetGray(x+7, y+3, color.Gray{Y: 0x20})
+		}
+	}
+
+	decoded, err := Decode(img)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal(decoded, data) {
+		t.Errorf("expected %s, got %s", data, decoded)
+	}
+}
+
+func TestDecodeNotFound(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 50, 50))
+	if _, err := Decode(img); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound for a blank image, got %v", err)
+	}
+}
+
+func TestTerminal(t *testing.T) {
+	code, err := Encode([]byte("ADSS"), Medium)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(code.Terminal(), "\n"), "\n")
+	side := code.Size + 2*quietZone
+	if len(lines) != (side+1)/2 {
+		t.Errorf("expected %d lines, got %d", (side+1)/2, len(lines))
+	}
+	for i, line := range lines {
+		if n := len([]rune(line)); n != side {
+			t.Errorf("line %d: expected %d characters, got %d", i, side, n)
+		}
+	}
+	if lines[0] != strings.Repeat("█", side) {
+		t.Errorf("expected the quiet zone to be drawn as blocks, got %q", lines[0])
+	}
+}
@@ -0,0 +1,185 @@
+package qr
+
+import "errors"
+
+// errUncorrectable is returned when a block has more errors than its error
+// correction codewords can correct.
+var errUncorrectable = errors.New("qr: too many errors to correct")
+
+// QR codes use Reed-Solomon codes over GF(2^8) with the reducing polynomial
+// x^8 + x^4 + x^3 + x^2 + 1, generated by α = 2.
+var gfExp, gfLog = gfTables()
+
+func gfTables() (exp [510]byte, log [256]byte) {
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		exp[i+255] = byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	return exp, log
+}
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+int(gfLog[b])]
+}
+
+func gfDiv(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[int(gfLog[a])+255-int(gfLog[b])]
+}
+
+// gfPow returns α^e.
+func gfPow(e int) byte {
+	e %= 255
+	if e < 0 {
+		e += 255
+	}
+	return gfExp[e]
+}
+
+// polyEval evaluates the polynomial with coefficients p, lowest degree first,
+// at x.
+func polyEval(p []byte, x byte) byte {
+	var y byte
+	for i := len(p) - 1; i >= 0; i-- {
+		y = gfMul(y, x) ^ p[i]
+	}
+	return y
+}
+
+// rsGenerator returns the generator polynomial (x - α^0)...(x - α^(degree-1)),
+// highest degree first without its leading 1.
+func rsGenerator(degree int) []byte {
+	g := make([]byte, degree)
+	g[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := 0; j < degree; j++ {
+			g[j] = gfMul(g[j], root)
+			if j+1 < degree {
+				g[j] ^= g[j+1]
+			}
+		}
+		root = gfMul(root, 2)
+	}
+	return g
+}
+
+// rsEncode returns the degree error correction codewords of data.
+func rsEncode(data []byte, degree int) []byte {
+	g := rsGenerator(degree)
+	rem := make([]byte, degree)
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[degree-1] = 0
+		for i := range rem {
+			rem[i] ^= gfMul(g[i], factor)
+		}
+	}
+	return rem
+}
+
+// rsCorrect corrects errors in block, data followed by degree error correction
+// codewords, in place. Up to degree/2 errors can be corrected.
+func rsCorrect(block []byte, degree int) error {
+	// Codeword i is the coefficient of x^(n-1-i).
+	n := len(block)
+	syndromes := make([]byte, degree)
+	clean := true
+	for i := range syndromes {
+		x := gfPow(i)
+		var s byte
+		for _, b := range block {
+			s = gfMul(s, x) ^ b
+		}
+		syndromes[i] = s
+		clean = clean && s == 0
+	}
+	if clean {
+		return nil
+	}
+
+	// Find the error locator with Berlekamp-Massey.
+	locator, prev := []byte{1}, []byte{1}
+	errs, shift, prevDiscrepancy := 0, 1, byte(1)
+	for k := 0; k < degree; k++ {
+		d := syndromes[k]
+		for i := 1; i <= errs && i < len(locator); i++ {
+			d ^= gfMul(locator[i], syndromes[k-i])
+		}
+		if d == 0 {
+			shift++
+			continue
+		}
+		old := append([]byte{}, locator...)
+		coef := gfDiv(d, prevDiscrepancy)
+		for len(locator) < len(prev)+shift {
+			locator = append(locator, 0)
+		}
+		for i, p := range prev {
+			locator[i+shift] ^= gfMul(coef, p)
+		}
+		if 2*errs <= k {
+			errs = k + 1 - errs
+			prev, prevDiscrepancy, shift = old, d, 1
+		} else {
+			shift++
+		}
+	}
+	if 2*errs > degree {
+		return errUncorrectable
+	}
+
+	// The error evaluator is the syndromes times the locator mod x^degree,
+	// and its derivative gives the magnitudes by Forney's algorithm.
+	evaluator := make([]byte, degree)
+	for i := range evaluator {
+		for j := 0; j <= i && j < len(locator); j++ {
+			evaluator[i] ^= gfMul(locator[j], syndromes[i-j])
+		}
+	}
+	derivative := make([]byte, len(locator))
+	for i := 1; i < len(locator); i += 2 {
+		derivative[i-1] = locator[i]
+	}
+
+	found := 0
+	for power := 0; power < n; power++ {
+		inv := gfPow(-power)
+		if polyEval(locator, inv) != 0 {
+			continue
+		}
+		denom := polyEval(derivative, inv)
+		if denom == 0 {
+			return errUncorrectable
+		}
+		block[n-1-power] ^= gfMul(gfPow(power), gfDiv(polyEval(evaluator, inv), denom))
+		found++
+	}
+	if found != errs {
+		return errUncorrectable
+	}
+
+	for i := 0; i < degree; i++ {
+		x := gfPow(i)
+		var s byte
+		for _, b := range block {
+			s = gfMul(s, x) ^ b
+		}
+		if s != 0 {
+			return errUncorrectable
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package qr
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestRSEncode(t *testing.T) {
+	// The version 1-M example from ISO/IEC 18004 Annex I, "01234567".
+	data := []byte{0x10, 0x20, 0x0c, 0x56, 0x61, 0x80, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11, 0xec, 0x11}
+	want := []byte{0xa5, 0x24, 0xd4, 0xc1, 0xed, 0x36, 0xc7, 0x87, 0x2c, 0x55}
+	if got := rsEncode(data, 10); !bytes.Equal(got, want) {
+		t.Errorf("expected %x, got %x", want, got)
+	}
+}
+
+func TestRSCorrect(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	data := make([]byte, 40)
+	rng.Read(data)
+	const ecc = 18
+	block := append(append([]byte{}, data...), rsEncode(data, ecc)...)
+
+	for errs := 0; errs <= ecc/2; errs++ {
+		damaged := append([]byte{}, block...)
+		for _, i := range rng.Perm(len(block))[:errs] {
+			damaged[i] ^= byte(rng.Intn(255) + 1)
+		}
+		if err := rsCorrect(damaged, ecc); err != nil {
+			t.Fatalf("%d errors: unexpected error: %s", errs, err)
+		}
+		if !bytes.Equal(damaged, block) {
+			t.Fatalf("%d errors: block not corrected", errs)
+		}
+	}
+
+	damaged := append([]byte{}, block...)
+	for _, i := range rng.Perm(len(block))[:ecc] {
+		damaged[i] ^= byte(rng.Intn(255) + 1)
+	}
+	if err := rsCorrect(damaged, ecc); err == nil && bytes.Equal(damaged, block) {
+		t.Errorf("expected %d errors to be uncorrectable", ecc)
+	}
+}
@@ -0,0 +1,296 @@
+package qr
+
+// symbol is a QR code being drawn or read, with the modules that belong to
+// function patterns marked so the codewords and masks skip them.
+type symbol struct {
+	size     int
+	modules  []bool
+	function []bool
+}
+
+// newSymbol returns a symbol of the version with its function patterns
+// drawn and the format and version information reserved.
+func newSymbol(version int) *symbol {
+	size := version*4 + 17
+	s := &symbol{size: size, modules: make([]bool, size*size), function: make([]bool, size*size)}
+
+	for i := 0; i < size; i++ {
+		s.set(6, i, i%2 == 0)
+		s.set(i, 6, i%2 == 0)
+	}
+
+	s.drawFinder(3, 3)
+	s.drawFinder(size-4, 3)
+	s.drawFinder(3, size-4)
+
+	positions := alignmentPositions(version)
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			// Skip the corners taken by finder patterns.
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					s.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+
+	s.drawFormat(Low, 0)
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = rem<<1 ^ (rem>>11)*0x1f25
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			a, b := size-11+i%3, i/3
+			s.set(a, b, bits>>uint(i)&1 == 1)
+			s.set(b, a, bits>>uint(i)&1 == 1)
+		}
+	}
+	return s
+}
+
+// set draws a function module.
+func (s *symbol) set(x, y int, dark bool) {
+	s.modules[y*s.size+x] = dark
+	s.function[y*s.size+x] = true
+}
+
+func (s *symbol) at(x, y int) bool {
+	return s.modules[y*s.size+x]
+}
+
+// drawFinder draws a finder pattern and its separator centered on x, y.
+func (s *symbol) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			if x+dx < 0 || x+dx >= s.size || y+dy < 0 || y+dy >= s.size {
+				continue
+			}
+			dist := max(abs(dx), abs(dy))
+			s.set(x+dx, y+dy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// alignmentPositions returns the centers of the alignment patterns of the
+// version along each axis.
+func alignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := (version*8 + n*3 + 5) / (n*4 - 4) * 2
+	positions := make([]int, n)
+	positions[0] = 6
+	for i, pos := n-1, version*4+10; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// formatInfo returns the 15 bits of format information for the level and
+// mask: five data bits protected by a BCH code and masked.
+func formatInfo(level Level, mask int) int {
+	data := formatBits[level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// formatPositions returns the module of each bit of the format information
+// in the two copies, the first around the top left finder and the second
+// split between the other two.
+func (s *symbol) formatPositions() (first, second [15][2]int) {
+	for i := 0; i < 15; i++ {
+		switch {
+		case i < 6:
+			first[i] = [2]int{8, i}
+		case i < 8:
+			first[i] = [2]int{8, i + 1}
+		case i == 8:
+			first[i] = [2]int{7, 8}
+		default:
+			first[i] = [2]int{14 - i, 8}
+		}
+		if i < 8 {
+			second[i] = [2]int{s.size - 1 - i, 8}
+		} else {
+			second[i] = [2]int{8, s.size - 15 + i}
+		}
+	}
+	return first, second
+}
+
+// drawFormat draws both copies of the format information and the dark module
+// beside the second.
+func (s *symbol) drawFormat(level Level, mask int) {
+	bits := formatInfo(level, mask)
+	first, second := s.formatPositions()
+	for i := 0; i < 15; i++ {
+		dark := bits>>uint(i)&1 == 1
+		s.set(first[i][0], first[i][1], dark)
+		s.set(second[i][0], second[i][1], dark)
+	}
+	s.set(8, s.size-8, true)
+}
+
+// dataPositions returns the modules that hold codewords in the order their
+// bits are placed: up and down two-module wide columns from the right,
+// skipping function patterns.
+func (s *symbol) dataPositions() [][2]int {
+	var out [][2]int
+	for right := s.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < s.size; vert++ {
+			y := vert
+			if upward {
+				y = s.size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				if x := right - j; !s.function[y*s.size+x] {
+					out = append(out, [2]int{x, y})
+				}
+			}
+		}
+	}
+	return out
+}
+
+// drawCodewords places the codewords in the data modules. Any modules left
+// over are light.
+func (s *symbol) drawCodewords(codewords []byte) {
+	for i, pos := range s.dataPositions() {
+		if i < len(codewords)*8 {
+			s.modules[pos[1]*s.size+pos[0]] = codewords[i/8]>>uint(7-i%8)&1 == 1
+		}
+	}
+}
+
+// readCodewords reads n codewords from the data modules.
+func (s *symbol) readCodewords(n int) []byte {
+	out := make([]byte, n)
+	for i, pos := range s.dataPositions() {
+		if i < n*8 && s.at(pos[0], pos[1]) {
+			out[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+	return out
+}
+
+// applyMask inverts the data modules selected by the mask. Applying it twice
+// undoes it.
+func (s *symbol) applyMask(mask int) {
+	for y := 0; y < s.size; y++ {
+		for x := 0; x < s.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !s.function[y*s.size+x] {
+				s.modules[y*s.size+x] = !s.modules[y*s.size+x]
+			}
+		}
+	}
+}
+
+// penalty scores how hard the symbol is to scan, following the four rules
+// used to choose a mask: long runs of one color, 2x2 blocks, patterns that
+// look like finders and an imbalance of dark and light.
+func (s *symbol) penalty() int {
+	finderLike := [2][11]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+
+	total, dark := 0, 0
+	for _, transposed := range []bool{false, true} {
+		at := s.at
+		if transposed {
+			at = func(x, y int) bool { return s.at(y, x) }
+		}
+		for y := 0; y < s.size; y++ {
+			run := 0
+			for x := 0; x < s.size; x++ {
+				if x > 0 && at(x, y) == at(x-1, y) {
+					run++
+				} else {
+					run = 1
+				}
+				if run == 5 {
+					total += 3
+				} else if run > 5 {
+					total++
+				}
+
+				for _, pattern := range finderLike {
+					if x+len(pattern) > s.size {
+						continue
+					}
+					matches := true
+					for i, p := range pattern {
+						matches = matches && at(x+i, y) == p
+					}
+					if matches {
+						total += 40
+					}
+				}
+			}
+		}
+	}
+
+	for y := 0; y < s.size; y++ {
+		for x := 0; x < s.size; x++ {
+			c := s.at(x, y)
+			if c {
+				dark++
+			}
+			if x+1 < s.size && y+1 < s.size && c == s.at(x+1, y) && c == s.at(x, y+1) && c == s.at(x+1, y+1) {
+				total += 3
+			}
+		}
+	}
+
+	modules := s.size * s.size
+	total += (abs(dark*20-modules*10)+modules-1)/modules*10 - 10
+	return total
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,53 @@
+package adss
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+)
+
+var (
+	iv1 = []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	iv2 = []byte{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+)
+
+// newKeyStreams derives the two AES-CTR keystreams used to encrypt the
+// message and the randomness. The IV is used as a domain separator so each
+// input gets a unique keystream.
+func newKeyStreams(k []byte) (cipher.Stream, cipher.Stream, error) {
+	ciph, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cipher.NewCTR(ciph, iv1), cipher.NewCTR(ciph, iv2), nil
+}
+
+// xorKeyStreamTwoInputs will derive an AES keystream using the key and then
+// generate a unique keystream for each input using the IV as a domain separator
+// and return the output. This can be used to encrypt and decrypt.
+//
+// Both outputs are backed by a single allocation.
+func xorKeyStreamTwoInputs(k, p1, p2 []byte) ([]byte, []byte, error) {
+	out := make([]byte, len(p1)+len(p2))
+	c1, c2 := out[:len(p1):len(p1)], out[len(p1):]
+	copy(c1, p1)
+	copy(c2, p2)
+
+	if err := xorKeyStreamTwoInputsInPlace(k, c1, c2); err != nil {
+		return nil, nil, err
+	}
+	return c1, c2, nil
+}
+
+// xorKeyStreamTwoInputsInPlace is like xorKeyStreamTwoInputs but overwrites
+// the inputs with the output rather than allocating.
+func xorKeyStreamTwoInputsInPlace(k, b1, b2 []byte) error {
+	stream1, stream2, err := newKeyStreams(k)
+	if err != nil {
+		return err
+	}
+
+	stream1.XORKeyStream(b1, b1)
+	stream2.XORKeyStream(b2, b2)
+	return nil
+}
@@ -0,0 +1,62 @@
+package adss
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+func TestXorKeyStreamTwoInputs(t *testing.T) {
+	k := make([]byte, 32)
+	p1 := make([]byte, 100003)
+	p2 := make([]byte, 32)
+	for _, b := range [][]byte{k, p1, p2} {
+		if _, err := rand.Read(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c1, c2, err := xorKeyStreamTwoInputs(k, p1, p2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Appending to the first output must not clobber the second since they
+	// share a backing array.
+	_ = append(c1, 0xff)
+	d1, d2, err := xorKeyStreamTwoInputs(k, c1, c2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !bytes.Equal(d1, p1) || !bytes.Equal(d2, p2) {
+		t.Errorf("decryption did not round trip")
+	}
+}
+
+func BenchmarkXorKeyStreamTwoInputs(b *testing.B) {
+	k := make([]byte, 32)
+	p1 := make([]byte, 16*1024*1024)
+	p2 := make([]byte, 32)
+
+	b.SetBytes(int64(len(p1)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := xorKeyStreamTwoInputs(k, p1, p2); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkXorKeyStreamTwoInputsInPlace(b *testing.B) {
+	k := make([]byte, 32)
+	p1 := make([]byte, 16*1024*1024)
+	p2 := make([]byte, 32)
+
+	b.SetBytes(int64(len(p1)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := xorKeyStreamTwoInputsInPlace(k, p1, p2); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
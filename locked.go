@@ -0,0 +1,179 @@
+package adss
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// LockedShare is a SecretShare whose secret portion has been encrypted under
+// a key derived from the holder's passphrase. The public fields are kept in
+// the clear and authenticated during unlocking.
+type LockedShare struct {
+	As  AccessStructure
+	ID  uint16
+	Pub struct {
+		C, D, J []byte
+	}
+	Tag []byte
+
+	Hardening *Argon2Params `json:",omitempty"`
+	Version   uint8         `json:",omitempty"`
+	Domain    string        `json:",omitempty"`
+	Scheme    uint8         `json:",omitempty"`
+	Points    []uint8       `json:",omitempty"`
+
+	KDF    Argon2Params
+	Salt   []byte
+	Nonce  []byte
+	Sealed []byte // AES-256-GCM encryption of Sec
+}
+
+// lockedNonceSize is the size of the standard AES-GCM nonce locked shares use.
+const lockedNonceSize = 12
+
+// LockShare encrypts the secret portion of share under a key derived from
+// passphrase using Argon2id with DefaultArgon2Params.
+func LockShare(share *SecretShare, passphrase []byte) (*LockedShare, error) {
+	return LockShareWithParams(share, passphrase, DefaultArgon2Params)
+}
+
+// LockShareWithParams is like LockShare but allows choosing the Argon2id
+// parameters.
+func LockShareWithParams(share *SecretShare, passphrase []byte, params Argon2Params) (*LockedShare, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	ls := &LockedShare{
+		As:  share.As,
+		ID:  share.ID,
+		Pub: share.Pub,
+		Tag: share.Tag,
+
+		Hardening: share.Hardening,
+		Version:   share.Version,
+		Domain:    share.Domain,
+		Scheme:    share.Scheme,
+		Points:    share.Points,
+
+		KDF:   params,
+		Salt:  make([]byte, 16),
+		Nonce: make([]byte, lockedNonceSize),
+	}
+	if _, err := rand.Read(ls.Salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(ls.Nonce); err != nil {
+		return nil, err
+	}
+
+	aead, err := ls.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	ls.Sealed = aead.Seal(nil, ls.Nonce, share.Sec, ls.additionalData())
+
+	return ls, nil
+}
+
+// Unlock decrypts the secret portion of the share with passphrase and returns
+// the original SecretShare. It fails if the passphrase is wrong or any field
+// of the locked share has been modified.
+func (ls *LockedShare) Unlock(passphrase []byte) (*SecretShare, error) {
+	// The KDF parameters come from the file, so they are checked against
+	// the limits before the expensive derivation, as is everything else that
+	// can be checked without it.
+	if err := ls.KDF.validate(); err != nil {
+		return nil, err
+	}
+	if len(ls.Nonce) != lockedNonceSize {
+		return nil, fmt.Errorf("invalid nonce length: %d", len(ls.Nonce))
+	}
+
+	aead, err := ls.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	sec, err := aead.Open(nil, ls.Nonce, ls.Sealed, ls.additionalData())
+	if err != nil {
+		return nil, fmt.Errorf("incorrect passphrase or corrupted share")
+	}
+
+	return &SecretShare{
+		As:  ls.As,
+		ID:  ls.ID,
+		Pub: ls.Pub,
+		Sec: sec,
+		Tag: ls.Tag,
+
+		Hardening: ls.Hardening,
+		Version:   ls.Version,
+		Domain:    ls.Domain,
+		Scheme:    ls.Scheme,
+		Points:    ls.Points,
+	}, nil
+}
+
+func (ls *LockedShare) aead(passphrase []byte) (cipher.AEAD, error) {
+	key := argon2.IDKey(passphrase, ls.Salt, ls.KDF.Time, ls.KDF.Memory, ls.KDF.Threads, 32)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData binds the public fields and KDF parameters to the
+// ciphertext. Each variable length field is length-prefixed so the encoding
+// is unambiguous.
+func (ls *LockedShare) additionalData() []byte {
+	out := make([]byte, 0)
+	out = append(out, ls.As.Bytes()...)
+	out = appendIDs(out, []uint16{ls.ID}, ls.As.wide())
+	for _, field := range [][]byte{ls.Pub.C, ls.Pub.D, ls.Pub.J, ls.Tag, ls.Salt} {
+		out = appendUint32(out, uint32(len(field)))
+		out = append(out, field...)
+	}
+	out = append(out, ls.KDF.Bytes()...)
+	if ls.Hardening != nil {
+		out = append(out, ls.Hardening.Bytes()...)
+	}
+	// Appended only when set so shares locked before versioning still unlock.
+	// The hardening parameters are a fixed length so this is unambiguous.
+	if ls.Version != VersionUnframed {
+		out = append(out, ls.Version)
+	}
+	// Each of the later fields is also written, even if empty, when any field
+	// after it is set so none can be mistaken for another.
+	exclusions := ls.As.exclusionBytes()
+	hasPoints := ls.Points != nil
+	hasScheme := ls.Scheme != SchemeShamir || hasPoints
+	if ls.Domain != "" || exclusions != nil || hasScheme {
+		out = appendUint32(out, uint32(len(ls.Domain)))
+		out = append(out, ls.Domain...)
+	}
+	if exclusions != nil || hasScheme {
+		out = appendUint32(out, uint32(len(exclusions)))
+		out = append(out, exclusions...)
+	}
+	if hasScheme {
+		out = append(out, ls.Scheme)
+	}
+	if hasPoints {
+		out = appendUint32(out, uint32(len(ls.Points)))
+		out = append(out, ls.Points...)
+	}
+	return out
+}
+
+func appendUint32(out []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	return append(out, buf[:]...)
+}
@@ -0,0 +1,65 @@
+package adss
+
+import (
+	"testing"
+)
+
+// testArgon2Params are cheap parameters so the tests run quickly.
+var testArgon2Params = Argon2Params{Time: 1, Memory: 64, Threads: 1}
+
+func TestLockAndUnlockShare(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	passphrase := []byte("correct horse battery staple")
+	locked, err := LockShareWithParams(shares[0], passphrase, testArgon2Params)
+	if err != nil {
+		t.Fatalf("unexpected error on locking: %s", err)
+	}
+
+	unlocked, err := locked.Unlock(passphrase)
+	if err != nil {
+		t.Fatalf("unexpected error on unlocking: %s", err)
+	}
+
+	if !unlocked.Equal(shares[0]) {
+		t.Errorf("unlocked share does not match original")
+	}
+
+	var errTests = []struct {
+		name   string
+		modify func(ls *LockedShare) []byte
+	}{
+		{"wrong passphrase", func(ls *LockedShare) []byte { return []byte("wrong") }},
+		{"modified ID", func(ls *LockedShare) []byte { ls.ID++; return passphrase }},
+		{"modified C", func(ls *LockedShare) []byte { ls.Pub.C[0]++; return passphrase }},
+		{"modified tag", func(ls *LockedShare) []byte { ls.Tag[0]++; return passphrase }},
+		{"modified sealed", func(ls *LockedShare) []byte { ls.Sealed[0]++; return passphrase }},
+		{"modified kdf", func(ls *LockedShare) []byte { ls.KDF.Time++; return passphrase }},
+		{"modified domain", func(ls *LockedShare) []byte { ls.Domain = "example.com"; return passphrase }},
+		{"short nonce", func(ls *LockedShare) []byte { ls.Nonce = ls.Nonce[:8]; return passphrase }},
+		{"zero kdf time", func(ls *LockedShare) []byte { ls.KDF.Time = 0; return passphrase }},
+		{"huge kdf memory", func(ls *LockedShare) []byte { ls.KDF.Memory = 0xffffffff; return passphrase }},
+		{"huge kdf time", func(ls *LockedShare) []byte { ls.KDF.Time = 0xffffffff; return passphrase }},
+		{"too many kdf threads", func(ls *LockedShare) []byte { ls.KDF.Threads = 255; return passphrase }},
+	}
+
+	for _, tt := range errTests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			mod, err := LockShareWithParams(cloneShare(shares[0]), passphrase, testArgon2Params)
+			if err != nil {
+				t.Fatalf("unexpected error on locking: %s", err)
+			}
+
+			if _, err := mod.Unlock(tt.modify(mod)); err == nil {
+				t.Errorf("expected error on unlocking")
+			}
+		})
+	}
+	if _, err := LockShareWithParams(shares[0], passphrase, Argon2Params{Time: 1, Memory: 0xffffffff, Threads: 1}); err == nil {
+		t.Errorf("expected error locking with parameters over the limits")
+	}
+}
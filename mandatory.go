@@ -0,0 +1,52 @@
+package adss
+
+import (
+	"fmt"
+	"sort"
+)
+
+// mandatoryMarker follows the threshold and count in the bytes of an access
+// structure with mandatory shares. It can't be mistaken for what follows them
+// otherwise: a version in the hash inputs, or a share ID in other encodings,
+// since IDs are below the count.
+const mandatoryMarker = 0xff
+
+// WithMandatory returns a copy of the access structure in which the shares
+// with the given IDs must be present to recover, in addition to meeting the
+// threshold, such as the security officer's share. There can be at most T
+// mandatory shares, and none of them may be excluded from recovering
+// together.
+func (as AccessStructure) WithMandatory(ids ...uint16) (AccessStructure, error) {
+	as = as.clone()
+	as.Mandatory = append(IDSet{}, ids...)
+	sort.Slice(as.Mandatory, func(i, j int) bool { return as.Mandatory[i] < as.Mandatory[j] })
+
+	if err := as.validateMandatory(); err != nil {
+		return as, err
+	}
+	return as, nil
+}
+
+// validateMandatory returns an error if the mandatory shares can't all be
+// part of a set of shares that recovers.
+func (as *AccessStructure) validateMandatory() error {
+	if len(as.Mandatory) > int(as.T) {
+		return fmt.Errorf("%d mandatory shares is more than the threshold of %d", len(as.Mandatory), as.T)
+	}
+	for i, id := range as.Mandatory {
+		if id >= as.N {
+			return fmt.Errorf("mandatory ID %d out of range for %d-of-%d", id, as.T, as.N)
+		}
+		if i > 0 && id <= as.Mandatory[i-1] {
+			return fmt.Errorf("mandatory IDs %s are not sorted or repeat an ID", as.Mandatory)
+		}
+	}
+	present := make([]bool, as.N)
+	for _, id := range as.Mandatory {
+		present[id] = true
+	}
+	if as.hasExclusion(present) {
+		return fmt.Errorf("mandatory IDs %s are excluded from recovering together", as.Mandatory)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package adss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAccessStructure_WithMandatory(t *testing.T) {
+	as, err := NewAccessStructure(3, 5).WithMandatory(4, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if compareIDSets(as.Mandatory, IDSet{1, 4}) != 0 {
+		t.Errorf("got mandatory IDs %v, expected: [1 4]", as.Mandatory)
+	}
+	if expected := []byte{3, 5, mandatoryMarker, 2, 1, 4}; !bytes.Equal(as.Bytes(), expected) {
+		t.Errorf("got bytes %x, expected: %x", as.Bytes(), expected)
+	}
+
+	excluded, err := NewAccessStructureWithExclusions(2, 3, IDSet{0, 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var errTests = []struct {
+		name string
+		as   AccessStructure
+		ids  []uint16
+	}{
+		{"more than threshold", NewAccessStructure(2, 3), []uint16{0, 1, 2}},
+		{"out of range", NewAccessStructure(2, 3), []uint16{3}},
+		{"repeated", NewAccessStructure(2, 3), []uint16{1, 1}},
+		{"excluded", excluded, []uint16{0, 1}},
+	}
+	for _, tt := range errTests {
+		if _, err := tt.as.WithMandatory(tt.ids...); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+func TestShareWithMandatory(t *testing.T) {
+	msg := []byte("hello world")
+	as, err := NewAccessStructure(2, 4).WithMandatory(0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	shares, err := Share(as, msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	if _, _, err := Recover([]*SecretShare{shares[1], shares[2], shares[3]}); err == nil {
+		t.Errorf("recovered without the mandatory share")
+	}
+	if recov, _, err := Recover([]*SecretShare{shares[3], shares[0]}); err != nil || !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %q, %v", recov, err)
+	}
+	if recov, valid, err := Recover(shares); err != nil || !bytes.Equal(recov, msg) || len(valid) != 4 {
+		t.Errorf("recovered %q, %d valid shares, %v", recov, len(valid), err)
+	}
+
+	// The mandatory shares are part of the checksum so they can't be removed.
+	stripped := make([]*SecretShare, 3)
+	for i := range stripped {
+		stripped[i] = cloneShare(shares[i+1])
+		stripped[i].As.Mandatory = nil
+	}
+	if recov, _, err := Recover(stripped); err == nil {
+		t.Errorf("recovered %q after removing the mandatory shares", recov)
+	}
+
+	if decoded, err := DecodeShareString(EncodeShareString(shares[0])); err != nil || !decoded.Equal(shares[0]) {
+		t.Errorf("share string round trip failed: %v", err)
+	}
+	locked, err := LockShareWithParams(shares[0], []byte("passphrase"), testArgon2Params)
+	if err != nil {
+		t.Fatalf("unexpected error on locking: %s", err)
+	}
+	locked.As.Mandatory = nil
+	if _, err := locked.Unlock([]byte("passphrase")); err == nil {
+		t.Errorf("unlocked after removing the mandatory shares")
+	}
+}
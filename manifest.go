@@ -0,0 +1,155 @@
+package adss
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Manifest describes a sharing without any secret material: its parameters,
+// fingerprint, the shares that were created and who holds them. It is built
+// when splitting, kept with the ceremony records, and used at recovery to
+// check that the provided shares are the ones that were handed out.
+type Manifest struct {
+	Threshold            uint16          `json:"threshold"`
+	Count                uint16          `json:"count"`
+	Fingerprint          string          `json:"fingerprint"`
+	AssociatedDataSHA256 string          `json:"associated_data_sha256"`
+	PayloadSHA256        string          `json:"payload_sha256"`
+	Hardening            *Argon2Params   `json:"hardening,omitempty"`
+	Version              uint8           `json:"version,omitempty"`
+	Domain               string          `json:"domain,omitempty"`
+	Scheme               uint8           `json:"scheme,omitempty"`
+	Points               []uint8         `json:"points,omitempty"`
+	CreatedAt            time.Time       `json:"created_at"`
+	Shares               []ManifestShare `json:"shares"`
+}
+
+// ManifestShare describes one share in a Manifest.
+type ManifestShare struct {
+	ID     uint16 `json:"id"`
+	Holder string `json:"holder,omitempty"`
+	SHA256 string `json:"sha256"`
+	// PossessionKey verifies the holder's proofs that they still hold the
+	// share, see SecretShare.ProvePossession.
+	PossessionKey []byte `json:"possession_key,omitempty"`
+}
+
+// NewManifest returns the manifest of a sharing. holders is either empty or
+// labels the holder of each share in order.
+func NewManifest(shares []*SecretShare, holders []string) (*Manifest, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("no shares provided")
+	}
+	if len(holders) > 0 && len(holders) != len(shares) {
+		return nil, fmt.Errorf("expected %d holders, got %d", len(shares), len(holders))
+	}
+
+	share0 := shares[0]
+	m := &Manifest{
+		Threshold:            share0.As.T,
+		Count:                share0.As.N,
+		Fingerprint:          share0.Fingerprint(),
+		AssociatedDataSHA256: hashHex(share0.Tag),
+		PayloadSHA256:        payloadHash(share0),
+		Hardening:            share0.Hardening,
+		Version:              share0.Version,
+		Domain:               share0.Domain,
+		Scheme:               share0.Scheme,
+		Points:               share0.Points,
+		CreatedAt:            time.Now().UTC(),
+		Shares:               make([]ManifestShare, len(shares)),
+	}
+
+	seen := make(map[uint16]bool, len(shares))
+	for i, share := range shares {
+		if err := checkConsistent(share0, share); err != nil {
+			return nil, err
+		}
+		if share.Fingerprint() != m.Fingerprint {
+			return nil, fmt.Errorf("shares are from different sharings")
+		}
+		if seen[share.ID] {
+			return nil, fmt.Errorf("duplicate share ID: %d", share.ID)
+		}
+		seen[share.ID] = true
+
+		m.Shares[i] = ManifestShare{ID: share.ID, SHA256: shareHash(share), PossessionKey: share.PossessionKey()}
+		if len(holders) > 0 {
+			m.Shares[i].Holder = holders[i]
+		}
+	}
+
+	return m, nil
+}
+
+// Holder returns the holder of the share with the given ID, which is empty if
+// the manifest doesn't name one.
+func (m *Manifest) Holder(id uint16) string {
+	for _, entry := range m.Shares {
+		if entry.ID == id {
+			return entry.Holder
+		}
+	}
+	return ""
+}
+
+// VerifyShare returns an error if the share isn't one of the shares described
+// by the manifest.
+func (m *Manifest) VerifyShare(share *SecretShare) error {
+	if share.As.T != m.Threshold || share.As.N != m.Count {
+		return fmt.Errorf("share is %d-of-%d, manifest declares %d-of-%d", share.As.T, share.As.N, m.Threshold, m.Count)
+	}
+	if share.Fingerprint() != m.Fingerprint {
+		return fmt.Errorf("share is from sharing %s, manifest declares %s", share.Fingerprint(), m.Fingerprint)
+	}
+	if hashHex(share.Tag) != m.AssociatedDataSHA256 {
+		return fmt.Errorf("share has different associated data than the manifest")
+	}
+	if payloadHash(share) != m.PayloadSHA256 {
+		return fmt.Errorf("share has a different public payload than the manifest")
+	}
+
+	for _, entry := range m.Shares {
+		if entry.ID == share.ID {
+			if entry.SHA256 != shareHash(share) {
+				return fmt.Errorf("share doesn't match share %d in the manifest", share.ID)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("share ID %d isn't in the manifest", share.ID)
+}
+
+// Verify returns an error for the first of the shares that VerifyShare
+// rejects.
+func (m *Manifest) Verify(shares []*SecretShare) error {
+	for _, share := range shares {
+		if err := m.VerifyShare(share); err != nil {
+			return fmt.Errorf("share %d: %w", share.ID, err)
+		}
+	}
+	return nil
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// payloadHash hashes the public payload, length prefixing each part.
+func payloadHash(share *SecretShare) string {
+	h := sha256.New()
+	for _, part := range [][]byte{share.Pub.C, share.Pub.D, share.Pub.J} {
+		h.Write(appendUint32(nil, uint32(len(part))))
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// shareHash hashes the decodable encoding of the share so a holder can check
+// they have the share listed in a manifest.
+func shareHash(share *SecretShare) string {
+	return hashHex(share.compactBytes())
+}
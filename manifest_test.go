@@ -0,0 +1,71 @@
+package adss
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	other, err := Share(NewAccessStructure(2, 3), []byte("hello world"), []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	m, err := NewManifest(shares[:2], []string{"alice", "bob"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if m.Threshold != 2 || m.Count != 3 || m.Fingerprint != shares[0].Fingerprint() || len(m.Shares) != 2 {
+		t.Errorf("unexpected manifest: %+v", m)
+	}
+	if m.Holder(1) != "bob" || m.Holder(2) != "" {
+		t.Errorf("unexpected holders: %q, %q", m.Holder(1), m.Holder(2))
+	}
+
+	// The manifest survives a round trip through JSON.
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("unexpected error marshalling: %s", err)
+	}
+	m = &Manifest{}
+	if err := json.Unmarshal(encoded, m); err != nil {
+		t.Fatalf("unexpected error unmarshalling: %s", err)
+	}
+
+	if err := m.Verify(shares[:2]); err != nil {
+		t.Errorf("unexpected error verifying: %s", err)
+	}
+
+	corrupt := cloneShare(shares[1])
+	corrupt.Sec[0]++
+	rotted := cloneShare(shares[1])
+	rotted.Pub.C[0]++
+
+	var tests = []struct {
+		name  string
+		share *SecretShare
+		err   string
+	}{
+		{"unlisted", shares[2], "share ID 2 isn't in the manifest"},
+		{"other sharing", other[0], "share is from sharing " + other[0].Fingerprint() + ", manifest declares " + m.Fingerprint},
+		{"corrupt secret", corrupt, "share doesn't match share 1 in the manifest"},
+		{"corrupt payload", rotted, "share has a different public payload than the manifest"},
+	}
+	for _, tt := range tests {
+		err := m.VerifyShare(tt.share)
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("%s: unexpected error, expected: %s, got: %v", tt.name, tt.err, err)
+		}
+	}
+
+	if _, err := NewManifest([]*SecretShare{shares[0], other[1]}, nil); err == nil {
+		t.Errorf("expected error for shares from different sharings")
+	}
+	if _, err := NewManifest(shares, []string{"alice"}); err == nil {
+		t.Errorf("expected error for wrong number of holders")
+	}
+}
@@ -0,0 +1,67 @@
+package adss
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// metadataMagic starts every tag that carries metadata.
+var metadataMagic = []byte("adss metadata\x00\x01")
+
+// Metadata describes a sharing so that shares found years later are
+// self-describing. It is stored in the associated data, so it is
+// authenticated along with the rest of the sharing and can't be changed
+// without invalidating the shares.
+type Metadata struct {
+	CreatedAt time.Time // zero if not recorded
+	Note      string
+}
+
+// TagWithMetadata returns associated data carrying both ad and md. Pass it as
+// the associated data to any of the Share functions and use ParseTag to get
+// them back from the shares.
+func TagWithMetadata(ad []byte, md Metadata) []byte {
+	out := append([]byte{}, metadataMagic...)
+
+	var createdAt [8]byte
+	if !md.CreatedAt.IsZero() {
+		binary.BigEndian.PutUint64(createdAt[:], uint64(md.CreatedAt.Unix()))
+	}
+	out = append(out, createdAt[:]...)
+
+	var length [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(length[:], uint64(len(md.Note)))
+	out = append(out, length[:n]...)
+	out = append(out, md.Note...)
+
+	return append(out, ad...)
+}
+
+// ParseTag splits associated data created by TagWithMetadata back into the
+// caller's associated data and the metadata. Associated data without metadata
+// is returned as is with nil metadata.
+func ParseTag(T []byte) ([]byte, *Metadata, error) {
+	if !bytes.HasPrefix(T, metadataMagic) {
+		return T, nil, nil
+	}
+
+	data := T[len(metadataMagic):]
+	if len(data) < 8 {
+		return nil, nil, fmt.Errorf("metadata too short")
+	}
+	md := &Metadata{}
+	if createdAt := binary.BigEndian.Uint64(data[:8]); createdAt != 0 {
+		md.CreatedAt = time.Unix(int64(createdAt), 0).UTC()
+	}
+	data = data[8:]
+
+	length, n := binary.Uvarint(data)
+	if n <= 0 || length > uint64(len(data)-n) {
+		return nil, nil, fmt.Errorf("metadata note length invalid")
+	}
+	md.Note = string(data[n : n+int(length)])
+
+	return data[n+int(length):], md, nil
+}
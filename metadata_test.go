@@ -0,0 +1,64 @@
+package adss
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTagWithMetadata(t *testing.T) {
+	createdAt := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var tests = []struct {
+		name string
+		ad   []byte
+		md   Metadata
+	}{
+		{"both", []byte("ad"), Metadata{CreatedAt: createdAt, Note: "root CA key"}},
+		{"no ad", nil, Metadata{CreatedAt: createdAt}},
+		{"no time", []byte("ad"), Metadata{Note: "note"}},
+		{"empty", nil, Metadata{}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ad, md, err := ParseTag(TagWithMetadata(tt.ad, tt.md))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !bytes.Equal(ad, tt.ad) {
+				t.Errorf("ad = %q, expected: %q", ad, tt.ad)
+			}
+			if md == nil || !md.CreatedAt.Equal(tt.md.CreatedAt) || md.Note != tt.md.Note {
+				t.Errorf("metadata = %+v, expected: %+v", md, tt.md)
+			}
+		})
+	}
+
+	ad, md, err := ParseTag([]byte("plain ad"))
+	if err != nil || md != nil || string(ad) != "plain ad" {
+		t.Errorf("unexpected result for plain ad: %q, %+v, %v", ad, md, err)
+	}
+
+	if _, _, err := ParseTag(append(append([]byte{}, metadataMagic...), 0, 0)); err == nil {
+		t.Errorf("expected error for truncated metadata")
+	}
+}
+
+func TestMetadataIsAuthenticated(t *testing.T) {
+	T := TagWithMetadata(nil, Metadata{Note: "original"})
+	shares, err := Share(NewAccessStructure(2, 2), []byte("hello world"), T)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	forged := TagWithMetadata(nil, Metadata{Note: "modified"})
+	modified := []*SecretShare{cloneShare(shares[0]), cloneShare(shares[1])}
+	for _, share := range modified {
+		share.Tag = forged
+	}
+
+	if _, _, err := Recover(modified); err == nil {
+		t.Errorf("recovered shares with modified metadata")
+	}
+}
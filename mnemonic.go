@@ -0,0 +1,202 @@
+package adss
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Share mnemonics encode a share as words from the BIP 39 English word list
+// for writing down by hand. The share's compact encoding is prefixed with a
+// header byte and its length as a uvarint and padded with zeros to a multiple
+// of four bytes, and then encoded as in BIP 39: a checksum of the first len/32
+// bits of its SHA-256 digest is appended and every 11 bits select a word. Only
+// the length isn't limited to 256 bits.
+//
+// The high four bits of the header are the version of the mnemonic format
+// and the low four bits identify the word list, so word lists in other
+// languages can be added without changing how existing mnemonics decode.
+
+// mnemonicVersion is the version of the mnemonic format, in the high bits of
+// the header.
+const mnemonicVersion = 0
+
+// mnemonicEnglish identifies the BIP 39 English word list in the low bits of
+// the header.
+const mnemonicEnglish = 0
+
+// wordList is a BIP 39 word list that mnemonics can be written in.
+type wordList struct {
+	id     uint8  // the identifier in the mnemonic header
+	source string // the words separated by spaces
+
+	once    sync.Once
+	words   []string
+	indexes map[string]uint16
+}
+
+// wordLists are the word lists mnemonics can be written in. Another language
+// is added with its BIP 39 list and an unused identifier, and decoding tells
+// the lists apart by their words. Only English is included so far.
+var wordLists = []*wordList{
+	{id: mnemonicEnglish, source: bip39English},
+}
+
+// load returns the words of the list and the index of each word and of its
+// first four letters, which identify a word in the BIP 39 lists.
+func (wl *wordList) load() ([]string, map[string]uint16) {
+	wl.once.Do(func() {
+		wl.words = strings.Fields(wl.source)
+		wl.indexes = make(map[string]uint16, 2*len(wl.words))
+		for i, word := range wl.words {
+			wl.indexes[word] = uint16(i)
+			if len(word) > 4 {
+				wl.indexes[word[:4]] = uint16(i)
+			}
+		}
+	})
+	return wl.words, wl.indexes
+}
+
+// bip39WordList returns the words and indexes of the BIP 39 English list.
+func bip39WordList() ([]string, map[string]uint16) {
+	return wordLists[0].load()
+}
+
+// wordListOf returns the word list that has the first of the words, or nil.
+func wordListOf(fields []string) *wordList {
+	if len(fields) == 0 {
+		return nil
+	}
+	for _, wl := range wordLists {
+		_, indexes := wl.load()
+		if _, ok := indexes[fields[0]]; ok {
+			return wl
+		}
+	}
+	return nil
+}
+
+// EncodeShareMnemonic encodes the share as words from the BIP 39 English word
+// list separated by spaces. A share takes three words for every four bytes of
+// its compact encoding, so mnemonics are intended for small shares.
+func EncodeShareMnemonic(share *SecretShare) string {
+	compact := share.compactBytes()
+	var header [1 + binary.MaxVarintLen64]byte
+	wl := wordLists[0]
+	header[0] = mnemonicVersion<<4 | wl.id
+	n := 1 + binary.PutUvarint(header[1:], uint64(len(compact)))
+	entropy := append(header[:n:n], compact...)
+	entropy = append(entropy, make([]byte, (4-len(entropy)%4)%4)...)
+	return strings.Join(wl.encode(entropy), " ")
+}
+
+// DecodeShareMnemonic decodes a share encoded with EncodeShareMnemonic. Words
+// may be separated by any whitespace, are case insensitive and may be
+// shortened to their first four letters. It returns an error naming the first
+// word that isn't in the list, or if the checksum doesn't match, which means a
+// word was written down wrong.
+func DecodeShareMnemonic(s string) (*SecretShare, error) {
+	fields := strings.Fields(strings.ToLower(s))
+	wl := wordListOf(fields)
+	if wl == nil {
+		wl = wordLists[0]
+	}
+	entropy, err := wl.decode(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	if version := entropy[0] >> 4; version != mnemonicVersion {
+		return nil, fmt.Errorf("unsupported mnemonic version %d", version)
+	}
+	if list := entropy[0] & 0xf; list != wl.id {
+		return nil, fmt.Errorf("mnemonic is for word list %d, but was written in the words of list %d", list, wl.id)
+	}
+	entropy = entropy[1:]
+
+	length, n := binary.Uvarint(entropy)
+	if n <= 0 || length > uint64(len(entropy)-n) {
+		return nil, fmt.Errorf("mnemonic length invalid")
+	}
+	padding := entropy[n+int(length):]
+	if len(padding) >= 4 {
+		return nil, fmt.Errorf("mnemonic padding invalid")
+	}
+	for _, b := range padding {
+		if b != 0 {
+			return nil, fmt.Errorf("mnemonic padding invalid")
+		}
+	}
+	return parseCompactShare(entropy[n : n+int(length)])
+}
+
+// encode returns the BIP 39 words of entropy, whose length must be a multiple
+// of four bytes.
+func (wl *wordList) encode(entropy []byte) []string {
+	words, _ := wl.load()
+	digest := sha256.Sum256(entropy)
+	bits := append(append([]byte{}, entropy...), digest[:]...)
+	out := make([]string, len(entropy)*8*33/32/11)
+	for i := range out {
+		out[i] = words[readBits(bits, i*11, 11)]
+	}
+	return out
+}
+
+// decode returns the entropy encoded by the lowercase BIP 39 words, checking
+// its checksum.
+func (wl *wordList) decode(fields []string) ([]byte, error) {
+	_, indexes := wl.load()
+	if len(fields) == 0 || len(fields)%3 != 0 {
+		return nil, fmt.Errorf("mnemonic has %d words, expected a multiple of 3", len(fields))
+	}
+
+	bits := make([]byte, (len(fields)*11+7)/8)
+	for i, word := range fields {
+		index, ok := indexes[word]
+		if !ok {
+			return nil, fmt.Errorf("word %d, %q, isn't in the word list", i+1, word)
+		}
+		writeBits(bits, i*11, 11, int(index))
+	}
+
+	entropyLen := len(fields) * 11 * 32 / 33 / 8
+	entropy := bits[:entropyLen]
+	digest := sha256.Sum256(entropy)
+	for i := 0; i < entropyLen*8/32; i++ {
+		if bitAt(bits, entropyLen*8+i) != bitAt(digest[:], i) {
+			return nil, fmt.Errorf("mnemonic checksum failed, check the words")
+		}
+	}
+	return entropy, nil
+}
+
+// bitAt returns bit i of data, counting from the most significant bit of the
+// first byte.
+func bitAt(data []byte, i int) int {
+	return int(data[i/8]>>(7-uint(i%8))) & 1
+}
+
+// readBits returns count bits of data starting at bit offset, most
+// significant first.
+func readBits(data []byte, offset, count int) int {
+	v := 0
+	for i := offset; i < offset+count; i++ {
+		v = v<<1 | bitAt(data, i)
+	}
+	return v
+}
+
+// writeBits sets count bits of data starting at bit offset to v, most
+// significant first.
+func writeBits(data []byte, offset, count, v int) {
+	for i := 0; i < count; i++ {
+		if v>>(count-1-i)&1 == 1 {
+			bit := offset + i
+			data[bit/8] |= 1 << (7 - uint(bit%8))
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package adss
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestShareMnemonic(t *testing.T) {
+	msg := []byte("hello world")
+	shares, err := Share(NewAccessStructure(2, 3), msg, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	hardened, err := ShareHardened(NewAccessStructure(2, 3), msg, nil, testArgon2Params)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	for _, share := range []*SecretShare{shares[0], shares[2], hardened[1]} {
+		mnemonic := EncodeShareMnemonic(share)
+		decoded, err := DecodeShareMnemonic(mnemonic)
+		if err != nil {
+			t.Fatalf("unexpected error decoding: %s", err)
+		}
+		if !decoded.Equal(share) {
+			t.Errorf("decoded share doesn't match")
+		}
+	}
+
+	// Words can be shortened to four letters, in any case, over several lines.
+	mnemonic := EncodeShareMnemonic(shares[1])
+	words := strings.Fields(mnemonic)
+	for i, word := range words {
+		if len(word) > 4 {
+			words[i] = strings.ToUpper(word[:4])
+		}
+		if i%6 == 5 {
+			words[i] += "\n"
+		}
+	}
+	decoded, err := DecodeShareMnemonic(strings.Join(words, " "))
+	if err != nil {
+		t.Fatalf("unexpected error decoding: %s", err)
+	}
+	if recov, _, err := Recover([]*SecretShare{shares[0], decoded}); err != nil || !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %q, %v", recov, err)
+	}
+
+	// Swapping two different words is detected.
+	words = strings.Fields(mnemonic)
+	for i := 0; i+1 < len(words); i++ {
+		if words[i] == words[i+1] {
+			continue
+		}
+		swapped := append([]string{}, words...)
+		swapped[i], swapped[i+1] = swapped[i+1], swapped[i]
+		if _, err := DecodeShareMnemonic(strings.Join(swapped, " ")); err == nil {
+			t.Errorf("swap of words %d and %d not detected", i+1, i+2)
+		}
+	}
+
+	// The header reserves room for other versions and word lists, which this
+	// release refuses rather than misreading.
+	compact := shares[0].compactBytes()
+	for _, header := range []byte{1 << 4, 1} {
+		entropy := append([]byte{header, byte(len(compact))}, compact...)
+		entropy = append(entropy, make([]byte, (4-len(entropy)%4)%4)...)
+		if _, err := DecodeShareMnemonic(strings.Join(wordLists[0].encode(entropy), " ")); err == nil {
+			t.Errorf("header %#x: expected an error", header)
+		}
+	}
+
+	for _, bad := range []string{"", "abandon", "abandon abandon notaword", strings.Join(words[:len(words)-3], " ")} {
+		if _, err := DecodeShareMnemonic(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func Test_bip39(t *testing.T) {
+	words, _ := bip39WordList()
+	if len(words) != 2048 {
+		t.Fatalf("got %d words, expected 2048", len(words))
+	}
+
+	// Test vectors from BIP 39, without a passphrase.
+	var tests = []struct {
+		entropy  string
+		mnemonic string
+	}{
+		{"00000000000000000000000000000000", "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"},
+		{"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f", "legal winner thank year wave sausage worth useful legal winner thank yellow"},
+		{"80808080808080808080808080808080", "letter advice cage absurd amount doctor acoustic avoid letter advice cage above"},
+		{"ffffffffffffffffffffffffffffffff", "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo wrong"},
+		{"9e885d952ad362caeb4efe34a8e91bd2", "ozone drill grab fiber curtain grace pudding thank cruise elder eight picnic"},
+		{"f585c11aec520db57dd353c69554b21a89b20fb0650966fa0a9d6f74fd989d8f", "void come effort suffer camp survey warrior heavy shoot primary clutch crush open amazing screen patrol group space point ten exist slush involve unfold"},
+	}
+	for _, tt := range tests {
+		entropy, _ := hex.DecodeString(tt.entropy)
+		if got := strings.Join(wordLists[0].encode(entropy), " "); got != tt.mnemonic {
+			t.Errorf("%s: got %q, expected: %q", tt.entropy, got, tt.mnemonic)
+		}
+		decoded, err := wordLists[0].decode(strings.Fields(tt.mnemonic))
+		if err != nil || !bytes.Equal(decoded, entropy) {
+			t.Errorf("%s: decoded %x, %v", tt.entropy, decoded, err)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+// Package mobile provides a bindings-friendly facade over the adss package so
+// that gomobile can generate iOS and Android bindings for it.
+//
+// gomobile only supports a small subset of Go types across the language
+// boundary: signed integers, strings, booleans, byte slices, and pointers to
+// structs built out of those. The types here flatten the adss types into that
+// subset and replace slices of shares with a ShareList that can be iterated
+// with Len and Get.
+package mobile
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jakecraige/adss"
+)
+
+// Share is a flat representation of an adss.SecretShare.
+type Share struct {
+	Threshold int
+	Count     int
+	// Exclusions are the sets of share IDs that may not jointly recover,
+	// comma-separated with the IDs of each joined by "+", such as "0+1,2+3".
+	Exclusions string
+	// Mandatory are the IDs of the shares that must be present to recover,
+	// joined by "+", such as "0+1".
+	Mandatory string
+	// Formula says which sets of shares can recover, with shares named by
+	// their IDs, such as "(0 AND 1) OR 2-of(2, 3, 4)", and is empty for none.
+	Formula string
+	// Holders are the names of the holders of the shares in ID order, one per
+	// line, and empty for none.
+	Holders string
+	ID      int
+	C, D, J []byte
+	Sec     []byte
+	Tag     []byte
+
+	// The Argon2id hardening parameters of the sharing, all zero when the
+	// sharing is not hardened.
+	HardenTime, HardenMemory, HardenThreads int
+
+	// Version is the encoding of the hash inputs of the sharing.
+	Version int
+
+	// Domain separates the hashes of the sharing from other applications'.
+	Domain string
+
+	// Scheme is the base scheme the key of the sharing is split with.
+	Scheme int
+
+	// Points are the Shamir evaluation points of the shares by ID, empty for
+	// the default of ID+1.
+	Points []byte
+}
+
+func formatExclusions(exclusions []adss.IDSet) string {
+	parts := make([]string, len(exclusions))
+	for i, exclusion := range exclusions {
+		parts[i] = exclusion.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func parseExclusions(s string) ([]adss.IDSet, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var exclusions []adss.IDSet
+	for _, part := range strings.Split(s, ",") {
+		exclusion, err := adss.ParseIDSet(part)
+		if err != nil {
+			return nil, err
+		}
+		exclusions = append(exclusions, exclusion)
+	}
+	return exclusions, nil
+}
+
+func fromSecretShare(ss *adss.SecretShare) *Share {
+	s := &Share{
+		Threshold:  int(ss.As.T),
+		Count:      int(ss.As.N),
+		Exclusions: formatExclusions(ss.As.Exclusions),
+		Mandatory:  ss.As.Mandatory.String(),
+		Formula:    ss.As.Formula.String(),
+		Holders:    strings.Join(ss.As.Holders, "\n"),
+		ID:         int(ss.ID),
+		C:          ss.Pub.C,
+		D:          ss.Pub.D,
+		J:          ss.Pub.J,
+		Sec:        ss.Sec,
+		Tag:        ss.Tag,
+		Version:    int(ss.Version),
+		Domain:     ss.Domain,
+		Scheme:     int(ss.Scheme),
+		Points:     ss.Points,
+	}
+	if ss.Hardening != nil {
+		s.HardenTime = int(ss.Hardening.Time)
+		s.HardenMemory = int(ss.Hardening.Memory)
+		s.HardenThreads = int(ss.Hardening.Threads)
+	}
+	return s
+}
+
+func (s *Share) toSecretShare() (*adss.SecretShare, error) {
+	if s.Threshold < 0 || s.Threshold > 0xffff || s.Count < 0 || s.Count > 0xffff {
+		return nil, fmt.Errorf("access structure out of range: %d-of-%d", s.Threshold, s.Count)
+	}
+	if s.ID < 0 || s.ID > 0xffff {
+		return nil, fmt.Errorf("share ID out of range: %d", s.ID)
+	}
+	if s.Version < 0 || s.Version > 255 {
+		return nil, fmt.Errorf("share version out of range: %d", s.Version)
+	}
+	if s.Scheme < 0 || s.Scheme > 255 {
+		return nil, fmt.Errorf("share scheme out of range: %d", s.Scheme)
+	}
+
+	exclusions, err := parseExclusions(s.Exclusions)
+	if err != nil {
+		return nil, err
+	}
+
+	var mandatory adss.IDSet
+	if s.Mandatory != "" {
+		if mandatory, err = adss.ParseIDSet(s.Mandatory); err != nil {
+			return nil, err
+		}
+	}
+
+	var formula *adss.Formula
+	if s.Formula != "" {
+		if formula, err = adss.ParseFormula(s.Formula, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	var holders []string
+	if s.Holders != "" {
+		holders = strings.Split(s.Holders, "\n")
+	}
+
+	ss := &adss.SecretShare{
+		As:  adss.AccessStructure{T: uint16(s.Threshold), N: uint16(s.Count), Exclusions: exclusions, Mandatory: mandatory, Formula: formula, Holders: holders},
+		ID:  uint16(s.ID),
+		Sec: s.Sec,
+		Tag: s.Tag,
+
+		Version: uint8(s.Version),
+		Domain:  s.Domain,
+		Scheme:  uint8(s.Scheme),
+	}
+	// gomobile passes empty arrays as empty slices rather than nil, which
+	// would bind empty points into the checksum.
+	if len(s.Points) > 0 {
+		ss.Points = s.Points
+	}
+	ss.Pub.C, ss.Pub.D, ss.Pub.J = s.C, s.D, s.J
+
+	if s.HardenTime != 0 || s.HardenMemory != 0 || s.HardenThreads != 0 {
+		if s.HardenTime < 0 || s.HardenMemory < 0 || s.HardenThreads < 0 || s.HardenThreads > 255 {
+			return nil, fmt.Errorf("hardening parameters out of range")
+		}
+		ss.Hardening = &adss.Argon2Params{
+			Time:    uint32(s.HardenTime),
+			Memory:  uint32(s.HardenMemory),
+			Threads: uint8(s.HardenThreads),
+		}
+	}
+	return ss, nil
+}
+
+// Marshal encodes the share in the same JSON format the adss CLI reads and
+// writes.
+func (s *Share) Marshal() ([]byte, error) {
+	ss, err := s.toSecretShare()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(ss)
+}
+
+// UnmarshalShare decodes a share from the JSON format the adss CLI reads and
+// writes, or any other encoding adss.DecodeShare recognizes, e.g. after
+// scanning it from a QR code.
+func UnmarshalShare(data []byte) (*Share, error) {
+	ss, err := adss.DecodeShare(data)
+	if err != nil {
+		return nil, err
+	}
+	return fromSecretShare(ss), nil
+}
+
+// ShareList is an ordered collection of shares.
+type ShareList struct {
+	shares []*Share
+}
+
+// NewShareList returns an empty ShareList.
+func NewShareList() *ShareList {
+	return &ShareList{}
+}
+
+// Len returns the number of shares in the list.
+func (l *ShareList) Len() int {
+	return len(l.shares)
+}
+
+// Get returns the share at index i or nil if it is out of range.
+func (l *ShareList) Get(i int) *Share {
+	if i < 0 || i >= len(l.shares) {
+		return nil
+	}
+	return l.shares[i]
+}
+
+// Add appends a share to the list.
+func (l *ShareList) Add(s *Share) {
+	l.shares = append(l.shares, s)
+}
+
+// Split creates a threshold-of-count sharing of secret bound to the associated
+// data ad.
+func Split(threshold, count int, secret, ad []byte) (*ShareList, error) {
+	if threshold < 0 || threshold > 0xffff || count < 0 || count > 0xffff {
+		return nil, fmt.Errorf("access structure out of range: %d-of-%d", threshold, count)
+	}
+
+	as := adss.NewAccessStructure(uint16(threshold), uint16(count))
+	shares, err := adss.Share(as, secret, ad)
+	if err != nil {
+		return nil, err
+	}
+
+	list := NewShareList()
+	for _, share := range shares {
+		list.Add(fromSecretShare(share))
+	}
+	return list, nil
+}
+
+// Recovery is the result of a successful recovery.
+type Recovery struct {
+	Secret []byte
+	valid  []int
+}
+
+// ValidCount returns the number of shares that were used to explain the
+// secret.
+func (r *Recovery) ValidCount() int {
+	return len(r.valid)
+}
+
+// ValidID returns the ID of the i-th valid share or -1 if it is out of range.
+func (r *Recovery) ValidID(i int) int {
+	if i < 0 || i >= len(r.valid) {
+		return -1
+	}
+	return r.valid[i]
+}
+
+// IsValid reports whether the share with the given ID was used to explain the
+// secret.
+func (r *Recovery) IsValid(id int) bool {
+	for _, v := range r.valid {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Recover attempts to recover the secret from the shares in list.
+func Recover(list *ShareList) (*Recovery, error) {
+	shares := make([]*adss.SecretShare, list.Len())
+	for i, s := range list.shares {
+		ss, err := s.toSecretShare()
+		if err != nil {
+			return nil, err
+		}
+		shares[i] = ss
+	}
+
+	secret, V, err := adss.Recover(shares)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Recovery{Secret: secret, valid: make([]int, len(V))}
+	for i, share := range V {
+		out.valid[i] = int(share.ID)
+	}
+	return out, nil
+}
@@ -0,0 +1,58 @@
+package mobile
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSplitAndRecover(t *testing.T) {
+	secret := []byte("hello world")
+	list, err := Split(2, 3, secret, []byte("some associated data"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	if list.Len() != 3 {
+		t.Fatalf("list.Len() = %d, expected: %d", list.Len(), 3)
+	}
+
+	// Round trip the shares through the JSON encoding as a scanning app would.
+	scanned := NewShareList()
+	for i := 0; i < 2; i++ {
+		data, err := list.Get(i).Marshal()
+		if err != nil {
+			t.Fatalf("unexpected error on marshal: %s", err)
+		}
+
+		share, err := UnmarshalShare(data)
+		if err != nil {
+			t.Fatalf("unexpected error on unmarshal: %s", err)
+		}
+		scanned.Add(share)
+	}
+
+	recov, err := Recover(scanned)
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+
+	if !bytes.Equal(recov.Secret, secret) {
+		t.Errorf("recovered %x != %x", recov.Secret, secret)
+	}
+
+	if recov.ValidCount() != 2 || !recov.IsValid(0) || !recov.IsValid(1) || recov.IsValid(2) {
+		t.Errorf("unexpected valid shares: %v", recov.valid)
+	}
+}
+
+func TestOutOfRange(t *testing.T) {
+	if _, err := Split(2, 70000, []byte("secret"), nil); err == nil {
+		t.Errorf("expected error for count out of range")
+	}
+
+	list := NewShareList()
+	list.Add(&Share{Threshold: 2, Count: 3, ID: -1})
+	if _, err := Recover(list); err == nil {
+		t.Errorf("expected error for ID out of range")
+	}
+}
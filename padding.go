@@ -0,0 +1,38 @@
+package adss
+
+import (
+	"fmt"
+)
+
+// PadSecret pads the secret to a multiple of blockSize bytes so that the
+// size of the shares doesn't reveal the exact size of the secret. It uses
+// ISO/IEC 7816-4 padding: a single 0x80 byte followed by zero bytes. At least
+// one byte of padding is always added so that it can be unambiguously removed
+// with UnpadSecret.
+func PadSecret(secret []byte, blockSize int) ([]byte, error) {
+	if blockSize <= 0 {
+		return nil, fmt.Errorf("invalid block size: %d", blockSize)
+	}
+
+	paddedLen := (len(secret)/blockSize + 1) * blockSize
+	out := make([]byte, paddedLen)
+	copy(out, secret)
+	out[len(secret)] = 0x80
+	return out, nil
+}
+
+// UnpadSecret removes the padding added by PadSecret.
+func UnpadSecret(padded []byte) ([]byte, error) {
+	for i := len(padded) - 1; i >= 0; i-- {
+		switch padded[i] {
+		case 0x00:
+			continue
+		case 0x80:
+			return padded[:i], nil
+		default:
+			return nil, fmt.Errorf("invalid padding")
+		}
+	}
+
+	return nil, fmt.Errorf("invalid padding")
+}
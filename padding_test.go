@@ -0,0 +1,51 @@
+package adss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPadSecret(t *testing.T) {
+	var tests = []struct {
+		name      string
+		secret    []byte
+		blockSize int
+		paddedLen int
+	}{
+		{"empty", []byte{}, 16, 16},
+		{"short", []byte("abc"), 16, 16},
+		{"full block", bytes.Repeat([]byte{0x80}, 16), 16, 32},
+		{"trailing zeros", []byte{1, 0, 0}, 4, 4},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			padded, err := PadSecret(tt.secret, tt.blockSize)
+			if err != nil {
+				t.Fatalf("unexpected error on padding: %s", err)
+			}
+
+			if len(padded) != tt.paddedLen {
+				t.Errorf("len(padded) = %d, expected: %d", len(padded), tt.paddedLen)
+			}
+
+			unpadded, err := UnpadSecret(padded)
+			if err != nil {
+				t.Fatalf("unexpected error on unpadding: %s", err)
+			}
+
+			if !bytes.Equal(unpadded, tt.secret) {
+				t.Errorf("unpadded %x != %x", unpadded, tt.secret)
+			}
+		})
+	}
+}
+
+func TestUnpadSecretInvalid(t *testing.T) {
+	for _, padded := range [][]byte{{}, {0, 0}, {0x80, 1}} {
+		if _, err := UnpadSecret(padded); err == nil {
+			t.Errorf("expected error unpadding %x", padded)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package adss
+
+// SharingResult is the outcome of recovering one of the sharings found by
+// RecoverAll.
+type SharingResult struct {
+	Fingerprint string         // the Fingerprint of the sharing
+	Shares      []*SecretShare // the provided shares from this sharing
+	Secret      []byte         // the recovered secret, nil if recovery failed
+	Valid       []*SecretShare // the valid shares, as returned by Recover
+	Err         error          // why recovery failed, nil if it succeeded
+}
+
+// PartitionShares groups the shares by the sharing they belong to, using
+// their Fingerprint. Groups are in the order their first share appears.
+func PartitionShares(shares []*SecretShare) [][]*SecretShare {
+	var groups [][]*SecretShare
+	index := make(map[string]int)
+	for _, share := range shares {
+		fp := share.Fingerprint()
+		i, ok := index[fp]
+		if !ok {
+			i = len(groups)
+			index[fp] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], share)
+	}
+	return groups
+}
+
+// RecoverAll recovers every sharing represented in a mixed batch of shares,
+// such as the contents of an old safe. The shares are partitioned by sharing
+// and each group is recovered independently, so a group that can't be
+// recovered doesn't affect the others. A share whose public payload is
+// corrupted ends up in a group of its own.
+func RecoverAll(shares []*SecretShare, opts ...RecoverOption) []SharingResult {
+	groups := PartitionShares(shares)
+	results := make([]SharingResult, len(groups))
+	for i, group := range groups {
+		results[i].Fingerprint = group[0].Fingerprint()
+		results[i].Shares = group
+		results[i].Secret, results[i].Valid, results[i].Err = Recover(group, opts...)
+	}
+	return results
+}
@@ -0,0 +1,54 @@
+package adss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRecoverAll(t *testing.T) {
+	msg1, msg2, msg3 := []byte("first"), []byte("second"), []byte("third")
+	shares1, err := Share(NewAccessStructure(2, 3), msg1, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	shares2, err := Share(NewAccessStructure(3, 5), msg2, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	shares3, err := Share(NewAccessStructure(2, 2), msg3, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	// The third sharing doesn't have enough shares to recover.
+	pile := []*SecretShare{shares2[4], shares1[0], shares3[1], shares2[0], shares1[2], shares2[2]}
+	results := RecoverAll(pile)
+	if len(results) != 3 {
+		t.Fatalf("len(results) = %d, expected: %d", len(results), 3)
+	}
+
+	var expected = []struct {
+		fingerprint string
+		shares      int
+		secret      []byte
+	}{
+		{shares2[0].Fingerprint(), 3, msg2},
+		{shares1[0].Fingerprint(), 2, msg1},
+		{shares3[0].Fingerprint(), 1, nil},
+	}
+	for i, tt := range expected {
+		result := results[i]
+		if result.Fingerprint != tt.fingerprint {
+			t.Errorf("%d: fingerprint = %s, expected: %s", i, result.Fingerprint, tt.fingerprint)
+		}
+		if len(result.Shares) != tt.shares {
+			t.Errorf("%d: len(shares) = %d, expected: %d", i, len(result.Shares), tt.shares)
+		}
+		if !bytes.Equal(result.Secret, tt.secret) {
+			t.Errorf("%d: recovered %x != %x", i, result.Secret, tt.secret)
+		}
+		if (tt.secret == nil) != (result.Err != nil) {
+			t.Errorf("%d: unexpected error: %v", i, result.Err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package adss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrPolicyDenied is wrapped by the error Recover returns when a Policy
+// refuses to release the secret.
+var ErrPolicyDenied = errors.New("recovery denied by policy")
+
+// PolicyRequest describes a recovery that is about to return a secret. It
+// contains no secret material.
+type PolicyRequest struct {
+	Fingerprint    string
+	Threshold      uint16
+	Count          uint16
+	AssociatedData []byte
+	// ShareIDs are the IDs of the valid shares the secret was recovered
+	// from.
+	ShareIDs []uint16
+	// Requester is who asked for the recovery, as set with WithRequester.
+	// It is empty if the caller didn't set one.
+	Requester string
+}
+
+// Policy decides whether a recovered secret may be returned, such as by
+// asking an OPA server or checking an approval system. It is called after the
+// shares have been verified and before the secret leaves Recover. Returning
+// an error denies the request.
+type Policy interface {
+	Authorize(ctx context.Context, req *PolicyRequest) error
+}
+
+// PolicyFunc adapts a function to a Policy.
+type PolicyFunc func(ctx context.Context, req *PolicyRequest) error
+
+// Authorize calls f.
+func (f PolicyFunc) Authorize(ctx context.Context, req *PolicyRequest) error {
+	return f(ctx, req)
+}
+
+// WithPolicy makes Recover ask the policy before returning the secret. If the
+// policy denies the request the secret is zeroed and an error wrapping
+// ErrPolicyDenied is returned.
+func WithPolicy(policy Policy) RecoverOption {
+	return func(cfg *recoverConfig) {
+		cfg.policy = policy
+	}
+}
+
+type requesterKey struct{}
+
+// WithRequester returns a context that identifies who is asking for a
+// recovery, for the Policy to see.
+func WithRequester(ctx context.Context, requester string) context.Context {
+	return context.WithValue(ctx, requesterKey{}, requester)
+}
+
+// authorize asks the policy whether the secret recovered from V may be
+// returned.
+func authorize(ctx context.Context, policy Policy, V []*SecretShare) error {
+	share0 := V[0]
+	req := &PolicyRequest{
+		Fingerprint:    share0.Fingerprint(),
+		Threshold:      share0.As.T,
+		Count:          share0.As.N,
+		AssociatedData: share0.Tag,
+		ShareIDs:       make([]uint16, len(V)),
+	}
+	for i, share := range V {
+		req.ShareIDs[i] = share.ID
+	}
+	req.Requester, _ = ctx.Value(requesterKey{}).(string)
+
+	if err := policy.Authorize(ctx, req); err != nil {
+		return fmt.Errorf("%w: %s", ErrPolicyDenied, err)
+	}
+	return nil
+}
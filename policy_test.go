@@ -0,0 +1,44 @@
+package adss
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestWithPolicy(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), []byte("prod db"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	var seen *PolicyRequest
+	policy := PolicyFunc(func(ctx context.Context, req *PolicyRequest) error {
+		seen = req
+		if req.Requester != "oncall" {
+			return errors.New("only oncall may recover")
+		}
+		return nil
+	})
+
+	ctx := WithRequester(context.Background(), "oncall")
+	if _, _, err := RecoverContext(ctx, shares[:2], WithPolicy(policy)); err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if seen.Fingerprint != shares[0].Fingerprint() || string(seen.AssociatedData) != "prod db" || len(seen.ShareIDs) != 2 {
+		t.Errorf("unexpected policy request: %+v", seen)
+	}
+
+	ctx = WithRequester(context.Background(), "intern")
+	M, V, err := RecoverContext(ctx, shares[:2], WithPolicy(policy))
+	if !errors.Is(err, ErrPolicyDenied) {
+		t.Errorf("err = %v, expected: %v", err, ErrPolicyDenied)
+	}
+	if M != nil || V != nil {
+		t.Errorf("secret returned despite the policy denying it")
+	}
+
+	if _, _, err := Recover(shares[:2], WithPolicy(policy)); !errors.Is(err, ErrPolicyDenied) {
+		t.Errorf("err = %v, expected: %v", err, ErrPolicyDenied)
+	}
+}
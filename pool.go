@@ -0,0 +1,76 @@
+package adss
+
+import (
+	"sync"
+)
+
+// Recovering from many shares attempts recovery with many candidate subsets,
+// and each attempt needs the same kinds of scratch space. These pools let the
+// attempts reuse it so large recoveries don't thrash the GC.
+
+var s1SharesPool = sync.Pool{
+	New: func() interface{} { return new(s1SharesScratch) },
+}
+
+// s1SharesScratch holds the base scheme shares for one recovery attempt.
+type s1SharesScratch struct {
+	values []s1SecretShare
+	ptrs   []*s1SecretShare
+}
+
+func getS1Shares(shares []*SecretShare) *s1SharesScratch {
+	scratch := s1SharesPool.Get().(*s1SharesScratch)
+	if cap(scratch.values) < len(shares) {
+		scratch.values = make([]s1SecretShare, len(shares))
+		scratch.ptrs = make([]*s1SecretShare, len(shares))
+	}
+	scratch.values = scratch.values[:len(shares)]
+	scratch.ptrs = scratch.ptrs[:len(shares)]
+
+	for i, share := range shares {
+		scratch.values[i] = s1SecretShare{
+			i:      share.ID,
+			t:      share.As.T,
+			n:      share.As.N,
+			x:      share.Point(),
+			wide:   share.splitWide(),
+			secret: share.Sec,
+		}
+		scratch.ptrs[i] = &scratch.values[i]
+	}
+	return scratch
+}
+
+func putS1Shares(scratch *s1SharesScratch) {
+	// Drop the references to the share secrets so the pool doesn't keep them
+	// alive.
+	for i := range scratch.values {
+		scratch.values[i] = s1SecretShare{}
+		scratch.ptrs[i] = nil
+	}
+	s1SharesPool.Put(scratch)
+}
+
+var bytesPool = sync.Pool{
+	New: func() interface{} { return new([]byte) },
+}
+
+// getBytes returns a pooled buffer of length n. The contents are undefined.
+func getBytes(n int) *[]byte {
+	buf := bytesPool.Get().(*[]byte)
+	if cap(*buf) < n {
+		*buf = make([]byte, n)
+	}
+	*buf = (*buf)[:n]
+	return buf
+}
+
+// putBytes zeroes the buffer, since it may have held secrets, and returns it
+// to the pool.
+func putBytes(buf *[]byte) {
+	b := *buf
+	for i := range b {
+		b[i] = 0
+	}
+	bytesPool.Put(buf)
+}
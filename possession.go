@@ -0,0 +1,98 @@
+package adss
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+)
+
+// possessionProofPrefix is prepended to proofs before signing so a proof
+// can't be confused with a signature on anything else.
+const possessionProofPrefix = "adss possession proof\n"
+
+// possessionKey derives an Ed25519 key from the whole share. Any change to
+// the share changes the key, so a signature with it shows the signer holds
+// the share intact, and the public key reveals nothing about the share.
+func (ss *SecretShare) possessionKey() ed25519.PrivateKey {
+	mac := hmac.New(sha256.New, []byte("adss possession key"))
+	mac.Write(ss.compactBytes())
+	return ed25519.NewKeyFromSeed(mac.Sum(nil))
+}
+
+// PossessionKey returns the public key that possession proofs for this share
+// verify with. The dealer records it when the share is created, such as in
+// the manifest, and it contains no secret material.
+func (ss *SecretShare) PossessionKey() ed25519.PublicKey {
+	return ss.possessionKey().Public().(ed25519.PublicKey)
+}
+
+// PossessionProof shows that its maker holds an intact share of a sharing,
+// without revealing the share. It is bound to a nonce chosen by the verifier,
+// or derived from something the verifier trusts to be fresh, so it can't be
+// made once and replayed after the share is lost.
+type PossessionProof struct {
+	Fingerprint   string            `json:"fingerprint"`
+	ShareID       uint16            `json:"share_id"`
+	Nonce         []byte            `json:"nonce"`
+	PossessionKey ed25519.PublicKey `json:"possession_key"`
+	Signature     []byte            `json:"signature"`
+}
+
+// ProvePossession returns a proof that the holder has this share, bound to
+// nonce.
+func (ss *SecretShare) ProvePossession(nonce []byte) *PossessionProof {
+	key := ss.possessionKey()
+	p := &PossessionProof{
+		Fingerprint:   ss.Fingerprint(),
+		ShareID:       ss.ID,
+		Nonce:         append([]byte{}, nonce...),
+		PossessionKey: key.Public().(ed25519.PublicKey),
+	}
+	p.Signature = ed25519.Sign(key, p.signedBytes())
+	return p
+}
+
+func (p *PossessionProof) signedBytes() []byte {
+	out := []byte(possessionProofPrefix)
+	for _, part := range [][]byte{[]byte(p.Fingerprint), p.Nonce} {
+		out = appendUint32(out, uint32(len(part)))
+		out = append(out, part...)
+	}
+	return appendShareID(out, p.ShareID)
+}
+
+// Verify returns an error unless the proof is for the sharing with the given
+// fingerprint, is bound to nonce, and was made with the share whose
+// PossessionKey is key. The key must come from a trusted record of the
+// sharing since anyone can make a valid proof for a key of their own.
+func (p *PossessionProof) Verify(fingerprint string, nonce []byte, key ed25519.PublicKey) error {
+	if p.Fingerprint != fingerprint {
+		return fmt.Errorf("proof is for sharing %s, expected %s", p.Fingerprint, fingerprint)
+	}
+	if !bytes.Equal(p.Nonce, nonce) {
+		return fmt.Errorf("proof is for a different nonce")
+	}
+	if len(key) != ed25519.PublicKeySize || !bytes.Equal(p.PossessionKey, key) {
+		return fmt.Errorf("proof is for a different share %d", p.ShareID)
+	}
+	if !ed25519.Verify(key, p.signedBytes(), p.Signature) {
+		return fmt.Errorf("proof signature is invalid")
+	}
+	return nil
+}
+
+// VerifyPossession returns an error unless the proof is bound to nonce and
+// shows possession of one of the shares in the manifest.
+func (m *Manifest) VerifyPossession(p *PossessionProof, nonce []byte) error {
+	for _, entry := range m.Shares {
+		if entry.ID == p.ShareID {
+			if entry.PossessionKey == nil {
+				return fmt.Errorf("manifest has no possession key for share %d", p.ShareID)
+			}
+			return p.Verify(m.Fingerprint, nonce, entry.PossessionKey)
+		}
+	}
+	return fmt.Errorf("share ID %d isn't in the manifest", p.ShareID)
+}
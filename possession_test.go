@@ -0,0 +1,84 @@
+package adss
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPossessionProof(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	fingerprint := shares[0].Fingerprint()
+	key := shares[0].PossessionKey()
+	nonce := []byte("audit 2026-10")
+
+	proof := shares[0].ProvePossession(nonce)
+	if err := proof.Verify(fingerprint, nonce, key); err != nil {
+		t.Errorf("unexpected error verifying: %s", err)
+	}
+	if err := proof.Verify(fingerprint, []byte("audit 2026-11"), key); err == nil {
+		t.Errorf("proof verified for a different nonce")
+	}
+	if err := proof.Verify(fingerprint, nonce, shares[1].PossessionKey()); err == nil {
+		t.Errorf("proof verified for a different share")
+	}
+	if err := shares[1].ProvePossession(nonce).Verify(fingerprint, nonce, key); err == nil {
+		t.Errorf("proof from another share verified")
+	}
+
+	other, err := Share(NewAccessStructure(2, 3), []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if err := other[0].ProvePossession(nonce).Verify(fingerprint, nonce, key); err == nil {
+		t.Errorf("proof from another sharing verified")
+	}
+
+	corrupt := *shares[0]
+	corrupt.Sec = append([]byte{}, corrupt.Sec...)
+	corrupt.Sec[0]++
+	if err := corrupt.ProvePossession(nonce).Verify(fingerprint, nonce, key); err == nil {
+		t.Errorf("proof from a corrupted share verified")
+	}
+
+	tampered := *proof
+	tampered.ShareID = 1
+	if err := tampered.Verify(fingerprint, nonce, key); err == nil {
+		t.Errorf("tampered proof verified")
+	}
+}
+
+func TestManifestVerifyPossession(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	m, err := NewManifest(shares, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Manifests are stored as JSON so the keys must survive it.
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	m = new(Manifest)
+	if err := json.Unmarshal(data, m); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	nonce := []byte("nonce")
+	for _, share := range shares {
+		if err := m.VerifyPossession(share.ProvePossession(nonce), nonce); err != nil {
+			t.Errorf("share %d: unexpected error: %s", share.ID, err)
+		}
+	}
+
+	m.Shares[0].PossessionKey = nil
+	if err := m.VerifyPossession(shares[0].ProvePossession(nonce), nonce); err == nil {
+		t.Errorf("expected error for a manifest without possession keys")
+	}
+}
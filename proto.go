@@ -0,0 +1,238 @@
+package adss
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Protocol buffer wire types used by the schema in proto/adss.proto.
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// MarshalProto returns the share encoded as the SecretShare message of
+// proto/adss.proto, for exchanging shares with services in other languages.
+// Fields at their zero value are left out, as usual for proto3.
+func (ss *SecretShare) MarshalProto() ([]byte, error) {
+	var as []byte
+	as = protoAppendUint(as, 1, uint64(ss.As.T))
+	as = protoAppendUint(as, 2, uint64(ss.As.N))
+	for _, exclusion := range ss.As.Exclusions {
+		as = protoAppendBytes(protoAppendKey(as, 3, protoBytes), appendIDs(nil, exclusion, ss.As.wide()))
+	}
+	if len(ss.As.Mandatory) > 0 {
+		as = protoAppendBytes(protoAppendKey(as, 4, protoBytes), appendIDs(nil, ss.As.Mandatory, ss.As.wide()))
+	}
+	if ss.As.Formula != nil {
+		as = protoAppendBytes(protoAppendKey(as, 5, protoBytes), ss.As.Formula.appendBytes(nil, ss.As.wide()))
+	}
+	for _, name := range ss.As.Holders {
+		as = protoAppendBytes(protoAppendKey(as, 6, protoBytes), []byte(name))
+	}
+
+	out := protoAppendBytes(protoAppendKey(nil, 1, protoBytes), as)
+	out = protoAppendUint(out, 2, uint64(ss.ID))
+	for i, field := range [][]byte{ss.Pub.C, ss.Pub.D, ss.Pub.J, ss.Sec, ss.Tag} {
+		if len(field) > 0 {
+			out = protoAppendBytes(protoAppendKey(out, uint64(3+i), protoBytes), field)
+		}
+	}
+	if ss.Hardening != nil {
+		var params []byte
+		params = protoAppendUint(params, 1, uint64(ss.Hardening.Time))
+		params = protoAppendUint(params, 2, uint64(ss.Hardening.Memory))
+		params = protoAppendUint(params, 3, uint64(ss.Hardening.Threads))
+		out = protoAppendBytes(protoAppendKey(out, 8, protoBytes), params)
+	}
+	out = protoAppendUint(out, 9, uint64(ss.Version))
+	if ss.Domain != "" {
+		out = protoAppendBytes(protoAppendKey(out, 10, protoBytes), []byte(ss.Domain))
+	}
+	out = protoAppendUint(out, 11, uint64(ss.Scheme))
+	if len(ss.Points) > 0 {
+		out = protoAppendBytes(protoAppendKey(out, 12, protoBytes), ss.Points)
+	}
+	return out, nil
+}
+
+// UnmarshalProto decodes a SecretShare message of proto/adss.proto into ss.
+// Unknown fields are skipped so messages from newer schemas can be read.
+func (ss *SecretShare) UnmarshalProto(data []byte) error {
+	var share SecretShare
+	// The encoding of share IDs depends on the count, which may come after
+	// them, so they are decoded at the end.
+	var exclusions [][]byte
+	var mandatory, formula []byte
+	err := protoFields(data, func(field uint64, value uint64, b []byte) error {
+		switch field {
+		case 1:
+			return protoFields(b, func(field uint64, value uint64, b []byte) error {
+				switch field {
+				case 1:
+					return protoUint16(&share.As.T, value, "threshold")
+				case 2:
+					return protoUint16(&share.As.N, value, "count")
+				case 3:
+					exclusions = append(exclusions, b)
+				case 4:
+					mandatory = b
+				case 5:
+					formula = b
+				case 6:
+					share.As.Holders = append(share.As.Holders, string(b))
+				}
+				return nil
+			})
+		case 2:
+			return protoUint16(&share.ID, value, "id")
+		case 3:
+			share.Pub.C = b
+		case 4:
+			share.Pub.D = b
+		case 5:
+			share.Pub.J = b
+		case 6:
+			share.Sec = b
+		case 7:
+			share.Tag = b
+		case 8:
+			share.Hardening = &Argon2Params{}
+			return protoFields(b, func(field uint64, value uint64, b []byte) error {
+				switch field {
+				case 1:
+					share.Hardening.Time = uint32(value)
+				case 2:
+					share.Hardening.Memory = uint32(value)
+				case 3:
+					return protoUint8(&share.Hardening.Threads, value, "threads")
+				}
+				return nil
+			})
+		case 9:
+			return protoUint8(&share.Version, value, "version")
+		case 10:
+			share.Domain = string(b)
+		case 11:
+			return protoUint8(&share.Scheme, value, "scheme")
+		case 12:
+			share.Points = b
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	wide := share.As.wide()
+	for _, b := range exclusions {
+		exclusion, err := parseIDs(b, wide)
+		if err != nil {
+			return err
+		}
+		share.As.Exclusions = append(share.As.Exclusions, exclusion)
+	}
+	if mandatory != nil {
+		if share.As.Mandatory, err = parseIDs(mandatory, wide); err != nil {
+			return err
+		}
+	}
+	if formula != nil {
+		if share.As.Formula, err = parseFormulaBytes(formula, wide); err != nil {
+			return err
+		}
+	}
+
+	*ss = share
+	return nil
+}
+
+func protoAppendKey(out []byte, field, wireType uint64) []byte {
+	return protoAppendVarint(out, field<<3|wireType)
+}
+
+func protoAppendVarint(out []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(out, buf[:n]...)
+}
+
+// protoAppendUint appends a varint field unless it is zero.
+func protoAppendUint(out []byte, field, v uint64) []byte {
+	if v == 0 {
+		return out
+	}
+	return protoAppendVarint(protoAppendKey(out, field, protoVarint), v)
+}
+
+func protoAppendBytes(out []byte, b []byte) []byte {
+	return append(protoAppendVarint(out, uint64(len(b))), b...)
+}
+
+// protoFields calls fn with each field of a message, passing varints as value
+// and a copy of length-delimited fields as b. Fixed width fields are skipped
+// since the schema has none.
+func protoFields(data []byte, fn func(field, value uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 || key>>3 == 0 {
+			return fmt.Errorf("protobuf: invalid field key")
+		}
+		data = data[n:]
+
+		field := key >> 3
+		var value uint64
+		var b []byte
+		switch key & 7 {
+		case protoVarint:
+			if value, n = binary.Uvarint(data); n <= 0 {
+				return fmt.Errorf("protobuf: invalid varint in field %d", field)
+			}
+			data = data[n:]
+		case protoBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return fmt.Errorf("protobuf: invalid length in field %d", field)
+			}
+			b = append([]byte{}, data[n:n+int(length)]...)
+			data = data[n+int(length):]
+		case protoFixed64, protoFixed32:
+			size := 8
+			if key&7 == protoFixed32 {
+				size = 4
+			}
+			if len(data) < size {
+				return fmt.Errorf("protobuf: truncated field %d", field)
+			}
+			data = data[size:]
+			continue
+		default:
+			return fmt.Errorf("protobuf: unsupported wire type %d in field %d", key&7, field)
+		}
+
+		if err := fn(field, value, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// protoUint8 stores a varint field that holds a byte.
+func protoUint8(dst *uint8, value uint64, name string) error {
+	if value > 0xff {
+		return fmt.Errorf("protobuf: %s out of range: %d", name, value)
+	}
+	*dst = uint8(value)
+	return nil
+}
+
+// protoUint16 stores a varint field that holds a share count or ID.
+func protoUint16(dst *uint16, value uint64, name string) error {
+	if value > 0xffff {
+		return fmt.Errorf("protobuf: %s out of range: %d", name, value)
+	}
+	*dst = uint16(value)
+	return nil
+}
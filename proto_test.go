@@ -0,0 +1,86 @@
+package adss
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestSecretShareProto(t *testing.T) {
+	msg := []byte("hello world")
+	as, err := NewAccessStructureWithExclusions(2, 4, IDSet{0, 1}, IDSet{2, 3})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	shares, err := ShareInDomain("example.com", as, msg, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	hardened, err := ShareHardened(NewAccessStructure(2, 3), msg, nil, testArgon2Params)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	pointed, err := ShareWithPoints([]uint8{2, 4, 6}, NewAccessStructure(2, 3), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	replicated, err := ShareWithScheme(SchemeReplicated, NewAccessStructure(2, 3), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	for _, share := range []*SecretShare{shares[0], hardened[1], pointed[2], replicated[0]} {
+		encoded, err := share.MarshalProto()
+		if err != nil {
+			t.Fatalf("unexpected error encoding: %s", err)
+		}
+		var decoded SecretShare
+		if err := decoded.UnmarshalProto(encoded); err != nil {
+			t.Fatalf("unexpected error decoding: %s", err)
+		}
+		if !decoded.Equal(share) {
+			t.Errorf("decoded share doesn't match")
+		}
+	}
+}
+
+func TestSecretShareProtoVector(t *testing.T) {
+	share := &SecretShare{
+		As:      NewAccessStructure(2, 3),
+		ID:      1,
+		Pub:     struct{ C, D, J []byte }{[]byte{0xc}, []byte{0xd}, []byte{0xa}},
+		Sec:     []byte{0x5e},
+		Version: VersionLabeled,
+	}
+	// access_structure {threshold: 2, count: 3}, id: 1, c, d, j, sec, version: 2
+	expected := "0a0408021003" + "1001" + "1a010c" + "22010d" + "2a010a" + "32015e" + "4802"
+	encoded, err := share.MarshalProto()
+	if err != nil {
+		t.Fatalf("unexpected error encoding: %s", err)
+	}
+	if hex.EncodeToString(encoded) != expected {
+		t.Errorf("got %x, expected: %s", encoded, expected)
+	}
+
+	// Unknown fields of every wire type are skipped.
+	withUnknown, _ := hex.DecodeString(expected + "a00601" + "aa0602abcd" + "a9060000000000000000" + "ad0600000000")
+	var decoded SecretShare
+	if err := decoded.UnmarshalProto(withUnknown); err != nil {
+		t.Fatalf("unexpected error decoding: %s", err)
+	}
+	if !decoded.Equal(share) {
+		t.Errorf("decoded share doesn't match")
+	}
+
+	for _, bad := range []string{
+		expected[:len(expected)-2], // truncated varint
+		"1a05" + "0c",              // truncated bytes
+		"10ffff04",                 // ID out of range
+		"0002",                     // field 0
+		"0b",                       // start group
+	} {
+		data, _ := hex.DecodeString(bad)
+		if err := decoded.UnmarshalProto(data); err == nil {
+			t.Errorf("%s: expected an error", bad)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package adss
+
+// PublicShare holds only the non-secret fields of a share. Indexes, manifests
+// and monitoring systems can use it to handle share metadata without any risk
+// of touching the secret part.
+type PublicShare struct {
+	As  AccessStructure
+	ID  uint16
+	Pub struct {
+		C, D, J []byte
+	}
+	Tag []byte
+
+	Hardening *Argon2Params `json:",omitempty"`
+	Version   uint8         `json:",omitempty"`
+	Domain    string        `json:",omitempty"`
+	Scheme    uint8         `json:",omitempty"`
+	Points    []uint8       `json:",omitempty"`
+}
+
+// Public returns the non-secret fields of the share. The returned share
+// references the same slices, which must be treated as read-only.
+func (ss *SecretShare) Public() *PublicShare {
+	return &PublicShare{
+		As:        ss.As,
+		ID:        ss.ID,
+		Pub:       ss.Pub,
+		Tag:       ss.Tag,
+		Hardening: ss.Hardening,
+		Version:   ss.Version,
+		Domain:    ss.Domain,
+		Scheme:    ss.Scheme,
+		Points:    ss.Points,
+	}
+}
+
+// Fingerprint identifies the sharing the share belongs to. It is the same as
+// the Fingerprint of the secret share.
+func (ps *PublicShare) Fingerprint() string {
+	return sharingFingerprint(ps.Pub.J)
+}
@@ -0,0 +1,35 @@
+package adss
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestPublicShare(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	share := shares[1]
+	public := share.Public()
+	if !public.As.Equal(share.As) || public.ID != share.ID || !bytes.Equal(public.Tag, share.Tag) {
+		t.Errorf("public share metadata doesn't match")
+	}
+	if !bytes.Equal(public.Pub.C, share.Pub.C) || !bytes.Equal(public.Pub.D, share.Pub.D) || !bytes.Equal(public.Pub.J, share.Pub.J) {
+		t.Errorf("public share payload doesn't match")
+	}
+	if public.Fingerprint() != share.Fingerprint() {
+		t.Errorf("fingerprint %s != %s", public.Fingerprint(), share.Fingerprint())
+	}
+
+	encoded, err := json.Marshal(public)
+	if err != nil {
+		t.Fatalf("unexpected error marshalling: %s", err)
+	}
+	if strings.Contains(string(encoded), "Sec") {
+		t.Errorf("public share encoding contains the secret: %s", encoded)
+	}
+}
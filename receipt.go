@@ -0,0 +1,129 @@
+package adss
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// receiptSigPrefix is prepended to a receipt before signing so that a receipt
+// signature can't be confused with a signature on anything else.
+const receiptSigPrefix = "adss share receipt\n"
+
+// Receipt is a holder's signed acknowledgment that they received a share.
+// The dealer collects a receipt from every holder as evidence the sharing was
+// delivered. It identifies the share by its hash so it contains no secret
+// material.
+type Receipt struct {
+	Fingerprint string            `json:"fingerprint"`
+	ShareID     uint16            `json:"share_id"`
+	ShareSHA256 string            `json:"share_sha256"`
+	Holder      string            `json:"holder,omitempty"`
+	ReceivedAt  time.Time         `json:"received_at"`
+	PublicKey   ed25519.PublicKey `json:"public_key"`
+	Signature   []byte            `json:"signature"`
+}
+
+// NewReceipt returns the holder's receipt for share signed with their key.
+func NewReceipt(share *SecretShare, holder string, key ed25519.PrivateKey) (*Receipt, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid key length: %d, expected: %d", len(key), ed25519.PrivateKeySize)
+	}
+
+	r := &Receipt{
+		Fingerprint: share.Fingerprint(),
+		ShareID:     share.ID,
+		ShareSHA256: shareHash(share),
+		Holder:      holder,
+		ReceivedAt:  time.Now().UTC().Truncate(time.Second),
+		PublicKey:   key.Public().(ed25519.PublicKey),
+	}
+	r.Signature = ed25519.Sign(key, r.signedBytes())
+	return r, nil
+}
+
+// signedBytes encodes every field but the signature, length prefixing the
+// variable length ones.
+func (r *Receipt) signedBytes() []byte {
+	out := []byte(receiptSigPrefix)
+	for _, part := range [][]byte{[]byte(r.Fingerprint), []byte(r.ShareSHA256), []byte(r.Holder), r.PublicKey} {
+		out = appendUint32(out, uint32(len(part)))
+		out = append(out, part...)
+	}
+	var receivedAt [8]byte
+	binary.BigEndian.PutUint64(receivedAt[:], uint64(r.ReceivedAt.Unix()))
+	return append(appendShareID(out, r.ShareID), receivedAt[:]...)
+}
+
+// Verify returns an error if the receipt's signature isn't valid for its
+// public key.
+func (r *Receipt) Verify() error {
+	if len(r.PublicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key length: %d, expected: %d", len(r.PublicKey), ed25519.PublicKeySize)
+	}
+	if !ed25519.Verify(r.PublicKey, r.signedBytes(), r.Signature) {
+		return fmt.Errorf("receipt signature is invalid")
+	}
+	return nil
+}
+
+// VerifyReceipt returns an error if the receipt isn't a valid receipt for one
+// of the shares in the manifest. If the manifest names the share's holder the
+// receipt must be from them. If keys is non-nil the receipt must be signed by
+// the key it lists for the holder.
+func (m *Manifest) VerifyReceipt(r *Receipt, keys map[string]ed25519.PublicKey) error {
+	if err := r.Verify(); err != nil {
+		return err
+	}
+	if r.Fingerprint != m.Fingerprint {
+		return fmt.Errorf("receipt is for sharing %s, manifest declares %s", r.Fingerprint, m.Fingerprint)
+	}
+
+	for _, entry := range m.Shares {
+		if entry.ID != r.ShareID {
+			continue
+		}
+		if entry.SHA256 != r.ShareSHA256 {
+			return fmt.Errorf("receipt doesn't match share %d in the manifest", r.ShareID)
+		}
+		if entry.Holder != "" && entry.Holder != r.Holder {
+			return fmt.Errorf("receipt is from %s, manifest declares holder %s", r.Holder, entry.Holder)
+		}
+		if keys != nil {
+			key, ok := keys[r.Holder]
+			if !ok {
+				return fmt.Errorf("no key for holder %s", r.Holder)
+			}
+			if !bytes.Equal(key, r.PublicKey) {
+				return fmt.Errorf("receipt isn't signed by the key of holder %s", r.Holder)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("share ID %d isn't in the manifest", r.ShareID)
+}
+
+// VerifyReceipts returns an error unless there is a valid receipt for every
+// share in the manifest.
+func (m *Manifest) VerifyReceipts(receipts []*Receipt, keys map[string]ed25519.PublicKey) error {
+	received := make(map[uint16]bool, len(receipts))
+	for _, r := range receipts {
+		if err := m.VerifyReceipt(r, keys); err != nil {
+			return fmt.Errorf("receipt for share %d: %w", r.ShareID, err)
+		}
+		received[r.ShareID] = true
+	}
+
+	var missing []uint16
+	for _, entry := range m.Shares {
+		if !received[entry.ID] {
+			missing = append(missing, entry.ID)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("no receipt for shares: %v", missing)
+	}
+	return nil
+}
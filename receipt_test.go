@@ -0,0 +1,91 @@
+package adss
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+)
+
+func TestReceipts(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	holders := []string{"alice", "bob", "carol"}
+	m, err := NewManifest(shares, holders)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	keys := make(map[string]ed25519.PublicKey)
+	receipts := make([]*Receipt, len(shares))
+	for i, share := range shares {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		keys[holders[i]] = pub
+
+		r, err := NewReceipt(share, holders[i], priv)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		// Receipts are returned to the dealer as JSON.
+		data, err := json.Marshal(r)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		receipts[i] = new(Receipt)
+		if err := json.Unmarshal(data, receipts[i]); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	if err := m.VerifyReceipts(receipts, keys); err != nil {
+		t.Errorf("unexpected error verifying receipts: %s", err)
+	}
+	if err := m.VerifyReceipts(receipts, nil); err != nil {
+		t.Errorf("unexpected error verifying receipts without keys: %s", err)
+	}
+	if err := m.VerifyReceipts(receipts[:2], keys); err == nil {
+		t.Errorf("expected error for a missing receipt")
+	}
+
+	tampered := *receipts[0]
+	tampered.ShareID = 1
+	if err := m.VerifyReceipts([]*Receipt{&tampered, receipts[1], receipts[2]}, keys); err == nil {
+		t.Errorf("expected error for a tampered receipt")
+	}
+
+	// Bob signing for carol's share is caught by the manifest's holders and
+	// by the keys.
+	_, bobKey, _ := ed25519.GenerateKey(rand.Reader)
+	wrongHolder, err := NewReceipt(shares[2], "bob", bobKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := m.VerifyReceipt(wrongHolder, nil); err == nil {
+		t.Errorf("expected error for a receipt from the wrong holder")
+	}
+	wrongKey, err := NewReceipt(shares[2], "carol", bobKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := m.VerifyReceipt(wrongKey, keys); err == nil {
+		t.Errorf("expected error for a receipt signed by the wrong key")
+	}
+
+	other, err := Share(NewAccessStructure(2, 3), []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	foreign, err := NewReceipt(other[0], "alice", priv)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := m.VerifyReceipt(foreign, nil); err == nil {
+		t.Errorf("expected error for a receipt from another sharing")
+	}
+}
@@ -0,0 +1,51 @@
+package adss
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// samePayload reports whether the shares hold the same public payload.
+func samePayload(a, b *SecretShare) bool {
+	return bytes.Equal(a.Pub.C, b.Pub.C) && bytes.Equal(a.Pub.D, b.Pub.D) && bytes.Equal(a.Pub.J, b.Pub.J)
+}
+
+// reconcilePayloads returns the shares with the public payload of any share
+// in the minority replaced by the payload held by a strict majority of them.
+// Shares that are replaced are copied so the inputs aren't modified.
+func reconcilePayloads(shares []*SecretShare) ([]*SecretShare, error) {
+	if len(shares) == 0 {
+		return shares, nil
+	}
+
+	majority, majorityCount := shares[0], 0
+	for _, candidate := range shares {
+		count := 0
+		for _, share := range shares {
+			if samePayload(candidate, share) {
+				count++
+			}
+		}
+		if count > majorityCount {
+			majority, majorityCount = candidate, count
+		}
+	}
+
+	if majorityCount == len(shares) {
+		return shares, nil
+	}
+	if majorityCount*2 <= len(shares) {
+		return nil, fmt.Errorf("no public payload is held by a majority of shares")
+	}
+
+	out := make([]*SecretShare, len(shares))
+	for i, share := range shares {
+		out[i] = share
+		if !samePayload(share, majority) {
+			repaired := *share
+			repaired.Pub = majority.Pub
+			out[i] = &repaired
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,54 @@
+package adss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRecoverWithMajorityPayload(t *testing.T) {
+	msg := []byte("hello world")
+	shares, err := Share(NewAccessStructure(3, 3), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	rotted := cloneShare(shares[0])
+	rotted.Pub.C[0]++
+	input := []*SecretShare{rotted, shares[1], shares[2]}
+
+	// Every share is needed, so the corrupted payload prevents recovery.
+	if _, _, err := Recover(input); err == nil {
+		t.Fatalf("expected error without reconciliation")
+	}
+
+	recov, V, err := Recover(input, WithMajorityPayload())
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x != %x", recov, msg)
+	}
+	if len(V) != 3 || !V[0].Equal(shares[0]) {
+		t.Errorf("expected the repaired share in V, got: %s", sharesDesc(V))
+	}
+	if bytes.Equal(rotted.Pub.C, shares[0].Pub.C) {
+		t.Errorf("input share was modified")
+	}
+
+	// A corrupted secret part can't be repaired.
+	bad := cloneShare(shares[0])
+	bad.Pub.C[0]++
+	bad.Sec[0]++
+	if _, _, err := Recover([]*SecretShare{bad, shares[1], shares[2]}, WithMajorityPayload()); err == nil {
+		t.Errorf("expected error with a corrupted secret")
+	}
+
+	// Without a majority there is nothing to reconcile with.
+	rotted2 := cloneShare(shares[1])
+	rotted2.Pub.D[0]++
+	_, _, err = Recover([]*SecretShare{rotted, rotted2}, WithMajorityPayload())
+	expected := "no public payload is held by a majority of shares"
+	if err == nil || err.Error() != expected {
+		t.Errorf("unexpected error, expected: %s, got: %v", expected, err)
+	}
+}
@@ -0,0 +1,199 @@
+package adss
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// Base schemes that the key of a sharing can be split with, recorded in each
+// share.
+const (
+	// SchemeShamir splits the key with Shamir's scheme over GF(2^8). Any T
+	// shares hold enough of the key to recover it, so exclusions and mandatory
+	// shares are only enforced by recovery refusing unsupported sets.
+	SchemeShamir uint8 = 0
+	// SchemeReplicated splits the key with replicated, or CNF, secret sharing.
+	// The key is the XOR of one piece per maximal set of shares that can't
+	// recover, and each share holds the pieces of the sets it isn't in. Sets
+	// that can't recover are missing a piece, so exclusions and mandatory
+	// shares are enforced by the sharing itself. Shares grow with the number
+	// of such sets, so it suits small access structures.
+	SchemeReplicated uint8 = 1
+	// SchemeFormula splits the key along the Formula of the access structure.
+	// Each gate splits its input with Shamir's scheme into a piece for each
+	// child, K of which recover it, and each share holds the piece of its
+	// leaf. Sets of shares that don't satisfy the formula hold too little to
+	// recover, and the shares are the size of Shamir shares.
+	SchemeFormula uint8 = 2
+	// SchemeShamir16 splits the key with Shamir's scheme over GF(2^16), two
+	// bytes at a time, as sharings of more than 255 shares always are. It
+	// lets smaller sharings use the same field, such as to match another
+	// deployment, and otherwise behaves like SchemeShamir.
+	SchemeShamir16 uint8 = 3
+)
+
+// Limits on the access structures that can be shared with SchemeReplicated,
+// which keep the search for the maximal unqualified sets and the size of the
+// shares reasonable.
+const (
+	maxReplicatedShares = 16
+	maxReplicatedSets   = 1024
+)
+
+// maximalUnqualifiedSets returns the maximal sets of share IDs that can't
+// recover under A, as bitmasks in increasing order. A set can recover if it
+// contains at least T shares that A supports.
+func maximalUnqualifiedSets(A AccessStructure) ([]uint32, error) {
+	if A.T == 0 || A.T > A.N {
+		return nil, fmt.Errorf("%w: %d-of-%d", ErrInvalidAccessStructure, A.T, A.N)
+	}
+	if A.N > maxReplicatedShares {
+		return nil, fmt.Errorf("%w: replicated sharing supports at most %d shares, got %d", ErrInvalidAccessStructure, maxReplicatedShares, A.N)
+	}
+
+	// A set is qualified if it is supported and meets the threshold, or if
+	// removing one of its shares leaves a qualified set. Removing a share
+	// gives a smaller mask, so visiting the masks in order sees it first.
+	full := uint32(1)<<A.N - 1
+	qualified := make([]bool, full+1)
+	ids := make([]uint16, 0, A.N)
+	for mask := uint32(0); mask <= full; mask++ {
+		for rest := mask; rest != 0; rest &= rest - 1 {
+			if qualified[mask&^(rest&-rest)] {
+				qualified[mask] = true
+				break
+			}
+		}
+		if !qualified[mask] && bits.OnesCount32(mask) >= int(A.T) {
+			qualified[mask] = A.isSupportedIDSet(maskIDs(mask, ids[:0]))
+		}
+	}
+	if !qualified[full] {
+		return nil, fmt.Errorf("%w: no set of shares can recover under it", ErrInvalidAccessStructure)
+	}
+
+	var sets []uint32
+	for mask := uint32(0); mask < full; mask++ {
+		if qualified[mask] {
+			continue
+		}
+		maximal := true
+		for rest := full &^ mask; rest != 0; rest &= rest - 1 {
+			if !qualified[mask|(rest&-rest)] {
+				maximal = false
+				break
+			}
+		}
+		if maximal {
+			if len(sets) == maxReplicatedSets {
+				return nil, fmt.Errorf("%w: replicated sharing supports at most %d unqualified sets", ErrInvalidAccessStructure, maxReplicatedSets)
+			}
+			sets = append(sets, mask)
+		}
+	}
+	return sets, nil
+}
+
+// maskIDs appends the IDs of the shares in the mask to ids.
+func maskIDs(mask uint32, ids []uint16) []uint16 {
+	for ; mask != 0; mask &= mask - 1 {
+		ids = append(ids, uint16(bits.TrailingZeros32(mask)))
+	}
+	return ids
+}
+
+// replicatedShare splits M into one piece per maximal unqualified set of A,
+// taking all but the last from a PRF keyed with R, and gives each share the
+// pieces of the sets it isn't in, in order.
+func replicatedShare(A AccessStructure, M, R []byte) ([]*s1SecretShare, error) {
+	sets, err := maximalUnqualifiedSets(A)
+	if err != nil {
+		return nil, err
+	}
+
+	prf := newPRF(R, []byte("adss replicated"))
+	pieces := make([][]byte, len(sets))
+	last := append([]byte{}, M...)
+	for i := range pieces[:len(pieces)-1] {
+		pieces[i] = make([]byte, len(M))
+		if _, err := io.ReadFull(prf, pieces[i]); err != nil {
+			return nil, err
+		}
+		for j, b := range pieces[i] {
+			last[j] ^= b
+		}
+	}
+	pieces[len(pieces)-1] = last
+
+	shares := make([]*s1SecretShare, A.N)
+	for i := range shares {
+		var secret []byte
+		for j, set := range sets {
+			if set&(1<<uint(i)) == 0 {
+				secret = append(secret, pieces[j]...)
+			}
+		}
+		shares[i] = &s1SecretShare{i: uint16(i), t: A.T, n: A.N, secret: secret}
+	}
+	return shares, nil
+}
+
+// replicatedRecover combines the pieces the shares hold. It fails if the
+// shares are all in one of the maximal unqualified sets, since no share holds
+// its piece. Where several shares hold a piece, the first is used; recovery
+// checks the shares against a resharing so a share holding a different piece
+// isn't valid.
+func replicatedRecover(shares []*SecretShare) ([]byte, error) {
+	if len(shares) < 1 {
+		return nil, fmt.Errorf("missing argument: shares, was nil or 0 length")
+	}
+	sets, err := maximalUnqualifiedSets(shares[0].As)
+	if err != nil {
+		return nil, err
+	}
+
+	pieces := make([][]byte, len(sets))
+	pieceLen := -1
+	for _, share := range shares {
+		if share.ID >= share.As.N {
+			return nil, &ShareError{ID: share.ID, Field: FieldID, Err: fmt.Errorf("%w for %d-of-%d", ErrShareIDOutOfRange, share.As.T, share.As.N)}
+		}
+		held := 0
+		for _, set := range sets {
+			if set&(1<<share.ID) == 0 {
+				held++
+			}
+		}
+		if held == 0 {
+			continue
+		}
+		if len(share.Sec)%held != 0 || (pieceLen != -1 && len(share.Sec)/held != pieceLen) {
+			return nil, fmt.Errorf("inconsistent share lengths: %d bytes for %d pieces", len(share.Sec), held)
+		}
+		pieceLen = len(share.Sec) / held
+
+		j := 0
+		for k, set := range sets {
+			if set&(1<<share.ID) == 0 {
+				if pieces[k] == nil {
+					pieces[k] = share.Sec[j*pieceLen : (j+1)*pieceLen]
+				}
+				j++
+			}
+		}
+	}
+
+	for k, piece := range pieces {
+		if piece == nil {
+			return nil, fmt.Errorf("not enough shares provided, none hold the piece for %v", IDSet(maskIDs(sets[k], nil)))
+		}
+	}
+	msg := make([]byte, pieceLen)
+	for _, piece := range pieces {
+		for i, b := range piece {
+			msg[i] ^= b
+		}
+	}
+	return msg, nil
+}
@@ -0,0 +1,140 @@
+package adss
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_maximalUnqualifiedSets(t *testing.T) {
+	excluded, err := NewAccessStructureWithExclusions(2, 3, IDSet{0, 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	mandatory, err := NewAccessStructure(2, 4).WithMandatory(0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var tests = []struct {
+		name     string
+		as       AccessStructure
+		expected []uint32
+	}{
+		{"2-of-3", NewAccessStructure(2, 3), []uint32{0x1, 0x2, 0x4}},
+		{"3-of-3", NewAccessStructure(3, 3), []uint32{0x3, 0x5, 0x6}},
+		{"1-of-2", NewAccessStructure(1, 2), []uint32{0x0}},
+		{"excluded", excluded, []uint32{0x3, 0x4}},
+		{"mandatory", mandatory, []uint32{0x1, 0xe}},
+	}
+	for _, tt := range tests {
+		sets, err := maximalUnqualifiedSets(tt.as)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", tt.name, err)
+		}
+		if len(sets) != len(tt.expected) {
+			t.Fatalf("%s: got sets %x, expected: %x", tt.name, sets, tt.expected)
+		}
+		for i := range sets {
+			if sets[i] != tt.expected[i] {
+				t.Errorf("%s: got sets %x, expected: %x", tt.name, sets, tt.expected)
+			}
+		}
+	}
+
+	if _, err := maximalUnqualifiedSets(NewAccessStructure(2, 17)); err == nil {
+		t.Errorf("expected an error for too many shares")
+	}
+}
+
+func TestShareWithScheme(t *testing.T) {
+	msg := []byte("hello world")
+	as, err := NewAccessStructureWithExclusions(2, 3, IDSet{0, 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	shares, err := ShareWithScheme(SchemeReplicated, as, msg, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	for _, share := range shares {
+		if share.Scheme != SchemeReplicated {
+			t.Errorf("share %d has scheme %d", share.ID, share.Scheme)
+		}
+	}
+
+	for _, subset := range [][]*SecretShare{shares, {shares[0], shares[2]}, {shares[2], shares[1]}} {
+		if recov, _, err := Recover(subset); err != nil || !bytes.Equal(recov, msg) {
+			t.Errorf("recovered %q from %s, %v", recov, sharesDesc(subset), err)
+		}
+	}
+
+	// The excluded shares don't hold the whole key, not only fail the check.
+	if _, err := replicatedRecover([]*SecretShare{shares[0], shares[1]}); err == nil {
+		t.Errorf("recovered the key from excluded shares")
+	}
+	if _, _, err := Recover([]*SecretShare{shares[0], shares[1]}); err == nil {
+		t.Errorf("recovered from excluded shares")
+	}
+
+	// The scheme is authenticated.
+	relabeled := make([]*SecretShare, len(shares))
+	for i, share := range shares {
+		relabeled[i] = cloneShare(share)
+		relabeled[i].Scheme = SchemeShamir
+	}
+	if recov, _, err := Recover(relabeled); err == nil {
+		t.Errorf("recovered %q after changing the scheme", recov)
+	}
+
+	if decoded, err := DecodeShareString(EncodeShareString(shares[0])); err != nil || !decoded.Equal(shares[0]) {
+		t.Errorf("share string round trip failed: %v", err)
+	}
+	locked, err := LockShareWithParams(shares[0], []byte("passphrase"), testArgon2Params)
+	if err != nil {
+		t.Fatalf("unexpected error on locking: %s", err)
+	}
+	locked.Scheme = SchemeShamir
+	if _, err := locked.Unlock([]byte("passphrase")); err == nil {
+		t.Errorf("unlocked after changing the scheme")
+	}
+
+	// Shares hold different numbers of pieces when the structure isn't
+	// symmetric.
+	mandatory, err := NewAccessStructure(3, 4).WithMandatory(0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	shares, err = ShareWithScheme(SchemeReplicated, mandatory, msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if recov, _, err := Recover([]*SecretShare{shares[3], shares[0], shares[1]}); err != nil || !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %q, %v", recov, err)
+	}
+	if _, err := replicatedRecover(shares[1:]); err == nil {
+		t.Errorf("recovered the key without the mandatory share")
+	}
+
+	if _, err := ShareWithScheme(SchemeShamir16+1, as, msg, nil); err == nil {
+		t.Errorf("expected an error for an unknown scheme")
+	}
+	if _, err := ShareWithScheme(SchemeReplicated, NewAccessStructure(2, 17), msg, nil); err == nil {
+		t.Errorf("expected an error for too many shares")
+	}
+}
+
+func TestShareReplicatedManySets(t *testing.T) {
+	// 4-of-16 has 560 maximal unqualified sets, more pieces than one HKDF
+	// stream has coins for.
+	msg := []byte("hello world")
+	shares, err := ShareWithScheme(SchemeReplicated, NewAccessStructure(4, 16), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if recov, _, err := Recover(shares[12:]); err != nil || !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %q, %v", recov, err)
+	}
+	if _, err := replicatedRecover(shares[13:]); err == nil {
+		t.Errorf("recovered the key from fewer than the threshold of shares")
+	}
+}
@@ -0,0 +1,140 @@
+package adss
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// revocationSigPrefix is prepended to a revocation list before signing so that
+// its signature can't be confused with a signature on anything else.
+const revocationSigPrefix = "adss revocation list\n"
+
+// RevocationList marks shares of a sharing as revoked, such as when a holder
+// reports their share was compromised. Revoked shares are still
+// cryptographically valid, so the list is signed by the dealer and passed to
+// Recover with WithRevocationList to stop them participating.
+type RevocationList struct {
+	Fingerprint string            `json:"fingerprint"`
+	Revoked     IDSet             `json:"revoked"`
+	IssuedAt    time.Time         `json:"issued_at"`
+	PublicKey   ed25519.PublicKey `json:"public_key"`
+	Signature   []byte            `json:"signature"`
+}
+
+// NewRevocationList returns a list revoking the shares with the given IDs of
+// the sharing with the fingerprint, signed with key.
+func NewRevocationList(fingerprint string, revoked []uint16, key ed25519.PrivateKey) (*RevocationList, error) {
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid key length: %d, expected: %d", len(key), ed25519.PrivateKeySize)
+	}
+	if len(revoked) == 0 {
+		return nil, fmt.Errorf("no share IDs to revoke")
+	}
+
+	l := &RevocationList{
+		Fingerprint: fingerprint,
+		IssuedAt:    time.Now().UTC().Truncate(time.Second),
+		PublicKey:   key.Public().(ed25519.PublicKey),
+	}
+	for _, id := range revoked {
+		if !l.revokes(id) {
+			l.Revoked = append(l.Revoked, id)
+		}
+	}
+	sort.Slice(l.Revoked, func(i, j int) bool { return l.Revoked[i] < l.Revoked[j] })
+
+	l.Signature = ed25519.Sign(key, l.signedBytes())
+	return l, nil
+}
+
+// signedBytes encodes every field but the signature, length prefixing the
+// variable length ones. Lists revoking an ID above 255 encode the IDs in two
+// bytes and end with a 2, so the signatures of other lists are unchanged.
+func (l *RevocationList) signedBytes() []byte {
+	wide := false
+	for _, id := range l.Revoked {
+		wide = wide || id > 0xff
+	}
+	out := []byte(revocationSigPrefix)
+	for _, part := range [][]byte{[]byte(l.Fingerprint), appendIDs(nil, l.Revoked, wide), l.PublicKey} {
+		out = appendUint32(out, uint32(len(part)))
+		out = append(out, part...)
+	}
+	var issuedAt [8]byte
+	binary.BigEndian.PutUint64(issuedAt[:], uint64(l.IssuedAt.Unix()))
+	out = append(out, issuedAt[:]...)
+	if wide {
+		out = append(out, 2)
+	}
+	return out
+}
+
+// Verify returns an error unless the list is signed by key.
+func (l *RevocationList) Verify(key ed25519.PublicKey) error {
+	if len(l.PublicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key length: %d, expected: %d", len(l.PublicKey), ed25519.PublicKeySize)
+	}
+	if !bytes.Equal(l.PublicKey, key) {
+		return fmt.Errorf("revocation list isn't signed by the expected key")
+	}
+	if !ed25519.Verify(l.PublicKey, l.signedBytes(), l.Signature) {
+		return fmt.Errorf("revocation list signature is invalid")
+	}
+	return nil
+}
+
+// IsRevoked reports whether the list revokes the share.
+func (l *RevocationList) IsRevoked(share *SecretShare) bool {
+	return share.Fingerprint() == l.Fingerprint && l.revokes(share.ID)
+}
+
+func (l *RevocationList) revokes(id uint16) bool {
+	for _, revoked := range l.Revoked {
+		if revoked == id {
+			return true
+		}
+	}
+	return false
+}
+
+// WithRevocationList makes Recover ignore the shares the list revokes, as if
+// they weren't provided. Recovery fails if the list isn't signed by key. The
+// list only applies to the sharing it names; a share can't escape it by
+// changing its fingerprint since that changes J, which every valid share of
+// the sharing must agree on.
+func WithRevocationList(list *RevocationList, key ed25519.PublicKey) RecoverOption {
+	return func(cfg *recoverConfig) {
+		cfg.revocations = append(cfg.revocations, trustedRevocationList{list, key})
+	}
+}
+
+type trustedRevocationList struct {
+	list *RevocationList
+	key  ed25519.PublicKey
+}
+
+// removeRevoked returns the shares that none of the lists revoke, or an error
+// if a list isn't validly signed.
+func removeRevoked(shares []*SecretShare, lists []trustedRevocationList) ([]*SecretShare, error) {
+	for _, trusted := range lists {
+		if err := trusted.list.Verify(trusted.key); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]*SecretShare, 0, len(shares))
+	for _, share := range shares {
+		revoked := false
+		for _, trusted := range lists {
+			revoked = revoked || trusted.list.IsRevoked(share)
+		}
+		if !revoked {
+			out = append(out, share)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,81 @@
+package adss
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+)
+
+func TestRevocationList(t *testing.T) {
+	msg := []byte("hello world")
+	shares, err := Share(NewAccessStructure(2, 3), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %s", err)
+	}
+
+	list, err := NewRevocationList(shares[0].Fingerprint(), []uint16{1, 1}, priv)
+	if err != nil {
+		t.Fatalf("unexpected error creating list: %s", err)
+	}
+	if compareIDSets(list.Revoked, IDSet{1}) != 0 {
+		t.Errorf("got revoked IDs %v, expected: [1]", list.Revoked)
+	}
+	if err := list.Verify(pub); err != nil {
+		t.Errorf("unexpected error verifying: %s", err)
+	}
+	if list.IsRevoked(shares[0]) || !list.IsRevoked(shares[1]) {
+		t.Errorf("unexpected revocation status")
+	}
+
+	// A revoked share can't make up the threshold.
+	if _, _, err := Recover([]*SecretShare{shares[0], shares[1]}, WithRevocationList(list, pub)); err == nil {
+		t.Errorf("recovered with a revoked share")
+	}
+	recov, V, err := Recover(shares, WithRevocationList(list, pub))
+	if err != nil || !bytes.Equal(recov, msg) {
+		t.Fatalf("recovered %q, %v", recov, err)
+	}
+	for _, share := range V {
+		if share.ID == 1 {
+			t.Errorf("revoked share is in the valid shares")
+		}
+	}
+
+	// Lists for other sharings don't apply.
+	other, err := NewRevocationList("other", []uint16{0, 1, 2}, priv)
+	if err != nil {
+		t.Fatalf("unexpected error creating list: %s", err)
+	}
+	if _, _, err := Recover(shares, WithRevocationList(other, pub)); err != nil {
+		t.Errorf("unexpected error on recovery: %s", err)
+	}
+
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating key: %s", err)
+	}
+	var errTests = []struct {
+		name   string
+		modify func(l *RevocationList) ed25519.PublicKey
+	}{
+		{"wrong key", func(l *RevocationList) ed25519.PublicKey { return otherPub }},
+		{"removed ID", func(l *RevocationList) ed25519.PublicKey { l.Revoked = IDSet{}; return pub }},
+		{"changed fingerprint", func(l *RevocationList) ed25519.PublicKey { l.Fingerprint = "other"; return pub }},
+		{"changed time", func(l *RevocationList) ed25519.PublicKey { l.IssuedAt = l.IssuedAt.Add(-1); return pub }},
+	}
+	for _, tt := range errTests {
+		mod := *list
+		key := tt.modify(&mod)
+		if err := mod.Verify(key); err == nil {
+			t.Errorf("%s: expected an error verifying", tt.name)
+		}
+		if _, _, err := Recover(shares, WithRevocationList(&mod, key)); err == nil {
+			t.Errorf("%s: recovered with an invalid list", tt.name)
+		}
+	}
+}
@@ -3,42 +3,91 @@ package adss
 import (
 	"crypto/sha256"
 	"fmt"
+	"io"
 
 	"golang.org/x/crypto/hkdf"
 )
 
 type s1SecretShare struct {
-	i, t, n uint8
+	i, t, n uint16
+	x       uint16 // the point the share's polynomials are evaluated at
+	wide    bool   // whether the polynomials are over GF(2^16)
 	secret  []byte
 }
 
 func s1Share(A AccessStructure, M, R, T []byte) ([]*s1SecretShare, error) {
+	return s1ShareAt(A, M, R, T, nil, A.wide())
+}
+
+// s1ShareAt is like s1Share but evaluates share i at points[i] rather than
+// i+1, for interoperating with deployments that use other points. The points
+// must be distinct and non-zero, see validatePoints.
+//
+// If wide, which access structures of more than 255 shares must be, M is
+// split over GF(2^16) at the points i+1, see wideShare, so there must be no
+// points and M must be of even length.
+func s1ShareAt(A AccessStructure, M, R, T []byte, points []uint8, wide bool) ([]*s1SecretShare, error) {
+	if A.wide() && !wide {
+		return nil, fmt.Errorf("sharings of more than 255 shares must be split over GF(2^16)")
+	}
+	if wide && (points != nil || len(M)%2 != 0) {
+		return nil, fmt.Errorf("sharings over GF(2^16) need an even length secret and the default points")
+	}
+
 	// Use HKDF-SHA256 as our PRF, keying it with the provided randomness
-	prf := hkdf.New(sha256.New, R, nil, T)
+	prf := newPRF(R, T)
 
+	// Each message block gets a polynomial of degree t-1 with the block as the
+	// intercept and the remaining coefficients from the PRF. We read all of the
+	// random coefficients up front; the PRF is a stream so this produces the
+	// same coefficients as reading them block by block.
+	degree := int(A.T) - 1
+	randCoeffs := make([]byte, len(M)*degree)
+	if _, err := io.ReadFull(prf, randCoeffs); err != nil {
+		return nil, err
+	}
+
+	if wide {
+		widePoints := make([]uint16, A.N)
+		for i := range widePoints {
+			widePoints[i] = uint16(i + 1)
+		}
+		secrets := wideShare(M, int(A.T), widePoints, randCoeffs)
+		shares := make([]*s1SecretShare, A.N)
+		for i, secret := range secrets {
+			shares[i] = &s1SecretShare{i: uint16(i), t: A.T, n: A.N, x: widePoints[i], wide: true, secret: secret}
+		}
+		return shares, nil
+	}
+
+	if points == nil {
+		points = defaultPoints(uint8(A.N))
+	}
 	secrets := make([][]byte, A.N)
 	for i := range secrets {
 		secrets[i] = make([]byte, len(M))
 	}
 
+	eval := newMultipointEvaluator(points, degree)
+	coeffs := make([]uint8, degree+1)
+	values := make([]uint8, A.N)
 	for i, msgBlock := range M { // for each message block
-		poly, err := makePolynomial(msgBlock, A.T-1, prf)
-		if err != nil {
-			return nil, err
-		}
+		coeffs[0] = msgBlock
+		copy(coeffs[1:], randCoeffs[i*degree:(i+1)*degree])
 
-		for j := 0; j < int(A.N); j++ { // create shares for each party
-			// We use j+1 here since we don't want to evaluate at 0, as that's the secret :)
-			secrets[j][i] = poly.evaluate(uint8(j + 1))
+		eval.evaluateAll(coeffs, values)
+		for j := range secrets { // create shares for each party
+			secrets[j][i] = values[j]
 		}
 	}
 
 	shares := make([]*s1SecretShare, A.N)
 	for i, secret := range secrets {
 		shares[i] = &s1SecretShare{
-			i:      uint8(i),
+			i:      uint16(i),
 			t:      A.T,
 			n:      A.N,
+			x:      uint16(points[i]),
 			secret: secret,
 		}
 	}
@@ -46,6 +95,39 @@ func s1Share(A AccessStructure, M, R, T []byte) ([]*s1SecretShare, error) {
 	return shares, nil
 }
 
+// hkdfLimit is the most output a single HKDF-SHA256 stream can produce.
+const hkdfLimit = 255 * sha256.Size
+
+// prf is an unlimited stream of coins keyed with R. Its first hkdfLimit bytes
+// are HKDF-SHA256 of R with info, as the coins of every sharing were before
+// thresholds above 256 needed more, and each further hkdfLimit bytes are
+// another HKDF-SHA256 stream salted with their chunk number.
+type prf struct {
+	R, info []byte
+	chunk   uint32
+	stream  io.Reader
+}
+
+func newPRF(R, info []byte) *prf {
+	return &prf{R: R, info: info, stream: io.LimitReader(hkdf.New(sha256.New, R, nil, info), hkdfLimit)}
+}
+
+func (p *prf) Read(out []byte) (int, error) {
+	n, err := p.stream.Read(out)
+	if err == io.EOF {
+		p.chunk++
+		if p.chunk == 0 {
+			return n, fmt.Errorf("coins exhausted")
+		}
+		salt := make([]byte, 0, len("adss coins")+4)
+		salt = append(salt, "adss coins"...)
+		salt = append(salt, byte(p.chunk>>24), byte(p.chunk>>16), byte(p.chunk>>8), byte(p.chunk))
+		p.stream = io.LimitReader(hkdf.New(sha256.New, p.R, salt, p.info), hkdfLimit)
+		err = nil
+	}
+	return n, err
+}
+
 func s1Recover(shares []*s1SecretShare) ([]byte, error) {
 	if shares == nil || len(shares) < 1 {
 		return nil, fmt.Errorf("missing argument: shares, was nil or 0 length")
@@ -57,13 +139,30 @@ func s1Recover(shares []*s1SecretShare) ([]byte, error) {
 		return nil, fmt.Errorf("not enough shares provided, got: %d, need: %d", t, k)
 	}
 
+	for _, share := range shares {
+		if len(share.secret) != mLen {
+			return nil, fmt.Errorf("inconsistent share lengths: %d and %d", len(share.secret), mLen)
+		}
+	}
+
+	if shares[0].wide {
+		if mLen%2 != 0 {
+			return nil, fmt.Errorf("odd share length %d in a sharing over GF(2^16)", mLen)
+		}
+		points := make([]uint16, t)
+		secrets := make([][]byte, t)
+		for j, share := range shares {
+			points[j], secrets[j] = share.x, share.secret
+		}
+		return wideRecover(points, secrets), nil
+	}
+
 	msg := make([]byte, mLen)
+	xSamples := make([]uint8, t)
+	ySamples := make([]uint8, t)
 	for i := range msg {
-		xSamples := make([]uint8, t)
-		ySamples := make([]uint8, t)
-
 		for j, share := range shares {
-			xSamples[j] = share.i + 1 // +1 to account for how we evaluated it in sharing
+			xSamples[j] = uint8(share.x)
 			ySamples[j] = share.secret[i]
 		}
 
@@ -72,3 +171,23 @@ func s1Recover(shares []*s1SecretShare) ([]byte, error) {
 
 	return msg, nil
 }
+
+// validatePoints returns an error unless there is one point per share of the
+// access structure and they are distinct and non-zero, since the polynomial's
+// value at zero is the secret.
+func validatePoints(points []uint8, A AccessStructure) error {
+	if len(points) != int(A.N) {
+		return fmt.Errorf("got %d evaluation points for %d shares", len(points), A.N)
+	}
+	var seen [256]bool
+	for i, x := range points {
+		if x == 0 {
+			return fmt.Errorf("evaluation point of share %d is zero", i)
+		}
+		if seen[x] {
+			return fmt.Errorf("evaluation point %d is repeated", x)
+		}
+		seen[x] = true
+	}
+	return nil
+}
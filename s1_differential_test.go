@@ -0,0 +1,147 @@
+package adss
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"flag"
+	"io"
+	mathrand "math/rand"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+var differential = flag.Int("differential", 0, "cross-check s1Share and s1Recover against an independent GF(2^8) implementation on this many random inputs")
+
+// The functions below are an independent implementation of the field used by
+// s1Share: GF(2^8) modulo x^8 + x^4 + x^3 + x + 1, computed bit by bit rather
+// than with log tables, so a regression in the tables or the evaluators is
+// caught rather than reproduced.
+
+func gfMul(a, b uint8) uint8 {
+	var out uint8
+	for b > 0 {
+		if b&1 == 1 {
+			out ^= a
+		}
+		carry := a&0x80 != 0
+		a <<= 1
+		if carry {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+	return out
+}
+
+// gfInverses[a] is the inverse of a, found by searching for the b with
+// a*b = 1.
+var gfInverses = func() [256]uint8 {
+	var inv [256]uint8
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			if gfMul(uint8(a), uint8(b)) == 1 {
+				inv[a] = uint8(b)
+				break
+			}
+		}
+	}
+	return inv
+}()
+
+// gfEval evaluates the polynomial at x with Horner's method.
+func gfEval(coeffs []uint8, x uint8) uint8 {
+	var out uint8
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		out = gfMul(out, x) ^ coeffs[i]
+	}
+	return out
+}
+
+// gfLagrangeZero returns the Lagrange basis coefficients for interpolating
+// the value at 0 of a polynomial through points at xs.
+func gfLagrangeZero(xs []uint8) []uint8 {
+	basis := make([]uint8, len(xs))
+	for i := range xs {
+		basis[i] = 1
+		for j := range xs {
+			if i != j {
+				// x_j / (x_j - x_i), and subtraction is xor
+				basis[i] = gfMul(basis[i], gfMul(xs[j], gfInverses[xs[j]^xs[i]]))
+			}
+		}
+	}
+	return basis
+}
+
+func TestS1Differential(t *testing.T) {
+	if *differential == 0 {
+		t.Skip("enable with -differential=N")
+	}
+
+	seed := time.Now().UnixNano()
+	t.Logf("seed: %d", seed)
+	rng := mathrand.New(mathrand.NewSource(seed))
+
+	for iter := 0; iter < *differential; iter++ {
+		n := uint8(1 + rng.Intn(255))
+		threshold := uint8(1 + rng.Intn(int(n)))
+		A := NewAccessStructure(uint16(threshold), uint16(n))
+		// s1Share is used on 32 byte keys, which keeps the coefficients
+		// within what HKDF can produce.
+		M := make([]byte, 1+rng.Intn(32))
+		R := make([]byte, 32)
+		T := make([]byte, rng.Intn(16))
+		rng.Read(M)
+		rng.Read(R)
+		rng.Read(T)
+
+		shares, err := s1Share(A, M, R, T)
+		if err != nil {
+			t.Fatalf("%d-of-%d: unexpected error on sharing: %s", threshold, n, err)
+		}
+
+		// Rebuild each block's polynomial from the PRF and check every share
+		// is its value at ID+1.
+		degree := int(threshold) - 1
+		coeffs := make([]byte, len(M)*degree)
+		if _, err := io.ReadFull(hkdf.New(sha256.New, R, nil, T), coeffs); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		for i := range M {
+			poly := append([]uint8{M[i]}, coeffs[i*degree:(i+1)*degree]...)
+			for _, share := range shares {
+				if expected := gfEval(poly, uint8(share.i+1)); share.secret[i] != expected {
+					t.Fatalf("%d-of-%d: share %d block %d = %x, expected: %x (seed %d)", threshold, n, share.i, i, share.secret[i], expected, seed)
+				}
+			}
+		}
+
+		// Any threshold of shares recovers with both implementations.
+		rng.Shuffle(len(shares), func(i, j int) { shares[i], shares[j] = shares[j], shares[i] })
+		subset := shares[:threshold]
+		recov, err := s1Recover(subset)
+		if err != nil {
+			t.Fatalf("%d-of-%d: unexpected error on recovery: %s", threshold, n, err)
+		}
+		if !bytes.Equal(recov, M) {
+			t.Fatalf("%d-of-%d: recovered %x, expected: %x (seed %d)", threshold, n, recov, M, seed)
+		}
+
+		xs := make([]uint8, len(subset))
+		for j, share := range subset {
+			xs[j] = uint8(share.i + 1)
+		}
+		basis := gfLagrangeZero(xs)
+		for i := range M {
+			var got uint8
+			for j, share := range subset {
+				got ^= gfMul(share.secret[i], basis[j])
+			}
+			if got != M[i] {
+				t.Fatalf("%d-of-%d: reference recovered block %d = %x, expected: %x (seed %d)", threshold, n, i, got, M[i], seed)
+			}
+		}
+	}
+}
@@ -2,7 +2,13 @@ package adss
 
 import (
 	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
 	"testing"
+
+	"golang.org/x/crypto/hkdf"
 )
 
 func Test_s1SplitAnds1Recover(t *testing.T) {
@@ -32,4 +38,161 @@ func Test_s1SplitAnds1Recover(t *testing.T) {
 	}
 }
 
+// naiveS1Share is the original implementation of s1Share which builds and
+// evaluates a fresh polynomial for every message block. It is kept to check
+// that the optimized implementation produces identical shares and to
+// benchmark against.
+func naiveS1Share(A AccessStructure, M, R, T []byte) ([][]byte, error) {
+	prf := hkdf.New(sha256.New, R, nil, T)
+
+	secrets := make([][]byte, A.N)
+	for i := range secrets {
+		secrets[i] = make([]byte, len(M))
+	}
+
+	for i, msgBlock := range M {
+		poly, err := makePolynomial(msgBlock, uint8(A.T-1), prf)
+		if err != nil {
+			return nil, err
+		}
+
+		for j := 0; j < int(A.N); j++ {
+			secrets[j][i] = poly.evaluate(uint8(j + 1))
+		}
+	}
+
+	return secrets, nil
+}
+
+func Test_s1ShareMatchesNaive(t *testing.T) {
+	// s1Share is only ever used to share 32 byte keys, and the HKDF output limit
+	// caps how many coefficients can be generated, so we test with that size.
+	msg := make([]byte, 32)
+	if _, err := rand.Read(msg); err != nil {
+		t.Fatal(err)
+	}
+	R, T := []byte("this is very random"), []byte("some associated data")
+
+	for _, as := range []AccessStructure{NewAccessStructure(1, 1), NewAccessStructure(2, 3), NewAccessStructure(5, 9), NewAccessStructure(255, 255)} {
+		expected, err := naiveS1Share(as, msg, R, T)
+		if err != nil {
+			t.Fatalf("unexpected error on naive sharing: %s", err)
+		}
+
+		shares, err := s1Share(as, msg, R, T)
+		if err != nil {
+			t.Fatalf("unexpected error on sharing: %s", err)
+		}
+
+		for i, share := range shares {
+			if !bytes.Equal(share.secret, expected[i]) {
+				t.Errorf("%d-of-%d: share %d differs from naive implementation", as.T, as.N, i)
+			}
+		}
+	}
+}
+
+func Test_prf(t *testing.T) {
+	R, info := []byte("this is very random"), []byte("some associated data")
+
+	// The first chunk is the HKDF stream every sharing used before, so
+	// existing sharings reproduce.
+	expected := make([]byte, hkdfLimit)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, R, nil, info), expected); err != nil {
+		t.Fatal(err)
+	}
+	coins := make([]byte, 3*hkdfLimit)
+	if _, err := io.ReadFull(newPRF(R, info), coins); err != nil {
+		t.Fatalf("unexpected error reading past the HKDF limit: %s", err)
+	}
+	if !bytes.Equal(coins[:hkdfLimit], expected) {
+		t.Errorf("first chunk differs from HKDF")
+	}
+	if bytes.Equal(coins[hkdfLimit:2*hkdfLimit], coins[:hkdfLimit]) || bytes.Equal(coins[2*hkdfLimit:], coins[hkdfLimit:2*hkdfLimit]) {
+		t.Errorf("chunks repeat")
+	}
+}
+
+func Test_fftEvaluatorMatchesDirect(t *testing.T) {
+	for _, degree := range []int{0, 1, 4, 16, 17, 100, 254} {
+		coeffs := make([]uint8, degree+1)
+		if _, err := rand.Read(coeffs); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, n := range []uint8{1, 3, 200, 255} {
+			expected := make([]uint8, n)
+			newEvaluator(n, degree).evaluateAll(coeffs, expected)
+
+			actual := make([]uint8, n)
+			(&fftEvaluator{}).evaluateAll(coeffs, actual)
+
+			if !bytes.Equal(actual, expected) {
+				t.Errorf("degree %d at %d points: fft %x != direct %x", degree, n, actual, expected)
+			}
+		}
+
+		points := []uint8{200, 3, 77}
+		expected := make([]uint8, len(points))
+		newEvaluatorAt(points, degree).evaluateAll(coeffs, expected)
+		actual := make([]uint8, len(points))
+		(&fftEvaluator{points: points}).evaluateAll(coeffs, actual)
+		if !bytes.Equal(actual, expected) {
+			t.Errorf("degree %d at points %v: fft %x != direct %x", degree, points, actual, expected)
+		}
+	}
+}
+
+func Test_s1ShareAt(t *testing.T) {
+	msg := []byte("abc")
+	points := []uint8{7, 3, 250}
+	shares, err := s1ShareAt(NewAccessStructure(2, 3), msg, []byte("this is very random"), nil, points, false)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	// Interpolating at the given points, as another deployment would,
+	// recovers the message.
+	for i := range msg {
+		x := []uint8{points[0], points[2]}
+		y := []uint8{shares[0].secret[i], shares[2].secret[i]}
+		if got := interpolatePolynomial(x, y, 0); got != msg[i] {
+			t.Errorf("block %d interpolated to %x, expected: %x", i, got, msg[i])
+		}
+	}
+	if recov, err := s1Recover(shares[1:]); err != nil || !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x, %v", recov, err)
+	}
+}
+
+func BenchmarkS1Share(b *testing.B) {
+	benchmarkS1Share(b, func(A AccessStructure, M, R, T []byte) error {
+		_, err := s1Share(A, M, R, T)
+		return err
+	})
+}
+
+func BenchmarkS1ShareNaive(b *testing.B) {
+	benchmarkS1Share(b, func(A AccessStructure, M, R, T []byte) error {
+		_, err := naiveS1Share(A, M, R, T)
+		return err
+	})
+}
+
+func benchmarkS1Share(b *testing.B, share func(A AccessStructure, M, R, T []byte) error) {
+	msg := make([]byte, 32)
+	for _, as := range []AccessStructure{NewAccessStructure(2, 3), NewAccessStructure(5, 10), NewAccessStructure(20, 50), NewAccessStructure(128, 255)} {
+		as := as
+		b.Run(fmt.Sprintf("%d-of-%d", as.T, as.N), func(b *testing.B) {
+			b.SetBytes(int64(len(msg)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := share(as, msg, []byte("random"), nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 // TODO: test validations & error messages
@@ -0,0 +1,146 @@
+package adss
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// ErrSharerClosed is returned for requests made after a Sharer is closed.
+var ErrSharerClosed = errors.New("sharer closed")
+
+// SharerConfig configures a Sharer. Zero values select the defaults.
+type SharerConfig struct {
+	// Workers is the number of requests processed concurrently. Defaults to
+	// runtime.NumCPU().
+	Workers int
+
+	// QueueSize is the number of requests that can wait for a worker before
+	// callers block. Defaults to 4*Workers.
+	QueueSize int
+
+	// MaxMessageSize limits the size of the secret in Share requests. Zero
+	// means no limit.
+	MaxMessageSize int
+
+	// MaxShares limits the number of shares in Recover requests. The work
+	// done by recovery grows combinatorially with the number of shares so
+	// servers should set this. Zero means no limit.
+	MaxShares int
+}
+
+// Sharer processes Share and Recover requests on a fixed pool of workers. It
+// is designed for servers handling many concurrent requests: the pool bounds
+// the CPU used, the queue bounds the memory used by waiting requests, and
+// every request can be cancelled with its context. It is safe for concurrent
+// use.
+type Sharer struct {
+	cfg  SharerConfig
+	jobs chan func()
+
+	mu     sync.RWMutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewSharer starts a Sharer's workers. Call Close to stop them.
+func NewSharer(cfg SharerConfig) *Sharer {
+	if cfg.Workers <= 0 {
+		cfg.Workers = runtime.NumCPU()
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 4 * cfg.Workers
+	}
+
+	s := &Sharer{cfg: cfg, jobs: make(chan func(), cfg.QueueSize)}
+	s.wg.Add(cfg.Workers)
+	for i := 0; i < cfg.Workers; i++ {
+		go func() {
+			defer s.wg.Done()
+			for job := range s.jobs {
+				job()
+			}
+		}()
+	}
+	return s
+}
+
+// Close stops accepting requests and waits for queued ones to finish.
+func (s *Sharer) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.jobs)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+// submit queues job, blocking while the queue is full, and waits for it to
+// finish. If ctx is done first it returns ctx.Err() and the job will skip its
+// work if it hasn't started.
+func (s *Sharer) submit(ctx context.Context, job func(ctx context.Context)) error {
+	done := make(chan struct{})
+	run := func() {
+		defer close(done)
+		if ctx.Err() == nil {
+			job(ctx)
+		}
+	}
+
+	s.mu.RLock()
+	if s.closed {
+		s.mu.RUnlock()
+		return ErrSharerClosed
+	}
+	select {
+	case s.jobs <- run:
+		s.mu.RUnlock()
+	case <-ctx.Done():
+		s.mu.RUnlock()
+		return ctx.Err()
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Share is like the package level Share but runs on the Sharer's workers.
+func (s *Sharer) Share(ctx context.Context, A AccessStructure, M, T []byte) ([]*SecretShare, error) {
+	if s.cfg.MaxMessageSize > 0 && len(M) > s.cfg.MaxMessageSize {
+		return nil, fmt.Errorf("message too large: %d bytes, limit: %d", len(M), s.cfg.MaxMessageSize)
+	}
+
+	var shares []*SecretShare
+	var err error
+	if submitErr := s.submit(ctx, func(ctx context.Context) {
+		shares, err = ShareContext(ctx, A, M, T)
+	}); submitErr != nil {
+		return nil, submitErr
+	}
+	return shares, err
+}
+
+// Recover is like the package level Recover but runs on the Sharer's workers.
+// Cancelling ctx stops the search for an explanation of the shares.
+func (s *Sharer) Recover(ctx context.Context, shares []*SecretShare, opts ...RecoverOption) ([]byte, []*SecretShare, error) {
+	if s.cfg.MaxShares > 0 && len(shares) > s.cfg.MaxShares {
+		return nil, nil, fmt.Errorf("too many shares: %d, limit: %d", len(shares), s.cfg.MaxShares)
+	}
+
+	var M []byte
+	var V []*SecretShare
+	var err error
+	if submitErr := s.submit(ctx, func(ctx context.Context) {
+		M, V, err = RecoverContext(ctx, shares, opts...)
+	}); submitErr != nil {
+		return nil, nil, submitErr
+	}
+	return M, V, err
+}
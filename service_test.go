@@ -0,0 +1,98 @@
+package adss
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestSharerConcurrent(t *testing.T) {
+	s := NewSharer(SharerConfig{Workers: 4, QueueSize: 2})
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 32)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			msg := []byte(fmt.Sprintf("message %d", i))
+			shares, err := s.Share(context.Background(), NewAccessStructure(2, 3), msg, nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			recov, _, err := s.Recover(context.Background(), shares[1:])
+			if err != nil {
+				errs <- err
+				return
+			}
+			if !bytes.Equal(recov, msg) {
+				errs <- fmt.Errorf("recovered %x != %x", recov, msg)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestSharerLimits(t *testing.T) {
+	s := NewSharer(SharerConfig{Workers: 1, MaxMessageSize: 4, MaxShares: 2})
+	defer s.Close()
+
+	if _, err := s.Share(context.Background(), NewAccessStructure(2, 3), []byte("too long"), nil); err == nil {
+		t.Errorf("expected error for message over the limit")
+	}
+
+	shares, err := s.Share(context.Background(), NewAccessStructure(2, 3), []byte("ok"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	if _, _, err := s.Recover(context.Background(), shares); err == nil {
+		t.Errorf("expected error for too many shares")
+	}
+}
+
+func TestSharerCancellation(t *testing.T) {
+	s := NewSharer(SharerConfig{Workers: 1})
+
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := s.Recover(ctx, shares); err != context.Canceled {
+		t.Errorf("unexpected error, expected: %s, got: %v", context.Canceled, err)
+	}
+
+	s.Close()
+	if _, _, err := s.Recover(context.Background(), shares); err != ErrSharerClosed {
+		t.Errorf("unexpected error, expected: %s, got: %v", ErrSharerClosed, err)
+	}
+}
+
+func TestRecoverContextCancelled(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, opts := range [][]RecoverOption{nil, {WithUniformWork()}} {
+		if _, _, err := RecoverContext(ctx, shares, opts...); err != context.Canceled {
+			t.Errorf("unexpected error, expected: %s, got: %v", context.Canceled, err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package adss
+
+import (
+	"context"
+)
+
+// Names of the spans started around the stages of sharing and recovery.
+const (
+	// SpanShare covers a whole sharing started with ShareContext.
+	SpanShare = "adss.Share"
+	// SpanComputeJKL covers hashing the inputs of a sharing into the
+	// commitment, key and coins, which dominates for hardened shares.
+	SpanComputeJKL = "adss.ComputeJKL"
+	// SpanRecover covers a whole recovery started with RecoverContext.
+	SpanRecover = "adss.Recover"
+	// SpanPlausibleShareSets covers enumerating the candidate subsets of the
+	// shares that recovery will try.
+	SpanPlausibleShareSets = "adss.PlausibleShareSets"
+	// SpanRecoverCandidate covers one attempt to recover from a candidate
+	// subset.
+	SpanRecoverCandidate = "adss.RecoverCandidate"
+)
+
+// Tracer starts spans so services embedding adss can see where the time goes
+// in sharing and recovery. Its method has the shape of Start on
+// OpenTelemetry's trace.Tracer, so adapting one takes a few lines:
+//
+//	type otelTracer struct{ trace.Tracer }
+//
+//	func (t otelTracer) Start(ctx context.Context, name string) (context.Context, adss.Span) {
+//		ctx, span := t.Tracer.Start(ctx, name)
+//		return ctx, otelSpan{span}
+//	}
+//
+//	type otelSpan struct{ trace.Span }
+//
+//	func (s otelSpan) SetAttribute(key string, value int64) {
+//		s.Span.SetAttributes(attribute.Int64(key, value))
+//	}
+//
+//	func (s otelSpan) End() { s.Span.End() }
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a traced stage of sharing or recovery. Attributes are counts such
+// as the number of shares, never share contents.
+type Span interface {
+	SetAttribute(key string, value int64)
+	End()
+}
+
+type tracerKey struct{}
+
+// WithTracer returns a context that makes ShareContext, RecoverContext and
+// the Sharer start spans with t.
+func WithTracer(ctx context.Context, t Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// startSpan starts a span with the tracer of ctx, if any.
+func startSpan(ctx context.Context, name string) (context.Context, Span) {
+	if t, ok := ctx.Value(tracerKey{}).(Tracer); ok && t != nil {
+		return t.Start(ctx, name)
+	}
+	return ctx, noopSpan{}
+}
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(key string, value int64) {}
+func (noopSpan) End()                                 {}
@@ -0,0 +1,104 @@
+package adss
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+)
+
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordingSpan
+}
+
+type recordingSpan struct {
+	name  string
+	attrs map[string]int64
+	ended bool
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	span := &recordingSpan{name: name, attrs: map[string]int64{}}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func (s *recordingSpan) SetAttribute(key string, value int64) { s.attrs[key] = value }
+func (s *recordingSpan) End()                                 { s.ended = true }
+
+func (t *recordingTracer) count(name string) int {
+	n := 0
+	for _, span := range t.spans {
+		if span.name == name {
+			n++
+		}
+	}
+	return n
+}
+
+func (t *recordingTracer) first(name string) *recordingSpan {
+	for _, span := range t.spans {
+		if span.name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func TestTracing(t *testing.T) {
+	msg := []byte("hello world")
+	tracer := &recordingTracer{}
+	ctx := WithTracer(context.Background(), tracer)
+
+	shares, err := ShareContext(ctx, NewAccessStructure(2, 3), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if tracer.count(SpanShare) != 1 || tracer.count(SpanComputeJKL) != 1 {
+		t.Errorf("got %d share and %d JKL spans, expected 1 each", tracer.count(SpanShare), tracer.count(SpanComputeJKL))
+	}
+	if span := tracer.first(SpanShare); span.attrs["adss.threshold"] != 2 || span.attrs["adss.shares"] != 3 {
+		t.Errorf("got share attributes %v", span.attrs)
+	}
+
+	tracer.spans = nil
+	recov, _, err := RecoverContext(ctx, shares)
+	if err != nil || !bytes.Equal(recov, msg) {
+		t.Fatalf("recovered %q, %v", recov, err)
+	}
+	if tracer.count(SpanRecover) != 1 || tracer.count(SpanPlausibleShareSets) != 1 {
+		t.Errorf("got %d recover and %d enumeration spans, expected 1 each", tracer.count(SpanRecover), tracer.count(SpanPlausibleShareSets))
+	}
+	candidates := tracer.first(SpanPlausibleShareSets).attrs["adss.candidates"]
+	if candidates < 1 || int64(tracer.count(SpanRecoverCandidate)) != candidates {
+		t.Errorf("got %d candidate spans for %d candidates", tracer.count(SpanRecoverCandidate), candidates)
+	}
+	for _, span := range tracer.spans {
+		if !span.ended {
+			t.Errorf("span %s wasn't ended", span.name)
+		}
+	}
+
+	// The Sharer passes the context on.
+	sharer := NewSharer(SharerConfig{})
+	defer sharer.Close()
+	tracer.spans = nil
+	if _, err := sharer.Share(ctx, NewAccessStructure(2, 3), msg, nil); err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if tracer.count(SpanShare) != 1 {
+		t.Errorf("got %d share spans from the Sharer, expected 1", tracer.count(SpanShare))
+	}
+
+	// Without a tracer nothing is recorded.
+	tracer.spans = nil
+	if _, _, err := RecoverContext(context.Background(), shares); err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if len(tracer.spans) != 0 {
+		t.Errorf("recorded %d spans without a tracer", len(tracer.spans))
+	}
+}
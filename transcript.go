@@ -0,0 +1,166 @@
+package adss
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// transcriptSigPrefix is prepended to a transcript before signing so that a
+// transcript signature can't be confused with a signature on anything else.
+const transcriptSigPrefix = "adss transcript signature\n"
+
+// Transcript is a machine-readable record of a split or a recovery for the
+// audit trail of a key ceremony. It identifies the inputs only by their
+// hashes, so it contains no secret material, and the participants sign it to
+// attest to what happened.
+type Transcript struct {
+	Operation            string            `json:"operation"` // "split" or "recover"
+	Fingerprint          string            `json:"fingerprint,omitempty"`
+	Threshold            uint16            `json:"threshold"`
+	Count                uint16            `json:"count"`
+	AssociatedDataSHA256 string            `json:"associated_data_sha256"`
+	PayloadSHA256        string            `json:"payload_sha256"`
+	Participants         []string          `json:"participants"`
+	StartedAt            time.Time         `json:"started_at"`
+	CompletedAt          time.Time         `json:"completed_at"`
+	Shares               []TranscriptShare `json:"shares"`
+	// Result is "success" or why the operation failed.
+	Result     string                `json:"result"`
+	Signatures []TranscriptSignature `json:"signatures,omitempty"`
+}
+
+// TranscriptShare records one share that was created or provided.
+type TranscriptShare struct {
+	ID     uint16 `json:"id"`
+	SHA256 string `json:"sha256"`
+	// Status is the share's ShareStatus after a recovery.
+	Status string `json:"status,omitempty"`
+}
+
+// TranscriptSignature is a participant's signature of a Transcript.
+type TranscriptSignature struct {
+	Signer    string            `json:"signer"`
+	PublicKey ed25519.PublicKey `json:"public_key"`
+	Signature []byte            `json:"signature"`
+}
+
+func newTranscript(operation string, shares []*SecretShare, participants []string, startedAt time.Time) *Transcript {
+	t := &Transcript{
+		Operation:    operation,
+		Participants: participants,
+		StartedAt:    startedAt.UTC(),
+		CompletedAt:  time.Now().UTC(),
+		Shares:       make([]TranscriptShare, len(shares)),
+		Result:       "success",
+	}
+	if len(shares) > 0 {
+		share0 := shares[0]
+		t.Threshold = share0.As.T
+		t.Count = share0.As.N
+		t.AssociatedDataSHA256 = hashHex(share0.Tag)
+		t.PayloadSHA256 = payloadHash(share0)
+	}
+	for i, share := range shares {
+		t.Shares[i] = TranscriptShare{ID: share.ID, SHA256: shareHash(share)}
+	}
+	return t
+}
+
+// NewSplitTranscript records that the participants created the shares,
+// starting at startedAt.
+func NewSplitTranscript(shares []*SecretShare, participants []string, startedAt time.Time) (*Transcript, error) {
+	if len(shares) == 0 {
+		return nil, fmt.Errorf("no shares provided")
+	}
+	t := newTranscript("split", shares, participants, startedAt)
+	t.Fingerprint = shares[0].Fingerprint()
+	return t, nil
+}
+
+// NewRecoverTranscript records a recovery from shares, starting at
+// startedAt. V and err are what Recover returned; the status of each share is
+// recorded when it succeeded and the error when it failed.
+func NewRecoverTranscript(shares, V []*SecretShare, err error, participants []string, startedAt time.Time) (*Transcript, error) {
+	t := newTranscript("recover", shares, participants, startedAt)
+	if err != nil {
+		t.Result = err.Error()
+		return t, nil
+	}
+
+	reports, err := ClassifyShares(shares, V)
+	if err != nil {
+		return nil, err
+	}
+	for i, report := range reports {
+		t.Shares[i].Status = report.Status.String()
+	}
+	// The provided shares may be from several sharings, so the summary
+	// describes the one recovered.
+	share0 := V[0]
+	t.Fingerprint = share0.Fingerprint()
+	t.Threshold = share0.As.T
+	t.Count = share0.As.N
+	t.AssociatedDataSHA256 = hashHex(share0.Tag)
+	t.PayloadSHA256 = payloadHash(share0)
+	return t, nil
+}
+
+// signedBytes encodes everything but the signatures, so participants can
+// sign in any order.
+func (t *Transcript) signedBytes() ([]byte, error) {
+	unsigned := *t
+	unsigned.Signatures = nil
+	data, err := json.Marshal(&unsigned)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(transcriptSigPrefix), data...), nil
+}
+
+// Sign adds the participant's signature to the transcript.
+func (t *Transcript) Sign(signer string, key ed25519.PrivateKey) error {
+	if len(key) != ed25519.PrivateKeySize {
+		return fmt.Errorf("invalid key length: %d, expected: %d", len(key), ed25519.PrivateKeySize)
+	}
+	data, err := t.signedBytes()
+	if err != nil {
+		return err
+	}
+
+	t.Signatures = append(t.Signatures, TranscriptSignature{
+		Signer:    signer,
+		PublicKey: key.Public().(ed25519.PublicKey),
+		Signature: ed25519.Sign(key, data),
+	})
+	return nil
+}
+
+// Verify returns an error unless every participant signed the transcript with
+// the key keys lists for them, and every signature is valid.
+func (t *Transcript) Verify(keys map[string]ed25519.PublicKey) error {
+	data, err := t.signedBytes()
+	if err != nil {
+		return err
+	}
+
+	signed := make(map[string]bool, len(t.Signatures))
+	for _, sig := range t.Signatures {
+		key, ok := keys[sig.Signer]
+		if !ok {
+			return fmt.Errorf("no key for signer %s", sig.Signer)
+		}
+		if len(key) != ed25519.PublicKeySize || !ed25519.Verify(key, data, sig.Signature) {
+			return fmt.Errorf("signature by %s is invalid", sig.Signer)
+		}
+		signed[sig.Signer] = true
+	}
+
+	for _, participant := range t.Participants {
+		if !signed[participant] {
+			return fmt.Errorf("%s hasn't signed the transcript", participant)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package adss
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTranscript(t *testing.T) {
+	start := time.Now()
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	participants := []string{"dealer", "witness"}
+	keys := make(map[string]ed25519.PublicKey)
+	privs := make(map[string]ed25519.PrivateKey)
+	for _, name := range participants {
+		keys[name], privs[name], err = ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	tr, err := NewSplitTranscript(shares, participants, start)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if tr.Fingerprint != shares[0].Fingerprint() || len(tr.Shares) != 3 {
+		t.Errorf("unexpected transcript: %+v", tr)
+	}
+
+	if err := tr.Sign("dealer", privs["dealer"]); err != nil {
+		t.Fatalf("unexpected error signing: %s", err)
+	}
+	if err := tr.Verify(keys); err == nil {
+		t.Errorf("expected error with a participant's signature missing")
+	}
+
+	// Participants sign a stored copy of the transcript.
+	data, err := json.Marshal(tr)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tr = new(Transcript)
+	if err := json.Unmarshal(data, tr); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := tr.Sign("witness", privs["witness"]); err != nil {
+		t.Fatalf("unexpected error signing: %s", err)
+	}
+	if err := tr.Verify(keys); err != nil {
+		t.Errorf("unexpected error verifying: %s", err)
+	}
+
+	tr.Result = "failed"
+	if err := tr.Verify(keys); err == nil {
+		t.Errorf("expected error for a modified transcript")
+	}
+}
+
+func TestRecoverTranscript(t *testing.T) {
+	shares, err := Share(NewAccessStructure(2, 3), []byte("hello world"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	bad := *shares[2]
+	bad.Sec = append([]byte{}, bad.Sec...)
+	bad.Sec[0]++
+	provided := []*SecretShare{shares[0], shares[1], &bad}
+
+	_, V, err := Recover(provided)
+	tr, err := NewRecoverTranscript(provided, V, err, []string{"alice"}, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if tr.Result != "success" || tr.Fingerprint != shares[0].Fingerprint() {
+		t.Errorf("unexpected transcript: %+v", tr)
+	}
+	expected := []string{"valid", "valid", "invalid"}
+	for i, share := range tr.Shares {
+		if share.Status != expected[i] {
+			t.Errorf("share %d: status = %s, expected: %s", share.ID, share.Status, expected[i])
+		}
+	}
+
+	tr, err = NewRecoverTranscript(provided[:1], nil, errors.New("not enough shares"), nil, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if tr.Result != "not enough shares" {
+		t.Errorf("result = %q, expected the error", tr.Result)
+	}
+}
@@ -0,0 +1,175 @@
+package transport
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/jakecraige/adss"
+)
+
+// TLS is a party that receives messages on a TLS listener and sends them by
+// connecting to the other parties' listeners. Each message is sent on its
+// own connection.
+//
+// The config should require and verify client certificates, with
+// tls.RequireAndVerifyClientCert, so parties are authenticated in both
+// directions. When the sender presents a certificate, messages are only
+// accepted if their From matches its subject common name, so a party can't
+// impersonate another.
+type TLS struct {
+	name   string
+	peers  map[string]string
+	config *tls.Config
+
+	listener net.Listener
+	inbox    chan *Message
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	wg        sync.WaitGroup
+}
+
+// ListenTLS starts the named party listening on addr. peers maps the name of
+// every other party to the address it listens on. config is used both to
+// serve and to connect, so it must contain this party's certificate and the
+// roots that the other parties' certificates chain to.
+func ListenTLS(name, addr string, peers map[string]string, config *tls.Config) (*TLS, error) {
+	listener, err := tls.Listen("tcp", addr, config)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &TLS{
+		name:     name,
+		peers:    peers,
+		config:   config,
+		listener: listener,
+		inbox:    make(chan *Message, 64),
+		closed:   make(chan struct{}),
+	}
+	t.wg.Add(1)
+	go t.serve()
+	return t, nil
+}
+
+// Addr returns the address the party is listening on.
+func (t *TLS) Addr() net.Addr {
+	return t.listener.Addr()
+}
+
+func (t *TLS) serve() {
+	defer t.wg.Done()
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+		t.wg.Add(1)
+		go func() {
+			defer t.wg.Done()
+			// Errors from a peer only affect its own message.
+			_ = t.handle(conn.(*tls.Conn))
+		}()
+	}
+}
+
+// handle reads the one message sent on conn.
+func (t *TLS) handle(conn *tls.Conn) error {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	if err := conn.Handshake(); err != nil {
+		return err
+	}
+	var msg Message
+	if err := json.NewDecoder(conn).Decode(&msg); err != nil {
+		return err
+	}
+	if msg.To != t.name {
+		return fmt.Errorf("message for %s sent to %s", msg.To, t.name)
+	}
+	if certs := conn.ConnectionState().PeerCertificates; len(certs) > 0 && certs[0].Subject.CommonName != msg.From {
+		return fmt.Errorf("message from %s sent with the certificate of %s", msg.From, certs[0].Subject.CommonName)
+	}
+
+	select {
+	case t.inbox <- &msg:
+		return nil
+	case <-t.closed:
+		return ErrClosed
+	}
+}
+
+func (t *TLS) send(ctx context.Context, msg *Message) error {
+	select {
+	case <-t.closed:
+		return ErrClosed
+	default:
+	}
+
+	addr, ok := t.peers[msg.To]
+	if !ok {
+		return fmt.Errorf("unknown party: %s", msg.To)
+	}
+
+	var dialer net.Dialer
+	raw, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("sending to %s: %w", msg.To, err)
+	}
+	defer raw.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		raw.SetDeadline(deadline)
+	}
+
+	config := t.config.Clone()
+	if config.ServerName == "" {
+		config.ServerName = msg.To
+	}
+	conn := tls.Client(raw, config)
+	if err := conn.Handshake(); err != nil {
+		return fmt.Errorf("sending to %s: %w", msg.To, err)
+	}
+	if err := json.NewEncoder(conn).Encode(msg); err != nil {
+		return fmt.Errorf("sending to %s: %w", msg.To, err)
+	}
+	return conn.Close()
+}
+
+// Send delivers the share to the party named to.
+func (t *TLS) Send(ctx context.Context, to, session string, share *adss.SecretShare) error {
+	return t.send(ctx, &Message{Kind: KindShare, From: t.name, To: to, Session: session, Share: share})
+}
+
+// Request asks the party named to to send back their share.
+func (t *TLS) Request(ctx context.Context, to, session string) error {
+	return t.send(ctx, &Message{Kind: KindRequest, From: t.name, To: to, Session: session})
+}
+
+// Receive returns the next message sent to this party.
+func (t *TLS) Receive(ctx context.Context) (*Message, error) {
+	select {
+	case msg := <-t.inbox:
+		return msg, nil
+	case <-t.closed:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close stops listening and waits for connections in progress to finish.
+func (t *TLS) Close() error {
+	var err error
+	t.closeOnce.Do(func() {
+		close(t.closed)
+		err = t.listener.Close()
+		t.wg.Wait()
+	})
+	return err
+}
@@ -0,0 +1,116 @@
+package transport
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testPKI issues certificates for the parties from a throwaway CA.
+type testPKI struct {
+	t      *testing.T
+	ca     *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+	roots  *x509.CertPool
+	serial int64
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	return &testPKI{t: t, ca: ca, caKey: key, roots: roots, serial: 1}
+}
+
+// config returns the TLS config of the named party.
+func (p *testPKI) config(name string) *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		p.t.Fatalf("unexpected error: %s", err)
+	}
+	p.serial++
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(p.serial),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p.ca, &key.PublicKey, p.caKey)
+	if err != nil {
+		p.t.Fatalf("unexpected error: %s", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		RootCAs:      p.roots,
+		ClientCAs:    p.roots,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+}
+
+func TestTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	names := []string{"dealer", "alice", "bob"}
+	peers := make(map[string]string)
+	parties := make(map[string]*TLS)
+	for _, name := range names {
+		party, err := ListenTLS(name, "127.0.0.1:0", peers, pki.config(name))
+		if err != nil {
+			t.Fatalf("unexpected error listening: %s", err)
+		}
+		defer party.Close()
+		parties[name] = party
+		peers[name] = party.Addr().String()
+	}
+
+	exchange(t, parties["dealer"], map[string]Transport{"alice": parties["alice"], "bob": parties["bob"]})
+
+	// Mallory has a valid certificate but can't send as the dealer.
+	mallory, err := ListenTLS("dealer", "127.0.0.1:0", peers, pki.config("mallory"))
+	if err != nil {
+		t.Fatalf("unexpected error listening: %s", err)
+	}
+	defer mallory.Close()
+	if err := mallory.Request(context.Background(), "alice", "ceremony-1"); err != nil {
+		t.Fatalf("unexpected error sending: %s", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if m, err := parties["alice"].Receive(ctx); err == nil {
+		t.Errorf("accepted a message from %s sent by mallory", m.From)
+	}
+
+	if err := parties["dealer"].Send(context.Background(), "carol", "", nil); err == nil {
+		t.Errorf("expected error sending to an unknown party")
+	}
+}
@@ -0,0 +1,137 @@
+// Package transport moves shares between the parties of a sharing: the dealer
+// sending shares to holders, and a coordinator requesting them back and
+// receiving them for recovery.
+//
+// Every party has its own Transport. The Memory network connects parties in
+// the same process, which is useful for tests and for applications that
+// bridge an existing channel themselves, and TLS connects parties over the
+// network. Other channels, such as a message queue or a ticketing system, can
+// be used by implementing Transport.
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jakecraige/adss"
+)
+
+// ErrClosed is returned by a Transport after it is closed.
+var ErrClosed = errors.New("transport closed")
+
+// Kind is the kind of a Message.
+type Kind string
+
+const (
+	// KindShare carries a share, either from the dealer to a holder or from
+	// a holder back to whoever requested it.
+	KindShare Kind = "share"
+	// KindRequest asks a holder to send their share back.
+	KindRequest Kind = "request"
+)
+
+// Message is what parties exchange over a Transport.
+type Message struct {
+	Kind Kind   `json:"kind"`
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Session identifies the sharing or recovery the message is part of so
+	// a party can take part in several at once.
+	Session string            `json:"session,omitempty"`
+	Share   *adss.SecretShare `json:"share,omitempty"`
+}
+
+// Transport is one party's connection to the others. Implementations must be
+// safe for concurrent use.
+type Transport interface {
+	// Send delivers the share to the party named to.
+	Send(ctx context.Context, to, session string, share *adss.SecretShare) error
+	// Request asks the party named to to send back their share.
+	Request(ctx context.Context, to, session string) error
+	// Receive returns the next message sent to this party, blocking until
+	// one arrives or ctx is done.
+	Receive(ctx context.Context) (*Message, error)
+	// Close disconnects the party.
+	Close() error
+}
+
+// Memory is an in-process network of parties.
+type Memory struct {
+	mu      sync.Mutex
+	inboxes map[string]chan *Message
+}
+
+// NewMemory returns an empty in-process network.
+func NewMemory() *Memory {
+	return &Memory{inboxes: make(map[string]chan *Message)}
+}
+
+// Join adds the named party to the network and returns its Transport.
+// Messages sent to a party that hasn't joined yet wait for it.
+func (m *Memory) Join(name string) Transport {
+	return &memoryTransport{name: name, net: m, inbox: m.inbox(name), closed: make(chan struct{})}
+}
+
+func (m *Memory) inbox(name string) chan *Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inbox, ok := m.inboxes[name]
+	if !ok {
+		inbox = make(chan *Message, 64)
+		m.inboxes[name] = inbox
+	}
+	return inbox
+}
+
+type memoryTransport struct {
+	name  string
+	net   *Memory
+	inbox chan *Message
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func (t *memoryTransport) send(ctx context.Context, msg *Message) error {
+	select {
+	case <-t.closed:
+		return ErrClosed
+	default:
+	}
+
+	select {
+	case t.net.inbox(msg.To) <- msg:
+		return nil
+	case <-t.closed:
+		return ErrClosed
+	case <-ctx.Done():
+		return fmt.Errorf("sending to %s: %w", msg.To, ctx.Err())
+	}
+}
+
+func (t *memoryTransport) Send(ctx context.Context, to, session string, share *adss.SecretShare) error {
+	return t.send(ctx, &Message{Kind: KindShare, From: t.name, To: to, Session: session, Share: share})
+}
+
+func (t *memoryTransport) Request(ctx context.Context, to, session string) error {
+	return t.send(ctx, &Message{Kind: KindRequest, From: t.name, To: to, Session: session})
+}
+
+func (t *memoryTransport) Receive(ctx context.Context) (*Message, error) {
+	select {
+	case msg := <-t.inbox:
+		return msg, nil
+	case <-t.closed:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (t *memoryTransport) Close() error {
+	t.closeOnce.Do(func() { close(t.closed) })
+	return nil
+}
@@ -0,0 +1,96 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jakecraige/adss"
+)
+
+// exchange distributes a 2-of-2 sharing from the dealer to the holders,
+// requests the shares back and recovers the secret from them.
+func exchange(t *testing.T, dealer Transport, holders map[string]Transport) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	msg := []byte("hello world")
+	shares, err := adss.Share(adss.NewAccessStructure(2, 2), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+
+	names := []string{"alice", "bob"}
+	for i, name := range names {
+		if err := dealer.Send(ctx, name, "ceremony-1", shares[i]); err != nil {
+			t.Fatalf("unexpected error sending to %s: %s", name, err)
+		}
+	}
+
+	// Each holder keeps their share until the dealer asks for it back.
+	for _, name := range names {
+		holder := holders[name]
+		m, err := holder.Receive(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error receiving: %s", err)
+		}
+		if m.Kind != KindShare || m.From != "dealer" || m.To != name || m.Session != "ceremony-1" {
+			t.Fatalf("unexpected message: %+v", m)
+		}
+		share := m.Share
+
+		if err := dealer.Request(ctx, name, "ceremony-1"); err != nil {
+			t.Fatalf("unexpected error requesting from %s: %s", name, err)
+		}
+		req, err := holder.Receive(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error receiving: %s", err)
+		}
+		if req.Kind != KindRequest || req.From != "dealer" {
+			t.Fatalf("unexpected message: %+v", req)
+		}
+		if err := holder.Send(ctx, req.From, req.Session, share); err != nil {
+			t.Fatalf("unexpected error returning share: %s", err)
+		}
+	}
+
+	var returned []*adss.SecretShare
+	for range names {
+		m, err := dealer.Receive(ctx)
+		if err != nil {
+			t.Fatalf("unexpected error receiving: %s", err)
+		}
+		returned = append(returned, m.Share)
+	}
+
+	recov, _, err := adss.Recover(returned)
+	if err != nil {
+		t.Fatalf("unexpected error on recovery: %s", err)
+	}
+	if !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %x != %x", recov, msg)
+	}
+}
+
+func TestMemory(t *testing.T) {
+	net := NewMemory()
+	dealer := net.Join("dealer")
+	holders := map[string]Transport{"alice": net.Join("alice"), "bob": net.Join("bob")}
+	exchange(t, dealer, holders)
+
+	dealer.Close()
+	if _, err := dealer.Receive(context.Background()); err != ErrClosed {
+		t.Errorf("err = %v, expected: %v", err, ErrClosed)
+	}
+	if err := dealer.Request(context.Background(), "alice", ""); err != ErrClosed {
+		t.Errorf("err = %v, expected: %v", err, ErrClosed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := holders["alice"].Receive(ctx); err != context.DeadlineExceeded {
+		t.Errorf("err = %v, expected: %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,126 @@
+package adss
+
+import "sync"
+
+// Sharings with more than 255 shares can't give each share a distinct
+// non-zero point in GF(2^8), so their keys are shared over GF(2^16) instead,
+// two bytes at a time. Elements are reduced by x^16+x^12+x^3+x+1, for which x
+// generates the multiplicative group.
+const widePolynomial = 0x1100b
+
+// wideMarker follows two zero bytes, which no narrower access structure starts
+// with, in the bytes of an access structure of more than 255 shares, see
+// AccessStructure.Bytes.
+const wideMarker = 0xfc
+
+// maxWideShares is the most shares a sharing can have: one per non-zero
+// element of GF(2^16).
+const maxWideShares = 0xffff
+
+var (
+	wideTablesOnce sync.Once
+	// wideExp[i] is x^i for i in [0, 2*65535) so products of two logarithms
+	// don't need reducing, and wideLog is its inverse on non-zero elements.
+	wideExp []uint16
+	wideLog []uint16
+)
+
+// wideTables builds the logarithm tables on first use, since most sharings
+// never need them.
+func wideTables() {
+	wideTablesOnce.Do(func() {
+		wideExp = make([]uint16, 2*maxWideShares)
+		wideLog = make([]uint16, 1<<16)
+		v := 1
+		for i := 0; i < maxWideShares; i++ {
+			wideExp[i] = uint16(v)
+			wideExp[i+maxWideShares] = uint16(v)
+			wideLog[v] = uint16(i)
+			v <<= 1
+			if v&0x10000 != 0 {
+				v ^= widePolynomial
+			}
+		}
+	})
+}
+
+// splitWide reports whether the key of the share's sharing was split over
+// GF(2^16), as it is for sharings of more than 255 shares or SchemeShamir16.
+func (ss *SecretShare) splitWide() bool {
+	return ss.As.wide() || ss.Scheme == SchemeShamir16
+}
+
+// wideMult multiplies two elements of GF(2^16). The tables must be built.
+func wideMult(a, b uint16) uint16 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return wideExp[int(wideLog[a])+int(wideLog[b])]
+}
+
+// wideDiv divides a by the non-zero b in GF(2^16). The tables must be built.
+func wideDiv(a, b uint16) uint16 {
+	if a == 0 {
+		return 0
+	}
+	return wideExp[int(wideLog[a])+maxWideShares-int(wideLog[b])]
+}
+
+// wideShare splits the even length secret into a share for each point, with
+// the coefficients of the polynomial of degree t-1 for each two-byte block
+// read from coins.
+func wideShare(secret []byte, t int, points []uint16, coins []byte) [][]byte {
+	wideTables()
+	shares := make([][]byte, len(points))
+	for j := range shares {
+		shares[j] = make([]byte, len(secret))
+	}
+
+	degree := t - 1
+	coeffs := make([]uint16, degree+1)
+	for i := 0; i < len(secret); i += 2 {
+		coeffs[0] = uint16(secret[i])<<8 | uint16(secret[i+1])
+		for k := 1; k <= degree; k++ {
+			c := coins[(i/2*degree+k-1)*2:]
+			coeffs[k] = uint16(c[0])<<8 | uint16(c[1])
+		}
+		for j, x := range points {
+			// Horner's method.
+			out := coeffs[degree]
+			for k := degree - 1; k >= 0; k-- {
+				out = wideMult(out, x) ^ coeffs[k]
+			}
+			shares[j][i], shares[j][i+1] = byte(out>>8), byte(out)
+		}
+	}
+	return shares
+}
+
+// wideRecover interpolates the shares at the distinct non-zero points back to
+// the secret. The Lagrange basis at zero is computed once since it only
+// depends on the points.
+func wideRecover(points []uint16, shares [][]byte) []byte {
+	wideTables()
+	basis := make([]uint16, len(points))
+	for i, xi := range points {
+		num, den := uint16(1), uint16(1)
+		for j, xj := range points {
+			if i == j {
+				continue
+			}
+			num = wideMult(num, xj)
+			den = wideMult(den, xi^xj)
+		}
+		basis[i] = wideDiv(num, den)
+	}
+
+	secret := make([]byte, len(shares[0]))
+	for b := 0; b < len(secret); b += 2 {
+		var out uint16
+		for i, share := range shares {
+			out ^= wideMult(uint16(share[b])<<8|uint16(share[b+1]), basis[i])
+		}
+		secret[b], secret[b+1] = byte(out>>8), byte(out)
+	}
+	return secret
+}
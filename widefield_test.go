@@ -0,0 +1,212 @@
+package adss
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestWideField(t *testing.T) {
+	wideTables()
+
+	// x generates the multiplicative group, so its powers reach every non-zero
+	// element once and the polynomial is primitive.
+	seen := make([]bool, 1<<16)
+	for i := 0; i < maxWideShares; i++ {
+		v := wideExp[i]
+		if v == 0 || seen[v] {
+			t.Fatalf("x^%d = %d repeats, the polynomial isn't primitive", i, v)
+		}
+		seen[v] = true
+	}
+
+	for _, a := range []uint16{1, 2, 0x1234, 0xfffe, 0xffff} {
+		for _, b := range []uint16{1, 3, 0x8000, 0xffff} {
+			if got := wideDiv(wideMult(a, b), b); got != a {
+				t.Errorf("%d*%d/%d = %d", a, b, b, got)
+			}
+		}
+		if wideMult(a, 0) != 0 {
+			t.Errorf("%d*0 isn't 0", a)
+		}
+	}
+}
+
+func TestWideShareRecover(t *testing.T) {
+	secret := []byte("an even secret!!")
+	coins := make([]byte, len(secret)*2)
+	for i := range coins {
+		coins[i] = byte(i * 7)
+	}
+	points := []uint16{1, 2, 300, 0xffff}
+	shares := wideShare(secret, 3, points, coins)
+
+	if recov := wideRecover(points[1:], shares[1:]); !bytes.Equal(recov, secret) {
+		t.Errorf("recovered %q, expected: %q", recov, secret)
+	}
+	if recov := wideRecover(points[:2], shares[:2]); bytes.Equal(recov, secret) {
+		t.Errorf("recovered with fewer than the threshold of shares")
+	}
+}
+
+func TestShareWide(t *testing.T) {
+	msg := []byte("hello world")
+	as, err := NewAccessStructure(3, 300).WithMandatory(299)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	shares, err := Share(as, msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if len(shares) != 300 || shares[299].ID != 299 || shares[299].Point() != 300 {
+		t.Fatalf("got %d shares, expected 300 numbered to 299", len(shares))
+	}
+	if expected := []byte{0, 0, wideMarker, 0, 3, 1, 44, mandatoryMarker, 0, 1, 1, 43}; !bytes.Equal(as.Bytes(), expected) {
+		t.Errorf("got bytes %x, expected: %x", as.Bytes(), expected)
+	}
+
+	recov, _, err := Recover([]*SecretShare{shares[7], shares[256], shares[299]})
+	if err != nil || !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %q, %v", recov, err)
+	}
+	if _, _, err := Recover([]*SecretShare{shares[7], shares[256], shares[298]}); !errors.Is(err, ErrMissingMandatory) {
+		t.Errorf("expected ErrMissingMandatory, got %v", err)
+	}
+	if _, err := ShareWithPoints(defaultPoints(255), NewAccessStructure(2, 300), msg, nil); err == nil {
+		t.Errorf("expected an error for evaluation points with more than 255 shares")
+	}
+
+	share := shares[256]
+	parsed, err := DecodeShareString(EncodeShareString(share))
+	if err != nil || !parsed.Equal(share) {
+		t.Errorf("share string didn't round trip: %v", err)
+	}
+	data, err := share.MarshalCBOR()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var fromCBOR SecretShare
+	if err := fromCBOR.UnmarshalCBOR(data); err != nil || !fromCBOR.Equal(share) {
+		t.Errorf("CBOR didn't round trip: %v", err)
+	}
+	data, err = share.MarshalProto()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var fromProto SecretShare
+	if err := fromProto.UnmarshalProto(data); err != nil || !fromProto.Equal(share) {
+		t.Errorf("proto didn't round trip: %v", err)
+	}
+
+	// A narrow share can't claim the wide flag.
+	narrow, err := Share(NewAccessStructure(2, 3), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	compact := narrow[0].compactBytes()
+	compact[3] |= compactExtended
+	compact = append(compact[:4], append([]byte{compactWide, 0, 0, 0}, compact[4:]...)...)
+	if _, err := parseCompactShare(compact); err == nil {
+		t.Errorf("expected an error for a narrow share with the wide flag")
+	}
+}
+
+func TestShareWideFormula(t *testing.T) {
+	msg := []byte("hello world")
+	group := NewAccessStructure(2, 200)
+	as, err := NewHierarchicalAccessStructure(2, group, group)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if as.N != 400 {
+		t.Fatalf("got %d shares, expected 400", as.N)
+	}
+	shares, err := ShareWithScheme(SchemeFormula, as, msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if path := shares[350].GroupPath(); len(path) != 2 || path[0] != 1 || path[1] != 150 {
+		t.Errorf("got group path %v, expected: [1 150]", path)
+	}
+	recov, _, err := Recover([]*SecretShare{shares[0], shares[199], shares[200], shares[399]})
+	if err != nil || !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %q, %v", recov, err)
+	}
+	if _, _, err := Recover([]*SecretShare{shares[0], shares[1], shares[2], shares[200]}); !errors.Is(err, ErrUnsatisfiedFormula) {
+		t.Errorf("expected ErrUnsatisfiedFormula, got %v", err)
+	}
+
+	parsed, err := DecodeShareString(EncodeShareString(shares[300]))
+	if err != nil || !parsed.Equal(shares[300]) {
+		t.Errorf("share string didn't round trip: %v", err)
+	}
+}
+
+func TestShareShamir16(t *testing.T) {
+	msg := []byte("hello world")
+	as := NewAccessStructure(2, 3)
+	shares, err := ShareWithScheme(SchemeShamir16, as, msg, []byte("ad"))
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	if !shares[0].splitWide() {
+		t.Errorf("the key of a shamir16 sharing isn't split over GF(2^16)")
+	}
+	recov, _, err := Recover(shares[1:])
+	if err != nil || !bytes.Equal(recov, msg) {
+		t.Errorf("recovered %q, %v", recov, err)
+	}
+
+	// The scheme is bound into the shares, so they can't be recovered as
+	// Shamir shares over GF(2^8).
+	relabeled := make([]*SecretShare, 2)
+	for i, share := range shares[:2] {
+		relabeled[i] = cloneShare(share)
+		relabeled[i].Scheme = SchemeShamir
+	}
+	if _, _, err := Recover(relabeled); err == nil {
+		t.Errorf("expected an error recovering shamir16 shares as shamir")
+	}
+
+	parsed, err := DecodeShareString(EncodeShareString(shares[2]))
+	if err != nil || !parsed.Equal(shares[2]) {
+		t.Errorf("share string didn't round trip: %v", err)
+	}
+}
+
+func TestShareWideThreshold(t *testing.T) {
+	// Thresholds above 256 need more coins than one HKDF stream gives.
+	msg := []byte("hello world")
+	for _, as := range []AccessStructure{NewAccessStructure(257, 300), NewAccessStructure(300, 1000)} {
+		shares, err := Share(as, msg, nil)
+		if err != nil {
+			t.Fatalf("%d-of-%d: unexpected error on sharing: %s", as.T, as.N, err)
+		}
+		recov, _, err := Recover(shares[as.N-as.T:])
+		if err != nil || !bytes.Equal(recov, msg) {
+			t.Errorf("%d-of-%d: recovered %q, %v", as.T, as.N, recov, err)
+		}
+		if _, _, err := Recover(shares[as.N-as.T+1:]); err == nil {
+			t.Errorf("%d-of-%d: recovered with fewer than the threshold of shares", as.T, as.N)
+		}
+	}
+}
+
+func TestRecoverForgedWideThreshold(t *testing.T) {
+	msg := []byte("hello world")
+	shares, err := Share(NewAccessStructure(2, 300), msg, nil)
+	if err != nil {
+		t.Fatalf("unexpected error on sharing: %s", err)
+	}
+	forged := make([]*SecretShare, len(shares))
+	for i, share := range shares {
+		forged[i] = cloneShare(share)
+		forged[i].As = NewAccessStructure(300, 300)
+	}
+	// Uniform work re-shares even after the checksum fails, which needs the
+	// coins for a threshold of 300.
+	if _, _, err := Recover(forged, WithUniformWork()); err == nil {
+		t.Errorf("expected an error recovering shares with a forged threshold")
+	}
+}